@@ -5,18 +5,23 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 
+	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rakibulbh/ai-finance-manager/internal/config"
 	"github.com/rakibulbh/ai-finance-manager/internal/logger"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/ratelimit"
 	"github.com/rakibulbh/ai-finance-manager/internal/repository/postgres"
 	"github.com/rakibulbh/ai-finance-manager/internal/rest"
+	authMW "github.com/rakibulbh/ai-finance-manager/internal/rest/middleware"
 	"github.com/rakibulbh/ai-finance-manager/internal/services"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
-
 func main() {
 	if err := logger.InitLogger(); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
@@ -40,36 +45,135 @@ func main() {
 
 	// 1. Repositories
 	userRepo := postgres.NewUserRepository(dbPool)
+	sessionRepo := postgres.NewSessionRepository(dbPool)
+	apiKeyRepo := postgres.NewAPIKeyRepository(dbPool)
+	auditRepo := postgres.NewAuditRepository(dbPool)
+	recurringRepo := postgres.NewRecurringRepository(dbPool)
+	scheduledRepo := postgres.NewScheduledRepository(dbPool)
+	tagRepo := postgres.NewTagRepository(dbPool)
+	transferRepo := postgres.NewTransferRepository(dbPool)
+	reportRepo := postgres.NewReportRepository(dbPool)
+	familyRepo := postgres.NewFamilyRepository(dbPool)
 	accountRepo := postgres.NewAccountRepository(dbPool)
 	ledgerRepo := postgres.NewLedgerRepository(dbPool)
 	investmentRepo := postgres.NewInvestmentRepository(dbPool)
 	plaidRepo := postgres.NewPlaidRepository(dbPool)
 
 	// 2. Services
-	plaidService := services.NewPlaidService(cfg.PlaidClientID, cfg.PlaidSecret, cfg.PlaidEnv, cfg.EncryptionKey)
+	tokenCipher, err := services.NewTokenCipher(cfg.EncryptionKeys, cfg.EncryptionKey)
+	if err != nil {
+		logger.Error("Invalid encryption key configuration", zap.Error(err))
+		os.Exit(1)
+	}
+
+	var (
+		plaidService rest.PlaidManager
+		webhookKeys  services.WebhookKeySource
+	)
+	if cfg.PlaidEnv == services.PlaidEnvFake {
+		fakePlaid, err := services.NewFakePlaidService(tokenCipher)
+		if err != nil {
+			logger.Error("Unable to create fake Plaid provider", zap.Error(err))
+			os.Exit(1)
+		}
+		logger.Warn("Using fake Plaid provider")
+		plaidService, webhookKeys = fakePlaid, fakePlaid
+	} else {
+		realPlaid := services.NewPlaidService(cfg.PlaidClientID, cfg.PlaidSecret, cfg.PlaidEnv, tokenCipher, cfg.PlaidWebhookURL)
+		plaidService, webhookKeys = realPlaid, realPlaid
+	}
+
+	var mailer services.Mailer
+	if cfg.Mailer == services.MailerSMTP {
+		mailer = services.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	} else {
+		mailer = services.NewLogMailer(cfg.MailDir, cfg.MailFrom)
+	}
+	accountMailer := services.NewAccountMailer(mailer, cfg.AppURL)
+	mfaService := services.NewMFAService(userRepo, tokenCipher, "Maybe Finance")
+
+	var rateLimitStore authMW.RateLimitStore
+	if cfg.RateLimitStore == ratelimit.StoreRedis {
+		rateLimitStore = ratelimit.NewRedisStore(redis.NewClient(&redis.Options{Addr: cfg.RedisAddr}))
+	} else {
+		rateLimitStore = ratelimit.NewMemoryStore()
+	}
+	lockout := models.LockoutPolicy{
+		Threshold: cfg.LoginLockoutThreshold,
+		Base:      cfg.LoginLockoutBase,
+		Max:       cfg.LoginLockoutMax,
+	}
+
+	trustedProxies, err := authMW.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		logger.Error("Invalid TRUSTED_PROXIES", zap.Error(err))
+		os.Exit(1)
+	}
+
 	asynqClient := asynq.NewClient(asynq.RedisClientOpt{Addr: cfg.RedisAddr})
 	defer asynqClient.Close()
 
 	// 3. Handlers
-	authHandler := rest.NewAuthHandler(userRepo, cfg.JWTSecret)
+	authHandler := rest.NewAuthHandler(userRepo, sessionRepo, accountMailer, mfaService, lockout, cfg.JWTSecret)
+	familyHandler := rest.NewFamilyHandler(familyRepo)
+
+	var ssoHandler *rest.SSOHandler
+	if cfg.OIDCIssuerURL != "" {
+		provider, err := services.NewOIDCProvider(context.Background(), cfg.OIDCIssuerURL, cfg.OIDCClientID, cfg.OIDCClientSecret, cfg.OIDCRedirectURL)
+		if err != nil {
+			logger.Error("Unable to load OIDC provider", zap.Error(err))
+			os.Exit(1)
+		}
+		provisioning, err := ssoProvisioning(cfg)
+		if err != nil {
+			logger.Error("Invalid OIDC configuration", zap.Error(err))
+			os.Exit(1)
+		}
+		ssoHandler = rest.NewSSOHandler(authHandler, provider, userRepo, provisioning)
+	}
+
 	accountHandler := rest.NewAccountHandler(accountRepo)
 	transactionHandler := rest.NewTransactionHandler(ledgerRepo)
 	investmentHandler := rest.NewInvestmentHandler(investmentRepo)
 	plaidHandler := rest.NewPlaidHandler(plaidService, plaidRepo, asynqClient)
+	apiKeyHandler := rest.NewAPIKeyHandler(apiKeyRepo)
+	auditHandler := rest.NewAuditHandler(auditRepo)
+	recurringHandler := rest.NewRecurringHandler(recurringRepo)
+	scheduledHandler := rest.NewScheduledHandler(scheduledRepo)
+	tagHandler := rest.NewTagHandler(tagRepo)
+	transferHandler := rest.NewTransferHandler(transferRepo)
+	reportHandler := rest.NewReportHandler(reportRepo)
+	plaidWebhookHandler := rest.NewPlaidWebhookHandler(services.NewWebhookVerifier(webhookKeys), plaidRepo, asynqClient)
 
 	// 4. Router Setup
 	r := rest.NewRouter(rest.RouterConfig{
-		AuthHandler:        authHandler,
-		AccountHandler:     accountHandler,
-		TransactionHandler: transactionHandler,
-		InvestmentHandler:  investmentHandler,
-		PlaidHandler:       plaidHandler,
-		JWTSecret:         cfg.JWTSecret,
+		AuthHandler:         authHandler,
+		SSOHandler:          ssoHandler,
+		FamilyHandler:       familyHandler,
+		AccountHandler:      accountHandler,
+		TransactionHandler:  transactionHandler,
+		InvestmentHandler:   investmentHandler,
+		PlaidHandler:        plaidHandler,
+		PlaidWebhookHandler: plaidWebhookHandler,
+		APIKeyHandler:       apiKeyHandler,
+		AuditHandler:        auditHandler,
+		RecurringHandler:    recurringHandler,
+		ScheduledHandler:    scheduledHandler,
+		TagHandler:          tagHandler,
+		TransferHandler:     transferHandler,
+		ReportHandler:       reportHandler,
+		Sessions:            sessionRepo,
+		APIKeys:             apiKeyRepo,
+		JWTSecret:           cfg.JWTSecret,
+		TrustedProxies:      trustedProxies,
+		RateLimitStore:      rateLimitStore,
+		AuthRateLimit: rest.AuthRateLimit{
+			PerIP:      cfg.AuthRateLimitPerIP,
+			PerAccount: cfg.AuthRateLimitPerAccount,
+			Period:     cfg.AuthRateLimitPeriod,
+		},
 	})
 
-
-
-
 	fmt.Printf("Starting application in %s mode...\n", cfg.Environment)
 	fmt.Printf("Server running on port :%s\n", cfg.Port)
 	if err := http.ListenAndServe(":"+cfg.Port, r); err != nil {
@@ -77,3 +181,25 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+func ssoProvisioning(cfg *config.Config) (models.SSOProvisioning, error) {
+	provisioning := models.SSOProvisioning{Role: cfg.OIDCDefaultRole}
+	if !models.ValidRole(provisioning.Role) {
+		return provisioning, fmt.Errorf("invalid OIDC_DEFAULT_ROLE %q", provisioning.Role)
+	}
+
+	if cfg.OIDCFamilyID != "" {
+		familyID, err := uuid.Parse(cfg.OIDCFamilyID)
+		if err != nil {
+			return provisioning, fmt.Errorf("invalid OIDC_FAMILY_ID: %w", err)
+		}
+		provisioning.FamilyID = &familyID
+	}
+
+	for _, domain := range strings.Split(cfg.OIDCAllowedDomains, ",") {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			provisioning.AllowedDomains = append(provisioning.AllowedDomains, domain)
+		}
+	}
+	return provisioning, nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"os"
 
@@ -16,6 +17,9 @@ import (
 )
 
 func main() {
+	reencryptTokens := flag.Bool("reencrypt-tokens", false, "re-encrypt stored Plaid access tokens and TOTP secrets with the current key and exit")
+	flag.Parse()
+
 	if err := logger.InitLogger(); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
 		os.Exit(1)
@@ -37,19 +41,73 @@ func main() {
 	defer dbPool.Close()
 
 	// 2. Services
-	plaidService := services.NewPlaidService(cfg.PlaidClientID, cfg.PlaidSecret, cfg.PlaidEnv, cfg.EncryptionKey)
+	tokenCipher, err := services.NewTokenCipher(cfg.EncryptionKeys, cfg.EncryptionKey)
+	if err != nil {
+		logger.Error("Invalid encryption key configuration", zap.Error(err))
+		os.Exit(1)
+	}
+
+	var plaidService jobs.PlaidProvider
+	if cfg.PlaidEnv == services.PlaidEnvFake {
+		fakePlaid, err := services.NewFakePlaidService(tokenCipher)
+		if err != nil {
+			logger.Error("Unable to create fake Plaid provider", zap.Error(err))
+			os.Exit(1)
+		}
+		logger.Warn("Using fake Plaid provider")
+		plaidService = fakePlaid
+	} else {
+		plaidService = services.NewPlaidService(cfg.PlaidClientID, cfg.PlaidSecret, cfg.PlaidEnv, tokenCipher, cfg.PlaidWebhookURL)
+	}
+
+	var mailer services.Mailer
+	if cfg.Mailer == services.MailerSMTP {
+		mailer = services.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	} else {
+		mailer = services.NewLogMailer(cfg.MailDir, cfg.MailFrom)
+	}
 
 	// 3. Repositories
 	plaidRepo := postgres.NewPlaidRepository(dbPool)
 	ledgerRepo := postgres.NewLedgerRepository(dbPool)
 	accountRepo := postgres.NewAccountRepository(dbPool)
+	investmentRepo := postgres.NewInvestmentRepository(dbPool)
+	reminderRepo := postgres.NewReminderRepository(dbPool)
+	familyRepo := postgres.NewFamilyRepository(dbPool)
+	recurringRepo := postgres.NewRecurringRepository(dbPool)
+	scheduledRepo := postgres.NewScheduledRepository(dbPool)
+	transferRepo := postgres.NewTransferRepository(dbPool)
+
+	if *reencryptTokens {
+		count, err := jobs.ReencryptAccessTokens(context.Background(), plaidRepo, tokenCipher)
+		fmt.Printf("Re-encrypted %d access tokens with key %q\n", count, tokenCipher.CurrentKeyID())
+		if err != nil {
+			logger.Error("Token re-encryption incomplete", zap.Error(err))
+		}
+		secrets, secretErr := jobs.ReencryptTOTPSecrets(context.Background(), postgres.NewUserRepository(dbPool), tokenCipher)
+		fmt.Printf("Re-encrypted %d TOTP secrets with key %q\n", secrets, tokenCipher.CurrentKeyID())
+		if secretErr != nil {
+			logger.Error("TOTP secret re-encryption incomplete", zap.Error(secretErr))
+		}
+		if err != nil || secretErr != nil {
+			os.Exit(1)
+		}
+		return
+	}
 
 	// 4. Worker Setup
 	svc := &jobs.WorkerServices{
-		Plaid:    plaidService,
-		DB:       plaidRepo,
-		Ledger:   ledgerRepo,
-		Accounts: accountRepo,
+		Plaid:       plaidService,
+		DB:          plaidRepo,
+		Ledger:      ledgerRepo,
+		Accounts:    accountRepo,
+		Investments: investmentRepo,
+		Reminders:   reminderRepo,
+		Members:     familyRepo,
+		Mailer:      services.NewAccountMailer(mailer, cfg.AppURL),
+		Recurring:   recurringRepo,
+		Scheduled:   scheduledRepo,
+		Transfers:   transferRepo,
 	}
 
 	srv := asynq.NewServer(
@@ -61,6 +119,51 @@ func main() {
 	mux.HandleFunc(jobs.TypeSyncAccount, func(ctx context.Context, t *asynq.Task) error {
 		return jobs.HandleSyncAccountTask(ctx, t, svc)
 	})
+	mux.HandleFunc(jobs.TypeSyncInvestments, func(ctx context.Context, t *asynq.Task) error {
+		return jobs.HandleSyncInvestmentsTask(ctx, t, svc)
+	})
+	mux.HandleFunc(jobs.TypeSyncLiabilities, func(ctx context.Context, t *asynq.Task) error {
+		return jobs.HandleSyncLiabilitiesTask(ctx, t, svc)
+	})
+	mux.HandleFunc(jobs.TypePaymentReminders, func(ctx context.Context, t *asynq.Task) error {
+		return jobs.HandlePaymentRemindersTask(ctx, t, svc)
+	})
+	mux.HandleFunc(jobs.TypeDetectRecurring, func(ctx context.Context, t *asynq.Task) error {
+		return jobs.HandleDetectRecurringTask(ctx, t, svc)
+	})
+	mux.HandleFunc(jobs.TypePostScheduled, func(ctx context.Context, t *asynq.Task) error {
+		return jobs.HandlePostScheduledTask(ctx, t, svc)
+	})
+	mux.HandleFunc(jobs.TypeMatchTransfers, func(ctx context.Context, t *asynq.Task) error {
+		return jobs.HandleMatchTransfersTask(ctx, t, svc)
+	})
+
+	// 5. Scheduled tasks. All are safe to run twice: reminders are recorded once per due
+	// date, detection updates series in place, scheduled transactions post each
+	// occurrence once and transfer matching skips entries already linked, so running more
+	// than one worker (and so more than one scheduler) doesn't duplicate anything.
+	scheduler := asynq.NewScheduler(asynq.RedisClientOpt{Addr: cfg.RedisAddr}, nil)
+	if _, err := scheduler.Register("0 9 * * *", jobs.NewPaymentRemindersTask()); err != nil {
+		logger.Error("Failed to schedule payment reminders", zap.Error(err))
+		os.Exit(1)
+	}
+	if _, err := scheduler.Register("0 3 * * *", jobs.NewDetectRecurringTask()); err != nil {
+		logger.Error("Failed to schedule recurring detection", zap.Error(err))
+		os.Exit(1)
+	}
+	if _, err := scheduler.Register("15 * * * *", jobs.NewPostScheduledTask()); err != nil {
+		logger.Error("Failed to schedule posting scheduled transactions", zap.Error(err))
+		os.Exit(1)
+	}
+	if _, err := scheduler.Register("45 * * * *", jobs.NewMatchTransfersTask()); err != nil {
+		logger.Error("Failed to schedule transfer matching", zap.Error(err))
+		os.Exit(1)
+	}
+	if err := scheduler.Start(); err != nil {
+		logger.Error("Scheduler failed", zap.Error(err))
+		os.Exit(1)
+	}
+	defer scheduler.Shutdown()
 
 	fmt.Printf("Worker server started on %s\n", cfg.RedisAddr)
 	if err := srv.Run(mux); err != nil {
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_accounts_plaid_item_id;
+ALTER TABLE accounts DROP COLUMN IF EXISTS plaid_item_id;
+ALTER TABLE accounts ALTER COLUMN plaid_account_id TYPE UUID USING plaid_account_id::UUID;
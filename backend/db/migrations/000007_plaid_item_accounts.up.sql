@@ -0,0 +1,7 @@
+-- Plaid account IDs are opaque strings, not UUIDs
+ALTER TABLE accounts ALTER COLUMN plaid_account_id TYPE TEXT USING plaid_account_id::TEXT;
+
+-- Link accounts to the Plaid item they were imported from
+ALTER TABLE accounts ADD COLUMN plaid_item_id UUID REFERENCES plaid_items(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_accounts_plaid_item_id ON accounts(plaid_item_id);
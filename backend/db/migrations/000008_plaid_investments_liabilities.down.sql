@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS credit_cards;
+DROP TABLE IF EXISTS loans;
+DROP INDEX IF EXISTS idx_entries_plaid_id;
+ALTER TABLE entries DROP COLUMN IF EXISTS plaid_id;
@@ -0,0 +1,23 @@
+-- Plaid transaction / investment transaction ID, so re-syncs don't duplicate entries
+ALTER TABLE entries ADD COLUMN plaid_id TEXT;
+CREATE UNIQUE INDEX idx_entries_plaid_id ON entries(plaid_id) WHERE plaid_id IS NOT NULL;
+
+-- Loan details (mortgages, student loans, ...)
+CREATE TABLE loans (
+    account_id UUID PRIMARY KEY REFERENCES accounts(id) ON DELETE CASCADE,
+    interest_rate DECIMAL(7,4),
+    term_months INTEGER,
+    minimum_payment DECIMAL(19,4),
+    next_payment_due_date DATE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+-- Credit card details
+CREATE TABLE credit_cards (
+    account_id UUID PRIMARY KEY REFERENCES accounts(id) ON DELETE CASCADE,
+    apr DECIMAL(7,4),
+    minimum_payment DECIMAL(19,4),
+    next_payment_due_date DATE,
+    last_statement_balance DECIMAL(19,4),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_accounts_plaid_account_id;
@@ -0,0 +1,2 @@
+-- Plaid account IDs are unique across items, so sync can upsert accounts by them
+CREATE UNIQUE INDEX idx_accounts_plaid_account_id ON accounts(plaid_account_id) WHERE plaid_account_id IS NOT NULL;
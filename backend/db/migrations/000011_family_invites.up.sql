@@ -0,0 +1,26 @@
+-- Families were only ever created by Register, whose user should have been the admin.
+-- Promote the first user of every family that has no admin yet.
+UPDATE users SET role = 'admin'
+WHERE id IN (
+    SELECT DISTINCT ON (family_id) id
+    FROM users
+    WHERE family_id NOT IN (SELECT family_id FROM users WHERE role = 'admin')
+    ORDER BY family_id, created_at
+);
+
+-- Invitations to join an existing family. Only the token hash is stored; the invite is
+-- bound to an email address and grants the role it was created with.
+CREATE TABLE family_invites (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    family_id UUID NOT NULL REFERENCES families(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    role TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    invited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    accepted_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_family_invites_family_id ON family_invites(family_id);
@@ -0,0 +1,4 @@
+-- Consecutive failed logins (wrong password or two-factor code) and the lockout they earn
+ALTER TABLE users
+    ADD COLUMN failed_login_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN locked_until TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS user_identities;
+
+-- SSO-only users can't be kept without a password
+DELETE FROM users WHERE password_digest IS NULL;
+ALTER TABLE users ALTER COLUMN password_digest SET NOT NULL;
@@ -0,0 +1,18 @@
+-- Who changed what in a family's data. Rows are only ever inserted, in the same
+-- transaction as the change they describe.
+CREATE TABLE audit_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    family_id UUID NOT NULL REFERENCES families(id) ON DELETE CASCADE,
+    actor_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    action TEXT NOT NULL,
+    entity_type TEXT NOT NULL,
+    entity_id UUID,
+    before JSONB,
+    after JSONB,
+    request_id TEXT,
+    ip_address TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_audit_events_family_created ON audit_events(family_id, created_at DESC);
+CREATE INDEX idx_audit_events_entity ON audit_events(entity_type, entity_id);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS crypto;
+DROP TABLE IF EXISTS investments;
+DROP TABLE IF EXISTS vehicles;
+DROP TABLE IF EXISTS properties;
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_type_check;
+ALTER TABLE accounts DROP COLUMN IF EXISTS type;
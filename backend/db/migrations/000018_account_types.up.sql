@@ -0,0 +1,45 @@
+-- Delegated types: every account has a type, and types with details of their own keep
+-- them in a table keyed by the account (loans and credit_cards already exist).
+ALTER TABLE accounts ADD COLUMN type TEXT DEFAULT 'depository' NOT NULL;
+
+-- Best guess for existing accounts
+UPDATE accounts SET type = 'credit_card' WHERE id IN (SELECT account_id FROM credit_cards) OR subtype = 'credit_card';
+UPDATE accounts SET type = 'loan' WHERE id IN (SELECT account_id FROM loans);
+UPDATE accounts SET type = 'other_liability' WHERE classification = 'liability' AND type = 'depository';
+UPDATE accounts SET type = 'investment'
+WHERE type = 'depository' AND EXISTS (SELECT 1 FROM entries e WHERE e.account_id = accounts.id AND e.entryable_type = 'Trade');
+
+ALTER TABLE accounts ADD CONSTRAINT accounts_type_check CHECK (type IN (
+    'depository', 'investment', 'crypto', 'property', 'vehicle', 'other_asset',
+    'credit_card', 'loan', 'other_liability'
+));
+
+CREATE TABLE properties (
+    account_id UUID PRIMARY KEY REFERENCES accounts(id) ON DELETE CASCADE,
+    address TEXT,
+    sqft INTEGER,
+    year_built INTEGER,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE TABLE vehicles (
+    account_id UUID PRIMARY KEY REFERENCES accounts(id) ON DELETE CASCADE,
+    make TEXT,
+    model TEXT,
+    year INTEGER,
+    mileage INTEGER,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE TABLE investments (
+    account_id UUID PRIMARY KEY REFERENCES accounts(id) ON DELETE CASCADE,
+    tax_treatment TEXT CHECK (tax_treatment IN ('taxable', 'tax_deferred', 'tax_exempt')),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE TABLE crypto (
+    account_id UUID PRIMARY KEY REFERENCES accounts(id) ON DELETE CASCADE,
+    network TEXT,
+    wallet_address TEXT,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
@@ -0,0 +1,3 @@
+ALTER TABLE loans
+    DROP COLUMN IF EXISTS start_date,
+    DROP COLUMN IF EXISTS original_principal;
@@ -0,0 +1,3 @@
+ALTER TABLE loans
+    ADD COLUMN IF NOT EXISTS original_principal DECIMAL(19, 4),
+    ADD COLUMN IF NOT EXISTS start_date DATE;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS payment_reminders;
+ALTER TABLE credit_cards
+    DROP COLUMN IF EXISTS payment_due_day,
+    DROP COLUMN IF EXISTS statement_close_day,
+    DROP COLUMN IF EXISTS credit_limit;
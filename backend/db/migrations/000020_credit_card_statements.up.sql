@@ -0,0 +1,15 @@
+ALTER TABLE credit_cards
+    ADD COLUMN IF NOT EXISTS credit_limit DECIMAL(19, 4),
+    ADD COLUMN IF NOT EXISTS statement_close_day INTEGER CHECK (statement_close_day BETWEEN 1 AND 31),
+    ADD COLUMN IF NOT EXISTS payment_due_day INTEGER CHECK (payment_due_day BETWEEN 1 AND 31);
+
+-- One row per payment reminder sent, so each due date is only reminded once
+CREATE TABLE payment_reminders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    family_id UUID NOT NULL REFERENCES families(id) ON DELETE CASCADE,
+    account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    due_date DATE NOT NULL,
+    amount DECIMAL(19, 4) DEFAULT 0 NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    UNIQUE (account_id, due_date)
+);
@@ -0,0 +1,23 @@
+-- Subscriptions and bills detected from entries. pattern_key identifies the series
+-- (its merchant or normalized name, and direction) so detection can update it in place.
+CREATE TABLE recurring_transactions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    family_id UUID NOT NULL REFERENCES families(id) ON DELETE CASCADE,
+    pattern_key TEXT NOT NULL,
+    merchant_id UUID REFERENCES merchants(id) ON DELETE SET NULL,
+    account_id UUID REFERENCES accounts(id) ON DELETE SET NULL,
+    name TEXT NOT NULL,
+    cadence TEXT NOT NULL CHECK (cadence IN ('weekly', 'monthly', 'annual')),
+    amount DECIMAL(19, 4) NOT NULL,
+    previous_amount DECIMAL(19, 4),
+    currency TEXT NOT NULL,
+    occurrences INTEGER NOT NULL,
+    last_date DATE NOT NULL,
+    next_date DATE NOT NULL,
+    status TEXT DEFAULT 'detected' NOT NULL CHECK (status IN ('detected', 'confirmed', 'dismissed')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    UNIQUE (family_id, pattern_key)
+);
+
+CREATE INDEX idx_recurring_transactions_next_date ON recurring_transactions(family_id, next_date);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS scheduled_transaction_occurrences;
+DROP TABLE IF EXISTS scheduled_transactions;
@@ -0,0 +1,32 @@
+-- Repeating manual entries, such as rent or a salary, posted by the worker on the dates
+-- their recurrence rule gives. posted_through is the last date the worker has handled.
+CREATE TABLE scheduled_transactions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    family_id UUID NOT NULL REFERENCES families(id) ON DELETE CASCADE,
+    account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    category_id UUID REFERENCES categories(id) ON DELETE SET NULL,
+    name TEXT NOT NULL,
+    amount DECIMAL(19, 4) NOT NULL,
+    currency TEXT NOT NULL,
+    rule TEXT NOT NULL,
+    start_date DATE NOT NULL,
+    posted_through DATE,
+    active BOOLEAN DEFAULT TRUE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE INDEX idx_scheduled_transactions_family ON scheduled_transactions(family_id);
+
+-- Single occurrences that were skipped or changed ahead of time, and the ones posted
+CREATE TABLE scheduled_transaction_occurrences (
+    scheduled_transaction_id UUID NOT NULL REFERENCES scheduled_transactions(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('skipped', 'overridden', 'posted')),
+    amount DECIMAL(19, 4),
+    name TEXT,
+    entry_id UUID REFERENCES entries(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (scheduled_transaction_id, date)
+);
@@ -0,0 +1,2 @@
+DROP VIEW IF EXISTS transaction_lines;
+DROP TABLE IF EXISTS transaction_splits;
@@ -0,0 +1,33 @@
+-- Lines of a transaction divided across categories; they add up to the entry's amount
+CREATE TABLE transaction_splits (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    category_id UUID REFERENCES categories(id) ON DELETE SET NULL,
+    amount DECIMAL(19, 4) NOT NULL,
+    memo TEXT DEFAULT '' NOT NULL,
+    position INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    UNIQUE (transaction_id, position)
+);
+
+-- One row per categorized line of every transaction entry: its split lines when it is
+-- split, otherwise the entry itself. Budgets, reports and search read categories and
+-- amounts from here rather than from transactions.category_id.
+CREATE VIEW transaction_lines AS
+SELECT
+    e.id AS entry_id, e.account_id, e.date, e.currency, e.name,
+    t.id AS transaction_id, t.kind, t.merchant_id,
+    s.category_id, s.amount, s.memo
+FROM entries e
+JOIN transactions t ON t.id = e.entryable_id
+JOIN transaction_splits s ON s.transaction_id = t.id
+WHERE e.entryable_type = 'Transaction'
+UNION ALL
+SELECT
+    e.id, e.account_id, e.date, e.currency, e.name,
+    t.id, t.kind, t.merchant_id,
+    t.category_id, e.amount, ''
+FROM entries e
+JOIN transactions t ON t.id = e.entryable_id
+WHERE e.entryable_type = 'Transaction'
+    AND NOT EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = t.id);
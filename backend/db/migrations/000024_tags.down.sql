@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS taggings;
+DROP TABLE IF EXISTS tags;
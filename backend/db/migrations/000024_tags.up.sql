@@ -0,0 +1,20 @@
+-- Family labels that cut across categories, like "vacation-2026" or "reimbursable"
+CREATE TABLE tags (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    family_id UUID NOT NULL REFERENCES families(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    color TEXT DEFAULT '' NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_tags_family_name ON tags(family_id, lower(name));
+
+CREATE TABLE taggings (
+    tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    entry_id UUID NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (tag_id, entry_id)
+);
+
+CREATE INDEX idx_taggings_entry_id ON taggings(entry_id);
@@ -0,0 +1,24 @@
+-- Pairs of entries that are the two sides of one transfer between a family's accounts,
+-- such as a card payment imported from both checking and the card. A suggested pair
+-- waits for review; a matched pair's entries share one 'transfer' transaction, and
+-- their original transactions are kept so unmatching can restore them. Rejected pairs
+-- are never suggested again.
+CREATE TABLE transfer_matches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    family_id UUID NOT NULL REFERENCES families(id) ON DELETE CASCADE,
+    from_entry_id UUID NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+    to_entry_id UUID NOT NULL REFERENCES entries(id) ON DELETE CASCADE,
+    status TEXT DEFAULT 'suggested' NOT NULL CHECK (status IN ('suggested', 'matched', 'rejected')),
+    automatic BOOLEAN DEFAULT FALSE NOT NULL,
+    transaction_id UUID REFERENCES transactions(id) ON DELETE SET NULL,
+    from_transaction_id UUID REFERENCES transactions(id) ON DELETE SET NULL,
+    to_transaction_id UUID REFERENCES transactions(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    UNIQUE (from_entry_id, to_entry_id)
+);
+
+CREATE INDEX idx_transfer_matches_family_status ON transfer_matches(family_id, status);
+CREATE INDEX idx_transfer_matches_to_entry ON transfer_matches(to_entry_id);
+CREATE UNIQUE INDEX idx_transfer_matches_matched_from ON transfer_matches(from_entry_id) WHERE status = 'matched';
+CREATE UNIQUE INDEX idx_transfer_matches_matched_to ON transfer_matches(to_entry_id) WHERE status = 'matched';
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS idx_entries_account_id ON entries(account_id);
+DROP INDEX IF EXISTS idx_entries_account_date;
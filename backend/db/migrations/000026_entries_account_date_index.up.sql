@@ -0,0 +1,4 @@
+-- Reports read a family's entries account by account over a date range. The composite
+-- index serves that and anything idx_entries_account_id did.
+CREATE INDEX idx_entries_account_date ON entries(account_id, date);
+DROP INDEX IF EXISTS idx_entries_account_id;
@@ -0,0 +1,170 @@
+// Package amortization computes fixed-rate loan schedules: how each monthly payment
+// splits into principal and interest, what is still owed at a date, and when a loan is
+// paid off with extra payments. Rates are annual percentages (4.5 means 4.5%) and
+// amounts are rounded to cents as a lender would.
+package amortization
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// ErrNeverPaidOff is returned when the payment doesn't cover the interest.
+var ErrNeverPaidOff = errors.New("payment doesn't cover the interest, so the loan is never paid off")
+
+// ErrInvalidLoan is returned for loans without a principal or term, or with a negative rate.
+var ErrInvalidLoan = errors.New("loan needs a positive principal and term and a non-negative rate")
+
+// Longest projection we run, so a payment a hair over the interest can't loop for ages.
+const maxMonths = 100 * 12
+
+// Loan is a fixed-rate loan paid monthly, the first payment due a month after Start.
+type Loan struct {
+	Principal  float64
+	AnnualRate float64
+	TermMonths int
+	Start      time.Time
+}
+
+// Payment is one month of a schedule. Balance is what is owed after it.
+type Payment struct {
+	Number    int       `json:"number"`
+	Date      time.Time `json:"date"`
+	Payment   float64   `json:"payment"`
+	Principal float64   `json:"principal"`
+	Interest  float64   `json:"interest"`
+	Balance   float64   `json:"balance"`
+}
+
+// MonthlyPayment is the level payment that pays off principal over months.
+func MonthlyPayment(principal, annualRate float64, months int) float64 {
+	if months <= 0 {
+		return 0
+	}
+	r := monthlyRate(annualRate)
+	if r == 0 {
+		return roundCents(principal / float64(months))
+	}
+	return roundCents(principal * r / (1 - math.Pow(1+r, -float64(months))))
+}
+
+// Schedule lays out every payment of loan. The last payment is adjusted to clear the
+// balance left by rounding.
+func Schedule(loan Loan) ([]Payment, error) {
+	if loan.Principal <= 0 || loan.TermMonths <= 0 || loan.AnnualRate < 0 {
+		return nil, ErrInvalidLoan
+	}
+	payment := MonthlyPayment(loan.Principal, loan.AnnualRate, loan.TermMonths)
+	return run(loan.Principal, loan.AnnualRate, payment, loan.Start, loan.TermMonths, true)
+}
+
+// BalanceAt is what schedule says is owed on date: the balance after the last payment
+// due on or before it, or the original principal before the first.
+func BalanceAt(schedule []Payment, principal float64, date time.Time) float64 {
+	balance := principal
+	for _, p := range schedule {
+		if p.Date.After(date) {
+			break
+		}
+		balance = p.Balance
+	}
+	return balance
+}
+
+// SplitPayment splits a payment made on balance into the month's interest and the
+// principal it pays down. A payment smaller than the interest is all interest.
+func SplitPayment(balance, annualRate, payment float64) (principal, interest float64) {
+	interest = roundCents(balance * monthlyRate(annualRate))
+	if interest > payment {
+		interest = payment
+	}
+	principal = roundCents(payment - interest)
+	if principal > balance {
+		principal = balance
+	}
+	return principal, interest
+}
+
+// Projection is how paying off a balance plays out.
+type Projection struct {
+	ExtraPayment  float64   `json:"extraPayment"`
+	Months        int       `json:"months"`
+	PayoffDate    time.Time `json:"payoffDate"`
+	TotalInterest float64   `json:"totalInterest"`
+	TotalPaid     float64   `json:"totalPaid"`
+}
+
+// Project pays balance down with payment plus extra each month, the first payment due a
+// month after from.
+func Project(balance, annualRate, payment, extra float64, from time.Time) (Projection, error) {
+	projection := Projection{ExtraPayment: extra, PayoffDate: from}
+	if balance <= 0 {
+		return projection, nil
+	}
+
+	schedule, err := run(balance, annualRate, payment+extra, from, maxMonths, false)
+	if err != nil {
+		return projection, err
+	}
+	last := schedule[len(schedule)-1]
+	if last.Balance > 0 {
+		return projection, ErrNeverPaidOff
+	}
+
+	projection.Months = len(schedule)
+	projection.PayoffDate = last.Date
+	for _, p := range schedule {
+		projection.TotalInterest += p.Interest
+		projection.TotalPaid += p.Payment
+	}
+	projection.TotalInterest = roundCents(projection.TotalInterest)
+	projection.TotalPaid = roundCents(projection.TotalPaid)
+	return projection, nil
+}
+
+// run pays down balance for up to months payments, stopping once it is cleared. With
+// settle the last payment takes whatever is left, so a full-term schedule ends at zero.
+func run(balance, annualRate, payment float64, start time.Time, months int, settle bool) ([]Payment, error) {
+	if payment <= roundCents(balance*monthlyRate(annualRate)) {
+		return nil, ErrNeverPaidOff
+	}
+
+	var schedule []Payment
+	for n := 1; n <= months && balance > 0; n++ {
+		principal, interest := SplitPayment(balance, annualRate, payment)
+		if settle && n == months {
+			principal = balance
+		}
+		balance = roundCents(balance - principal)
+		schedule = append(schedule, Payment{
+			Number:    n,
+			Date:      AddMonths(start, n),
+			Payment:   roundCents(principal + interest),
+			Principal: principal,
+			Interest:  interest,
+			Balance:   balance,
+		})
+	}
+	return schedule, nil
+}
+
+// AddMonths moves t by n calendar months, keeping the day of month where it exists and
+// using the month's last day where it doesn't (Jan 31 + 1 month is Feb 28 or 29).
+func AddMonths(t time.Time, n int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(n), 1, 0, 0, 0, 0, t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, t.Location())
+}
+
+func monthlyRate(annualRate float64) float64 {
+	return annualRate / 100 / 12
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,100 @@
+package amortization
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestMonthlyPayment(t *testing.T) {
+	assert.Equal(t, 1199.10, MonthlyPayment(200000, 6, 360))
+	assert.Equal(t, 100.0, MonthlyPayment(1200, 0, 12), "zero rate divides evenly")
+	assert.Equal(t, 0.0, MonthlyPayment(1200, 5, 0))
+}
+
+func TestSchedule(t *testing.T) {
+	schedule, err := Schedule(Loan{Principal: 200000, AnnualRate: 6, TermMonths: 360, Start: date(2024, 1, 15)})
+	require.NoError(t, err)
+	require.Len(t, schedule, 360)
+
+	first := schedule[0]
+	assert.Equal(t, date(2024, 2, 15), first.Date)
+	assert.Equal(t, 1000.00, first.Interest)
+	assert.Equal(t, 199.10, first.Principal)
+	assert.Equal(t, 199800.90, first.Balance)
+
+	last := schedule[359]
+	assert.Equal(t, date(2054, 1, 15), last.Date)
+	assert.Equal(t, 0.0, last.Balance)
+	assert.InDelta(t, 1199.10, last.Payment, 2, "rounding leftovers land on the last payment")
+
+	var principal float64
+	for _, p := range schedule {
+		principal += p.Principal
+		assert.InDelta(t, p.Payment, p.Principal+p.Interest, 0.001)
+	}
+	assert.InDelta(t, 200000, principal, 0.001)
+
+	_, err = Schedule(Loan{Principal: 1000, TermMonths: 0})
+	assert.ErrorIs(t, err, ErrInvalidLoan)
+}
+
+func TestBalanceAt(t *testing.T) {
+	loan := Loan{Principal: 1200, TermMonths: 12, Start: date(2024, 1, 1)}
+	schedule, err := Schedule(loan)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1200.0, BalanceAt(schedule, loan.Principal, date(2024, 1, 20)))
+	assert.Equal(t, 1100.0, BalanceAt(schedule, loan.Principal, date(2024, 2, 1)))
+	assert.Equal(t, 900.0, BalanceAt(schedule, loan.Principal, date(2024, 4, 30)))
+	assert.Equal(t, 0.0, BalanceAt(schedule, loan.Principal, date(2030, 1, 1)))
+}
+
+func TestSplitPayment(t *testing.T) {
+	principal, interest := SplitPayment(200000, 6, 1199.10)
+	assert.Equal(t, 199.10, principal)
+	assert.Equal(t, 1000.00, interest)
+
+	principal, interest = SplitPayment(200000, 6, 500)
+	assert.Equal(t, 0.0, principal)
+	assert.Equal(t, 500.0, interest, "a payment below the interest is all interest")
+
+	principal, interest = SplitPayment(100, 12, 500)
+	assert.Equal(t, 100.0, principal, "can't pay down more than is owed")
+	assert.Equal(t, 1.0, interest)
+}
+
+func TestProject(t *testing.T) {
+	from := date(2024, 1, 15)
+	base, err := Project(200000, 6, 1199.10, 0, from)
+	require.NoError(t, err)
+	assert.InDelta(t, 360, base.Months, 1, "the rounded payment may leave a last cent-sized month")
+	assert.Equal(t, AddMonths(from, base.Months), base.PayoffDate)
+
+	extra, err := Project(200000, 6, 1199.10, 200, from)
+	require.NoError(t, err)
+	assert.Equal(t, 200.0, extra.ExtraPayment)
+	assert.Less(t, extra.Months, base.Months)
+	assert.True(t, extra.PayoffDate.Before(base.PayoffDate))
+	assert.Less(t, extra.TotalInterest, base.TotalInterest)
+
+	_, err = Project(200000, 6, 900, 0, from)
+	assert.ErrorIs(t, err, ErrNeverPaidOff)
+
+	paid, err := Project(0, 6, 900, 0, from)
+	require.NoError(t, err)
+	assert.Equal(t, 0, paid.Months)
+}
+
+func TestAddMonths(t *testing.T) {
+	assert.Equal(t, date(2024, 2, 29), AddMonths(date(2024, 1, 31), 1))
+	assert.Equal(t, date(2023, 2, 28), AddMonths(date(2023, 1, 31), 1))
+	assert.Equal(t, date(2025, 1, 31), AddMonths(date(2024, 1, 31), 12))
+	assert.Equal(t, date(2024, 3, 15), AddMonths(date(2023, 12, 15), 3))
+}
@@ -0,0 +1,29 @@
+// Package audit carries who is making a change from the HTTP layer down to the
+// repositories, which record it in the same transaction as the change itself.
+package audit
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Actor is who a change is attributed to. Changes made outside a request, like Plaid
+// syncs in the worker, have no user.
+type Actor struct {
+	UserID    *uuid.UUID
+	RequestID string
+	IPAddress string
+}
+
+type actorKey struct{}
+
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor stored in ctx, or the zero Actor for system changes.
+func ActorFrom(ctx context.Context) Actor {
+	actor, _ := ctx.Value(actorKey{}).(Actor)
+	return actor
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/joho/godotenv"
 	"github.com/rakibulbh/ai-finance-manager/internal/logger"
 	"github.com/spf13/viper"
@@ -15,15 +17,61 @@ type Config struct {
 	// Plaid
 	PlaidClientID string `mapstructure:"PLAID_CLIENT_ID"`
 	PlaidSecret   string `mapstructure:"PLAID_SECRET"`
-	PlaidEnv      string `mapstructure:"PLAID_ENV"`
+	PlaidEnv      string `mapstructure:"PLAID_ENV"` // sandbox, development, production, or fake for the in-process fake
+	// Public URL Plaid should POST webhooks to, e.g. https://example.com/api/plaid/webhook
+	PlaidWebhookURL string `mapstructure:"PLAID_WEBHOOK_URL"`
 
 	// Encryption
 	EncryptionKey string `mapstructure:"ENCRYPTION_KEY"`
+	// Comma separated <id>:<key> pairs, current key first. Older keys stay listed until
+	// Plaid tokens and TOTP secrets have been re-encrypted with `worker -reencrypt-tokens`.
+	EncryptionKeys string `mapstructure:"ENCRYPTION_KEYS"`
 
 	// Redis
 	RedisAddr string `mapstructure:"REDIS_ADDR"`
-}
 
+	// Email
+	Mailer       string `mapstructure:"MAILER"` // smtp, or log (the default) for local development
+	SMTPHost     string `mapstructure:"SMTP_HOST"`
+	SMTPPort     string `mapstructure:"SMTP_PORT"`
+	SMTPUsername string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword string `mapstructure:"SMTP_PASSWORD"`
+	MailFrom     string `mapstructure:"MAIL_FROM"`
+	// Directory the log mailer writes .eml files to; empty logs them instead
+	MailDir string `mapstructure:"MAIL_DIR"`
+	// Base URL of the web app, used for links in emails
+	AppURL string `mapstructure:"APP_URL"`
+
+	// Rate limiting of the public auth endpoints
+	RateLimitStore string `mapstructure:"RATE_LIMIT_STORE"` // memory (the default), or redis to share limits through REDIS_ADDR
+	// Requests allowed per period from one IP, and against one email address
+	AuthRateLimitPerIP      int           `mapstructure:"AUTH_RATE_LIMIT_PER_IP"`
+	AuthRateLimitPerAccount int           `mapstructure:"AUTH_RATE_LIMIT_PER_ACCOUNT"`
+	AuthRateLimitPeriod     time.Duration `mapstructure:"AUTH_RATE_LIMIT_PERIOD"`
+
+	// Comma separated IPs or CIDR ranges of the reverse proxies in front of the API.
+	// X-Forwarded-For and X-Real-IP are only believed from these; empty ignores them.
+	TrustedProxies string `mapstructure:"TRUSTED_PROXIES"`
+
+	// Accounts lock after LOGIN_LOCKOUT_THRESHOLD consecutive failures, for
+	// LOGIN_LOCKOUT_BASE doubling with each further failure up to LOGIN_LOCKOUT_MAX
+	LoginLockoutThreshold int           `mapstructure:"LOGIN_LOCKOUT_THRESHOLD"`
+	LoginLockoutBase      time.Duration `mapstructure:"LOGIN_LOCKOUT_BASE"`
+	LoginLockoutMax       time.Duration `mapstructure:"LOGIN_LOCKOUT_MAX"`
+
+	// OpenID Connect single sign-on, off unless OIDC_ISSUER_URL is set. docker-compose
+	// runs a mock provider for local development at http://localhost:8085/default.
+	OIDCIssuerURL    string `mapstructure:"OIDC_ISSUER_URL"`
+	OIDCClientID     string `mapstructure:"OIDC_CLIENT_ID"`
+	OIDCClientSecret string `mapstructure:"OIDC_CLIENT_SECRET"`
+	// Where the provider sends users back to, i.e. the web app's callback page
+	OIDCRedirectURL string `mapstructure:"OIDC_REDIRECT_URL"`
+	// Family new SSO users join, with OIDC_DEFAULT_ROLE. Empty gives each their own family.
+	OIDCFamilyID    string `mapstructure:"OIDC_FAMILY_ID"`
+	OIDCDefaultRole string `mapstructure:"OIDC_DEFAULT_ROLE"`
+	// Comma separated email domains allowed to sign up through SSO; empty allows any
+	OIDCAllowedDomains string `mapstructure:"OIDC_ALLOWED_DOMAINS"`
+}
 
 func LoadConfig() (*Config, error) {
 	// 1. Load .env file if it exists
@@ -43,9 +91,41 @@ func LoadConfig() (*Config, error) {
 	viper.BindEnv("PLAID_CLIENT_ID")
 	viper.BindEnv("PLAID_SECRET")
 	viper.BindEnv("PLAID_ENV")
+	viper.BindEnv("PLAID_WEBHOOK_URL")
 	viper.BindEnv("ENCRYPTION_KEY")
+	viper.BindEnv("ENCRYPTION_KEYS")
 	viper.BindEnv("REDIS_ADDR")
+	viper.BindEnv("MAILER")
+	viper.BindEnv("SMTP_HOST")
+	viper.BindEnv("SMTP_PORT")
+	viper.BindEnv("SMTP_USERNAME")
+	viper.BindEnv("SMTP_PASSWORD")
+	viper.BindEnv("MAIL_FROM")
+	viper.BindEnv("MAIL_DIR")
+	viper.BindEnv("APP_URL")
+	viper.BindEnv("RATE_LIMIT_STORE")
+	viper.BindEnv("AUTH_RATE_LIMIT_PER_IP")
+	viper.BindEnv("AUTH_RATE_LIMIT_PER_ACCOUNT")
+	viper.BindEnv("AUTH_RATE_LIMIT_PERIOD")
+	viper.BindEnv("TRUSTED_PROXIES")
+	viper.BindEnv("LOGIN_LOCKOUT_THRESHOLD")
+	viper.BindEnv("LOGIN_LOCKOUT_BASE")
+	viper.BindEnv("LOGIN_LOCKOUT_MAX")
+	viper.BindEnv("OIDC_ISSUER_URL")
+	viper.BindEnv("OIDC_CLIENT_ID")
+	viper.BindEnv("OIDC_CLIENT_SECRET")
+	viper.BindEnv("OIDC_REDIRECT_URL")
+	viper.BindEnv("OIDC_FAMILY_ID")
+	viper.BindEnv("OIDC_DEFAULT_ROLE")
+	viper.BindEnv("OIDC_ALLOWED_DOMAINS")
 
+	viper.SetDefault("AUTH_RATE_LIMIT_PER_IP", 20)
+	viper.SetDefault("AUTH_RATE_LIMIT_PER_ACCOUNT", 5)
+	viper.SetDefault("AUTH_RATE_LIMIT_PERIOD", time.Minute)
+	viper.SetDefault("LOGIN_LOCKOUT_THRESHOLD", 5)
+	viper.SetDefault("LOGIN_LOCKOUT_BASE", time.Minute)
+	viper.SetDefault("LOGIN_LOCKOUT_MAX", time.Hour)
+	viper.SetDefault("OIDC_DEFAULT_ROLE", "member")
 
 	var cfg Config
 	err = viper.Unmarshal(&cfg)
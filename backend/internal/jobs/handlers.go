@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,15 +16,26 @@ import (
 )
 
 type WorkerServices struct {
-	Plaid    PlaidProvider
-	DB       ItemStorage
-	Ledger   LedgerStorage
-	Accounts AccountStorage
+	Plaid       PlaidProvider
+	DB          ItemStorage
+	Ledger      LedgerStorage
+	Accounts    AccountStorage
+	Investments InvestmentStorage
+	Reminders   ReminderStorage
+	Members     MemberStorage
+	Mailer      ReminderMailer
+	Recurring   RecurringStorage
+	Scheduled   ScheduledStorage
+	Transfers   TransferStorage
 }
 
 type PlaidProvider interface {
 	DecryptToken(encryptedToken string) (string, error)
+	GetAccounts(ctx context.Context, accessToken string) ([]plaid.AccountBase, error)
 	SyncTransactions(ctx context.Context, accessToken string, cursor string) (plaid.TransactionsSyncResponse, error)
+	GetInvestmentHoldings(ctx context.Context, accessToken string) (plaid.InvestmentsHoldingsGetResponse, error)
+	GetInvestmentTransactions(ctx context.Context, accessToken string, start, end time.Time) ([]plaid.InvestmentTransaction, []plaid.Security, error)
+	GetLiabilities(ctx context.Context, accessToken string) (plaid.LiabilitiesGetResponse, error)
 }
 
 type ItemStorage interface {
@@ -35,93 +47,301 @@ type ItemStorage interface {
 type LedgerStorage interface {
     GetOrCreateMerchant(ctx context.Context, name string, familyID uuid.UUID) (uuid.UUID, error)
 	CreateTransaction(ctx context.Context, entry *models.Entry, txDetail *models.Transaction) error
+	HasEntry(ctx context.Context, id uuid.UUID) (bool, error)
+	HasPlaidEntry(ctx context.Context, plaidID string) (bool, error)
+	UpdatePlaidEntry(ctx context.Context, entry *models.Entry) error
+	DeletePlaidEntry(ctx context.Context, plaidID string) error
 }
 
 type AccountStorage interface {
     GetByPlaidID(ctx context.Context, familyID uuid.UUID, plaidAccountID string) (*models.Account, error)
+	UpsertPlaidAccount(ctx context.Context, itemID uuid.UUID, plaidAccountID string, acc *models.Account) error
+	UpsertLoanDetails(ctx context.Context, accountID uuid.UUID, details *models.LoanDetails) error
+	UpsertCreditCardDetails(ctx context.Context, accountID uuid.UUID, details *models.CreditCardDetails) error
 }
 
-func HandleSyncAccountTask(ctx context.Context, t *asynq.Task, svc *WorkerServices) error {
+type InvestmentStorage interface {
+	GetOrCreateSecurity(ctx context.Context, ticker, name string) (uuid.UUID, error)
+	UpdateSecurityPrice(ctx context.Context, ticker string, price float64) error
+	CreateTrade(ctx context.Context, entry *models.Entry, trade *models.Trade) error
+}
+
+// loadItem decodes a per-item task payload and returns the item with its decrypted access token.
+func loadItem(ctx context.Context, t *asynq.Task, svc *WorkerServices) (*models.PlaidItem, string, error) {
 	var p SyncAccountPayload
 	if err := json.Unmarshal(t.Payload(), &p); err != nil {
-		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+		return nil, "", fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
 	}
 
-	// 1. Fetch Item
 	item, err := svc.DB.GetItemByID(ctx, p.ItemID)
 	if err != nil {
-		return fmt.Errorf("failed to fetch plaid item: %w", err)
+		return nil, "", fmt.Errorf("failed to fetch plaid item: %w", err)
 	}
 
-	// 2. Decrypt Token
 	accessToken, err := svc.Plaid.DecryptToken(item.AccessToken)
 	if err != nil {
-		return fmt.Errorf("failed to decrypt access token: %w", err)
+		return nil, "", fmt.Errorf("failed to decrypt access token: %w", err)
+	}
+
+	return item, accessToken, nil
+}
+
+// plaidErrorCode returns the Plaid error code carried by err, or "" if it isn't a Plaid API error.
+func plaidErrorCode(err error) string {
+	plaidErr, convErr := plaid.ToPlaidError(err)
+	if convErr != nil {
+		return ""
+	}
+	return plaidErr.ErrorCode
+}
+
+// productUnavailable reports whether Plaid refused a request because the item doesn't
+// have the product (e.g. a checking-only bank asked for holdings). Retrying won't help.
+func productUnavailable(err error) bool {
+	switch plaidErrorCode(err) {
+	case "PRODUCTS_NOT_SUPPORTED", "NO_INVESTMENT_ACCOUNTS", "NO_LIABILITY_ACCOUNTS",
+		"ADDITIONAL_CONSENT_REQUIRED", "INVALID_PRODUCT":
+		return true
+	}
+	return false
+}
+
+// parsePlaidDate parses Plaid's YYYY-MM-DD dates, returning nil when absent or malformed.
+func parsePlaidDate(value plaid.NullableString) *time.Time {
+	if value.Get() == nil {
+		return nil
+	}
+	date, err := time.Parse("2006-01-02", *value.Get())
+	if err != nil {
+		return nil
 	}
+	return &date
+}
+
+// Plaid asks us to restart pagination from the original cursor when the item changes
+// mid-sync; give up after a few attempts and let asynq retry later.
+const maxSyncRestarts = 3
 
-	// 3. Sync from Plaid
-	resp, err := svc.Plaid.SyncTransactions(ctx, accessToken, item.SyncCursor)
+func HandleSyncAccountTask(ctx context.Context, t *asynq.Task, svc *WorkerServices) error {
+	// 1-2. Fetch Item & Decrypt Token
+	item, accessToken, err := loadItem(ctx, t, svc)
 	if err != nil {
-		return fmt.Errorf("plaid sync failed: %w", err)
+		return err
+	}
+
+	// 3. Make sure every Plaid account exists locally before attaching transactions
+	plaidAccounts, err := svc.Plaid.GetAccounts(ctx, accessToken)
+	if err != nil {
+		return fmt.Errorf("plaid accounts fetch failed: %w", err)
+	}
+	if err := upsertPlaidAccounts(ctx, svc, item, plaidAccounts); err != nil {
+		return err
 	}
 
+	// 4. Sync from Plaid, page by page
+	cursor := item.SyncCursor
+	restarts := 0
+	for {
+		resp, err := svc.Plaid.SyncTransactions(ctx, accessToken, cursor)
+		if err != nil {
+			if plaidErrorCode(err) == "TRANSACTIONS_SYNC_MUTATION_DURING_PAGINATION" && restarts < maxSyncRestarts {
+				// Pages are applied idempotently, so replaying them is safe
+				restarts++
+				cursor = item.SyncCursor
+				continue
+			}
+			return fmt.Errorf("plaid sync failed: %w", err)
+		}
+
+		if err := applySyncPage(ctx, svc, item, resp); err != nil {
+			return err
+		}
+
+		cursor = resp.NextCursor
+		if !resp.HasMore {
+			break
+		}
+	}
+
+	// 5. Plaid's balances win over the ledger's running sum
+	if err := upsertPlaidAccounts(ctx, svc, item, plaidAccounts); err != nil {
+		return err
+	}
+
+	// 6. Update Cursor
+	if err := svc.DB.UpdateCursor(ctx, item.ItemID, cursor); err != nil {
+		return fmt.Errorf("failed to update cursor: %w", err)
+	}
+
+	return nil
+}
+
+func upsertPlaidAccounts(ctx context.Context, svc *WorkerServices, item *models.PlaidItem, plaidAccounts []plaid.AccountBase) error {
+	for _, plAcc := range plaidAccounts {
+		acc := plaidAccount(item, plAcc)
+		if err := svc.Accounts.UpsertPlaidAccount(ctx, item.ID, plAcc.AccountId, acc); err != nil {
+			return fmt.Errorf("failed to save plaid account %s: %w", plAcc.AccountId, err)
+		}
+	}
+	return nil
+}
 
-	// 4. Process Added Transactions
+// plaidAccount maps a Plaid account onto ours. Plaid reports what is owed on credit and
+// loan accounts as a positive balance, which is how we store liabilities too.
+func plaidAccount(item *models.PlaidItem, plAcc plaid.AccountBase) *models.Account {
+	classification := "asset"
+	if plAcc.Type == plaid.ACCOUNTTYPE_CREDIT || plAcc.Type == plaid.ACCOUNTTYPE_LOAN {
+		classification = "liability"
+	}
+
+	subtype := string(plAcc.Type)
+	if plAcc.Subtype.Get() != nil {
+		subtype = strings.ReplaceAll(string(*plAcc.Subtype.Get()), " ", "_")
+	}
+
+	currency := "USD"
+	if plAcc.Balances.IsoCurrencyCode.Get() != nil {
+		currency = *plAcc.Balances.IsoCurrencyCode.Get()
+	}
+
+	return &models.Account{
+		FamilyID:       item.FamilyID,
+		Name:           plAcc.Name,
+		Type:           plaidAccountType(plAcc.Type, classification),
+		Subtype:        subtype,
+		Classification: classification,
+		Balance:        plAcc.Balances.GetCurrent(),
+		Currency:       currency,
+	}
+}
+
+// plaidAccountType maps Plaid's account types onto ours. Plaid's "other" can be either
+// side of the balance sheet.
+func plaidAccountType(t plaid.AccountType, classification string) string {
+	switch t {
+	case plaid.ACCOUNTTYPE_DEPOSITORY:
+		return models.AccountTypeDepository
+	case plaid.ACCOUNTTYPE_CREDIT:
+		return models.AccountTypeCreditCard
+	case plaid.ACCOUNTTYPE_LOAN:
+		return models.AccountTypeLoan
+	case plaid.ACCOUNTTYPE_INVESTMENT, plaid.ACCOUNTTYPE_BROKERAGE:
+		return models.AccountTypeInvestment
+	}
+	if classification == "liability" {
+		return models.AccountTypeOtherLiability
+	}
+	return models.AccountTypeOtherAsset
+}
+
+func applySyncPage(ctx context.Context, svc *WorkerServices, item *models.PlaidItem, resp plaid.TransactionsSyncResponse) error {
+	// Added Transactions
 	for _, plTx := range resp.Added {
-		// Find Account
-		acc, err := svc.Accounts.GetByPlaidID(ctx, item.FamilyID, plTx.AccountId)
+		// Skip anything a previous (partially failed) run already imported
+		exists, err := svc.Ledger.HasPlaidEntry(ctx, plTx.TransactionId)
 		if err != nil {
-			logger.Warn("Account not found for Plaid ID, skipping transaction",
-				zap.String("plaid_account_id", plTx.AccountId),
-				zap.String("transaction_id", plTx.TransactionId))
+			return fmt.Errorf("failed to check for existing transaction %s: %w", plTx.TransactionId, err)
+		}
+		if exists {
 			continue
 		}
 
-		// Prepare Entry
-		date, err := time.Parse("2006-01-02", plTx.Date)
+		if err := createPlaidTransaction(ctx, svc, item, plTx); err != nil {
+			return err
+		}
+	}
+
+	// Modified Transactions (e.g. pending -> posted with a new amount)
+	for _, plTx := range resp.Modified {
+		exists, err := svc.Ledger.HasPlaidEntry(ctx, plTx.TransactionId)
 		if err != nil {
-			logger.Error("Failed to parse date from Plaid transaction", zap.String("date", plTx.Date), zap.Error(err))
+			return fmt.Errorf("failed to check for existing transaction %s: %w", plTx.TransactionId, err)
+		}
+		if !exists {
+			if err := createPlaidTransaction(ctx, svc, item, plTx); err != nil {
+				return err
+			}
 			continue
 		}
 
-		currency := "USD"
-		if plTx.IsoCurrencyCode.Get() != nil {
-			currency = *plTx.IsoCurrencyCode.Get()
+		entry, ok := plaidEntry(plTx)
+		if !ok {
+			continue
+		}
+		if err := svc.Ledger.UpdatePlaidEntry(ctx, entry); err != nil {
+			return fmt.Errorf("failed to update synced transaction %s: %w", plTx.TransactionId, err)
 		}
+	}
 
-		entry := &models.Entry{
-			AccountID: acc.ID,
-			Amount:    plTx.Amount,
-			Date:      date,
-			Name:      plTx.Name,
-			Currency:  currency,
+	// Removed Transactions
+	for _, removed := range resp.Removed {
+		if removed.TransactionId == nil {
+			continue
+		}
+		if err := svc.Ledger.DeletePlaidEntry(ctx, *removed.TransactionId); err != nil {
+			return fmt.Errorf("failed to remove synced transaction %s: %w", *removed.TransactionId, err)
 		}
+	}
 
+	return nil
+}
 
-		// Prepare Transaction details
-		var merchantID *uuid.UUID
-		if plTx.MerchantName.Get() != nil {
-			mID, err := svc.Ledger.GetOrCreateMerchant(ctx, *plTx.MerchantName.Get(), item.FamilyID)
-			if err == nil {
-				merchantID = &mID
-			}
-		}
+func createPlaidTransaction(ctx context.Context, svc *WorkerServices, item *models.PlaidItem, plTx plaid.Transaction) error {
+	// Find Account
+	acc, err := svc.Accounts.GetByPlaidID(ctx, item.FamilyID, plTx.AccountId)
+	if err != nil {
+		logger.Warn("Account not found for Plaid ID, skipping transaction",
+			zap.String("plaid_account_id", plTx.AccountId),
+			zap.String("transaction_id", plTx.TransactionId))
+		return nil
+	}
 
-		txDetail := &models.Transaction{
-			MerchantID: merchantID,
-			Kind:       "standard",
-		}
+	// Prepare Entry
+	entry, ok := plaidEntry(plTx)
+	if !ok {
+		return nil
+	}
+	entry.AccountID = acc.ID
 
-		// Save
-		if err := svc.Ledger.CreateTransaction(ctx, entry, txDetail); err != nil {
-			return fmt.Errorf("failed to save synced transaction %s: %w", plTx.TransactionId, err)
+	// Prepare Transaction details
+	var merchantID *uuid.UUID
+	if plTx.MerchantName.Get() != nil {
+		mID, err := svc.Ledger.GetOrCreateMerchant(ctx, *plTx.MerchantName.Get(), item.FamilyID)
+		if err == nil {
+			merchantID = &mID
 		}
 	}
 
-	// 5. Update Cursor
-	if err := svc.DB.UpdateCursor(ctx, item.ItemID, resp.NextCursor); err != nil {
-		return fmt.Errorf("failed to update cursor: %w", err)
+	txDetail := &models.Transaction{
+		MerchantID: merchantID,
+		Kind:       "standard",
 	}
 
+	// Save
+	if err := svc.Ledger.CreateTransaction(ctx, entry, txDetail); err != nil {
+		return fmt.Errorf("failed to save synced transaction %s: %w", plTx.TransactionId, err)
+	}
 	return nil
 }
+
+// plaidEntry builds the ledger entry for a Plaid transaction, without the account.
+func plaidEntry(plTx plaid.Transaction) (*models.Entry, bool) {
+	date, err := time.Parse("2006-01-02", plTx.Date)
+	if err != nil {
+		logger.Error("Failed to parse date from Plaid transaction", zap.String("date", plTx.Date), zap.Error(err))
+		return nil, false
+	}
+
+	currency := "USD"
+	if plTx.IsoCurrencyCode.Get() != nil {
+		currency = *plTx.IsoCurrencyCode.Get()
+	}
+
+	return &models.Entry{
+		Amount:   plTx.Amount,
+		Date:     date,
+		Name:     plTx.Name,
+		Currency: currency,
+		PlaidID:  plTx.TransactionId,
+	}, true
+}
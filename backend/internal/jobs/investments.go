@@ -0,0 +1,151 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/plaid/plaid-go/v20/plaid"
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"go.uber.org/zap"
+)
+
+// How far back investment transactions are pulled. Already imported ones are skipped by plaid_id.
+const investmentHistory = 2 * 365 * 24 * time.Hour
+
+func HandleSyncInvestmentsTask(ctx context.Context, t *asynq.Task, svc *WorkerServices) error {
+	item, accessToken, err := loadItem(ctx, t, svc)
+	if err != nil {
+		return err
+	}
+
+	// 1. Holdings: keep securities and their prices current
+	holdings, err := svc.Plaid.GetInvestmentHoldings(ctx, accessToken)
+	if err != nil {
+		if productUnavailable(err) {
+			logger.Warn("Investments not available for item", zap.String("item_id", item.ItemID), zap.Error(err))
+			return nil
+		}
+		return fmt.Errorf("plaid holdings fetch failed: %w", err)
+	}
+	if err := upsertPlaidAccounts(ctx, svc, item, holdings.Accounts); err != nil {
+		return err
+	}
+
+	securities := make(map[string]plaid.Security)
+	for _, sec := range holdings.Securities {
+		securities[sec.SecurityId] = sec
+		if _, err := svc.Investments.GetOrCreateSecurity(ctx, securityTicker(sec), securityName(sec)); err != nil {
+			return fmt.Errorf("failed to save security %s: %w", sec.SecurityId, err)
+		}
+	}
+
+	for _, holding := range holdings.Holdings {
+		sec, ok := securities[holding.SecurityId]
+		if !ok {
+			continue
+		}
+		if err := svc.Investments.UpdateSecurityPrice(ctx, securityTicker(sec), holding.InstitutionPrice); err != nil {
+			return fmt.Errorf("failed to update price for %s: %w", securityTicker(sec), err)
+		}
+	}
+
+	// 2. Investment transactions: buys/sells become trades, everything else cash movements
+	end := time.Now()
+	transactions, txSecurities, err := svc.Plaid.GetInvestmentTransactions(ctx, accessToken, end.Add(-investmentHistory), end)
+	if err != nil {
+		if productUnavailable(err) {
+			return nil
+		}
+		return fmt.Errorf("plaid investment transactions fetch failed: %w", err)
+	}
+	for _, sec := range txSecurities {
+		securities[sec.SecurityId] = sec
+	}
+
+	for _, invTx := range transactions {
+		exists, err := svc.Ledger.HasPlaidEntry(ctx, invTx.InvestmentTransactionId)
+		if err != nil {
+			return fmt.Errorf("failed to check for existing investment transaction %s: %w", invTx.InvestmentTransactionId, err)
+		}
+		if exists {
+			continue
+		}
+
+		acc, err := svc.Accounts.GetByPlaidID(ctx, item.FamilyID, invTx.AccountId)
+		if err != nil {
+			logger.Warn("Account not found for Plaid ID, skipping investment transaction",
+				zap.String("plaid_account_id", invTx.AccountId),
+				zap.String("investment_transaction_id", invTx.InvestmentTransactionId))
+			continue
+		}
+
+		date, err := time.Parse("2006-01-02", invTx.Date)
+		if err != nil {
+			logger.Error("Failed to parse date from Plaid investment transaction", zap.String("date", invTx.Date), zap.Error(err))
+			continue
+		}
+
+		currency := "USD"
+		if invTx.IsoCurrencyCode.Get() != nil {
+			currency = *invTx.IsoCurrencyCode.Get()
+		}
+
+		// Plaid's sign matches the ledger's: positive is cash leaving the account (a buy or
+		// fee), negative is cash coming in (a sale or dividend). Trades and cash entries alike.
+		entry := &models.Entry{
+			AccountID: acc.ID,
+			Amount:    invTx.Amount,
+			Date:      date,
+			Name:      invTx.Name,
+			Currency:  currency,
+			PlaidID:   invTx.InvestmentTransactionId,
+		}
+
+		sec, hasSecurity := securities[invTx.GetSecurityId()]
+		isTrade := invTx.Type == plaid.INVESTMENTTRANSACTIONTYPE_BUY || invTx.Type == plaid.INVESTMENTTRANSACTIONTYPE_SELL
+		if isTrade && hasSecurity {
+			secID, err := svc.Investments.GetOrCreateSecurity(ctx, securityTicker(sec), securityName(sec))
+			if err != nil {
+				return fmt.Errorf("failed to save security %s: %w", sec.SecurityId, err)
+			}
+
+			trade := &models.Trade{
+				SecurityID: secID,
+				Qty:        math.Abs(invTx.Quantity),
+				Price:      invTx.Price,
+				Kind:       string(invTx.Type),
+			}
+			if err := svc.Investments.CreateTrade(ctx, entry, trade); err != nil {
+				return fmt.Errorf("failed to save trade %s: %w", invTx.InvestmentTransactionId, err)
+			}
+			continue
+		}
+
+		// Dividends, fees, contributions, ...
+		if err := svc.Ledger.CreateTransaction(ctx, entry, &models.Transaction{Kind: "standard"}); err != nil {
+			return fmt.Errorf("failed to save investment cash transaction %s: %w", invTx.InvestmentTransactionId, err)
+		}
+	}
+
+	return nil
+}
+
+// securityTicker picks the identifier stored in securities.ticker.
+// Not every Plaid security has a ticker (e.g. some mutual funds), so fall back to Plaid's ID.
+func securityTicker(sec plaid.Security) string {
+	if sec.TickerSymbol.Get() != nil && *sec.TickerSymbol.Get() != "" {
+		return *sec.TickerSymbol.Get()
+	}
+	return sec.SecurityId
+}
+
+func securityName(sec plaid.Security) string {
+	if sec.Name.Get() != nil && *sec.Name.Get() != "" {
+		return *sec.Name.Get()
+	}
+	return securityTicker(sec)
+}
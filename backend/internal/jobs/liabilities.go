@@ -0,0 +1,156 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/plaid/plaid-go/v20/plaid"
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"go.uber.org/zap"
+)
+
+func HandleSyncLiabilitiesTask(ctx context.Context, t *asynq.Task, svc *WorkerServices) error {
+	item, accessToken, err := loadItem(ctx, t, svc)
+	if err != nil {
+		return err
+	}
+
+	resp, err := svc.Plaid.GetLiabilities(ctx, accessToken)
+	if err != nil {
+		if productUnavailable(err) {
+			logger.Warn("Liabilities not available for item", zap.String("item_id", item.ItemID), zap.Error(err))
+			return nil
+		}
+		return fmt.Errorf("plaid liabilities fetch failed: %w", err)
+	}
+	if err := upsertPlaidAccounts(ctx, svc, item, resp.Accounts); err != nil {
+		return err
+	}
+
+	// 1. Credit cards. Plaid only reports dates, so the cycle days come from the last
+	// statement and the next due date.
+	limits := make(map[string]float64)
+	for _, acc := range resp.Accounts {
+		limits[acc.AccountId] = acc.Balances.GetLimit()
+	}
+	for _, card := range resp.Liabilities.Credit {
+		accountID, ok := liabilityAccount(ctx, svc, item, card.AccountId.Get())
+		if !ok {
+			continue
+		}
+
+		details := &models.CreditCardDetails{
+			APR:                  purchaseAPR(card.Aprs),
+			MinimumPayment:       card.GetMinimumPaymentAmount(),
+			NextPaymentDueDate:   parsePlaidDate(card.NextPaymentDueDate),
+			LastStatementBalance: card.GetLastStatementBalance(),
+			CreditLimit:          limits[card.GetAccountId()],
+		}
+		if closed := parsePlaidDate(card.LastStatementIssueDate); closed != nil {
+			details.StatementCloseDay = closed.Day()
+		}
+		if details.NextPaymentDueDate != nil {
+			details.PaymentDueDay = details.NextPaymentDueDate.Day()
+		}
+		if err := svc.Accounts.UpsertCreditCardDetails(ctx, accountID, details); err != nil {
+			return fmt.Errorf("failed to save credit card details: %w", err)
+		}
+	}
+
+	// 2. Mortgages
+	for _, mortgage := range resp.Liabilities.Mortgage {
+		accountID, ok := liabilityAccount(ctx, svc, item, &mortgage.AccountId)
+		if !ok {
+			continue
+		}
+
+		details := &models.LoanDetails{
+			InterestRate:       mortgage.InterestRate.GetPercentage(),
+			TermMonths:         parseLoanTerm(mortgage.GetLoanTerm()),
+			MinimumPayment:     mortgage.GetNextMonthlyPayment(),
+			NextPaymentDueDate: parsePlaidDate(mortgage.NextPaymentDueDate),
+			OriginalPrincipal:  mortgage.GetOriginationPrincipalAmount(),
+			StartDate:          parsePlaidDate(mortgage.OriginationDate),
+		}
+		if err := svc.Accounts.UpsertLoanDetails(ctx, accountID, details); err != nil {
+			return fmt.Errorf("failed to save mortgage details: %w", err)
+		}
+	}
+
+	// 3. Student loans
+	for _, loan := range resp.Liabilities.Student {
+		accountID, ok := liabilityAccount(ctx, svc, item, loan.AccountId.Get())
+		if !ok {
+			continue
+		}
+
+		details := &models.LoanDetails{
+			InterestRate:       loan.InterestRatePercentage,
+			MinimumPayment:     loan.GetMinimumPaymentAmount(),
+			NextPaymentDueDate: parsePlaidDate(loan.NextPaymentDueDate),
+			OriginalPrincipal:  loan.GetOriginationPrincipalAmount(),
+			StartDate:          parsePlaidDate(loan.OriginationDate),
+		}
+		if err := svc.Accounts.UpsertLoanDetails(ctx, accountID, details); err != nil {
+			return fmt.Errorf("failed to save student loan details: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func liabilityAccount(ctx context.Context, svc *WorkerServices, item *models.PlaidItem, plaidAccountID *string) (uuid.UUID, bool) {
+	if plaidAccountID == nil {
+		return uuid.Nil, false
+	}
+
+	acc, err := svc.Accounts.GetByPlaidID(ctx, item.FamilyID, *plaidAccountID)
+	if err != nil {
+		logger.Warn("Account not found for Plaid ID, skipping liability",
+			zap.String("plaid_account_id", *plaidAccountID),
+			zap.String("item_id", item.ItemID))
+		return uuid.Nil, false
+	}
+	return acc.ID, true
+}
+
+// purchaseAPR returns the purchase APR, which is what a card's "APR" usually means,
+// falling back to the first one listed.
+func purchaseAPR(aprs []plaid.APR) float64 {
+	for _, apr := range aprs {
+		if apr.AprType == "purchase_apr" {
+			return apr.AprPercentage
+		}
+	}
+	if len(aprs) > 0 {
+		return aprs[0].AprPercentage
+	}
+	return 0
+}
+
+// parseLoanTerm turns Plaid's free-form loan term ("30 year", "360 month") into months.
+// Returns 0 when it can't tell.
+func parseLoanTerm(term string) int {
+	fields := strings.Fields(strings.ToLower(term))
+	if len(fields) != 2 {
+		return 0
+	}
+
+	n, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0
+	}
+
+	switch strings.TrimSuffix(fields[1], "s") {
+	case "year":
+		return n * 12
+	case "month":
+		return n
+	}
+	return 0
+}
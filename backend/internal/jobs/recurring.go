@@ -0,0 +1,65 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/recurring"
+	"go.uber.org/zap"
+)
+
+const TypeDetectRecurring = "recurring:detect"
+
+// How far back detection looks: long enough to see an annual charge twice.
+const recurringLookbackMonths = 18
+
+type RecurringStorage interface {
+	ListActiveFamilies(ctx context.Context, since time.Time) ([]uuid.UUID, error)
+	ListTransactions(ctx context.Context, familyID uuid.UUID, since time.Time) ([]recurring.Transaction, error)
+	SaveDetected(ctx context.Context, familyID uuid.UUID, found []models.RecurringTransaction) error
+}
+
+// NewDetectRecurringTask is scheduled daily; it has no payload.
+func NewDetectRecurringTask() *asynq.Task {
+	return asynq.NewTask(TypeDetectRecurring, nil)
+}
+
+// HandleDetectRecurringTask scans each family's recent transactions for subscriptions
+// and bills. One family failing doesn't stop the rest; the task fails at the end so
+// asynq retries.
+func HandleDetectRecurringTask(ctx context.Context, t *asynq.Task, svc *WorkerServices) error {
+	since := time.Now().AddDate(0, -recurringLookbackMonths, 0)
+	families, err := svc.Recurring.ListActiveFamilies(ctx, since)
+	if err != nil {
+		return fmt.Errorf("failed to list families: %w", err)
+	}
+
+	failed := 0
+	for _, familyID := range families {
+		if err := detectRecurring(ctx, svc, familyID, since); err != nil {
+			logger.Error("Recurring detection failed", zap.String("family_id", familyID.String()), zap.Error(err))
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("recurring detection failed for %d families", failed)
+	}
+	return nil
+}
+
+func detectRecurring(ctx context.Context, svc *WorkerServices, familyID uuid.UUID, since time.Time) error {
+	txs, err := svc.Recurring.ListTransactions(ctx, familyID, since)
+	if err != nil {
+		return err
+	}
+	found := recurring.Detect(familyID, txs)
+	if len(found) == 0 {
+		return nil
+	}
+	return svc.Recurring.SaveDetected(ctx, familyID, found)
+}
@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/recurring"
+)
+
+type recurringStore struct {
+	transactions map[uuid.UUID][]recurring.Transaction
+	failing      map[uuid.UUID]bool
+	saved        map[uuid.UUID][]models.RecurringTransaction
+}
+
+func (s *recurringStore) ListActiveFamilies(ctx context.Context, since time.Time) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for id := range s.transactions {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (s *recurringStore) ListTransactions(ctx context.Context, familyID uuid.UUID, since time.Time) ([]recurring.Transaction, error) {
+	if s.failing[familyID] {
+		return nil, errors.New("connection reset")
+	}
+	return s.transactions[familyID], nil
+}
+
+func (s *recurringStore) SaveDetected(ctx context.Context, familyID uuid.UUID, found []models.RecurringTransaction) error {
+	s.saved[familyID] = found
+	return nil
+}
+
+func TestHandleDetectRecurringTask(t *testing.T) {
+	good, bad := uuid.New(), uuid.New()
+	start := time.Now().AddDate(0, -4, 0)
+	var txs []recurring.Transaction
+	for i := 0; i < 4; i++ {
+		txs = append(txs, recurring.Transaction{Name: "Streaming", Amount: 15.49, Currency: "USD", Date: start.AddDate(0, i, 0)})
+	}
+
+	store := &recurringStore{
+		transactions: map[uuid.UUID][]recurring.Transaction{good: txs, bad: nil},
+		failing:      map[uuid.UUID]bool{bad: true},
+		saved:        make(map[uuid.UUID][]models.RecurringTransaction),
+	}
+	svc := &WorkerServices{Recurring: store}
+
+	err := HandleDetectRecurringTask(context.Background(), NewDetectRecurringTask(), svc)
+	if err == nil {
+		t.Error("Expected the failing family to fail the task so it is retried")
+	}
+	if found := store.saved[good]; len(found) != 1 || found[0].Cadence != models.RecurringCadenceMonthly {
+		t.Errorf("Expected the other family's subscription to be saved anyway, got %+v", found)
+	}
+}
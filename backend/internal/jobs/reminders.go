@@ -0,0 +1,87 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/statements"
+	"go.uber.org/zap"
+)
+
+const TypePaymentReminders = "reminders:payments_due"
+
+// How many days ahead of a due date its reminder goes out.
+const reminderLeadDays = 3
+
+type ReminderStorage interface {
+	ListCreditCards(ctx context.Context) ([]models.Account, error)
+	RecordPaymentReminder(ctx context.Context, reminder *models.PaymentReminder) (bool, error)
+}
+
+type MemberStorage interface {
+	ListMembers(ctx context.Context, familyID uuid.UUID) ([]models.User, error)
+}
+
+type ReminderMailer interface {
+	SendPaymentReminder(ctx context.Context, to, accountName string, reminder models.PaymentReminder) error
+}
+
+// NewPaymentRemindersTask is scheduled daily; it has no payload.
+func NewPaymentRemindersTask() *asynq.Task {
+	return asynq.NewTask(TypePaymentReminders, nil)
+}
+
+// HandlePaymentRemindersTask reminds each family of credit card payments due within
+// reminderLeadDays. A reminder is recorded before it is emailed, so however often the
+// task runs, each due date is reminded once.
+func HandlePaymentRemindersTask(ctx context.Context, t *asynq.Task, svc *WorkerServices) error {
+	return sendPaymentReminders(ctx, svc, time.Now())
+}
+
+func sendPaymentReminders(ctx context.Context, svc *WorkerServices, now time.Time) error {
+	cards, err := svc.Reminders.ListCreditCards(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list credit cards: %w", err)
+	}
+
+	cutoff := time.Date(now.Year(), now.Month(), now.Day()+reminderLeadDays, 0, 0, 0, 0, time.UTC)
+	for _, card := range cards {
+		due, ok := statements.NextDue(card.CreditCardDetails, now)
+		if !ok || due.After(cutoff) {
+			continue
+		}
+
+		reminder := models.PaymentReminder{
+			FamilyID:  card.FamilyID,
+			AccountID: card.ID,
+			DueDate:   due,
+			Amount:    card.CreditCardDetails.MinimumPayment,
+		}
+		created, err := svc.Reminders.RecordPaymentReminder(ctx, &reminder)
+		if err != nil {
+			return fmt.Errorf("failed to record payment reminder: %w", err)
+		}
+		if !created {
+			continue
+		}
+
+		members, err := svc.Members.ListMembers(ctx, card.FamilyID)
+		if err != nil {
+			return fmt.Errorf("failed to list family members: %w", err)
+		}
+		for _, member := range members {
+			if err := svc.Mailer.SendPaymentReminder(ctx, member.Email, card.Name, reminder); err != nil {
+				logger.Error("Failed to send payment reminder",
+					zap.String("account_id", card.ID.String()),
+					zap.String("user_id", member.ID.String()),
+					zap.Error(err))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+type reminderStore struct {
+	cards     []models.Account
+	reminders map[string]models.PaymentReminder // account ID + due date
+	members   map[uuid.UUID][]models.User
+}
+
+func (s *reminderStore) ListCreditCards(ctx context.Context) ([]models.Account, error) {
+	return s.cards, nil
+}
+
+func (s *reminderStore) RecordPaymentReminder(ctx context.Context, reminder *models.PaymentReminder) (bool, error) {
+	key := reminder.AccountID.String() + reminder.DueDate.Format("2006-01-02")
+	if _, ok := s.reminders[key]; ok {
+		return false, nil
+	}
+	reminder.ID = uuid.New()
+	s.reminders[key] = *reminder
+	return true, nil
+}
+
+func (s *reminderStore) ListMembers(ctx context.Context, familyID uuid.UUID) ([]models.User, error) {
+	return s.members[familyID], nil
+}
+
+type sentReminder struct {
+	to, account string
+	reminder    models.PaymentReminder
+}
+
+type reminderMailer struct {
+	sent []sentReminder
+}
+
+func (m *reminderMailer) SendPaymentReminder(ctx context.Context, to, accountName string, reminder models.PaymentReminder) error {
+	m.sent = append(m.sent, sentReminder{to, accountName, reminder})
+	return nil
+}
+
+func TestSendPaymentReminders(t *testing.T) {
+	familyID := uuid.New()
+	now := time.Date(2024, 3, 13, 9, 0, 0, 0, time.UTC)
+	soon := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	dueSoon := models.Account{
+		ID: uuid.New(), FamilyID: familyID, Name: "Visa",
+		CreditCardDetails: &models.CreditCardDetails{MinimumPayment: 35, NextPaymentDueDate: &soon},
+	}
+	dueLater := models.Account{
+		ID: uuid.New(), FamilyID: familyID, Name: "Amex",
+		CreditCardDetails: &models.CreditCardDetails{StatementCloseDay: 1, PaymentDueDay: 28},
+	}
+	unknown := models.Account{ID: uuid.New(), FamilyID: familyID, Name: "Store Card", CreditCardDetails: &models.CreditCardDetails{}}
+
+	store := &reminderStore{
+		cards:     []models.Account{dueSoon, dueLater, unknown},
+		reminders: make(map[string]models.PaymentReminder),
+		members: map[uuid.UUID][]models.User{
+			familyID: {{ID: uuid.New(), Email: "a@example.com"}, {ID: uuid.New(), Email: "b@example.com"}},
+		},
+	}
+	mailer := &reminderMailer{}
+	svc := &WorkerServices{Reminders: store, Members: store, Mailer: mailer}
+
+	if err := sendPaymentReminders(context.Background(), svc, now); err != nil {
+		t.Fatalf("Reminders failed: %v", err)
+	}
+	if len(mailer.sent) != 2 {
+		t.Fatalf("Expected a reminder to each member for the card due soon, got %d", len(mailer.sent))
+	}
+	sent := mailer.sent[0]
+	if sent.account != "Visa" || !sent.reminder.DueDate.Equal(soon) || sent.reminder.Amount != 35 {
+		t.Errorf("Unexpected reminder %+v", sent)
+	}
+
+	// Running again, as a retry or a second worker would, sends nothing new
+	if err := sendPaymentReminders(context.Background(), svc, now.Add(time.Hour)); err != nil {
+		t.Fatalf("Reminders failed: %v", err)
+	}
+	if len(mailer.sent) != 2 {
+		t.Errorf("Expected no duplicate reminders, got %d emails", len(mailer.sent))
+	}
+	if len(store.reminders) != 1 {
+		t.Errorf("Expected 1 recorded reminder, got %d", len(store.reminders))
+	}
+}
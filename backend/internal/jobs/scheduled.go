@@ -0,0 +1,130 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/schedule"
+	"go.uber.org/zap"
+)
+
+const TypePostScheduled = "scheduled:post"
+
+// Namespace for the IDs of entries posted from schedules; see scheduledEntryID.
+var scheduledEntryNamespace = uuid.MustParse("6f1c2a9e-4b7d-5e3f-9a81-2c4d6e8f0b13")
+
+type ScheduledStorage interface {
+	ListDue(ctx context.Context, today time.Time) ([]models.ScheduledTransaction, error)
+	ListOccurrences(ctx context.Context, scheduleID uuid.UUID, from, to time.Time) ([]models.ScheduledOccurrence, error)
+	RecordPosted(ctx context.Context, scheduleID uuid.UUID, date time.Time, entryID uuid.UUID) error
+	SetPostedThrough(ctx context.Context, scheduleID uuid.UUID, date time.Time) error
+}
+
+// NewPostScheduledTask is scheduled hourly; it has no payload.
+func NewPostScheduledTask() *asynq.Task {
+	return asynq.NewTask(TypePostScheduled, nil)
+}
+
+// HandlePostScheduledTask posts every scheduled transaction's occurrences up to today,
+// catching up on any the worker missed. One schedule failing doesn't stop the rest;
+// the task fails at the end so asynq retries.
+func HandlePostScheduledTask(ctx context.Context, t *asynq.Task, svc *WorkerServices) error {
+	return postScheduled(ctx, svc, time.Now())
+}
+
+func postScheduled(ctx context.Context, svc *WorkerServices, now time.Time) error {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	due, err := svc.Scheduled.ListDue(ctx, today)
+	if err != nil {
+		return fmt.Errorf("failed to list scheduled transactions: %w", err)
+	}
+
+	failed := 0
+	for _, s := range due {
+		if err := postSchedule(ctx, svc, s, today); err != nil {
+			logger.Error("Posting scheduled transaction failed", zap.String("schedule_id", s.ID.String()), zap.Error(err))
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("posting failed for %d scheduled transactions", failed)
+	}
+	return nil
+}
+
+// postSchedule posts s's occurrences after its posted_through date up to today, except
+// skipped ones, then moves posted_through to today. Each occurrence's entry gets an ID
+// derived from the schedule and date, so one posted by a run that failed before
+// recording it is found rather than posted twice.
+func postSchedule(ctx context.Context, svc *WorkerServices, s models.ScheduledTransaction, today time.Time) error {
+	rule, err := schedule.Parse(s.Rule)
+	if err != nil {
+		return fmt.Errorf("invalid rule %q: %w", s.Rule, err)
+	}
+	from := s.StartDate
+	if s.PostedThrough != nil {
+		from = s.PostedThrough.AddDate(0, 0, 1)
+	}
+
+	dates := rule.Between(s.StartDate, from, today)
+	if len(dates) > 0 {
+		recorded, err := svc.Scheduled.ListOccurrences(ctx, s.ID, dates[0], today)
+		if err != nil {
+			return err
+		}
+		occurrences := make(map[time.Time]models.ScheduledOccurrence, len(recorded))
+		for _, o := range recorded {
+			occurrences[o.Date.UTC()] = o
+		}
+
+		for _, date := range dates {
+			o, ok := occurrences[date]
+			if ok && (o.Status == models.OccurrenceStatusSkipped || o.Status == models.OccurrenceStatusPosted) {
+				continue
+			}
+			if err := postOccurrence(ctx, svc, s, date, o); err != nil {
+				return fmt.Errorf("failed to post %s: %w", date.Format("2006-01-02"), err)
+			}
+		}
+	}
+	return svc.Scheduled.SetPostedThrough(ctx, s.ID, today)
+}
+
+func postOccurrence(ctx context.Context, svc *WorkerServices, s models.ScheduledTransaction, date time.Time, o models.ScheduledOccurrence) error {
+	entry := &models.Entry{
+		ID:        scheduledEntryID(s.ID, date),
+		AccountID: s.AccountID,
+		Amount:    s.Amount,
+		Currency:  s.Currency,
+		Date:      date,
+		Name:      s.Name,
+	}
+	if o.Amount != nil {
+		entry.Amount = *o.Amount
+	}
+	if o.Name != nil {
+		entry.Name = *o.Name
+	}
+
+	exists, err := svc.Ledger.HasEntry(ctx, entry.ID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		txDetail := &models.Transaction{CategoryID: s.CategoryID, Kind: "standard"}
+		if err := svc.Ledger.CreateTransaction(ctx, entry, txDetail); err != nil {
+			return err
+		}
+	}
+	return svc.Scheduled.RecordPosted(ctx, s.ID, date, entry.ID)
+}
+
+// scheduledEntryID is the ID of the entry posted for a schedule's occurrence on date.
+func scheduledEntryID(scheduleID uuid.UUID, date time.Time) uuid.UUID {
+	return uuid.NewSHA1(scheduledEntryNamespace, []byte(scheduleID.String()+"/"+date.Format("2006-01-02")))
+}
@@ -0,0 +1,159 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+type scheduledStore struct {
+	due           []models.ScheduledTransaction
+	occurrences   map[uuid.UUID][]models.ScheduledOccurrence
+	posted        map[uuid.UUID]map[time.Time]uuid.UUID
+	postedThrough map[uuid.UUID]time.Time
+}
+
+func newScheduledStore(due ...models.ScheduledTransaction) *scheduledStore {
+	return &scheduledStore{
+		due:           due,
+		occurrences:   make(map[uuid.UUID][]models.ScheduledOccurrence),
+		posted:        make(map[uuid.UUID]map[time.Time]uuid.UUID),
+		postedThrough: make(map[uuid.UUID]time.Time),
+	}
+}
+
+func (s *scheduledStore) ListDue(ctx context.Context, today time.Time) ([]models.ScheduledTransaction, error) {
+	var due []models.ScheduledTransaction
+	for _, st := range s.due {
+		if through, ok := s.postedThrough[st.ID]; ok {
+			st.PostedThrough = &through
+		}
+		if st.PostedThrough == nil || st.PostedThrough.Before(today) {
+			due = append(due, st)
+		}
+	}
+	return due, nil
+}
+
+func (s *scheduledStore) ListOccurrences(ctx context.Context, scheduleID uuid.UUID, from, to time.Time) ([]models.ScheduledOccurrence, error) {
+	return s.occurrences[scheduleID], nil
+}
+
+func (s *scheduledStore) RecordPosted(ctx context.Context, scheduleID uuid.UUID, date time.Time, entryID uuid.UUID) error {
+	if s.posted[scheduleID] == nil {
+		s.posted[scheduleID] = make(map[time.Time]uuid.UUID)
+	}
+	s.posted[scheduleID][date] = entryID
+	return nil
+}
+
+func (s *scheduledStore) SetPostedThrough(ctx context.Context, scheduleID uuid.UUID, date time.Time) error {
+	s.postedThrough[scheduleID] = date
+	return nil
+}
+
+// entryLedger records the transactions posted; its other LedgerStorage methods aren't used.
+type entryLedger struct {
+	LedgerStorage
+	entries map[uuid.UUID]*models.Entry
+	created int
+}
+
+func (l *entryLedger) HasEntry(ctx context.Context, id uuid.UUID) (bool, error) {
+	_, ok := l.entries[id]
+	return ok, nil
+}
+
+func (l *entryLedger) CreateTransaction(ctx context.Context, entry *models.Entry, txDetail *models.Transaction) error {
+	l.entries[entry.ID] = entry
+	l.created++
+	return nil
+}
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestPostScheduled(t *testing.T) {
+	rent := models.ScheduledTransaction{
+		ID: uuid.New(), AccountID: uuid.New(), Name: "Rent", Amount: 1200, Currency: "USD",
+		Rule: "FREQ=MONTHLY;BYMONTHDAY=1", StartDate: day(2024, 1, 1), Active: true,
+	}
+	store := newScheduledStore(rent)
+	override := 1300.0
+	store.occurrences[rent.ID] = []models.ScheduledOccurrence{
+		{ScheduledTransactionID: rent.ID, Date: day(2024, 2, 1), Status: models.OccurrenceStatusOverridden, Amount: &override},
+		{ScheduledTransactionID: rent.ID, Date: day(2024, 3, 1), Status: models.OccurrenceStatusSkipped},
+	}
+	ledger := &entryLedger{entries: make(map[uuid.UUID]*models.Entry)}
+	svc := &WorkerServices{Scheduled: store, Ledger: ledger}
+
+	if err := postScheduled(context.Background(), svc, day(2024, 4, 10).Add(9*time.Hour)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if ledger.created != 3 {
+		t.Errorf("Expected January, February and April to be posted, got %d entries", ledger.created)
+	}
+	if _, ok := store.posted[rent.ID][day(2024, 3, 1)]; ok {
+		t.Error("Expected the skipped occurrence not to be posted")
+	}
+	feb := ledger.entries[scheduledEntryID(rent.ID, day(2024, 2, 1))]
+	if feb == nil || feb.Amount != 1300 || feb.Name != "Rent" {
+		t.Errorf("Expected February's override amount to be used, got %+v", feb)
+	}
+	if got := store.postedThrough[rent.ID]; !got.Equal(day(2024, 4, 10)) {
+		t.Errorf("Expected posted through today, got %v", got)
+	}
+
+	// Running again the same day posts nothing new
+	if err := postScheduled(context.Background(), svc, day(2024, 4, 10).Add(10*time.Hour)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if ledger.created != 3 {
+		t.Errorf("Expected no more entries on a second run, got %d", ledger.created)
+	}
+}
+
+func TestPostScheduled_AlreadyPostedEntry(t *testing.T) {
+	// A run that posted the entry but failed before recording it
+	salary := models.ScheduledTransaction{
+		ID: uuid.New(), AccountID: uuid.New(), Name: "Salary", Amount: -2500, Currency: "USD",
+		Rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=FR", StartDate: day(2024, 1, 5), Active: true,
+	}
+	store := newScheduledStore(salary)
+	first := scheduledEntryID(salary.ID, day(2024, 1, 5))
+	ledger := &entryLedger{entries: map[uuid.UUID]*models.Entry{first: {ID: first}}}
+	svc := &WorkerServices{Scheduled: store, Ledger: ledger}
+
+	if err := postScheduled(context.Background(), svc, day(2024, 1, 20)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if ledger.created != 1 {
+		t.Errorf("Expected only January 19th to be created, got %d entries", ledger.created)
+	}
+	if got := store.posted[salary.ID][day(2024, 1, 5)]; got != first {
+		t.Errorf("Expected the existing entry to be recorded as posted, got %v", got)
+	}
+}
+
+func TestPostScheduled_InvalidRule(t *testing.T) {
+	broken := models.ScheduledTransaction{ID: uuid.New(), Rule: "FREQ=HOURLY", StartDate: day(2024, 1, 1)}
+	daily := models.ScheduledTransaction{ID: uuid.New(), Name: "Coffee", Amount: 4, Rule: "FREQ=DAILY", StartDate: day(2024, 1, 1)}
+	store := newScheduledStore(broken, daily)
+	ledger := &entryLedger{entries: make(map[uuid.UUID]*models.Entry)}
+	svc := &WorkerServices{Scheduled: store, Ledger: ledger}
+
+	if err := postScheduled(context.Background(), svc, day(2024, 1, 3)); err == nil {
+		t.Error("Expected the broken schedule to fail the task so it is retried")
+	}
+	if ledger.created != 3 {
+		t.Errorf("Expected the other schedule to be posted anyway, got %d entries", ledger.created)
+	}
+	if _, ok := store.postedThrough[broken.ID]; ok {
+		t.Error("Expected the broken schedule not to be marked posted")
+	}
+}
@@ -8,15 +8,45 @@ import (
 )
 
 const (
-	TypeSyncAccount = "sync:account"
+	TypeSyncAccount     = "sync:account"
+	TypeSyncInvestments = "sync:investments"
+	TypeSyncLiabilities = "sync:liabilities"
 )
 
+// SyncAccountPayload is shared by every per-item Plaid sync task.
 type SyncAccountPayload struct {
 	FamilyID uuid.UUID `json:"family_id"`
 	ItemID   string    `json:"item_id"`
 }
 
 func NewSyncAccountTask(familyID uuid.UUID, itemID string) (*asynq.Task, error) {
+	return newItemTask(TypeSyncAccount, familyID, itemID)
+}
+
+func NewSyncInvestmentsTask(familyID uuid.UUID, itemID string) (*asynq.Task, error) {
+	return newItemTask(TypeSyncInvestments, familyID, itemID)
+}
+
+func NewSyncLiabilitiesTask(familyID uuid.UUID, itemID string) (*asynq.Task, error) {
+	return newItemTask(TypeSyncLiabilities, familyID, itemID)
+}
+
+// NewItemSyncTasks returns the tasks for a full refresh of an item: transactions, investments and liabilities.
+func NewItemSyncTasks(familyID uuid.UUID, itemID string) ([]*asynq.Task, error) {
+	var tasks []*asynq.Task
+	for _, newTask := range []func(uuid.UUID, string) (*asynq.Task, error){
+		NewSyncAccountTask, NewSyncInvestmentsTask, NewSyncLiabilitiesTask,
+	} {
+		task, err := newTask(familyID, itemID)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+func newItemTask(taskType string, familyID uuid.UUID, itemID string) (*asynq.Task, error) {
 	payload, err := json.Marshal(SyncAccountPayload{
 		FamilyID: familyID,
 		ItemID:   itemID,
@@ -25,5 +55,5 @@ func NewSyncAccountTask(familyID uuid.UUID, itemID string) (*asynq.Task, error)
 		return nil, err
 	}
 
-	return asynq.NewTask(TypeSyncAccount, payload), nil
+	return asynq.NewTask(taskType, payload), nil
 }
@@ -0,0 +1,341 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/services"
+)
+
+// memoryStore backs every storage interface the sync handlers need, keyed the way the
+// Postgres repositories are (Plaid IDs for accounts and entries).
+type memoryStore struct {
+	items       map[string]*models.PlaidItem
+	accounts    map[string]*models.Account // plaid account ID -> account
+	entries     map[string]*models.Entry   // plaid ID -> entry
+	loans       map[uuid.UUID]*models.LoanDetails
+	creditCards map[uuid.UUID]*models.CreditCardDetails
+	securities  map[string]float64 // ticker -> price
+	trades      map[string]*models.Trade
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		items:       make(map[string]*models.PlaidItem),
+		accounts:    make(map[string]*models.Account),
+		entries:     make(map[string]*models.Entry),
+		loans:       make(map[uuid.UUID]*models.LoanDetails),
+		creditCards: make(map[uuid.UUID]*models.CreditCardDetails),
+		securities:  make(map[string]float64),
+		trades:      make(map[string]*models.Trade),
+	}
+}
+
+func (m *memoryStore) GetItemsByFamily(ctx context.Context, familyID uuid.UUID) ([]models.PlaidItem, error) {
+	var items []models.PlaidItem
+	for _, item := range m.items {
+		if item.FamilyID == familyID {
+			items = append(items, *item)
+		}
+	}
+	return items, nil
+}
+
+func (m *memoryStore) GetItemByID(ctx context.Context, itemID string) (*models.PlaidItem, error) {
+	item, ok := m.items[itemID]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return item, nil
+}
+
+func (m *memoryStore) UpdateCursor(ctx context.Context, itemID string, cursor string) error {
+	m.items[itemID].SyncCursor = cursor
+	return nil
+}
+
+func (m *memoryStore) GetOrCreateMerchant(ctx context.Context, name string, familyID uuid.UUID) (uuid.UUID, error) {
+	return uuid.NewSHA1(familyID, []byte(name)), nil
+}
+
+func (m *memoryStore) CreateTransaction(ctx context.Context, entry *models.Entry, txDetail *models.Transaction) error {
+	entry.ID = uuid.New()
+	m.entries[entry.PlaidID] = entry
+	return nil
+}
+
+func (m *memoryStore) HasEntry(ctx context.Context, id uuid.UUID) (bool, error) {
+	for _, entry := range m.entries {
+		if entry.ID == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryStore) HasPlaidEntry(ctx context.Context, plaidID string) (bool, error) {
+	_, ok := m.entries[plaidID]
+	return ok, nil
+}
+
+func (m *memoryStore) UpdatePlaidEntry(ctx context.Context, entry *models.Entry) error {
+	existing, ok := m.entries[entry.PlaidID]
+	if !ok {
+		return pgx.ErrNoRows
+	}
+	entry.ID, entry.AccountID = existing.ID, existing.AccountID
+	m.entries[entry.PlaidID] = entry
+	return nil
+}
+
+func (m *memoryStore) DeletePlaidEntry(ctx context.Context, plaidID string) error {
+	delete(m.entries, plaidID)
+	return nil
+}
+
+func (m *memoryStore) GetByPlaidID(ctx context.Context, familyID uuid.UUID, plaidAccountID string) (*models.Account, error) {
+	acc, ok := m.accounts[plaidAccountID]
+	if !ok || acc.FamilyID != familyID {
+		return nil, pgx.ErrNoRows
+	}
+	return acc, nil
+}
+
+func (m *memoryStore) UpsertPlaidAccount(ctx context.Context, itemID uuid.UUID, plaidAccountID string, acc *models.Account) error {
+	if existing, ok := m.accounts[plaidAccountID]; ok {
+		acc.ID = existing.ID
+	} else {
+		acc.ID = uuid.New()
+	}
+	m.accounts[plaidAccountID] = acc
+	return nil
+}
+
+func (m *memoryStore) UpsertLoanDetails(ctx context.Context, accountID uuid.UUID, details *models.LoanDetails) error {
+	m.loans[accountID] = details
+	return nil
+}
+
+func (m *memoryStore) UpsertCreditCardDetails(ctx context.Context, accountID uuid.UUID, details *models.CreditCardDetails) error {
+	m.creditCards[accountID] = details
+	return nil
+}
+
+func (m *memoryStore) GetOrCreateSecurity(ctx context.Context, ticker, name string) (uuid.UUID, error) {
+	if _, ok := m.securities[ticker]; !ok {
+		m.securities[ticker] = 0
+	}
+	return uuid.NewSHA1(uuid.Nil, []byte(ticker)), nil
+}
+
+func (m *memoryStore) UpdateSecurityPrice(ctx context.Context, ticker string, price float64) error {
+	m.securities[ticker] = price
+	return nil
+}
+
+func (m *memoryStore) CreateTrade(ctx context.Context, entry *models.Entry, trade *models.Trade) error {
+	entry.ID = uuid.New()
+	m.entries[entry.PlaidID] = entry
+	m.trades[entry.PlaidID] = trade
+	return nil
+}
+
+// setupFakeSync links one item through the fake provider the same way PlaidHandler does.
+func setupFakeSync(t *testing.T, publicToken string) (*services.FakePlaidService, *memoryStore, *WorkerServices, *models.PlaidItem, string) {
+	t.Helper()
+
+	tokens, err := services.NewTokenCipher("", "0123456789abcdef0123456789abcdef")
+	if err != nil {
+		t.Fatalf("Failed to create token cipher: %v", err)
+	}
+	fake, err := services.NewFakePlaidService(tokens)
+	if err != nil {
+		t.Fatalf("Failed to create fake provider: %v", err)
+	}
+
+	accessToken, itemID, err := fake.ExchangePublicToken(context.Background(), publicToken)
+	if err != nil {
+		t.Fatalf("Failed to exchange public token: %v", err)
+	}
+	encrypted, err := fake.EncryptToken(accessToken)
+	if err != nil {
+		t.Fatalf("Failed to encrypt access token: %v", err)
+	}
+
+	store := newMemoryStore()
+	item := &models.PlaidItem{ID: uuid.New(), FamilyID: uuid.New(), ItemID: itemID, AccessToken: encrypted}
+	store.items[itemID] = item
+
+	svc := &WorkerServices{
+		Plaid:       fake,
+		DB:          store,
+		Ledger:      store,
+		Accounts:    store,
+		Investments: store,
+	}
+	return fake, store, svc, item, accessToken
+}
+
+func runTask(t *testing.T, svc *WorkerServices, item *models.PlaidItem, newTask func(uuid.UUID, string) (*asynq.Task, error), handle func(context.Context, *asynq.Task, *WorkerServices) error) error {
+	t.Helper()
+
+	task, err := newTask(item.FamilyID, item.ItemID)
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	return handle(context.Background(), task, svc)
+}
+
+func TestSyncAccount_FakePlaid(t *testing.T) {
+	_, store, svc, item, _ := setupFakeSync(t, "good")
+
+	// First sync drains the initial batch across two pages
+	if err := runTask(t, svc, item, NewSyncAccountTask, HandleSyncAccountTask); err != nil {
+		t.Fatalf("First sync failed: %v", err)
+	}
+
+	if len(store.accounts) != 6 {
+		t.Errorf("Expected 6 accounts, got %d", len(store.accounts))
+	}
+	if len(store.entries) != 5 {
+		t.Fatalf("Expected 5 entries after first sync, got %d", len(store.entries))
+	}
+	if item.SyncCursor != "fake-cursor-2" {
+		t.Errorf("Expected cursor fake-cursor-2, got %q", item.SyncCursor)
+	}
+
+	card := store.accounts[item.ItemID+"-credit"]
+	if card.Classification != "liability" || card.Subtype != "credit_card" {
+		t.Errorf("Expected credit card liability, got %s/%s", card.Classification, card.Subtype)
+	}
+	if store.entries[item.ItemID+"-tx-3"].AccountID != card.ID {
+		t.Error("Expected card transaction to be attached to the card account")
+	}
+
+	// Second sync applies modifications and removals
+	if err := runTask(t, svc, item, NewSyncAccountTask, HandleSyncAccountTask); err != nil {
+		t.Fatalf("Second sync failed: %v", err)
+	}
+
+	if len(store.entries) != 6 {
+		t.Errorf("Expected 6 entries after second sync, got %d", len(store.entries))
+	}
+	if _, ok := store.entries[item.ItemID+"-tx-3"]; ok {
+		t.Error("Expected removed transaction to be deleted")
+	}
+	modified := store.entries[item.ItemID+"-tx-5"]
+	if modified.Amount != 25.90 || modified.Name != "Uber Trip" {
+		t.Errorf("Expected modified transaction 25.90 Uber Trip, got %v %s", modified.Amount, modified.Name)
+	}
+
+	// Caught up: nothing changes
+	if err := runTask(t, svc, item, NewSyncAccountTask, HandleSyncAccountTask); err != nil {
+		t.Fatalf("Third sync failed: %v", err)
+	}
+	if len(store.entries) != 6 {
+		t.Errorf("Expected no changes once caught up, got %d entries", len(store.entries))
+	}
+}
+
+func TestSyncAccount_FakePlaid_MutationDuringPagination(t *testing.T) {
+	fake, store, svc, item, accessToken := setupFakeSync(t, "good")
+	fake.FailNextSync(accessToken, "TRANSACTIONS_SYNC_MUTATION_DURING_PAGINATION")
+
+	if err := runTask(t, svc, item, NewSyncAccountTask, HandleSyncAccountTask); err != nil {
+		t.Fatalf("Expected sync to restart and succeed, got %v", err)
+	}
+	if len(store.entries) != 5 {
+		t.Errorf("Expected 5 entries, got %d", len(store.entries))
+	}
+}
+
+func TestSyncAccount_FakePlaid_ItemError(t *testing.T) {
+	_, store, svc, item, _ := setupFakeSync(t, "error_ITEM_LOGIN_REQUIRED")
+
+	err := runTask(t, svc, item, NewSyncAccountTask, HandleSyncAccountTask)
+	if err == nil {
+		t.Fatal("Expected sync to fail for an item needing login")
+	}
+	if item.SyncCursor != "" {
+		t.Errorf("Expected cursor to be left alone, got %q", item.SyncCursor)
+	}
+	if len(store.entries) != 0 {
+		t.Errorf("Expected no entries, got %d", len(store.entries))
+	}
+}
+
+func TestSyncInvestments_FakePlaid(t *testing.T) {
+	_, store, svc, item, _ := setupFakeSync(t, "good")
+
+	if err := runTask(t, svc, item, NewSyncInvestmentsTask, HandleSyncInvestmentsTask); err != nil {
+		t.Fatalf("Investments sync failed: %v", err)
+	}
+
+	if store.securities["AAPL"] != 190.00 || store.securities["VTI"] != 240.50 {
+		t.Errorf("Expected holding prices to be saved, got %v", store.securities)
+	}
+
+	trade, ok := store.trades[item.ItemID+"-inv-1"]
+	if !ok || trade.Qty != 10 || trade.Kind != "buy" {
+		t.Errorf("Expected a buy of 10 shares, got %+v", trade)
+	}
+	// Ledger sign: positive is an outflow
+	if buy := store.entries[item.ItemID+"-inv-1"]; buy.Amount != 1500.00 {
+		t.Errorf("Expected buy to take 1500 out of the account, got %v", buy.Amount)
+	}
+	if dividend, ok := store.entries[item.ItemID+"-inv-2"]; !ok || dividend.Amount != -12.34 {
+		t.Errorf("Expected a dividend paying 12.34 into the account, got %+v", dividend)
+	}
+
+	// Re-running doesn't duplicate anything
+	if err := runTask(t, svc, item, NewSyncInvestmentsTask, HandleSyncInvestmentsTask); err != nil {
+		t.Fatalf("Second investments sync failed: %v", err)
+	}
+	if len(store.trades) != 1 {
+		t.Errorf("Expected 1 trade after re-sync, got %d", len(store.trades))
+	}
+}
+
+func TestSyncInvestments_FakePlaid_ProductUnavailable(t *testing.T) {
+	fake, store, svc, item, accessToken := setupFakeSync(t, "good")
+	fake.SetError(accessToken, "NO_INVESTMENT_ACCOUNTS")
+
+	if err := runTask(t, svc, item, NewSyncInvestmentsTask, HandleSyncInvestmentsTask); err != nil {
+		t.Fatalf("Expected missing product to be skipped, got %v", err)
+	}
+	if len(store.securities) != 0 {
+		t.Errorf("Expected no securities, got %d", len(store.securities))
+	}
+}
+
+func TestSyncLiabilities_FakePlaid(t *testing.T) {
+	_, store, svc, item, _ := setupFakeSync(t, "good")
+
+	if err := runTask(t, svc, item, NewSyncLiabilitiesTask, HandleSyncLiabilitiesTask); err != nil {
+		t.Fatalf("Liabilities sync failed: %v", err)
+	}
+
+	card := store.creditCards[store.accounts[item.ItemID+"-credit"].ID]
+	if card == nil || card.APR != 22.49 || card.LastStatementBalance != 380.25 {
+		t.Errorf("Expected purchase APR and statement balance, got %+v", card)
+	}
+	if card != nil && (card.CreditLimit != 5000 || card.StatementCloseDay != 20 || card.PaymentDueDay != 15) {
+		t.Errorf("Expected limit 5000, statements closing on the 20th and due on the 15th, got %+v", card)
+	}
+
+	mortgage := store.loans[store.accounts[item.ItemID+"-mortgage"].ID]
+	if mortgage == nil || mortgage.TermMonths != 360 || mortgage.InterestRate != 6.125 {
+		t.Errorf("Expected 30 year mortgage at 6.125%%, got %+v", mortgage)
+	}
+	if mortgage != nil && (mortgage.NextPaymentDueDate == nil || mortgage.NextPaymentDueDate.Format("2006-01-02") != "2024-02-01") {
+		t.Errorf("Expected next payment due 2024-02-01, got %v", mortgage.NextPaymentDueDate)
+	}
+
+	if store.loans[store.accounts[item.ItemID+"-student"].ID] == nil {
+		t.Error("Expected student loan details")
+	}
+}
@@ -0,0 +1,105 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"go.uber.org/zap"
+)
+
+type TokenStorage interface {
+	GetAllItems(ctx context.Context) ([]models.PlaidItem, error)
+	UpdateAccessToken(ctx context.Context, itemID, oldToken, newToken string) (bool, error)
+}
+
+// TOTPSecretStorage is satisfied by *postgres.UserRepository.
+type TOTPSecretStorage interface {
+	ListTOTPSecrets(ctx context.Context) ([]models.StoredTOTPSecret, error)
+	UpdateTOTPSecret(ctx context.Context, userID uuid.UUID, oldSecret, newSecret string) (bool, error)
+}
+
+type TokenReencrypter interface {
+	Reencrypt(encryptedToken string) (string, bool, error)
+}
+
+// ReencryptAccessTokens moves every stored Plaid access token onto the current encryption
+// key, after which older keys can be dropped from ENCRYPTION_KEYS. It keeps going past
+// tokens it can't read and returns how many it re-encrypted.
+func ReencryptAccessTokens(ctx context.Context, store TokenStorage, tokens TokenReencrypter) (int, error) {
+	items, err := store.GetAllItems(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list plaid items: %w", err)
+	}
+
+	reencrypted, failed := 0, 0
+	for _, item := range items {
+		newToken, changed, err := tokens.Reencrypt(item.AccessToken)
+		if err != nil {
+			logger.Error("Failed to re-encrypt access token", zap.String("item_id", item.ItemID), zap.Error(err))
+			failed++
+			continue
+		}
+		if !changed {
+			continue
+		}
+
+		updated, err := store.UpdateAccessToken(ctx, item.ItemID, item.AccessToken, newToken)
+		if err != nil {
+			return reencrypted, fmt.Errorf("failed to save access token for %s: %w", item.ItemID, err)
+		}
+		if !updated {
+			// Relinked or removed since we listed it; the new token already uses the current key
+			logger.Warn("Access token changed during re-encryption, skipping", zap.String("item_id", item.ItemID))
+			continue
+		}
+		reencrypted++
+	}
+
+	if failed > 0 {
+		return reencrypted, fmt.Errorf("%d access tokens could not be re-encrypted", failed)
+	}
+	return reencrypted, nil
+}
+
+// ReencryptTOTPSecrets moves every user's TOTP secret onto the current encryption key.
+// They are sealed with the same keys as Plaid access tokens, so both must be
+// re-encrypted before an old key is dropped, or two-factor users can't sign in. Like
+// ReencryptAccessTokens it keeps going past secrets it can't read.
+func ReencryptTOTPSecrets(ctx context.Context, store TOTPSecretStorage, tokens TokenReencrypter) (int, error) {
+	secrets, err := store.ListTOTPSecrets(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list totp secrets: %w", err)
+	}
+
+	reencrypted, failed := 0, 0
+	for _, s := range secrets {
+		newSecret, changed, err := tokens.Reencrypt(s.Secret)
+		if err != nil {
+			logger.Error("Failed to re-encrypt TOTP secret", zap.String("user_id", s.UserID.String()), zap.Error(err))
+			failed++
+			continue
+		}
+		if !changed {
+			continue
+		}
+
+		updated, err := store.UpdateTOTPSecret(ctx, s.UserID, s.Secret, newSecret)
+		if err != nil {
+			return reencrypted, fmt.Errorf("failed to save totp secret for %s: %w", s.UserID, err)
+		}
+		if !updated {
+			// Re-enrolled or disabled since we listed it
+			logger.Warn("TOTP secret changed during re-encryption, skipping", zap.String("user_id", s.UserID.String()))
+			continue
+		}
+		reencrypted++
+	}
+
+	if failed > 0 {
+		return reencrypted, fmt.Errorf("%d totp secrets could not be re-encrypted", failed)
+	}
+	return reencrypted, nil
+}
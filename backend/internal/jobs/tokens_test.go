@@ -0,0 +1,195 @@
+package jobs
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/services"
+)
+
+func (m *memoryStore) GetAllItems(ctx context.Context) ([]models.PlaidItem, error) {
+	var items []models.PlaidItem
+	for _, item := range m.items {
+		items = append(items, *item)
+	}
+	return items, nil
+}
+
+func (m *memoryStore) UpdateAccessToken(ctx context.Context, itemID, oldToken, newToken string) (bool, error) {
+	item, ok := m.items[itemID]
+	if !ok || item.AccessToken != oldToken {
+		return false, nil
+	}
+	item.AccessToken = newToken
+	return true, nil
+}
+
+func TestReencryptAccessTokens(t *testing.T) {
+	const oldKey, newKey = "0123456789abcdef0123456789abcdef", "fedcba9876543210fedcba9876543210"
+
+	old, err := services.NewTokenCipher("k1:"+oldKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newMemoryStore()
+	for _, itemID := range []string{"item-1", "item-2"} {
+		encrypted, _ := old.Encrypt("access-" + itemID)
+		store.items[itemID] = &models.PlaidItem{ItemID: itemID, AccessToken: encrypted}
+	}
+
+	rotated, err := services.NewTokenCipher("k2:"+newKey+",k1:"+oldKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := ReencryptAccessTokens(context.Background(), store, rotated)
+	if err != nil {
+		t.Fatalf("Re-encryption failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 re-encrypted tokens, got %d", count)
+	}
+
+	// Everything must be readable with only the new key
+	current, _ := services.NewTokenCipher("k2:"+newKey, "")
+	for itemID, item := range store.items {
+		token, err := current.Decrypt(item.AccessToken)
+		if err != nil || token != "access-"+itemID {
+			t.Errorf("Expected %s readable with new key, got %q (%v)", itemID, token, err)
+		}
+	}
+
+	// Running again is a no-op
+	count, err = ReencryptAccessTokens(context.Background(), store, rotated)
+	if err != nil || count != 0 {
+		t.Errorf("Expected second run to do nothing, got %d (%v)", count, err)
+	}
+}
+
+// totpStore keeps TOTP state in memory for both MFAService and the re-encryption job.
+type totpStore struct {
+	states map[uuid.UUID]*models.TOTPState
+}
+
+func (m *totpStore) SetPendingTOTPSecret(ctx context.Context, userID uuid.UUID, secret string) error {
+	m.states[userID] = &models.TOTPState{Secret: secret}
+	return nil
+}
+
+func (m *totpStore) GetTOTP(ctx context.Context, userID uuid.UUID) (*models.TOTPState, error) {
+	s, ok := m.states[userID]
+	if !ok {
+		return nil, models.ErrMFANotSetUp
+	}
+	state := *s
+	return &state, nil
+}
+
+func (m *totpStore) MarkTOTPStepUsed(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	s := m.states[userID]
+	if s.LastStep >= step {
+		return false, nil
+	}
+	s.LastStep = step
+	return true, nil
+}
+
+func (m *totpStore) EnableTOTP(ctx context.Context, userID uuid.UUID, hashes []string) error {
+	now := time.Now()
+	m.states[userID].EnabledAt = &now
+	return nil
+}
+
+func (m *totpStore) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, hashes []string) error {
+	return nil
+}
+
+func (m *totpStore) UseRecoveryCode(ctx context.Context, userID uuid.UUID, hash string) (bool, error) {
+	return false, nil
+}
+
+func (m *totpStore) DisableTOTP(ctx context.Context, userID uuid.UUID) error {
+	delete(m.states, userID)
+	return nil
+}
+
+func (m *totpStore) ListTOTPSecrets(ctx context.Context) ([]models.StoredTOTPSecret, error) {
+	var secrets []models.StoredTOTPSecret
+	for userID, s := range m.states {
+		secrets = append(secrets, models.StoredTOTPSecret{UserID: userID, Secret: s.Secret})
+	}
+	return secrets, nil
+}
+
+func (m *totpStore) UpdateTOTPSecret(ctx context.Context, userID uuid.UUID, oldSecret, newSecret string) (bool, error) {
+	s, ok := m.states[userID]
+	if !ok || s.Secret != oldSecret {
+		return false, nil
+	}
+	s.Secret = newSecret
+	return true, nil
+}
+
+// totpNow computes the current RFC 6238 code for a base32 secret, as an authenticator app would.
+func totpNow(t *testing.T, secret string) string {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(time.Now().Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:offset+4])&0x7fffffff)%1000000)
+}
+
+func TestReencryptTOTPSecrets(t *testing.T) {
+	const oldKey, newKey = "0123456789abcdef0123456789abcdef", "fedcba9876543210fedcba9876543210"
+	ctx := context.Background()
+
+	old, err := services.NewTokenCipher("k1:"+oldKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &totpStore{states: make(map[uuid.UUID]*models.TOTPState)}
+	userID := uuid.New()
+	secret, _, err := services.NewMFAService(store, old, "Maybe Finance").BeginSetup(ctx, userID, "user@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.EnableTOTP(ctx, userID, nil)
+
+	rotated, err := services.NewTokenCipher("k2:"+newKey+",k1:"+oldKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := ReencryptTOTPSecrets(ctx, store, rotated)
+	if err != nil {
+		t.Fatalf("Re-encryption failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 re-encrypted secret, got %d", count)
+	}
+
+	// Once the old key is dropped the user can still sign in with their authenticator
+	current, _ := services.NewTokenCipher("k2:"+newKey, "")
+	if err := services.NewMFAService(store, current, "Maybe Finance").Verify(ctx, userID, totpNow(t, secret)); err != nil {
+		t.Errorf("Expected TOTP code to verify with only the new key, got %v", err)
+	}
+
+	count, err = ReencryptTOTPSecrets(ctx, store, rotated)
+	if err != nil || count != 0 {
+		t.Errorf("Expected second run to do nothing, got %d (%v)", count, err)
+	}
+}
@@ -0,0 +1,69 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
+	"github.com/rakibulbh/ai-finance-manager/internal/transfers"
+	"go.uber.org/zap"
+)
+
+const TypeMatchTransfers = "transfers:match"
+
+// How far back matching looks. Older entries have had every chance to be paired.
+const transferLookbackDays = 60
+
+type TransferStorage interface {
+	ListActiveFamilies(ctx context.Context, since time.Time) ([]uuid.UUID, error)
+	ListCandidates(ctx context.Context, familyID uuid.UUID, since time.Time) ([]transfers.Entry, error)
+	ListRejected(ctx context.Context, familyID uuid.UUID) (map[transfers.Pair]bool, error)
+	SaveMatches(ctx context.Context, familyID uuid.UUID, matches []transfers.Match) error
+}
+
+// NewMatchTransfersTask is scheduled hourly, after Plaid syncs; it has no payload.
+func NewMatchTransfersTask() *asynq.Task {
+	return asynq.NewTask(TypeMatchTransfers, nil)
+}
+
+// HandleMatchTransfersTask pairs up the two sides of transfers between each family's
+// accounts. One family failing doesn't stop the rest; the task fails at the end so
+// asynq retries.
+func HandleMatchTransfersTask(ctx context.Context, t *asynq.Task, svc *WorkerServices) error {
+	since := time.Now().AddDate(0, 0, -transferLookbackDays)
+	families, err := svc.Transfers.ListActiveFamilies(ctx, since)
+	if err != nil {
+		return fmt.Errorf("failed to list families: %w", err)
+	}
+
+	failed := 0
+	for _, familyID := range families {
+		if err := matchTransfers(ctx, svc, familyID, since); err != nil {
+			logger.Error("Transfer matching failed", zap.String("family_id", familyID.String()), zap.Error(err))
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("transfer matching failed for %d families", failed)
+	}
+	return nil
+}
+
+func matchTransfers(ctx context.Context, svc *WorkerServices, familyID uuid.UUID, since time.Time) error {
+	entries, err := svc.Transfers.ListCandidates(ctx, familyID, since)
+	if err != nil {
+		return err
+	}
+	rejected, err := svc.Transfers.ListRejected(ctx, familyID)
+	if err != nil {
+		return err
+	}
+	matches := transfers.Find(entries, rejected)
+	if len(matches) == 0 {
+		return nil
+	}
+	return svc.Transfers.SaveMatches(ctx, familyID, matches)
+}
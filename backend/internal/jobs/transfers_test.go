@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/transfers"
+)
+
+type transferStore struct {
+	entries  map[uuid.UUID][]transfers.Entry
+	rejected map[uuid.UUID]map[transfers.Pair]bool
+	saved    map[uuid.UUID][]transfers.Match
+}
+
+func (s *transferStore) ListActiveFamilies(ctx context.Context, since time.Time) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for id := range s.entries {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (s *transferStore) ListCandidates(ctx context.Context, familyID uuid.UUID, since time.Time) ([]transfers.Entry, error) {
+	return s.entries[familyID], nil
+}
+
+func (s *transferStore) ListRejected(ctx context.Context, familyID uuid.UUID) (map[transfers.Pair]bool, error) {
+	return s.rejected[familyID], nil
+}
+
+func (s *transferStore) SaveMatches(ctx context.Context, familyID uuid.UUID, matches []transfers.Match) error {
+	s.saved[familyID] = matches
+	return nil
+}
+
+func TestHandleMatchTransfersTask(t *testing.T) {
+	familyID := uuid.New()
+	checking, card, savings := uuid.New(), uuid.New(), uuid.New()
+	now := time.Now()
+	payment := transfers.Entry{ID: uuid.New(), AccountID: checking, Amount: 300, Currency: "USD", Date: now.AddDate(0, 0, -3)}
+	onCard := transfers.Entry{ID: uuid.New(), AccountID: card, Amount: -300, Currency: "USD", Date: now.AddDate(0, 0, -1), Liability: true}
+	// Paired with the payment once, and the family said it wasn't a transfer
+	deposit := transfers.Entry{ID: uuid.New(), AccountID: savings, Amount: -300, Currency: "USD", Date: now.AddDate(0, 0, -3)}
+
+	store := &transferStore{
+		entries: map[uuid.UUID][]transfers.Entry{familyID: {payment, onCard, deposit}},
+		rejected: map[uuid.UUID]map[transfers.Pair]bool{
+			familyID: {{From: payment.ID, To: deposit.ID}: true},
+		},
+		saved: make(map[uuid.UUID][]transfers.Match),
+	}
+	svc := &WorkerServices{Transfers: store}
+
+	if err := HandleMatchTransfersTask(context.Background(), NewMatchTransfersTask(), svc); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	saved := store.saved[familyID]
+	if len(saved) != 1 || saved[0].To != onCard.ID || !saved[0].Automatic {
+		t.Errorf("Expected the card payment to be matched outright, got %+v", saved)
+	}
+}
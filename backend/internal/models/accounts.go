@@ -1,28 +1,205 @@
 package models
 
-import "github.com/google/uuid"
+import (
+    "errors"
+    "time"
+
+    "github.com/google/uuid"
+)
+
+// ErrAccountBalanceNotZero is returned when closing an account that still holds money
+// (or owes it).
+var ErrAccountBalanceNotZero = errors.New("account balance must be zero to close it")
+
+// ErrAccountDetailsMismatch is returned for details that belong to another account type.
+var ErrAccountDetailsMismatch = errors.New("account details don't match the account type")
+
+// Account statuses. Only active accounts are listed and count towards net worth;
+// archived and closed accounts keep their history. Closed accounts had a zero balance
+// when they were closed.
+const (
+    AccountStatusActive   = "active"
+    AccountStatusArchived = "archived"
+    AccountStatusClosed   = "closed"
+)
+
+func ValidAccountStatus(status string) bool {
+    switch status {
+    case AccountStatusActive, AccountStatusArchived, AccountStatusClosed:
+        return true
+    }
+    return false
+}
+
+// Account types. Accounts are delegated types: each type is either an asset or a
+// liability, and types with attributes of their own keep them in the matching details.
+const (
+    AccountTypeDepository     = "depository"
+    AccountTypeInvestment     = "investment"
+    AccountTypeCrypto         = "crypto"
+    AccountTypeProperty       = "property"
+    AccountTypeVehicle        = "vehicle"
+    AccountTypeOtherAsset     = "other_asset"
+    AccountTypeCreditCard     = "credit_card"
+    AccountTypeLoan           = "loan"
+    AccountTypeOtherLiability = "other_liability"
+)
+
+var accountTypeClassifications = map[string]string{
+    AccountTypeDepository:     "asset",
+    AccountTypeInvestment:     "asset",
+    AccountTypeCrypto:         "asset",
+    AccountTypeProperty:       "asset",
+    AccountTypeVehicle:        "asset",
+    AccountTypeOtherAsset:     "asset",
+    AccountTypeCreditCard:     "liability",
+    AccountTypeLoan:           "liability",
+    AccountTypeOtherLiability: "liability",
+}
+
+// AccountClassification returns whether accounts of accountType are assets or
+// liabilities. ok is false for unknown types.
+func AccountClassification(accountType string) (classification string, ok bool) {
+    classification, ok = accountTypeClassifications[accountType]
+    return classification, ok
+}
 
 type Account struct {
     ID             uuid.UUID `json:"id"`
     FamilyID       uuid.UUID `json:"familyId"`
     Name           string    `json:"name"`
-    Type           string    `json:"type"`           // "depository", "loan", "property", "credit_card"
+    Type           string    `json:"type"`           // One of the AccountType constants
     Subtype        string    `json:"subtype"`        // "checking", "mortgage"
     Classification string    `json:"classification"` // "asset", "liability"
     Balance        float64   `json:"balance"`        // Raw number
     Currency       string    `json:"currency"`
+    Status         string    `json:"status"`
 
     // Detailed Attributes (Optional, only filled if applicable)
-    PropertyDetails *PropertyDetails `json:"propertyDetails,omitempty"`
-    LoanDetails     *LoanDetails     `json:"loanDetails,omitempty"`
+    PropertyDetails   *PropertyDetails   `json:"propertyDetails,omitempty"`
+    VehicleDetails    *VehicleDetails    `json:"vehicleDetails,omitempty"`
+    InvestmentDetails *InvestmentDetails `json:"investmentDetails,omitempty"`
+    CryptoDetails     *CryptoDetails     `json:"cryptoDetails,omitempty"`
+    LoanDetails       *LoanDetails       `json:"loanDetails,omitempty"`
+    CreditCardDetails *CreditCardDetails `json:"creditCardDetails,omitempty"`
+}
+
+func (a *Account) Details() AccountDetails {
+    return AccountDetails{
+        PropertyDetails:   a.PropertyDetails,
+        VehicleDetails:    a.VehicleDetails,
+        InvestmentDetails: a.InvestmentDetails,
+        CryptoDetails:     a.CryptoDetails,
+        LoanDetails:       a.LoanDetails,
+        CreditCardDetails: a.CreditCardDetails,
+    }
+}
+
+func (a *Account) SetDetails(d AccountDetails) {
+    a.PropertyDetails = d.PropertyDetails
+    a.VehicleDetails = d.VehicleDetails
+    a.InvestmentDetails = d.InvestmentDetails
+    a.CryptoDetails = d.CryptoDetails
+    a.LoanDetails = d.LoanDetails
+    a.CreditCardDetails = d.CreditCardDetails
+}
+
+// AccountDetails are the attributes specific to an account's type. At most the one
+// matching the type is set.
+type AccountDetails struct {
+    PropertyDetails   *PropertyDetails   `json:"propertyDetails,omitempty"`
+    VehicleDetails    *VehicleDetails    `json:"vehicleDetails,omitempty"`
+    InvestmentDetails *InvestmentDetails `json:"investmentDetails,omitempty"`
+    CryptoDetails     *CryptoDetails     `json:"cryptoDetails,omitempty"`
+    LoanDetails       *LoanDetails       `json:"loanDetails,omitempty"`
+    CreditCardDetails *CreditCardDetails `json:"creditCardDetails,omitempty"`
+}
+
+// MatchType reports whether the only details set, if any, are accountType's.
+func (d AccountDetails) MatchType(accountType string) bool {
+    set := map[string]bool{
+        AccountTypeProperty:   d.PropertyDetails != nil,
+        AccountTypeVehicle:    d.VehicleDetails != nil,
+        AccountTypeInvestment: d.InvestmentDetails != nil,
+        AccountTypeCrypto:     d.CryptoDetails != nil,
+        AccountTypeLoan:       d.LoanDetails != nil,
+        AccountTypeCreditCard: d.CreditCardDetails != nil,
+    }
+    for t, isSet := range set {
+        if isSet && t != accountType {
+            return false
+        }
+    }
+    return true
+}
+
+// AccountUpdate holds the fields a PUT /accounts/{id} changes. Nil fields are left as
+// they are. An account's type can't change, so only its own details can be given.
+type AccountUpdate struct {
+    Name    *string `json:"name"`
+    Subtype *string `json:"subtype"`
+    Status  *string `json:"status"`
+
+    AccountDetails
 }
 
 type PropertyDetails struct {
-    Address string `json:"address"`
-    Sqft    int    `json:"sqft"`
+    Address   string `json:"address"`
+    Sqft      int    `json:"sqft"`
+    YearBuilt int    `json:"yearBuilt,omitempty"`
+}
+
+type VehicleDetails struct {
+    Make    string `json:"make"`
+    Model   string `json:"model"`
+    Year    int    `json:"year,omitempty"`
+    Mileage int    `json:"mileage,omitempty"`
+}
+
+// How an investment account is taxed.
+const (
+    TaxTreatmentTaxable     = "taxable"
+    TaxTreatmentTaxDeferred = "tax_deferred"
+    TaxTreatmentTaxExempt   = "tax_exempt"
+)
+
+type InvestmentDetails struct {
+    TaxTreatment string `json:"taxTreatment,omitempty"`
+}
+
+func ValidTaxTreatment(treatment string) bool {
+    switch treatment {
+    case "", TaxTreatmentTaxable, TaxTreatmentTaxDeferred, TaxTreatmentTaxExempt:
+        return true
+    }
+    return false
 }
 
+type CryptoDetails struct {
+    Network       string `json:"network,omitempty"`
+    WalletAddress string `json:"walletAddress,omitempty"`
+}
+
+// LoanDetails describes a fixed-rate loan. OriginalPrincipal and StartDate, together with
+// the rate and term, are what its amortization schedule is built from.
 type LoanDetails struct {
-    InterestRate float64 `json:"interestRate"`
-    TermMonths   int     `json:"termMonths"`
+    InterestRate       float64    `json:"interestRate"`
+    TermMonths         int        `json:"termMonths"`
+    MinimumPayment     float64    `json:"minimumPayment"`
+    NextPaymentDueDate *time.Time `json:"nextPaymentDueDate,omitempty"`
+    OriginalPrincipal  float64    `json:"originalPrincipal"`
+    StartDate          *time.Time `json:"startDate,omitempty"`
+}
+
+// CreditCardDetails describes a card's terms and statement cycle. StatementCloseDay and
+// PaymentDueDay are days of the month (1-31, the month's last day in shorter months),
+// or 0 when unknown.
+type CreditCardDetails struct {
+    APR                  float64    `json:"apr"`
+    MinimumPayment       float64    `json:"minimumPayment"`
+    NextPaymentDueDate   *time.Time `json:"nextPaymentDueDate,omitempty"`
+    LastStatementBalance float64    `json:"lastStatementBalance"`
+    CreditLimit          float64    `json:"creditLimit"`
+    StatementCloseDay    int        `json:"statementCloseDay"`
+    PaymentDueDay        int        `json:"paymentDueDay"`
 }
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrAPIKeyInvalid = errors.New("API key is invalid, expired or revoked")
+
+// API key scopes. Keys act with their owner's role, further limited to their scopes.
+const (
+	ScopeReadAccounts      = "read:accounts"
+	ScopeWriteAccounts     = "write:accounts"
+	ScopeReadTransactions  = "read:transactions"
+	ScopeWriteTransactions = "write:transactions"
+	ScopeReadInvestments   = "read:investments"
+	ScopeWriteInvestments  = "write:investments"
+)
+
+var apiKeyScopes = []string{
+	ScopeReadAccounts, ScopeWriteAccounts,
+	ScopeReadTransactions, ScopeWriteTransactions,
+	ScopeReadInvestments, ScopeWriteInvestments,
+}
+
+func ValidScope(scope string) bool {
+	for _, s := range apiKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey is a user-created key for calling the API from scripts. Like sessions, only a
+// hash of the key is stored; Prefix is its first few characters, for telling keys apart.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"userId"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+// APIKeyPrincipal is who a request authenticated with an API key acts as.
+type APIKeyPrincipal struct {
+	KeyID    uuid.UUID
+	UserID   uuid.UUID
+	FamilyID uuid.UUID
+	Role     string
+	Scopes   []string
+}
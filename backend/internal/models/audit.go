@@ -0,0 +1,65 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit actions, named <entity>.<verb>.
+const (
+	AuditAccountCreate     = "account.create"
+	AuditAccountUpdate     = "account.update"
+	AuditAccountDelete     = "account.delete"
+	AuditTransactionCreate = "transaction.create"
+	AuditTransactionUpdate = "transaction.update"
+	AuditTransactionDelete = "transaction.delete"
+	AuditTransferCreate    = "transfer.create"
+	AuditTransferMatch     = "transfer.match"
+	AuditTransferUnmatch   = "transfer.unmatch"
+	AuditTradeCreate       = "trade.create"
+	AuditTagCreate         = "tag.create"
+	AuditTagUpdate         = "tag.update"
+	AuditTagDelete         = "tag.delete"
+	AuditTagEntries        = "tag.tag_entries"
+	AuditUntagEntries      = "tag.untag_entries"
+	AuditScheduleCreate    = "scheduled_transaction.create"
+	AuditScheduleUpdate    = "scheduled_transaction.update"
+	AuditScheduleDelete    = "scheduled_transaction.delete"
+	AuditOccurrenceSet     = "scheduled_occurrence.set"
+	AuditOccurrenceDelete  = "scheduled_occurrence.delete"
+	AuditPlaidItemSave     = "plaid_item.save"
+	AuditPlaidItemRemove   = "plaid_item.remove"
+	AuditInviteCreate      = "family_invite.create"
+	AuditInviteRevoke      = "family_invite.revoke"
+	AuditMemberRoleUpdate  = "member.role_update"
+)
+
+// AuditEvent records one change to a family's data: who made it, from where, and the
+// entity before and after. ActorUserID is nil for system changes such as Plaid syncs.
+type AuditEvent struct {
+	ID          uuid.UUID       `json:"id"`
+	FamilyID    uuid.UUID       `json:"familyId"`
+	ActorUserID *uuid.UUID      `json:"actorUserId,omitempty"`
+	Action      string          `json:"action"`
+	EntityType  string          `json:"entityType"`
+	EntityID    *uuid.UUID      `json:"entityId,omitempty"`
+	Before      json.RawMessage `json:"before,omitempty"`
+	After       json.RawMessage `json:"after,omitempty"`
+	RequestID   string          `json:"requestId,omitempty"`
+	IPAddress   string          `json:"ipAddress,omitempty"`
+	CreatedAt   time.Time       `json:"createdAt"`
+}
+
+// AuditFilter narrows a family's audit events. Zero fields don't filter.
+type AuditFilter struct {
+	ActorUserID *uuid.UUID
+	Action      string
+	EntityType  string
+	EntityID    *uuid.UUID
+	From        *time.Time
+	To          *time.Time
+	Limit       int
+	Offset      int
+}
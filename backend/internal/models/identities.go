@@ -0,0 +1,60 @@
+package models
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrIdentityEmailUnverified  = errors.New("identity provider hasn't verified the email address")
+	ErrIdentityDomainNotAllowed = errors.New("email domain isn't allowed to sign up with single sign-on")
+)
+
+// ExternalIdentity is a user as an OpenID Connect provider knows them. Issuer and Subject
+// identify them for good; the email can change on the provider's side.
+type ExternalIdentity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// OIDCAuthRequest is one sign-in attempt. State, Nonce and Verifier must be kept by the
+// client that started it and handed back with the authorization code.
+type OIDCAuthRequest struct {
+	URL      string
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+// SSOProvisioning decides where users signing in with SSO for the first time end up.
+// With FamilyID set they join that family with Role; otherwise each founds their own
+// family, as with Register.
+type SSOProvisioning struct {
+	FamilyID *uuid.UUID
+	Role     string
+	// Email domains allowed to sign up this way. Empty allows any.
+	AllowedDomains []string
+}
+
+// AllowsEmail reports whether a new user with email may be provisioned.
+func (p SSOProvisioning) AllowsEmail(email string) bool {
+	if len(p.AllowedDomains) == 0 {
+		return true
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := email[at+1:]
+	for _, allowed := range p.AllowedDomains {
+		if strings.EqualFold(domain, allowed) {
+			return true
+		}
+	}
+	return false
+}
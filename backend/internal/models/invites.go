@@ -0,0 +1,28 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInviteNotFound      = errors.New("invite not found or no longer valid")
+	ErrInviteEmailMismatch = errors.New("invite was sent to a different email address")
+	ErrLastAdmin           = errors.New("family must keep at least one admin")
+)
+
+// FamilyInvite lets someone join an existing family. Like sessions, only a hash of the
+// token is stored.
+type FamilyInvite struct {
+	ID         uuid.UUID  `json:"id"`
+	FamilyID   uuid.UUID  `json:"familyId"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	InvitedBy  *uuid.UUID `json:"invitedBy,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	AcceptedAt *time.Time `json:"acceptedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
@@ -18,6 +18,9 @@ type Entry struct {
 	// Polymorphic Fields
 	EntryableType string    `db:"entryable_type" json:"entryableType"` // "Transaction", "Valuation"
 	EntryableID   uuid.UUID `db:"entryable_id" json:"entryableId"`
+
+	// Set for entries imported from Plaid
+	PlaidID string `db:"plaid_id" json:"-"`
 }
 
 // Transaction represents the 'transactions' table - specific info
@@ -26,12 +29,29 @@ type Transaction struct {
 	CategoryID *uuid.UUID `db:"category_id" json:"categoryId"`
 	MerchantID *uuid.UUID `db:"merchant_id" json:"merchantId"`
 	Kind       string     `db:"kind" json:"kind"` // "standard", "transfer"
+
+	// Set when the transaction is split across categories; see TransactionSplit
+	Splits []TransactionSplit `json:"splits,omitempty"`
 }
 
 // API Response model: Combining them into one usable struct
 type TransactionDetail struct {
 	Entry           // Embed the Entry fields
-	CategoryName string `json:"categoryName"`
-	MerchantName string `json:"merchantName"`
-	Kind         string `json:"kind"`
+	CategoryID   *uuid.UUID `json:"categoryId"`
+	CategoryName string     `json:"categoryName"`
+	MerchantName string     `json:"merchantName"`
+	Kind         string     `json:"kind"`
+	Split        bool       `json:"split"`
+	Tags         []Tag      `json:"tags"`
+}
+
+// TransactionFilter narrows a family's transactions. Zero fields don't filter; an entry
+// must have every tag in TagIDs.
+type TransactionFilter struct {
+	AccountID *uuid.UUID
+	TagIDs    []uuid.UUID
+	From      *time.Time
+	To        *time.Time
+	Limit     int
+	Offset    int
 }
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMFANotSetUp       = errors.New("two-factor authentication has not been set up")
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrInvalidMFACode    = errors.New("invalid two-factor authentication code")
+)
+
+// TOTPState is a user's authenticator enrollment. Secret is encrypted at rest; EnabledAt
+// stays nil until the user proves their app works by entering a code.
+type TOTPState struct {
+	Secret    string
+	EnabledAt *time.Time
+	// Last time step a code was accepted for, so a code can't be replayed
+	LastStep int64
+}
+
+// StoredTOTPSecret is a user's encrypted TOTP secret, pending or enabled, as kept for
+// re-encryption.
+type StoredTOTPSecret struct {
+	UserID uuid.UUID
+	Secret string
+}
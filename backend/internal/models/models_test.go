@@ -59,6 +59,19 @@ func TestUser_Model(t *testing.T) {
 	})
 }
 
+func TestLockoutPolicy_LockFor(t *testing.T) {
+	policy := LockoutPolicy{Threshold: 5, Base: time.Minute, Max: 10 * time.Minute}
+
+	assert.Equal(t, time.Duration(0), policy.LockFor(4))
+	assert.Equal(t, time.Minute, policy.LockFor(5))
+	assert.Equal(t, 2*time.Minute, policy.LockFor(6))
+	assert.Equal(t, 8*time.Minute, policy.LockFor(8))
+	assert.Equal(t, 10*time.Minute, policy.LockFor(9))
+	assert.Equal(t, 10*time.Minute, policy.LockFor(50))
+
+	assert.Equal(t, time.Duration(0), LockoutPolicy{}.LockFor(100), "a zero threshold disables lockout")
+}
+
 // Family Model Tests
 func TestFamily_Model(t *testing.T) {
 	t.Run("should create valid family", func(t *testing.T) {
@@ -384,6 +397,29 @@ func TestLoanDetails_Model(t *testing.T) {
 	})
 }
 
+func TestAccountClassification(t *testing.T) {
+	classification, ok := AccountClassification(AccountTypeVehicle)
+	assert.True(t, ok)
+	assert.Equal(t, "asset", classification)
+
+	classification, ok = AccountClassification(AccountTypeCreditCard)
+	assert.True(t, ok)
+	assert.Equal(t, "liability", classification)
+
+	_, ok = AccountClassification("brokerage")
+	assert.False(t, ok)
+}
+
+func TestAccountDetails_MatchType(t *testing.T) {
+	assert.True(t, AccountDetails{}.MatchType(AccountTypeDepository), "no details match any type")
+	assert.True(t, AccountDetails{PropertyDetails: &PropertyDetails{}}.MatchType(AccountTypeProperty))
+	assert.False(t, AccountDetails{PropertyDetails: &PropertyDetails{}}.MatchType(AccountTypeVehicle))
+	assert.False(t, AccountDetails{LoanDetails: &LoanDetails{}, CreditCardDetails: &CreditCardDetails{}}.MatchType(AccountTypeLoan))
+
+	acc := Account{Type: AccountTypeCrypto, CryptoDetails: &CryptoDetails{Network: "bitcoin"}}
+	assert.True(t, acc.Details().MatchType(acc.Type))
+}
+
 // JSON Serialization Tests
 func TestModel_JSONTags(t *testing.T) {
 	t.Run("user should serialize correctly", func(t *testing.T) {
@@ -557,3 +593,31 @@ func TestModel_EdgeCases(t *testing.T) {
 		assert.Equal(t, 0.01, entry.Amount)
 	})
 }
+
+func TestValidateSplits(t *testing.T) {
+	groceries, household := uuid.New(), uuid.New()
+	lines := []TransactionSplit{
+		{CategoryID: &groceries, Amount: 82.15},
+		{CategoryID: &household, Amount: 30.10, Memo: "Paper towels"},
+		{Amount: 12.50},
+	}
+
+	assert.NoError(t, ValidateSplits(124.75, lines))
+	assert.NoError(t, ValidateSplits(124.75, nil), "no lines means not split")
+	assert.ErrorIs(t, ValidateSplits(125, lines), ErrSplitTotalMismatch)
+	assert.ErrorIs(t, ValidateSplits(82.15, lines[:1]), ErrSplitTooFewLines)
+	assert.ErrorIs(t, ValidateSplits(82.15, []TransactionSplit{{Amount: 82.15}, {Amount: 0.001}}), ErrSplitZeroAmount)
+
+	// Refunds split the same way
+	assert.NoError(t, ValidateSplits(-40, []TransactionSplit{{Amount: -25}, {Amount: -15}}))
+}
+
+func TestScaleSplits(t *testing.T) {
+	lines := []TransactionSplit{{Amount: 50}, {Amount: 30}, {Amount: 20}}
+
+	// A tip added when a pending transaction posts
+	scaled := ScaleSplits(lines, 100, 115.55)
+	assert.Equal(t, []float64{57.78, 34.67, 23.1}, []float64{scaled[0].Amount, scaled[1].Amount, scaled[2].Amount})
+	assert.NoError(t, ValidateSplits(115.55, scaled))
+	assert.Equal(t, 50.0, lines[0].Amount, "the original lines are left alone")
+}
@@ -18,3 +18,12 @@ type PlaidItem struct {
 	CreatedAt       time.Time `json:"createdAt"`
 	UpdatedAt       time.Time `json:"updatedAt"`
 }
+
+// Plaid item statuses, driven by the ITEM webhooks.
+const (
+	PlaidItemStatusActive            = "active"
+	PlaidItemStatusLoginRequired     = "login_required"
+	PlaidItemStatusError             = "error"
+	PlaidItemStatusPendingExpiration = "pending_expiration"
+	PlaidItemStatusRevoked           = "revoked"
+)
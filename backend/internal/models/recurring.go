@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// How often a recurring transaction happens
+const (
+	RecurringCadenceWeekly  = "weekly"
+	RecurringCadenceMonthly = "monthly"
+	RecurringCadenceAnnual  = "annual"
+)
+
+// A detected recurring transaction starts out detected; the family confirms it as a
+// real subscription or bill, or dismisses it so it stops being shown.
+const (
+	RecurringStatusDetected  = "detected"
+	RecurringStatusConfirmed = "confirmed"
+	RecurringStatusDismissed = "dismissed"
+)
+
+func ValidRecurringStatus(status string) bool {
+	switch status {
+	case RecurringStatusDetected, RecurringStatusConfirmed, RecurringStatusDismissed:
+		return true
+	}
+	return false
+}
+
+// RecurringTransaction is a series of similar entries at a regular cadence. Amount is
+// the latest occurrence's; PreviousAmount is set when it differs from the one before.
+// Missed and PriceChanged are worked out when the series is read.
+type RecurringTransaction struct {
+	ID             uuid.UUID  `json:"id"`
+	FamilyID       uuid.UUID  `json:"familyId"`
+	PatternKey     string     `json:"-"`
+	MerchantID     *uuid.UUID `json:"merchantId,omitempty"`
+	AccountID      *uuid.UUID `json:"accountId,omitempty"`
+	Name           string     `json:"name"`
+	Cadence        string     `json:"cadence"`
+	Amount         float64    `json:"amount"`
+	PreviousAmount *float64   `json:"previousAmount,omitempty"`
+	Currency       string     `json:"currency"`
+	Occurrences    int        `json:"occurrences"`
+	LastDate       time.Time  `json:"lastDate"`
+	NextDate       time.Time  `json:"nextDate"`
+	Status         string     `json:"status"`
+	Missed         bool       `json:"missed"`
+	PriceChanged   bool       `json:"priceChanged"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+// RecurringFilter narrows a family's recurring transactions. An empty Status lists all
+// but the dismissed ones.
+type RecurringFilter struct {
+	Status string
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentReminder is a reminder sent to a family that a payment is coming due. Each
+// account's due date is reminded at most once.
+type PaymentReminder struct {
+	ID        uuid.UUID `json:"id"`
+	FamilyID  uuid.UUID `json:"familyId"`
+	AccountID uuid.UUID `json:"accountId"`
+	DueDate   time.Time `json:"dueDate"`
+	Amount    float64   `json:"amount"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Report intervals: the length of each period a report is broken into
+const (
+	ReportIntervalDay     = "day"
+	ReportIntervalWeek    = "week"
+	ReportIntervalMonth   = "month"
+	ReportIntervalQuarter = "quarter"
+	ReportIntervalYear    = "year"
+)
+
+func ValidReportInterval(interval string) bool {
+	switch interval {
+	case ReportIntervalDay, ReportIntervalWeek, ReportIntervalMonth, ReportIntervalQuarter, ReportIntervalYear:
+		return true
+	}
+	return false
+}
+
+// CashFlowFilter is the range and breakdown of a cash-flow report. Dates are inclusive.
+// An entry must have every tag in TagIDs.
+type CashFlowFilter struct {
+	Start    time.Time
+	End      time.Time
+	Interval string
+	TagIDs   []uuid.UUID
+}
+
+// CashFlowReport is a family's income and spending over a range, in its currency, with
+// transfers between its own accounts left out. Income and Expense are both positive;
+// SavingsRate is the share of income not spent, nil when there was no income.
+type CashFlowReport struct {
+	Start             time.Time          `json:"start"`
+	End               time.Time          `json:"end"`
+	Interval          string             `json:"interval"`
+	Currency          string             `json:"currency"`
+	Income            float64            `json:"income"`
+	Expense           float64            `json:"expense"`
+	Net               float64            `json:"net"`
+	SavingsRate       *float64           `json:"savingsRate"`
+	Periods           []CashFlowPeriod   `json:"periods"`
+	IncomeCategories  []CashFlowCategory `json:"incomeCategories"`
+	ExpenseCategories []CashFlowCategory `json:"expenseCategories"`
+	Sankey            CashFlowSankey     `json:"sankey"`
+}
+
+// CashFlowPeriod is one interval of a report, starting on Start.
+type CashFlowPeriod struct {
+	Start       time.Time `json:"start"`
+	Income      float64   `json:"income"`
+	Expense     float64   `json:"expense"`
+	Net         float64   `json:"net"`
+	SavingsRate *float64  `json:"savingsRate"`
+}
+
+// CashFlowCategory totals one category on the income or expense side. CategoryID is nil
+// for uncategorized transactions. Share is the fraction of its side's total.
+type CashFlowCategory struct {
+	CategoryID *uuid.UUID `json:"categoryId"`
+	Name       string     `json:"name"`
+	Color      string     `json:"color"`
+	Amount     float64    `json:"amount"`
+	Share      float64    `json:"share"`
+}
+
+// CashFlowSankey is the report as a flow diagram: income categories flow into a single
+// cash-flow node, which flows out to expense categories and to savings, or is topped up
+// from a deficit when spending was more than income.
+type CashFlowSankey struct {
+	Nodes []SankeyNode `json:"nodes"`
+	Links []SankeyLink `json:"links"`
+}
+
+type SankeyNode struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color,omitempty"`
+	Kind  string `json:"kind"` // income, expense, cash_flow, surplus or deficit
+}
+
+type SankeyLink struct {
+	Source string  `json:"source"`
+	Target string  `json:"target"`
+	Value  float64 `json:"value"`
+}
+
+// MerchantSpending is what the family spent at one merchant over a report's range, net
+// of refunds.
+type MerchantSpending struct {
+	MerchantID   uuid.UUID `json:"merchantId"`
+	Name         string    `json:"name"`
+	Amount       float64   `json:"amount"`
+	Transactions int       `json:"transactions"`
+	LastDate     time.Time `json:"lastDate"`
+}
+
+// CategoryTrend is a category's spending month by month, oldest first. CategoryID is nil
+// for uncategorized spending.
+type CategoryTrend struct {
+	CategoryID *uuid.UUID      `json:"categoryId"`
+	Name       string          `json:"name"`
+	Color      string          `json:"color"`
+	Total      float64         `json:"total"`
+	Months     []CategoryMonth `json:"months"`
+}
+
+// CategoryMonth is one month of a CategoryTrend. Change is the fraction it rose or fell
+// from the month before, nil for the first month or when nothing was spent before.
+type CategoryMonth struct {
+	Month  time.Time `json:"month"`
+	Amount float64   `json:"amount"`
+	Change *float64  `json:"change"`
+}
+
+// LargeTransaction is one of the biggest outgoing transactions in a report's range.
+// Amount is what was spent, so positive.
+type LargeTransaction struct {
+	EntryID      uuid.UUID  `json:"entryId"`
+	AccountID    uuid.UUID  `json:"accountId"`
+	Date         time.Time  `json:"date"`
+	Name         string     `json:"name"`
+	Amount       float64    `json:"amount"`
+	Currency     string     `json:"currency"`
+	CategoryID   *uuid.UUID `json:"categoryId"`
+	CategoryName string     `json:"categoryName"`
+	MerchantName string     `json:"merchantName"`
+}
+
+// SpendingAnomaly is a month's spending in a category more than two standard deviations
+// above its average over the trailing months.
+type SpendingAnomaly struct {
+	CategoryID *uuid.UUID `json:"categoryId"`
+	Name       string     `json:"name"`
+	Color      string     `json:"color"`
+	Month      time.Time  `json:"month"`
+	Amount     float64    `json:"amount"`
+	Average    float64    `json:"average"`
+	StdDev     float64    `json:"stdDev"`
+	Threshold  float64    `json:"threshold"`
+}
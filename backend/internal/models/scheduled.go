@@ -0,0 +1,52 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrScheduleAccountLinked = errors.New("scheduled transactions are only for accounts not linked to Plaid")
+	ErrOccurrencePosted      = errors.New("occurrence has already been posted")
+)
+
+// A single occurrence of a scheduled transaction is skipped or overridden ahead of time,
+// and marked posted once the worker has created its entry.
+const (
+	OccurrenceStatusSkipped    = "skipped"
+	OccurrenceStatusOverridden = "overridden"
+	OccurrenceStatusPosted     = "posted"
+)
+
+// ScheduledTransaction is a repeating manual entry, like rent on the 1st of the month.
+// Rule is a recurrence rule in the subset the schedule package understands, with its
+// occurrences counted from StartDate. PostedThrough is the last date the worker has
+// posted, so a schedule created with a past start date isn't backfilled before it.
+type ScheduledTransaction struct {
+	ID            uuid.UUID  `json:"id"`
+	FamilyID      uuid.UUID  `json:"familyId"`
+	AccountID     uuid.UUID  `json:"accountId"`
+	CategoryID    *uuid.UUID `json:"categoryId,omitempty"`
+	Name          string     `json:"name"`
+	Amount        float64    `json:"amount"`
+	Currency      string     `json:"currency"`
+	Rule          string     `json:"rule"`
+	StartDate     time.Time  `json:"startDate"`
+	PostedThrough *time.Time `json:"postedThrough,omitempty"`
+	Active        bool       `json:"active"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// ScheduledOccurrence records what happened to one date of a scheduled transaction.
+// Amount and Name replace the schedule's own when set.
+type ScheduledOccurrence struct {
+	ScheduledTransactionID uuid.UUID  `json:"scheduledTransactionId"`
+	Date                   time.Time  `json:"date"`
+	Status                 string     `json:"status"`
+	Amount                 *float64   `json:"amount,omitempty"`
+	Name                   *string    `json:"name,omitempty"`
+	EntryID                *uuid.UUID `json:"entryId,omitempty"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"errors"
+	"math"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSplitTooFewLines   = errors.New("a split needs at least two lines")
+	ErrSplitZeroAmount    = errors.New("split lines need a non-zero amount")
+	ErrSplitTotalMismatch = errors.New("split amounts must add up to the transaction amount")
+	ErrSplitNotAllowed    = errors.New("only standard transactions can be split")
+)
+
+// TransactionSplit is one line of a transaction divided across categories, like the
+// groceries part of a supermarket receipt. A split transaction's lines add up to its
+// entry's amount and take the place of its own category in budgets and reports.
+type TransactionSplit struct {
+	ID            uuid.UUID  `json:"id"`
+	TransactionID uuid.UUID  `json:"transactionId"`
+	CategoryID    *uuid.UUID `json:"categoryId"`
+	Amount        float64    `json:"amount"`
+	Memo          string     `json:"memo"`
+}
+
+// ValidateSplits checks that lines can divide an entry of amount total. No lines at all
+// is valid: the transaction isn't split.
+func ValidateSplits(total float64, lines []TransactionSplit) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	if len(lines) < 2 {
+		return ErrSplitTooFewLines
+	}
+	var sum float64
+	for _, line := range lines {
+		if math.Round(line.Amount*100) == 0 {
+			return ErrSplitZeroAmount
+		}
+		sum += line.Amount
+	}
+	if math.Round(sum*100) != math.Round(total*100) {
+		return ErrSplitTotalMismatch
+	}
+	return nil
+}
+
+// ScaleSplits returns lines resized in proportion for an entry whose amount changed from
+// from to to, so they still add up. Rounding is settled on the last line.
+func ScaleSplits(lines []TransactionSplit, from, to float64) []TransactionSplit {
+	scaled := make([]TransactionSplit, len(lines))
+	copy(scaled, lines)
+	if len(scaled) == 0 || from == 0 {
+		return scaled
+	}
+
+	var sum float64
+	for i := range scaled[:len(scaled)-1] {
+		scaled[i].Amount = math.Round(scaled[i].Amount*to/from*100) / 100
+		sum += scaled[i].Amount
+	}
+	scaled[len(scaled)-1].Amount = math.Round((to-sum)*100) / 100
+	return scaled
+}
@@ -1,6 +1,13 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrTagNameTaken = errors.New("family already has a tag with this name")
 
 type Category struct {
 	ID       uuid.UUID `json:"id"`
@@ -13,3 +20,14 @@ type Merchant struct {
 	ID   uuid.UUID `json:"id"`
 	Name string    `json:"name"`
 }
+
+// Tag is a family's label for entries that cuts across categories, like
+// "vacation-2026" or "reimbursable". Names are unique per family, ignoring case.
+type Tag struct {
+	ID        uuid.UUID `json:"id"`
+	FamilyID  uuid.UUID `json:"familyId"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
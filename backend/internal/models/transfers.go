@@ -0,0 +1,47 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTransferMatchNotSuggested = errors.New("only suggested matches can be confirmed or rejected")
+	ErrTransferNotMatched        = errors.New("only matched transfers can be unmatched")
+	ErrTransferEntryUnavailable  = errors.New("an entry is already a transfer or is split")
+)
+
+// A transfer match starts out suggested; the family confirms it, which links the
+// entries, or rejects it. Only an unambiguous payment from an asset account into a
+// liability is matched straight away, and unmatching one rejects it so it isn't paired
+// again.
+const (
+	TransferMatchSuggested = "suggested"
+	TransferMatchMatched   = "matched"
+	TransferMatchRejected  = "rejected"
+)
+
+func ValidTransferMatchStatus(status string) bool {
+	switch status {
+	case TransferMatchSuggested, TransferMatchMatched, TransferMatchRejected:
+		return true
+	}
+	return false
+}
+
+// TransferMatch pairs the outgoing and incoming entries of one transfer between two of
+// a family's accounts. FromEntry is the one money left, with the positive amount.
+// TransactionID is the shared transfer transaction once matched.
+type TransferMatch struct {
+	ID            uuid.UUID  `json:"id"`
+	FamilyID      uuid.UUID  `json:"familyId"`
+	FromEntry     Entry      `json:"fromEntry"`
+	ToEntry       Entry      `json:"toEntry"`
+	Status        string     `json:"status"`
+	Automatic     bool       `json:"automatic"`
+	TransactionID *uuid.UUID `json:"transactionId,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
@@ -1,11 +1,44 @@
 package models
 
 import (
+	"errors"
+	"net/mail"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const (
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+	// Read-only access, e.g. for an accountant
+	RoleViewer = "viewer"
+)
+
+// Purposes of the single-use tokens we email to users
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+)
+
+var ErrUserTokenInvalid = errors.New("token is invalid, expired or already used")
+
+// ValidRole reports whether role can be assigned to a family member.
+func ValidRole(role string) bool {
+	switch role {
+	case RoleAdmin, RoleMember, RoleViewer:
+		return true
+	}
+	return false
+}
+
+// ValidEmail reports whether email is a bare address, like a@example.com, that is safe
+// to put in an email's headers.
+func ValidEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
 type Family struct {
 	ID        uuid.UUID `db:"id" json:"id"`
 	Name      string    `db:"name" json:"name"`
@@ -18,5 +51,35 @@ type User struct {
 	FamilyID       uuid.UUID `db:"family_id" json:"familyId"`
 	Email          string    `db:"email" json:"email"`
 	PasswordDigest string    `db:"password_digest" json:"-"` // Never return password in JSON
-	Role           string    `db:"role" json:"role"`         // "admin" or "member"
+	Role           string    `db:"role" json:"role"`         // "admin", "member" or "viewer"
+	// Nil until the user follows the link in their verification email
+	EmailVerifiedAt *time.Time `db:"email_verified_at" json:"emailVerifiedAt,omitempty"`
+	// Login needs a TOTP or recovery code as well as the password
+	MFAEnabled bool `json:"mfaEnabled"`
+	// Set after too many failed logins; login is refused until then
+	LockedUntil *time.Time `json:"-"`
+}
+
+// LockoutPolicy locks an account once it reaches Threshold consecutive failed logins.
+// The lock starts at Base and doubles with every further failure, up to Max.
+type LockoutPolicy struct {
+	Threshold int
+	Base      time.Duration
+	Max       time.Duration
+}
+
+// LockFor is how long to lock an account after its nth consecutive failure, or 0.
+func (p LockoutPolicy) LockFor(failures int) time.Duration {
+	if p.Threshold <= 0 || failures < p.Threshold {
+		return 0
+	}
+
+	lock := p.Base
+	for i := p.Threshold; i < failures && lock < p.Max; i++ {
+		lock *= 2
+	}
+	if p.Max > 0 && lock > p.Max {
+		lock = p.Max
+	}
+	return lock
 }
@@ -0,0 +1,102 @@
+// Package ratelimit counts requests per key in fixed windows. MemoryStore suits a single
+// API instance; RedisStore shares counts between instances.
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Values for RATE_LIMIT_STORE
+const (
+	StoreMemory = "memory"
+	StoreRedis  = "redis"
+)
+
+type MemoryStore struct {
+	mu      sync.Mutex
+	windows map[string]*window
+	calls   int
+	now     func() time.Time
+}
+
+type window struct {
+	count   int
+	resetAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{windows: make(map[string]*window), now: time.Now}
+}
+
+// Allow counts a request against key and reports whether it is within limit for the
+// current window. When it isn't, the duration is how long until the window resets.
+func (s *MemoryStore) Allow(ctx context.Context, key string, limit int, period time.Duration) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.calls++
+	if s.calls%1000 == 0 {
+		s.sweep(now)
+	}
+
+	w, ok := s.windows[key]
+	if !ok || !now.Before(w.resetAt) {
+		w = &window{resetAt: now.Add(period)}
+		s.windows[key] = w
+	}
+
+	w.count++
+	if w.count > limit {
+		return false, w.resetAt.Sub(now), nil
+	}
+	return true, 0, nil
+}
+
+// sweep drops expired windows so keys seen once don't accumulate forever.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, w := range s.windows {
+		if !now.Before(w.resetAt) {
+			delete(s.windows, key)
+		}
+	}
+}
+
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client, prefix: "ratelimit:"}
+}
+
+// Increments the counter, starting the window on the first hit, and returns the count and
+// the window's remaining milliseconds in one round trip.
+var incrementScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return {count, redis.call('PTTL', KEYS[1])}
+`)
+
+func (s *RedisStore) Allow(ctx context.Context, key string, limit int, period time.Duration) (bool, time.Duration, error) {
+	res, err := incrementScript.Run(ctx, s.client, []string{s.prefix + key}, period.Milliseconds()).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+
+	count, ttl := res[0], time.Duration(res[1])*time.Millisecond
+	if count > int64(limit) {
+		if ttl < 0 {
+			ttl = period
+		}
+		return false, ttl, nil
+	}
+	return true, 0, nil
+}
@@ -0,0 +1,37 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_FixedWindow(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Unix(1700000000, 0)
+	store.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		ok, _, err := store.Allow(ctx, "ip:1.2.3.4", 3, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, ok, "request %d", i+1)
+	}
+
+	now = now.Add(20 * time.Second)
+	ok, retryAfter, err := store.Allow(ctx, "ip:1.2.3.4", 3, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 40*time.Second, retryAfter)
+
+	// Other keys have their own budget
+	ok, _, _ = store.Allow(ctx, "ip:5.6.7.8", 3, time.Minute)
+	assert.True(t, ok)
+
+	now = now.Add(40 * time.Second)
+	ok, _, _ = store.Allow(ctx, "ip:1.2.3.4", 3, time.Minute)
+	assert.True(t, ok, "window should reset")
+}
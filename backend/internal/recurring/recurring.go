@@ -0,0 +1,228 @@
+// Package recurring finds subscriptions and bills in a family's transactions: series
+// from the same merchant (or with the same name when there is no merchant), for a
+// similar amount, at a weekly, monthly or annual cadence.
+package recurring
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/amortization"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+// How much an occurrence may differ from the series' usual amount, as a fraction of it.
+// The latest occurrence may differ by more, which is reported as a price change.
+const (
+	amountTolerance      = 0.2
+	priceChangeTolerance = 0.5
+)
+
+// Transaction is an entry as detection sees it.
+type Transaction struct {
+	AccountID    uuid.UUID
+	MerchantID   *uuid.UUID
+	MerchantName string
+	Name         string
+	Amount       float64
+	Currency     string
+	Date         time.Time
+}
+
+type cadence struct {
+	name           string
+	minDays        int // gaps between occurrences that count as this cadence
+	maxDays        int
+	minOccurrences int
+	graceDays      int // how late an occurrence may be before it is missed
+}
+
+var cadences = []cadence{
+	{models.RecurringCadenceWeekly, 5, 9, 3, 3},
+	{models.RecurringCadenceMonthly, 26, 35, 3, 5},
+	{models.RecurringCadenceAnnual, 350, 380, 2, 14},
+}
+
+// Detect returns the recurring series in txs, with the family and pattern key set but
+// no status.
+func Detect(familyID uuid.UUID, txs []Transaction) []models.RecurringTransaction {
+	groups := make(map[string][]Transaction)
+	for _, tx := range txs {
+		if tx.Amount == 0 {
+			continue
+		}
+		k := key(tx)
+		groups[k] = append(groups[k], tx)
+	}
+
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var found []models.RecurringTransaction
+	for _, k := range keys {
+		if r, ok := detectSeries(groups[k]); ok {
+			r.FamilyID = familyID
+			r.PatternKey = k
+			found = append(found, r)
+		}
+	}
+	return found
+}
+
+func detectSeries(txs []Transaction) (models.RecurringTransaction, bool) {
+	sort.Slice(txs, func(i, j int) bool { return txs[i].Date.Before(txs[j].Date) })
+	if len(txs) < 2 {
+		return models.RecurringTransaction{}, false
+	}
+
+	gaps := make([]int, 0, len(txs)-1)
+	for i := 1; i < len(txs); i++ {
+		gaps = append(gaps, days(txs[i].Date.Sub(txs[i-1].Date)))
+	}
+	c, ok := cadenceOf(gaps)
+	if !ok || len(txs) < c.minOccurrences {
+		return models.RecurringTransaction{}, false
+	}
+
+	// Most gaps must fit the cadence; one skipped or doubled-up occurrence is fine
+	regular := 0
+	for _, g := range gaps {
+		if g >= c.minDays && g <= c.maxDays {
+			regular++
+		}
+	}
+	if regular*4 < len(gaps)*3 {
+		return models.RecurringTransaction{}, false
+	}
+
+	earlier, last := txs[:len(txs)-1], txs[len(txs)-1]
+	usual := medianAmount(earlier)
+	for _, tx := range earlier {
+		if !near(tx.Amount, usual, amountTolerance) {
+			return models.RecurringTransaction{}, false
+		}
+	}
+	if !near(last.Amount, usual, priceChangeTolerance) {
+		return models.RecurringTransaction{}, false
+	}
+
+	accountID := last.AccountID
+	r := models.RecurringTransaction{
+		MerchantID:  last.MerchantID,
+		AccountID:   &accountID,
+		Name:        last.Name,
+		Cadence:     c.name,
+		Amount:      last.Amount,
+		Currency:    last.Currency,
+		Occurrences: len(txs),
+		LastDate:    toDay(last.Date),
+		NextDate:    next(toDay(last.Date), c.name),
+	}
+	if last.MerchantName != "" {
+		r.Name = last.MerchantName
+	}
+	if previous := earlier[len(earlier)-1].Amount; math.Abs(previous-last.Amount) >= 0.01 {
+		r.PreviousAmount = &previous
+	}
+	return r, true
+}
+
+// Annotate sets the flags on r that depend on today: Missed when the next occurrence is
+// overdue by more than its cadence allows, and PriceChanged when the latest amount
+// differs from the one before.
+func Annotate(r *models.RecurringTransaction, now time.Time) {
+	r.PriceChanged = r.PreviousAmount != nil
+	r.Missed = false
+	for _, c := range cadences {
+		if c.name == r.Cadence {
+			r.Missed = toDay(now).After(r.NextDate.AddDate(0, 0, c.graceDays))
+		}
+	}
+}
+
+// key identifies a series: its merchant, or its name without words containing digits or
+// punctuation (which carry dates and reference numbers), plus whether money goes out or
+// comes in.
+func key(tx Transaction) string {
+	direction := ":out"
+	if tx.Amount < 0 {
+		direction = ":in"
+	}
+	if tx.MerchantID != nil {
+		return "merchant:" + tx.MerchantID.String() + direction + ":" + tx.Currency
+	}
+	return "name:" + normalizeName(tx.Name) + direction + ":" + tx.Currency
+}
+
+var (
+	hasDigit   = regexp.MustCompile(`[0-9]`)
+	nonLetters = regexp.MustCompile(`[^a-z]+`)
+)
+
+func normalizeName(name string) string {
+	var words []string
+	for _, word := range strings.Fields(strings.ToLower(name)) {
+		if hasDigit.MatchString(word) {
+			continue
+		}
+		if word = nonLetters.ReplaceAllString(word, ""); word != "" {
+			words = append(words, word)
+		}
+	}
+	if len(words) == 0 {
+		// Nothing but reference numbers; better to match them exactly than lump them together
+		return strings.ToLower(strings.TrimSpace(name))
+	}
+	return strings.Join(words, " ")
+}
+
+// cadenceOf picks the cadence the median gap falls in.
+func cadenceOf(gaps []int) (cadence, bool) {
+	sorted := append([]int(nil), gaps...)
+	sort.Ints(sorted)
+	median := sorted[len(sorted)/2]
+	for _, c := range cadences {
+		if median >= c.minDays && median <= c.maxDays {
+			return c, true
+		}
+	}
+	return cadence{}, false
+}
+
+func next(last time.Time, cadence string) time.Time {
+	switch cadence {
+	case models.RecurringCadenceWeekly:
+		return last.AddDate(0, 0, 7)
+	case models.RecurringCadenceAnnual:
+		return amortization.AddMonths(last, 12)
+	}
+	return amortization.AddMonths(last, 1)
+}
+
+func medianAmount(txs []Transaction) float64 {
+	amounts := make([]float64, 0, len(txs))
+	for _, tx := range txs {
+		amounts = append(amounts, tx.Amount)
+	}
+	sort.Float64s(amounts)
+	return amounts[len(amounts)/2]
+}
+
+func near(amount, usual, tolerance float64) bool {
+	return math.Abs(amount-usual) <= math.Abs(usual)*tolerance
+}
+
+func days(d time.Duration) int {
+	return int(math.Round(d.Hours() / 24))
+}
+
+func toDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
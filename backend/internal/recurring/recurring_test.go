@@ -0,0 +1,129 @@
+package recurring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// series returns count transactions starting at start, step apart, all for amount.
+func series(name string, merchantID *uuid.UUID, amount float64, start time.Time, count int, step func(time.Time, int) time.Time) []Transaction {
+	var txs []Transaction
+	for i := 0; i < count; i++ {
+		txs = append(txs, Transaction{
+			AccountID: uuid.New(), MerchantID: merchantID, Name: name, Amount: amount, Currency: "USD", Date: step(start, i),
+		})
+	}
+	return txs
+}
+
+func monthly(start time.Time, i int) time.Time { return start.AddDate(0, i, 0) }
+func weekly(start time.Time, i int) time.Time  { return start.AddDate(0, 0, 7*i) }
+func annual(start time.Time, i int) time.Time  { return start.AddDate(i, 0, 0) }
+
+func TestDetect_Cadences(t *testing.T) {
+	familyID := uuid.New()
+	netflix := uuid.New()
+
+	var txs []Transaction
+	txs = append(txs, series("NETFLIX.COM", &netflix, 15.49, date(2024, 1, 3), 5, monthly)...)
+	txs = append(txs, series("Gym #1234", nil, 12, date(2024, 3, 4), 6, weekly)...)
+	txs = append(txs, series("Domain renewal", nil, 20, date(2022, 6, 1), 2, annual)...)
+	// Groceries: frequent but the amounts are all over the place
+	txs = append(txs,
+		Transaction{Name: "Grocer", Amount: 82.10, Currency: "USD", Date: date(2024, 1, 7)},
+		Transaction{Name: "Grocer", Amount: 14.99, Currency: "USD", Date: date(2024, 1, 14)},
+		Transaction{Name: "Grocer", Amount: 140.35, Currency: "USD", Date: date(2024, 1, 21)},
+	)
+
+	found := Detect(familyID, txs)
+	byName := make(map[string]models.RecurringTransaction)
+	for _, r := range found {
+		assert.Equal(t, familyID, r.FamilyID)
+		byName[r.Name] = r
+	}
+	require.Len(t, found, 3, "found %+v", found)
+
+	sub := byName["NETFLIX.COM"]
+	assert.Equal(t, models.RecurringCadenceMonthly, sub.Cadence)
+	assert.Equal(t, 15.49, sub.Amount)
+	assert.Equal(t, 5, sub.Occurrences)
+	assert.Equal(t, date(2024, 5, 3), sub.LastDate)
+	assert.Equal(t, date(2024, 6, 3), sub.NextDate)
+	assert.Equal(t, &netflix, sub.MerchantID)
+	assert.Nil(t, sub.PreviousAmount)
+
+	assert.Equal(t, models.RecurringCadenceWeekly, byName["Gym #1234"].Cadence)
+	assert.Equal(t, models.RecurringCadenceAnnual, byName["Domain renewal"].Cadence)
+	assert.Equal(t, date(2024, 6, 1), byName["Domain renewal"].NextDate)
+}
+
+func TestDetect_MerchantNameAndNormalizedNames(t *testing.T) {
+	// No merchant: names differing only in reference numbers are one series
+	txs := []Transaction{
+		{Name: "SPOTIFY P0A1B2", Amount: 9.99, Currency: "USD", Date: date(2024, 1, 10)},
+		{Name: "SPOTIFY P0C3D4", Amount: 9.99, Currency: "USD", Date: date(2024, 2, 10)},
+		{Name: "Spotify P0E5F6", Amount: 9.99, Currency: "USD", Date: date(2024, 3, 11)},
+	}
+	found := Detect(uuid.New(), txs)
+	require.Len(t, found, 1)
+	assert.Equal(t, "Spotify P0E5F6", found[0].Name)
+
+	merchant := uuid.New()
+	for i := range txs {
+		txs[i].MerchantID, txs[i].MerchantName = &merchant, "Spotify"
+	}
+	found = Detect(uuid.New(), txs)
+	require.Len(t, found, 1)
+	assert.Equal(t, "Spotify", found[0].Name, "the merchant's name wins")
+}
+
+func TestDetect_PriceChange(t *testing.T) {
+	txs := series("Streaming", nil, 15.49, date(2024, 1, 3), 4, monthly)
+	txs = append(txs, Transaction{Name: "Streaming", Amount: 17.99, Currency: "USD", Date: date(2024, 5, 3)})
+
+	found := Detect(uuid.New(), txs)
+	require.Len(t, found, 1)
+	assert.Equal(t, 17.99, found[0].Amount)
+	require.NotNil(t, found[0].PreviousAmount)
+	assert.Equal(t, 15.49, *found[0].PreviousAmount)
+}
+
+func TestDetect_NotRecurring(t *testing.T) {
+	// Too few for monthly
+	assert.Empty(t, Detect(uuid.New(), series("Rent", nil, 1500, date(2024, 1, 1), 2, monthly)))
+
+	// Irregular gaps
+	irregular := []Transaction{
+		{Name: "Cafe", Amount: 4.5, Currency: "USD", Date: date(2024, 1, 1)},
+		{Name: "Cafe", Amount: 4.5, Currency: "USD", Date: date(2024, 1, 3)},
+		{Name: "Cafe", Amount: 4.5, Currency: "USD", Date: date(2024, 2, 20)},
+		{Name: "Cafe", Amount: 4.5, Currency: "USD", Date: date(2024, 2, 21)},
+	}
+	assert.Empty(t, Detect(uuid.New(), irregular))
+
+	// Money in and money out don't mix
+	mixed := series("Venmo", nil, 50, date(2024, 1, 1), 2, monthly)
+	mixed = append(mixed, series("Venmo", nil, -50, date(2024, 3, 1), 2, monthly)...)
+	assert.Empty(t, Detect(uuid.New(), mixed))
+}
+
+func TestAnnotate(t *testing.T) {
+	previous := 15.49
+	r := models.RecurringTransaction{Cadence: models.RecurringCadenceMonthly, NextDate: date(2024, 6, 3), PreviousAmount: &previous}
+
+	Annotate(&r, date(2024, 6, 8))
+	assert.False(t, r.Missed, "within the grace period")
+	assert.True(t, r.PriceChanged)
+
+	Annotate(&r, date(2024, 6, 9))
+	assert.True(t, r.Missed)
+}
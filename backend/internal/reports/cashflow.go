@@ -0,0 +1,228 @@
+// Package reports summarizes a family's transactions over a range of dates.
+package reports
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+// MaxPeriods is the most periods a report may be broken into, e.g. a year of days.
+const MaxPeriods = 400
+
+const (
+	uncategorizedName  = "Uncategorized"
+	uncategorizedColor = "#737373"
+)
+
+// Line is the total of a family's transaction lines in one category on one day, signed
+// as in the ledger, where a positive amount is money going out. Income is set for lines
+// that count as income rather than spending.
+type Line struct {
+	Date         time.Time
+	CategoryID   *uuid.UUID
+	CategoryName string
+	Color        string
+	Income       bool
+	Amount       float64
+}
+
+// PeriodStart returns the start of the interval t falls in. Weeks start on Monday.
+func PeriodStart(t time.Time, interval string) time.Time {
+	y, m, d := t.Date()
+	switch interval {
+	case models.ReportIntervalWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, time.UTC)
+	case models.ReportIntervalMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	case models.ReportIntervalQuarter:
+		return time.Date(y, m-(m-1)%3, 1, 0, 0, 0, 0, time.UTC)
+	case models.ReportIntervalYear:
+		return time.Date(y, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func nextPeriod(start time.Time, interval string) time.Time {
+	switch interval {
+	case models.ReportIntervalWeek:
+		return start.AddDate(0, 0, 7)
+	case models.ReportIntervalMonth:
+		return start.AddDate(0, 1, 0)
+	case models.ReportIntervalQuarter:
+		return start.AddDate(0, 3, 0)
+	case models.ReportIntervalYear:
+		return start.AddDate(1, 0, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// CountPeriods returns how many intervals the range from start to end touches.
+func CountPeriods(start, end time.Time, interval string) int {
+	count := 0
+	for p := PeriodStart(start, interval); !p.After(end); p = nextPeriod(p, interval) {
+		count++
+		if count > MaxPeriods {
+			break
+		}
+	}
+	return count
+}
+
+// CashFlow builds the report for filter's range from lines, which should all fall
+// within it and be in currency.
+func CashFlow(lines []Line, filter models.CashFlowFilter, currency string) models.CashFlowReport {
+	report := models.CashFlowReport{
+		Start: filter.Start, End: filter.End, Interval: filter.Interval, Currency: currency,
+		Periods:           []models.CashFlowPeriod{},
+		IncomeCategories:  []models.CashFlowCategory{},
+		ExpenseCategories: []models.CashFlowCategory{},
+	}
+
+	periodIndex := make(map[time.Time]int)
+	for p := PeriodStart(filter.Start, filter.Interval); !p.After(filter.End); p = nextPeriod(p, filter.Interval) {
+		periodIndex[p] = len(report.Periods)
+		report.Periods = append(report.Periods, models.CashFlowPeriod{Start: p})
+	}
+
+	type categoryKey struct {
+		income bool
+		id     uuid.UUID
+	}
+	categories := make(map[categoryKey]*models.CashFlowCategory)
+	for _, line := range lines {
+		// Both sides are reported as positive totals
+		amount := line.Amount
+		if line.Income {
+			amount = -amount
+		}
+
+		if i, ok := periodIndex[PeriodStart(line.Date, filter.Interval)]; ok {
+			if line.Income {
+				report.Periods[i].Income += amount
+			} else {
+				report.Periods[i].Expense += amount
+			}
+		}
+
+		key := categoryKey{income: line.Income}
+		if line.CategoryID != nil {
+			key.id = *line.CategoryID
+		}
+		c, ok := categories[key]
+		if !ok {
+			c = &models.CashFlowCategory{CategoryID: line.CategoryID, Name: line.CategoryName, Color: line.Color}
+			if line.CategoryID == nil {
+				c.Name, c.Color = uncategorizedName, uncategorizedColor
+			}
+			categories[key] = c
+		}
+		c.Amount += amount
+	}
+
+	for i := range report.Periods {
+		p := &report.Periods[i]
+		p.Income, p.Expense = cents(p.Income), cents(p.Expense)
+		p.Net = cents(p.Income - p.Expense)
+		p.SavingsRate = savingsRate(p.Income, p.Expense)
+		report.Income += p.Income
+		report.Expense += p.Expense
+	}
+	report.Income, report.Expense = cents(report.Income), cents(report.Expense)
+	report.Net = cents(report.Income - report.Expense)
+	report.SavingsRate = savingsRate(report.Income, report.Expense)
+
+	for key, c := range categories {
+		c.Amount = cents(c.Amount)
+		if key.income {
+			report.IncomeCategories = append(report.IncomeCategories, *c)
+		} else {
+			report.ExpenseCategories = append(report.ExpenseCategories, *c)
+		}
+	}
+	sortCategories(report.IncomeCategories, report.Income)
+	sortCategories(report.ExpenseCategories, report.Expense)
+
+	report.Sankey = sankey(report.IncomeCategories, report.ExpenseCategories)
+	return report
+}
+
+// sortCategories puts the largest first and works out each one's share of total.
+func sortCategories(categories []models.CashFlowCategory, total float64) {
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Amount != categories[j].Amount {
+			return categories[i].Amount > categories[j].Amount
+		}
+		return categories[i].Name < categories[j].Name
+	})
+	if total == 0 {
+		return
+	}
+	for i := range categories {
+		categories[i].Share = math.Round(categories[i].Amount/total*10000) / 10000
+	}
+}
+
+// sankey links income categories to expense categories through a cash-flow node. A
+// category with a net refund has nothing to draw, so it is left out.
+func sankey(income, expense []models.CashFlowCategory) models.CashFlowSankey {
+	const hub = "cash_flow"
+	s := models.CashFlowSankey{
+		Nodes: []models.SankeyNode{{ID: hub, Name: "Cash flow", Kind: "cash_flow"}},
+		Links: []models.SankeyLink{},
+	}
+
+	var in, out float64
+	for _, c := range income {
+		if c.Amount <= 0 {
+			continue
+		}
+		id := "income:" + nodeKey(c.CategoryID)
+		s.Nodes = append(s.Nodes, models.SankeyNode{ID: id, Name: c.Name, Color: c.Color, Kind: "income"})
+		s.Links = append(s.Links, models.SankeyLink{Source: id, Target: hub, Value: c.Amount})
+		in += c.Amount
+	}
+	for _, c := range expense {
+		if c.Amount <= 0 {
+			continue
+		}
+		id := "expense:" + nodeKey(c.CategoryID)
+		s.Nodes = append(s.Nodes, models.SankeyNode{ID: id, Name: c.Name, Color: c.Color, Kind: "expense"})
+		s.Links = append(s.Links, models.SankeyLink{Source: hub, Target: id, Value: c.Amount})
+		out += c.Amount
+	}
+
+	switch diff := cents(in - out); {
+	case diff > 0:
+		s.Nodes = append(s.Nodes, models.SankeyNode{ID: "surplus", Name: "Savings", Kind: "surplus"})
+		s.Links = append(s.Links, models.SankeyLink{Source: hub, Target: "surplus", Value: diff})
+	case diff < 0:
+		s.Nodes = append(s.Nodes, models.SankeyNode{ID: "deficit", Name: "Deficit", Kind: "deficit"})
+		s.Links = append(s.Links, models.SankeyLink{Source: "deficit", Target: hub, Value: -diff})
+	}
+	return s
+}
+
+func nodeKey(categoryID *uuid.UUID) string {
+	if categoryID == nil {
+		return "uncategorized"
+	}
+	return categoryID.String()
+}
+
+// savingsRate is the share of income not spent, to four places; nil without income.
+func savingsRate(income, expense float64) *float64 {
+	if income <= 0 {
+		return nil
+	}
+	rate := math.Round((income-expense)/income*10000) / 10000
+	return &rate
+}
+
+func cents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,93 @@
+package reports
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestPeriodStart(t *testing.T) {
+	wed := date(2024, 5, 15)
+	assert.Equal(t, date(2024, 5, 15), PeriodStart(wed, models.ReportIntervalDay))
+	assert.Equal(t, date(2024, 5, 13), PeriodStart(wed, models.ReportIntervalWeek))
+	assert.Equal(t, date(2024, 5, 13), PeriodStart(date(2024, 5, 19), models.ReportIntervalWeek), "Sunday ends the week")
+	assert.Equal(t, date(2024, 5, 1), PeriodStart(wed, models.ReportIntervalMonth))
+	assert.Equal(t, date(2024, 4, 1), PeriodStart(wed, models.ReportIntervalQuarter))
+	assert.Equal(t, date(2024, 1, 1), PeriodStart(wed, models.ReportIntervalYear))
+
+	assert.Equal(t, 12, CountPeriods(date(2024, 1, 15), date(2024, 12, 1), models.ReportIntervalMonth))
+	assert.Equal(t, MaxPeriods+1, CountPeriods(date(2020, 1, 1), date(2024, 1, 1), models.ReportIntervalDay))
+}
+
+func TestCashFlow(t *testing.T) {
+	salary, groceries, rent := uuid.New(), uuid.New(), uuid.New()
+	lines := []Line{
+		{Date: date(2024, 1, 1), CategoryID: &salary, CategoryName: "Salary", Income: true, Amount: -4000},
+		{Date: date(2024, 1, 3), CategoryID: &rent, CategoryName: "Rent", Amount: 1500},
+		{Date: date(2024, 1, 9), CategoryID: &groceries, CategoryName: "Groceries", Amount: 420.50},
+		{Date: date(2024, 2, 1), CategoryID: &salary, CategoryName: "Salary", Income: true, Amount: -4000},
+		{Date: date(2024, 2, 3), CategoryID: &rent, CategoryName: "Rent", Amount: 1500},
+		{Date: date(2024, 2, 11), CategoryID: &groceries, CategoryName: "Groceries", Amount: 379.50},
+		// A refund in an expense category takes spending down
+		{Date: date(2024, 2, 12), CategoryID: &groceries, CategoryName: "Groceries", Amount: -20},
+		{Date: date(2024, 2, 20), Income: true, Amount: -80},
+		{Date: date(2024, 2, 21), Amount: 60},
+	}
+	filter := models.CashFlowFilter{Start: date(2024, 1, 1), End: date(2024, 3, 31), Interval: models.ReportIntervalMonth}
+
+	report := CashFlow(lines, filter, "USD")
+
+	assert.Equal(t, 8080.0, report.Income)
+	assert.Equal(t, 3840.0, report.Expense)
+	assert.Equal(t, 4240.0, report.Net)
+	require.NotNil(t, report.SavingsRate)
+	assert.Equal(t, 0.5248, *report.SavingsRate)
+
+	require.Len(t, report.Periods, 3)
+	assert.Equal(t, 4000.0, report.Periods[0].Income)
+	assert.Equal(t, 1920.5, report.Periods[0].Expense)
+	assert.Equal(t, 1919.5, report.Periods[1].Expense)
+	assert.Nil(t, report.Periods[2].SavingsRate, "March had no income")
+
+	require.Len(t, report.IncomeCategories, 2)
+	assert.Equal(t, "Salary", report.IncomeCategories[0].Name)
+	assert.Equal(t, "Uncategorized", report.IncomeCategories[1].Name)
+	require.Len(t, report.ExpenseCategories, 3)
+	assert.Equal(t, "Rent", report.ExpenseCategories[0].Name)
+	assert.Equal(t, 780.0, report.ExpenseCategories[1].Amount)
+	assert.Equal(t, 0.7813, report.ExpenseCategories[0].Share)
+
+	// 2 income and 3 expense categories, the hub and savings
+	assert.Len(t, report.Sankey.Nodes, 7)
+	var toHub, fromHub float64
+	for _, link := range report.Sankey.Links {
+		if link.Target == "cash_flow" {
+			toHub += link.Value
+		} else {
+			fromHub += link.Value
+		}
+	}
+	assert.Equal(t, toHub, fromHub, "what flows in flows out")
+	assert.Equal(t, models.SankeyLink{Source: "cash_flow", Target: "surplus", Value: 4240}, report.Sankey.Links[len(report.Sankey.Links)-1])
+}
+
+func TestCashFlow_Deficit(t *testing.T) {
+	filter := models.CashFlowFilter{Start: date(2024, 1, 1), End: date(2024, 1, 31), Interval: models.ReportIntervalWeek}
+	report := CashFlow([]Line{
+		{Date: date(2024, 1, 2), Income: true, Amount: -100},
+		{Date: date(2024, 1, 20), Amount: 250},
+	}, filter, "USD")
+
+	assert.Len(t, report.Periods, 5)
+	require.NotNil(t, report.SavingsRate)
+	assert.Equal(t, -1.5, *report.SavingsRate)
+	assert.Equal(t, models.SankeyLink{Source: "deficit", Target: "cash_flow", Value: 150}, report.Sankey.Links[len(report.Sankey.Links)-1])
+}
@@ -0,0 +1,160 @@
+package reports
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+// How unusual a month's spending in a category must be to count as an anomaly, and the
+// trailing months its average is taken over: six by default, at least three of which
+// need spending for the average to mean anything.
+const (
+	anomalyDeviations     = 2
+	MinAnomalyHistory     = 3
+	DefaultAnomalyHistory = 6
+	MaxAnomalyHistory     = 24
+)
+
+// CategorySpend is what was spent in one category in the month starting on Month. It is
+// positive, net of refunds.
+type CategorySpend struct {
+	Month        time.Time
+	CategoryID   *uuid.UUID
+	CategoryName string
+	Color        string
+	Amount       float64
+}
+
+// Months returns the first day of each month from start's to end's.
+func Months(start, end time.Time) []time.Time {
+	var months []time.Time
+	for m := PeriodStart(start, models.ReportIntervalMonth); !m.After(end); m = m.AddDate(0, 1, 0) {
+		months = append(months, m)
+	}
+	return months
+}
+
+type categoryInfo struct {
+	id    *uuid.UUID
+	name  string
+	color string
+}
+
+// byCategory totals spend per category and month.
+func byCategory(spend []CategorySpend) (map[uuid.UUID]map[time.Time]float64, map[uuid.UUID]categoryInfo) {
+	amounts := make(map[uuid.UUID]map[time.Time]float64)
+	info := make(map[uuid.UUID]categoryInfo)
+	for _, s := range spend {
+		key := uuid.Nil
+		if s.CategoryID != nil {
+			key = *s.CategoryID
+		}
+		if _, ok := amounts[key]; !ok {
+			amounts[key] = make(map[time.Time]float64)
+			info[key] = categoryInfo{s.CategoryID, s.CategoryName, s.Color}
+			if s.CategoryID == nil {
+				info[key] = categoryInfo{nil, uncategorizedName, uncategorizedColor}
+			}
+		}
+		amounts[key][PeriodStart(s.Month, models.ReportIntervalMonth)] += s.Amount
+	}
+	return amounts, info
+}
+
+// CategoryTrends returns each category's spending month by month from start's month to
+// end's, largest total first.
+func CategoryTrends(spend []CategorySpend, start, end time.Time) []models.CategoryTrend {
+	months := Months(start, end)
+	amounts, info := byCategory(spend)
+
+	trends := []models.CategoryTrend{}
+	for key, byMonth := range amounts {
+		c := info[key]
+		trend := models.CategoryTrend{CategoryID: c.id, Name: c.name, Color: c.color, Months: make([]models.CategoryMonth, len(months))}
+		for i, m := range months {
+			month := models.CategoryMonth{Month: m, Amount: cents(byMonth[m])}
+			if i > 0 && trend.Months[i-1].Amount > 0 {
+				prev := trend.Months[i-1].Amount
+				change := math.Round((month.Amount-prev)/prev*10000) / 10000
+				month.Change = &change
+			}
+			trend.Months[i] = month
+			trend.Total += month.Amount
+		}
+		trend.Total = cents(trend.Total)
+		trends = append(trends, trend)
+	}
+	sort.Slice(trends, func(i, j int) bool {
+		if trends[i].Total != trends[j].Total {
+			return trends[i].Total > trends[j].Total
+		}
+		return trends[i].Name < trends[j].Name
+	})
+	return trends
+}
+
+// Anomalies returns the categories whose spending in month was more than two standard
+// deviations above their average over the history months before it, most unusual
+// first. spend should cover those months and month itself. Months with no spending
+// count as zero, but a category needs spending in at least three of them.
+func Anomalies(spend []CategorySpend, month time.Time, history int) []models.SpendingAnomaly {
+	month = PeriodStart(month, models.ReportIntervalMonth)
+	amounts, info := byCategory(spend)
+
+	anomalies := []models.SpendingAnomaly{}
+	for key, byMonth := range amounts {
+		current := cents(byMonth[month])
+		if current <= 0 {
+			continue
+		}
+
+		var sum float64
+		active := 0
+		trailing := make([]float64, history)
+		for i := range trailing {
+			trailing[i] = byMonth[month.AddDate(0, -(i+1), 0)]
+			sum += trailing[i]
+			if trailing[i] > 0 {
+				active++
+			}
+		}
+		if active < MinAnomalyHistory {
+			continue
+		}
+		mean := sum / float64(history)
+		var squares float64
+		for _, v := range trailing {
+			squares += (v - mean) * (v - mean)
+		}
+		stdDev := math.Sqrt(squares / float64(history))
+		threshold := mean + anomalyDeviations*stdDev
+		if current <= cents(threshold) {
+			continue
+		}
+
+		c := info[key]
+		anomalies = append(anomalies, models.SpendingAnomaly{
+			CategoryID: c.id, Name: c.name, Color: c.color, Month: month,
+			Amount: current, Average: cents(mean), StdDev: cents(stdDev), Threshold: cents(threshold),
+		})
+	}
+	// Most standard deviations above the average first
+	score := func(a models.SpendingAnomaly) float64 {
+		if a.StdDev == 0 {
+			return math.Inf(1)
+		}
+		return (a.Amount - a.Average) / a.StdDev
+	}
+	sort.Slice(anomalies, func(i, j int) bool {
+		si, sj := score(anomalies[i]), score(anomalies[j])
+		if si != sj {
+			return si > sj
+		}
+		return anomalies[i].Name < anomalies[j].Name
+	})
+	return anomalies
+}
@@ -0,0 +1,61 @@
+package reports
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCategoryTrends(t *testing.T) {
+	groceries, dining := uuid.New(), uuid.New()
+	spend := []CategorySpend{
+		{Month: date(2024, 1, 1), CategoryID: &groceries, CategoryName: "Groceries", Amount: 400},
+		{Month: date(2024, 2, 1), CategoryID: &groceries, CategoryName: "Groceries", Amount: 500},
+		{Month: date(2024, 3, 1), CategoryID: &groceries, CategoryName: "Groceries", Amount: 450},
+		{Month: date(2024, 3, 1), CategoryID: &dining, CategoryName: "Dining", Amount: 120},
+		{Month: date(2024, 2, 1), Amount: 35},
+	}
+
+	trends := CategoryTrends(spend, date(2024, 1, 15), date(2024, 3, 10))
+	require.Len(t, trends, 3)
+	assert.Equal(t, "Groceries", trends[0].Name)
+	assert.Equal(t, 1350.0, trends[0].Total)
+	require.Len(t, trends[0].Months, 3)
+	assert.Nil(t, trends[0].Months[0].Change)
+	require.NotNil(t, trends[0].Months[1].Change)
+	assert.Equal(t, 0.25, *trends[0].Months[1].Change)
+	assert.Equal(t, -0.1, *trends[0].Months[2].Change)
+
+	assert.Equal(t, "Dining", trends[1].Name)
+	assert.Nil(t, trends[1].Months[2].Change, "nothing was spent the month before")
+	assert.Equal(t, "Uncategorized", trends[2].Name)
+	assert.Nil(t, trends[2].CategoryID)
+}
+
+func TestAnomalies(t *testing.T) {
+	groceries, travel, gifts, rent := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	var spend []CategorySpend
+	add := func(id uuid.UUID, name string, amounts ...float64) {
+		for i, amount := range amounts {
+			if amount > 0 {
+				spend = append(spend, CategorySpend{Month: date(2024, 1, 1).AddDate(0, i, 0), CategoryID: &id, CategoryName: name, Amount: amount})
+			}
+		}
+	}
+	// Six months of history, then July
+	add(groceries, "Groceries", 400, 420, 390, 410, 405, 415, 900)
+	add(travel, "Travel", 0, 0, 0, 0, 0, 300, 2500)                 // too little history
+	add(gifts, "Gifts", 50, 0, 300, 0, 40, 60, 250)                 // within the usual spread
+	add(rent, "Rent", 1500, 1500, 1500, 1500, 1500, 1500, 1500.004) // rounds to the same
+
+	anomalies := Anomalies(spend, date(2024, 7, 20), DefaultAnomalyHistory)
+	require.Len(t, anomalies, 1)
+	a := anomalies[0]
+	assert.Equal(t, "Groceries", a.Name)
+	assert.Equal(t, date(2024, 7, 1), a.Month)
+	assert.Equal(t, 900.0, a.Amount)
+	assert.Equal(t, 406.67, a.Average)
+	assert.Greater(t, a.Amount, a.Threshold)
+}
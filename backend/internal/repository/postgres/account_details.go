@@ -0,0 +1,277 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+// querier is satisfied by both *pgxpool.Pool and pgx.Tx.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// saveAccountDetails upserts whichever type-specific details are set.
+func saveAccountDetails(ctx context.Context, db querier, accountID uuid.UUID, details models.AccountDetails) error {
+	if d := details.PropertyDetails; d != nil {
+		query := `
+			INSERT INTO properties (account_id, address, sqft, year_built)
+			VALUES ($1, NULLIF($2, ''), NULLIF($3, 0), NULLIF($4, 0))
+			ON CONFLICT (account_id) DO UPDATE SET
+				address = EXCLUDED.address,
+				sqft = EXCLUDED.sqft,
+				year_built = EXCLUDED.year_built,
+				updated_at = NOW()
+		`
+		if _, err := db.Exec(ctx, query, accountID, d.Address, d.Sqft, d.YearBuilt); err != nil {
+			return err
+		}
+	}
+	if d := details.VehicleDetails; d != nil {
+		query := `
+			INSERT INTO vehicles (account_id, make, model, year, mileage)
+			VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), NULLIF($4, 0), NULLIF($5, 0))
+			ON CONFLICT (account_id) DO UPDATE SET
+				make = EXCLUDED.make,
+				model = EXCLUDED.model,
+				year = EXCLUDED.year,
+				mileage = EXCLUDED.mileage,
+				updated_at = NOW()
+		`
+		if _, err := db.Exec(ctx, query, accountID, d.Make, d.Model, d.Year, d.Mileage); err != nil {
+			return err
+		}
+	}
+	if d := details.InvestmentDetails; d != nil {
+		query := `
+			INSERT INTO investments (account_id, tax_treatment)
+			VALUES ($1, NULLIF($2, ''))
+			ON CONFLICT (account_id) DO UPDATE SET
+				tax_treatment = EXCLUDED.tax_treatment,
+				updated_at = NOW()
+		`
+		if _, err := db.Exec(ctx, query, accountID, d.TaxTreatment); err != nil {
+			return err
+		}
+	}
+	if d := details.CryptoDetails; d != nil {
+		query := `
+			INSERT INTO crypto (account_id, network, wallet_address)
+			VALUES ($1, NULLIF($2, ''), NULLIF($3, ''))
+			ON CONFLICT (account_id) DO UPDATE SET
+				network = EXCLUDED.network,
+				wallet_address = EXCLUDED.wallet_address,
+				updated_at = NOW()
+		`
+		if _, err := db.Exec(ctx, query, accountID, d.Network, d.WalletAddress); err != nil {
+			return err
+		}
+	}
+	if d := details.LoanDetails; d != nil {
+		if err := upsertLoanDetails(ctx, db, accountID, d); err != nil {
+			return err
+		}
+	}
+	if d := details.CreditCardDetails; d != nil {
+		if err := upsertCreditCardDetails(ctx, db, accountID, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeAccountDetails returns current with the details set in update replacing their
+// counterparts.
+func mergeAccountDetails(current, update models.AccountDetails) models.AccountDetails {
+	if update.PropertyDetails != nil {
+		current.PropertyDetails = update.PropertyDetails
+	}
+	if update.VehicleDetails != nil {
+		current.VehicleDetails = update.VehicleDetails
+	}
+	if update.InvestmentDetails != nil {
+		current.InvestmentDetails = update.InvestmentDetails
+	}
+	if update.CryptoDetails != nil {
+		current.CryptoDetails = update.CryptoDetails
+	}
+	if update.LoanDetails != nil {
+		current.LoanDetails = update.LoanDetails
+	}
+	if update.CreditCardDetails != nil {
+		current.CreditCardDetails = update.CreditCardDetails
+	}
+	return current
+}
+
+// upsertLoanDetails saves a loan's details. The term and origination rarely change and
+// Plaid often omits them, so a missing value keeps the one already stored.
+func upsertLoanDetails(ctx context.Context, db querier, accountID uuid.UUID, details *models.LoanDetails) error {
+	query := `
+		INSERT INTO loans (account_id, interest_rate, term_months, minimum_payment, next_payment_due_date, original_principal, start_date)
+		VALUES ($1, $2, NULLIF($3, 0), $4, $5, NULLIF($6, 0), $7)
+		ON CONFLICT (account_id) DO UPDATE SET
+			interest_rate = EXCLUDED.interest_rate,
+			term_months = COALESCE(EXCLUDED.term_months, loans.term_months),
+			minimum_payment = EXCLUDED.minimum_payment,
+			next_payment_due_date = EXCLUDED.next_payment_due_date,
+			original_principal = COALESCE(EXCLUDED.original_principal, loans.original_principal),
+			start_date = COALESCE(EXCLUDED.start_date, loans.start_date),
+			updated_at = NOW()
+	`
+	_, err := db.Exec(ctx, query,
+		accountID, details.InterestRate, details.TermMonths, details.MinimumPayment, details.NextPaymentDueDate,
+		details.OriginalPrincipal, details.StartDate,
+	)
+	return err
+}
+
+// upsertCreditCardDetails saves a card's details. Like a loan's term, a missing limit or
+// statement cycle keeps the one already stored.
+func upsertCreditCardDetails(ctx context.Context, db querier, accountID uuid.UUID, details *models.CreditCardDetails) error {
+	query := `
+		INSERT INTO credit_cards (
+			account_id, apr, minimum_payment, next_payment_due_date, last_statement_balance,
+			credit_limit, statement_close_day, payment_due_day
+		)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), NULLIF($7, 0), NULLIF($8, 0))
+		ON CONFLICT (account_id) DO UPDATE SET
+			apr = EXCLUDED.apr,
+			minimum_payment = EXCLUDED.minimum_payment,
+			next_payment_due_date = EXCLUDED.next_payment_due_date,
+			last_statement_balance = EXCLUDED.last_statement_balance,
+			credit_limit = COALESCE(EXCLUDED.credit_limit, credit_cards.credit_limit),
+			statement_close_day = COALESCE(EXCLUDED.statement_close_day, credit_cards.statement_close_day),
+			payment_due_day = COALESCE(EXCLUDED.payment_due_day, credit_cards.payment_due_day),
+			updated_at = NOW()
+	`
+	_, err := db.Exec(ctx, query,
+		accountID, details.APR, details.MinimumPayment, details.NextPaymentDueDate, details.LastStatementBalance,
+		details.CreditLimit, details.StatementCloseDay, details.PaymentDueDay,
+	)
+	return err
+}
+
+// loadAccountDetails fills in the type-specific details of accounts, one query per
+// detail table that any of them needs.
+func loadAccountDetails(ctx context.Context, db querier, accounts []models.Account) error {
+	byID := make(map[uuid.UUID]*models.Account, len(accounts))
+	idsByType := make(map[string][]uuid.UUID)
+	for i := range accounts {
+		acc := &accounts[i]
+		byID[acc.ID] = acc
+		idsByType[acc.Type] = append(idsByType[acc.Type], acc.ID)
+	}
+
+	loaders := []struct {
+		accountType string
+		query       string
+		scan        func(rows pgx.Rows) (uuid.UUID, func(*models.Account), error)
+	}{
+		{
+			models.AccountTypeProperty,
+			`SELECT account_id, COALESCE(address, ''), COALESCE(sqft, 0), COALESCE(year_built, 0) FROM properties WHERE account_id = ANY($1)`,
+			func(rows pgx.Rows) (uuid.UUID, func(*models.Account), error) {
+				var id uuid.UUID
+				var d models.PropertyDetails
+				err := rows.Scan(&id, &d.Address, &d.Sqft, &d.YearBuilt)
+				return id, func(a *models.Account) { a.PropertyDetails = &d }, err
+			},
+		},
+		{
+			models.AccountTypeVehicle,
+			`SELECT account_id, COALESCE(make, ''), COALESCE(model, ''), COALESCE(year, 0), COALESCE(mileage, 0) FROM vehicles WHERE account_id = ANY($1)`,
+			func(rows pgx.Rows) (uuid.UUID, func(*models.Account), error) {
+				var id uuid.UUID
+				var d models.VehicleDetails
+				err := rows.Scan(&id, &d.Make, &d.Model, &d.Year, &d.Mileage)
+				return id, func(a *models.Account) { a.VehicleDetails = &d }, err
+			},
+		},
+		{
+			models.AccountTypeInvestment,
+			`SELECT account_id, COALESCE(tax_treatment, '') FROM investments WHERE account_id = ANY($1)`,
+			func(rows pgx.Rows) (uuid.UUID, func(*models.Account), error) {
+				var id uuid.UUID
+				var d models.InvestmentDetails
+				err := rows.Scan(&id, &d.TaxTreatment)
+				return id, func(a *models.Account) { a.InvestmentDetails = &d }, err
+			},
+		},
+		{
+			models.AccountTypeCrypto,
+			`SELECT account_id, COALESCE(network, ''), COALESCE(wallet_address, '') FROM crypto WHERE account_id = ANY($1)`,
+			func(rows pgx.Rows) (uuid.UUID, func(*models.Account), error) {
+				var id uuid.UUID
+				var d models.CryptoDetails
+				err := rows.Scan(&id, &d.Network, &d.WalletAddress)
+				return id, func(a *models.Account) { a.CryptoDetails = &d }, err
+			},
+		},
+		{
+			models.AccountTypeLoan,
+			`
+				SELECT account_id, COALESCE(interest_rate, 0), COALESCE(term_months, 0), COALESCE(minimum_payment, 0), next_payment_due_date,
+					COALESCE(original_principal, 0), start_date
+				FROM loans WHERE account_id = ANY($1)
+			`,
+			func(rows pgx.Rows) (uuid.UUID, func(*models.Account), error) {
+				var id uuid.UUID
+				var d models.LoanDetails
+				err := rows.Scan(&id, &d.InterestRate, &d.TermMonths, &d.MinimumPayment, &d.NextPaymentDueDate, &d.OriginalPrincipal, &d.StartDate)
+				return id, func(a *models.Account) { a.LoanDetails = &d }, err
+			},
+		},
+		{
+			models.AccountTypeCreditCard,
+			`
+				SELECT account_id, COALESCE(apr, 0), COALESCE(minimum_payment, 0), next_payment_due_date, COALESCE(last_statement_balance, 0),
+					COALESCE(credit_limit, 0), COALESCE(statement_close_day, 0), COALESCE(payment_due_day, 0)
+				FROM credit_cards WHERE account_id = ANY($1)
+			`,
+			func(rows pgx.Rows) (uuid.UUID, func(*models.Account), error) {
+				var id uuid.UUID
+				var d models.CreditCardDetails
+				err := rows.Scan(
+					&id, &d.APR, &d.MinimumPayment, &d.NextPaymentDueDate, &d.LastStatementBalance,
+					&d.CreditLimit, &d.StatementCloseDay, &d.PaymentDueDay,
+				)
+				return id, func(a *models.Account) { a.CreditCardDetails = &d }, err
+			},
+		},
+	}
+
+	for _, loader := range loaders {
+		ids := idsByType[loader.accountType]
+		if len(ids) == 0 {
+			continue
+		}
+		if err := loadDetails(ctx, db, loader.query, ids, byID, loader.scan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadDetails(ctx context.Context, db querier, query string, ids []uuid.UUID, byID map[uuid.UUID]*models.Account, scan func(pgx.Rows) (uuid.UUID, func(*models.Account), error)) error {
+	rows, err := db.Query(ctx, query, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		id, apply, err := scan(rows)
+		if err != nil {
+			return err
+		}
+		if acc, ok := byID[id]; ok {
+			apply(acc)
+		}
+	}
+	return rows.Err()
+}
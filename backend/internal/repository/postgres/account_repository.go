@@ -1,10 +1,14 @@
 package postgres
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rakibulbh/ai-finance-manager/internal/models"
 )
@@ -27,17 +31,21 @@ func (r *AccountRepository) Create(ctx context.Context, acc *models.Account) err
 
 	// 1. Insert Account
 	queryAcc := `
-		INSERT INTO accounts (family_id, name, balance, currency, subtype, classification)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id
+		INSERT INTO accounts (family_id, name, type, balance, currency, subtype, classification)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, status
 	`
 	err = tx.QueryRow(ctx, queryAcc,
-		acc.FamilyID, acc.Name, acc.Balance, acc.Currency, acc.Subtype, acc.Classification,
-	).Scan(&acc.ID)
+		acc.FamilyID, acc.Name, acc.Type, acc.Balance, acc.Currency, acc.Subtype, acc.Classification,
+	).Scan(&acc.ID, &acc.Status)
 	if err != nil {
 		return err
 	}
 
+	if err := saveAccountDetails(ctx, tx, acc.ID, acc.Details()); err != nil {
+		return err
+	}
+
 	// 2. If initial balance > 0, create a Valuation Entry
 	if acc.Balance != 0 {
 		// Valuation entry id
@@ -60,13 +68,21 @@ func (r *AccountRepository) Create(ctx context.Context, acc *models.Account) err
 		}
 	}
 
+	err = recordAudit(ctx, tx, auditChange{
+		FamilyID: acc.FamilyID, Action: models.AuditAccountCreate,
+		EntityType: "account", EntityID: acc.ID, After: acc,
+	})
+	if err != nil {
+		return err
+	}
+
 	return tx.Commit(ctx)
 }
 
 
 func (r *AccountRepository) ListByFamilyID(ctx context.Context, familyID uuid.UUID) ([]models.Account, error) {
 	query := `
-		SELECT id, family_id, name, balance, currency, subtype, classification
+		SELECT id, family_id, name, type, balance, currency, subtype, classification, status
 		FROM accounts
 		WHERE family_id = $1 AND status = 'active'
 	`
@@ -80,14 +96,20 @@ func (r *AccountRepository) ListByFamilyID(ctx context.Context, familyID uuid.UU
 	for rows.Next() {
 		var acc models.Account
 		err := rows.Scan(
-			&acc.ID, &acc.FamilyID, &acc.Name, &acc.Balance, &acc.Currency, &acc.Subtype, &acc.Classification,
+			&acc.ID, &acc.FamilyID, &acc.Name, &acc.Type, &acc.Balance, &acc.Currency, &acc.Subtype, &acc.Classification, &acc.Status,
 		)
 		if err != nil {
 			return nil, err
 		}
 		accounts = append(accounts, acc)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
+	if err := loadAccountDetails(ctx, r.db, accounts); err != nil {
+		return nil, err
+	}
 	return accounts, nil
 }
 
@@ -104,18 +126,348 @@ func (r *AccountRepository) GetNetWorth(ctx context.Context, familyID uuid.UUID)
 	return netWorth, err
 }
 
+// GetByID returns pgx.ErrNoRows if the family has no such account, whatever its status.
+func (r *AccountRepository) GetByID(ctx context.Context, familyID, accountID uuid.UUID) (*models.Account, error) {
+	query := `
+		SELECT id, family_id, name, type, balance, currency, COALESCE(subtype, ''), classification, status
+		FROM accounts
+		WHERE id = $1 AND family_id = $2
+	`
+	var acc models.Account
+	err := r.db.QueryRow(ctx, query, accountID, familyID).Scan(
+		&acc.ID, &acc.FamilyID, &acc.Name, &acc.Type, &acc.Balance, &acc.Currency, &acc.Subtype, &acc.Classification, &acc.Status,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := []models.Account{acc}
+	if err := loadAccountDetails(ctx, r.db, accounts); err != nil {
+		return nil, err
+	}
+	return &accounts[0], nil
+}
+
+// Update applies update to the family's account. Closing it fails with
+// models.ErrAccountBalanceNotZero unless its balance is zero, and details for another
+// account type with models.ErrAccountDetailsMismatch.
+func (r *AccountRepository) Update(ctx context.Context, familyID, accountID uuid.UUID, update models.AccountUpdate) (*models.Account, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Locked, so a transaction posted meanwhile can't slip past the balance check
+	before, err := lockAccount(ctx, tx, familyID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	acc := *before
+	if update.Name != nil {
+		acc.Name = *update.Name
+	}
+	if update.Subtype != nil {
+		acc.Subtype = *update.Subtype
+	}
+	if update.Status != nil {
+		acc.Status = *update.Status
+	}
+	if acc.Status == models.AccountStatusClosed && before.Status != models.AccountStatusClosed && acc.Balance != 0 {
+		return nil, models.ErrAccountBalanceNotZero
+	}
+	if !update.MatchType(acc.Type) {
+		return nil, models.ErrAccountDetailsMismatch
+	}
+
+	queryUpdate := `UPDATE accounts SET name = $1, subtype = $2, status = $3 WHERE id = $4`
+	if _, err := tx.Exec(ctx, queryUpdate, acc.Name, acc.Subtype, acc.Status, acc.ID); err != nil {
+		return nil, err
+	}
+
+	if err := saveAccountDetails(ctx, tx, acc.ID, update.AccountDetails); err != nil {
+		return nil, err
+	}
+	acc.SetDetails(mergeAccountDetails(before.Details(), update.AccountDetails))
+
+	err = recordAudit(ctx, tx, auditChange{
+		FamilyID: familyID, Action: models.AuditAccountUpdate,
+		EntityType: "account", EntityID: acc.ID, Before: before, After: acc,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &acc, tx.Commit(ctx)
+}
+
+// Delete removes the family's account with all its entries. Returns pgx.ErrNoRows if the
+// family has no such account.
+func (r *AccountRepository) Delete(ctx context.Context, familyID, accountID uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	acc, err := lockAccount(ctx, tx, familyID, accountID)
+	if err != nil {
+		return err
+	}
+
+	if err := deleteAccounts(ctx, tx, []uuid.UUID{acc.ID}); err != nil {
+		return err
+	}
+
+	err = recordAudit(ctx, tx, auditChange{
+		FamilyID: familyID, Action: models.AuditAccountDelete,
+		EntityType: "account", EntityID: acc.ID, Before: acc,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func lockAccount(ctx context.Context, tx pgx.Tx, familyID, accountID uuid.UUID) (*models.Account, error) {
+	query := `
+		SELECT id, family_id, name, type, balance, currency, COALESCE(subtype, ''), classification, status
+		FROM accounts
+		WHERE id = $1 AND family_id = $2
+		FOR UPDATE
+	`
+	var acc models.Account
+	err := tx.QueryRow(ctx, query, accountID, familyID).Scan(
+		&acc.ID, &acc.FamilyID, &acc.Name, &acc.Type, &acc.Balance, &acc.Currency, &acc.Subtype, &acc.Classification, &acc.Status,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := []models.Account{acc}
+	if err := loadAccountDetails(ctx, tx, accounts); err != nil {
+		return nil, err
+	}
+	return &accounts[0], nil
+}
+
+// ListEntries returns the account's entries dated on or after from, oldest first.
+func (r *AccountRepository) ListEntries(ctx context.Context, accountID uuid.UUID, from time.Time) ([]models.Entry, error) {
+	query := `
+		SELECT id, account_id, amount, date, currency, name, entryable_type, entryable_id, COALESCE(plaid_id, '')
+		FROM entries
+		WHERE account_id = $1 AND date >= $2
+		ORDER BY date, id
+	`
+	rows, err := r.db.Query(ctx, query, accountID, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.Entry
+	for rows.Next() {
+		var e models.Entry
+		err := rows.Scan(&e.ID, &e.AccountID, &e.Amount, &e.Date, &e.Currency, &e.Name, &e.EntryableType, &e.EntryableID, &e.PlaidID)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
 func (r *AccountRepository) GetByPlaidID(ctx context.Context, familyID uuid.UUID, plaidAccountID string) (*models.Account, error) {
 	query := `
-		SELECT id, family_id, name, balance, currency, subtype, classification
+		SELECT id, family_id, name, type, balance, currency, subtype, classification
 		FROM accounts
 		WHERE family_id = $1 AND plaid_account_id = $2
 	`
 	var acc models.Account
 	err := r.db.QueryRow(ctx, query, familyID, plaidAccountID).Scan(
-		&acc.ID, &acc.FamilyID, &acc.Name, &acc.Balance, &acc.Currency, &acc.Subtype, &acc.Classification,
+		&acc.ID, &acc.FamilyID, &acc.Name, &acc.Type, &acc.Balance, &acc.Currency, &acc.Subtype, &acc.Classification,
 	)
 	if err != nil {
 		return nil, err
 	}
 	return &acc, nil
 }
+
+// UpsertPlaidAccount creates or refreshes an account imported from a Plaid item. Plaid's
+// reported balance replaces ours, since the institution is the source of truth for it.
+// Like other mutations it is audited, with no actor when a sync makes it; a refresh
+// that changes nothing records nothing.
+func (r *AccountRepository) UpsertPlaidAccount(ctx context.Context, itemID uuid.UUID, plaidAccountID string, acc *models.Account) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var before *models.Account
+	var existingID, familyID uuid.UUID
+	queryExisting := `SELECT id, family_id FROM accounts WHERE plaid_account_id = $1`
+	err = tx.QueryRow(ctx, queryExisting, plaidAccountID).Scan(&existingID, &familyID)
+	switch {
+	case err == nil:
+		if before, err = lockAccount(ctx, tx, familyID, existingID); err != nil {
+			return err
+		}
+	case errors.Is(err, pgx.ErrNoRows):
+		familyID = acc.FamilyID
+	default:
+		return err
+	}
+
+	query := `
+		INSERT INTO accounts (family_id, name, type, balance, currency, subtype, classification, plaid_account_id, plaid_item_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (plaid_account_id) WHERE plaid_account_id IS NOT NULL DO UPDATE SET
+			name = EXCLUDED.name,
+			balance = EXCLUDED.balance,
+			plaid_item_id = EXCLUDED.plaid_item_id
+		RETURNING id
+	`
+	err = tx.QueryRow(ctx, query,
+		acc.FamilyID, acc.Name, acc.Type, acc.Balance, acc.Currency, acc.Subtype, acc.Classification, plaidAccountID, itemID,
+	).Scan(&acc.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := auditSyncedAccount(ctx, tx, familyID, acc.ID, before); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// UpsertLoanDetails saves the loan terms Plaid reports for an account, audited like
+// UpsertPlaidAccount.
+func (r *AccountRepository) UpsertLoanDetails(ctx context.Context, accountID uuid.UUID, details *models.LoanDetails) error {
+	return r.syncDetails(ctx, accountID, func(tx pgx.Tx) error {
+		return upsertLoanDetails(ctx, tx, accountID, details)
+	})
+}
+
+// UpsertCreditCardDetails saves the card terms Plaid reports for an account, audited
+// like UpsertPlaidAccount.
+func (r *AccountRepository) UpsertCreditCardDetails(ctx context.Context, accountID uuid.UUID, details *models.CreditCardDetails) error {
+	return r.syncDetails(ctx, accountID, func(tx pgx.Tx) error {
+		return upsertCreditCardDetails(ctx, tx, accountID, details)
+	})
+}
+
+// syncDetails locks the account, applies save to it and audits the difference.
+func (r *AccountRepository) syncDetails(ctx context.Context, accountID uuid.UUID, save func(tx pgx.Tx) error) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var familyID uuid.UUID
+	if err := tx.QueryRow(ctx, `SELECT family_id FROM accounts WHERE id = $1`, accountID).Scan(&familyID); err != nil {
+		return err
+	}
+	before, err := lockAccount(ctx, tx, familyID, accountID)
+	if err != nil {
+		return err
+	}
+	if err := save(tx); err != nil {
+		return err
+	}
+
+	if err := auditSyncedAccount(ctx, tx, familyID, accountID, before); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// auditSyncedAccount records a synced account's creation, if before is nil, or its
+// update, unless nothing changed.
+func auditSyncedAccount(ctx context.Context, tx pgx.Tx, familyID, accountID uuid.UUID, before *models.Account) error {
+	after, err := lockAccount(ctx, tx, familyID, accountID)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		return recordAudit(ctx, tx, auditChange{
+			FamilyID: familyID, Action: models.AuditAccountCreate,
+			EntityType: "account", EntityID: accountID, After: after,
+		})
+	}
+
+	changed, err := accountChanged(before, after)
+	if err != nil || !changed {
+		return err
+	}
+	return recordAudit(ctx, tx, auditChange{
+		FamilyID: familyID, Action: models.AuditAccountUpdate,
+		EntityType: "account", EntityID: accountID, Before: before, After: after,
+	})
+}
+
+// accountChanged compares accounts as they would appear in an audit event.
+func accountChanged(before, after *models.Account) (bool, error) {
+	b, err := auditJSON(before)
+	if err != nil {
+		return false, err
+	}
+	a, err := auditJSON(after)
+	if err != nil {
+		return false, err
+	}
+	return !bytes.Equal(b, a), nil
+}
+
+// deleteAccounts removes accounts inside tx. Their entries cascade, so afterwards we
+// clean up transaction and trade rows that no entry points at anymore. A transfer keeps
+// its shared transaction row while the other side still exists.
+func deleteAccounts(ctx context.Context, tx pgx.Tx, accountIDs []uuid.UUID) error {
+	if len(accountIDs) == 0 {
+		return nil
+	}
+
+	entryableIDs, err := collectIDs(ctx, tx,
+		`SELECT entryable_id FROM entries WHERE account_id = ANY($1)`, accountIDs)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM accounts WHERE id = ANY($1)`, accountIDs); err != nil {
+		return err
+	}
+
+	orphaned := `
+		DELETE FROM %s t
+		WHERE t.id = ANY($1)
+		AND NOT EXISTS (SELECT 1 FROM entries e WHERE e.entryable_id = t.id)
+	`
+	for _, table := range []string{"transactions", "trades"} {
+		if _, err := tx.Exec(ctx, fmt.Sprintf(orphaned, table), entryableIDs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func collectIDs(ctx context.Context, tx pgx.Tx, query string, args ...interface{}) ([]uuid.UUID, error) {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -507,3 +507,27 @@ func BenchmarkAccountRepository_GetNetWorth(b *testing.B) {
 	familyID := uuid.New()
 	_ = familyID
 }
+
+// A Plaid refresh that changes nothing shouldn't fill the audit log
+func TestAccountChanged(t *testing.T) {
+	before := &models.Account{ID: uuid.New(), Name: "Visa", Balance: 420.10, Classification: "liability"}
+	before.CreditCardDetails = &models.CreditCardDetails{APR: 24.99, MinimumPayment: 35}
+
+	same := *before
+	card := *before.CreditCardDetails
+	same.CreditCardDetails = &card
+	changed, err := accountChanged(before, &same)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	card.APR = 27.99
+	changed, err = accountChanged(before, &same)
+	assert.NoError(t, err)
+	assert.True(t, changed, "new card terms")
+
+	same.CreditCardDetails = before.CreditCardDetails
+	same.Balance = 515.40
+	changed, err = accountChanged(before, &same)
+	assert.NoError(t, err)
+	assert.True(t, changed, "new balance")
+}
@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+type APIKeyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAPIKeyRepository(db *pgxpool.Pool) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+func (r *APIKeyRepository) CreateAPIKey(ctx context.Context, key *models.APIKey, keyHash string) error {
+	query := `
+		INSERT INTO api_keys (user_id, name, key_prefix, key_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRow(ctx, query,
+		key.UserID, key.Name, key.Prefix, keyHash, key.Scopes, key.ExpiresAt,
+	).Scan(&key.ID, &key.CreatedAt)
+}
+
+// ListAPIKeys returns the user's keys that haven't been revoked, expired ones included
+// so they can be cleaned up.
+func (r *APIKeyRepository) ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]models.APIKey, error) {
+	query := `
+		SELECT id, user_id, name, key_prefix, scopes, created_at, expires_at, last_used_at, revoked_at
+		FROM api_keys
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC
+	`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		var k models.APIKey
+		err := rows.Scan(
+			&k.ID, &k.UserID, &k.Name, &k.Prefix, &k.Scopes,
+			&k.CreatedAt, &k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey returns pgx.ErrNoRows if the user has no such active key.
+func (r *APIKeyRepository) RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID) error {
+	query := `UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+	tag, err := r.db.Exec(ctx, query, keyID, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// AuthenticateAPIKey looks up a live key by hash for the auth middleware and records
+// that it was used. The owner's current family and role come along, so role changes
+// apply to their keys straight away.
+func (r *APIKeyRepository) AuthenticateAPIKey(ctx context.Context, keyHash string) (*models.APIKeyPrincipal, error) {
+	query := `
+		UPDATE api_keys k SET last_used_at = NOW()
+		FROM users u
+		WHERE k.key_hash = $1
+			AND k.revoked_at IS NULL
+			AND (k.expires_at IS NULL OR k.expires_at > NOW())
+			AND u.id = k.user_id
+		RETURNING k.id, k.user_id, u.family_id, u.role, k.scopes
+	`
+	var p models.APIKeyPrincipal
+	err := r.db.QueryRow(ctx, query, keyHash).Scan(&p.KeyID, &p.UserID, &p.FamilyID, &p.Role, &p.Scopes)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, models.ErrAPIKeyInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
@@ -0,0 +1,169 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rakibulbh/ai-finance-manager/internal/audit"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+// auditChange is one mutation to record. Before and After are marshalled to JSON; nil
+// leaves them empty, as for creates and deletes.
+type auditChange struct {
+	FamilyID   uuid.UUID
+	Action     string
+	EntityType string
+	EntityID   uuid.UUID
+	Before     interface{}
+	After      interface{}
+}
+
+// recordAudit writes change inside tx, so the event exists if and only if the change
+// does. The actor comes from ctx; changes made outside a request have none.
+func recordAudit(ctx context.Context, tx pgx.Tx, change auditChange) error {
+	before, err := auditJSON(change.Before)
+	if err != nil {
+		return err
+	}
+	after, err := auditJSON(change.After)
+	if err != nil {
+		return err
+	}
+
+	var entityID *uuid.UUID
+	if change.EntityID != uuid.Nil {
+		entityID = &change.EntityID
+	}
+
+	actor := audit.ActorFrom(ctx)
+	query := `
+		INSERT INTO audit_events (family_id, actor_user_id, action, entity_type, entity_id, before, after, request_id, ip_address)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''))
+	`
+	_, err = tx.Exec(ctx, query,
+		change.FamilyID, actor.UserID, change.Action, change.EntityType, entityID, before, after, actor.RequestID, actor.IPAddress,
+	)
+	return err
+}
+
+func auditJSON(v interface{}) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("audit: %w", err)
+	}
+	return b, nil
+}
+
+// auditEntry is how entries appear in audit events.
+type auditEntry struct {
+	ID        uuid.UUID `json:"id"`
+	AccountID uuid.UUID `json:"accountId"`
+	Amount    float64   `json:"amount"`
+	Date      string    `json:"date"`
+	Currency  string    `json:"currency"`
+	Name      string    `json:"name"`
+	PlaidID   string    `json:"plaidId,omitempty"`
+}
+
+func newAuditEntry(e *models.Entry) auditEntry {
+	return auditEntry{
+		ID:        e.ID,
+		AccountID: e.AccountID,
+		Amount:    e.Amount,
+		Date:      e.Date.Format("2006-01-02"),
+		Currency:  e.Currency,
+		Name:      e.Name,
+		PlaidID:   e.PlaidID,
+	}
+}
+
+// updateBalance applies an entry amount to an account's balance inside tx and returns the
+// family it belongs to, for the audit event. Entry amounts are positive when money leaves
+// the account, which lowers an asset's balance and raises what is owed on a liability.
+func updateBalance(ctx context.Context, tx pgx.Tx, accountID uuid.UUID, amount float64) (uuid.UUID, error) {
+	var familyID uuid.UUID
+	query := `
+		UPDATE accounts
+		SET balance = balance + CASE WHEN classification = 'liability' THEN $1 ELSE -$1 END
+		WHERE id = $2
+		RETURNING family_id
+	`
+	err := tx.QueryRow(ctx, query, amount, accountID).Scan(&familyID)
+	return familyID, err
+}
+
+type AuditRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAuditRepository(db *pgxpool.Pool) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// ListEvents returns the family's audit events matching filter, newest first.
+func (r *AuditRepository) ListEvents(ctx context.Context, familyID uuid.UUID, filter models.AuditFilter) ([]models.AuditEvent, error) {
+	conditions := []string{"family_id = $1"}
+	args := []interface{}{familyID}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.ActorUserID != nil {
+		add("actor_user_id = $%d", *filter.ActorUserID)
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if filter.EntityType != "" {
+		add("entity_type = $%d", filter.EntityType)
+	}
+	if filter.EntityID != nil {
+		add("entity_id = $%d", *filter.EntityID)
+	}
+	if filter.From != nil {
+		add("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("created_at < $%d", *filter.To)
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`
+		SELECT id, family_id, actor_user_id, action, entity_type, entity_id, before, after,
+			COALESCE(request_id, ''), COALESCE(ip_address, ''), created_at
+		FROM audit_events
+		WHERE %s
+		ORDER BY created_at DESC, id
+		LIMIT $%d OFFSET $%d
+	`, strings.Join(conditions, " AND "), len(args)-1, len(args))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.AuditEvent
+	for rows.Next() {
+		var e models.AuditEvent
+		err := rows.Scan(
+			&e.ID, &e.FamilyID, &e.ActorUserID, &e.Action, &e.EntityType, &e.EntityID,
+			&e.Before, &e.After, &e.RequestID, &e.IPAddress, &e.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
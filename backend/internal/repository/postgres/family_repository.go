@@ -0,0 +1,188 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+type FamilyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewFamilyRepository(db *pgxpool.Pool) *FamilyRepository {
+	return &FamilyRepository{db: db}
+}
+
+func (r *FamilyRepository) CreateInvite(ctx context.Context, invite *models.FamilyInvite, tokenHash string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO family_invites (family_id, email, role, token_hash, invited_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	err = tx.QueryRow(ctx, query,
+		invite.FamilyID, invite.Email, invite.Role, tokenHash, invite.InvitedBy, invite.ExpiresAt,
+	).Scan(&invite.ID, &invite.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	err = recordAudit(ctx, tx, auditChange{
+		FamilyID: invite.FamilyID, Action: models.AuditInviteCreate,
+		EntityType: "family_invite", EntityID: invite.ID, After: invite,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ListPendingInvites returns the family's invites that can still be accepted.
+func (r *FamilyRepository) ListPendingInvites(ctx context.Context, familyID uuid.UUID) ([]models.FamilyInvite, error) {
+	query := `
+		SELECT id, family_id, email, role, invited_by, created_at, expires_at, accepted_at, revoked_at
+		FROM family_invites
+		WHERE family_id = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY created_at DESC
+	`
+	rows, err := r.db.Query(ctx, query, familyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invites []models.FamilyInvite
+	for rows.Next() {
+		var i models.FamilyInvite
+		err := rows.Scan(
+			&i.ID, &i.FamilyID, &i.Email, &i.Role, &i.InvitedBy,
+			&i.CreatedAt, &i.ExpiresAt, &i.AcceptedAt, &i.RevokedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, i)
+	}
+	return invites, rows.Err()
+}
+
+// RevokeInvite cancels a pending invite. Returns pgx.ErrNoRows if the family has no such
+// pending invite.
+func (r *FamilyRepository) RevokeInvite(ctx context.Context, familyID, inviteID uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var i models.FamilyInvite
+	query := `
+		UPDATE family_invites SET revoked_at = NOW()
+		WHERE id = $1 AND family_id = $2 AND accepted_at IS NULL AND revoked_at IS NULL
+		RETURNING id, family_id, email, role, invited_by, created_at, expires_at, accepted_at, revoked_at
+	`
+	err = tx.QueryRow(ctx, query, inviteID, familyID).Scan(
+		&i.ID, &i.FamilyID, &i.Email, &i.Role, &i.InvitedBy,
+		&i.CreatedAt, &i.ExpiresAt, &i.AcceptedAt, &i.RevokedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	err = recordAudit(ctx, tx, auditChange{
+		FamilyID: familyID, Action: models.AuditInviteRevoke,
+		EntityType: "family_invite", EntityID: i.ID, After: i,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *FamilyRepository) ListMembers(ctx context.Context, familyID uuid.UUID) ([]models.User, error) {
+	query := `SELECT id, family_id, email, role FROM users WHERE family_id = $1 ORDER BY created_at`
+	rows, err := r.db.Query(ctx, query, familyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []models.User
+	for rows.Next() {
+		var u models.User
+		if err := rows.Scan(&u.ID, &u.FamilyID, &u.Email, &u.Role); err != nil {
+			return nil, err
+		}
+		members = append(members, u)
+	}
+	return members, rows.Err()
+}
+
+// GetMember returns pgx.ErrNoRows if the user doesn't belong to the family.
+func (r *FamilyRepository) GetMember(ctx context.Context, familyID, userID uuid.UUID) (*models.User, error) {
+	var u models.User
+	query := `SELECT id, family_id, email, role FROM users WHERE id = $1 AND family_id = $2`
+	err := r.db.QueryRow(ctx, query, userID, familyID).Scan(&u.ID, &u.FamilyID, &u.Email, &u.Role)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// UpdateMemberRole changes a member's role. Demoting the family's last admin fails with
+// models.ErrLastAdmin.
+func (r *FamilyRepository) UpdateMemberRole(ctx context.Context, familyID, userID uuid.UUID, role string) (*models.User, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the family's admins so two concurrent demotions can't both pass the check
+	if _, err := tx.Exec(ctx, `SELECT id FROM users WHERE family_id = $1 AND role = 'admin' FOR UPDATE`, familyID); err != nil {
+		return nil, err
+	}
+
+	var u models.User
+	query := `SELECT id, family_id, email, role FROM users WHERE id = $1 AND family_id = $2 FOR UPDATE`
+	if err := tx.QueryRow(ctx, query, userID, familyID).Scan(&u.ID, &u.FamilyID, &u.Email, &u.Role); err != nil {
+		return nil, err
+	}
+
+	if u.Role == models.RoleAdmin && role != models.RoleAdmin {
+		var otherAdmins bool
+		queryCheck := `SELECT EXISTS (SELECT 1 FROM users WHERE family_id = $1 AND id != $2 AND role = 'admin')`
+		if err := tx.QueryRow(ctx, queryCheck, familyID, userID).Scan(&otherAdmins); err != nil {
+			return nil, err
+		}
+		if !otherAdmins {
+			return nil, models.ErrLastAdmin
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE users SET role = $1 WHERE id = $2`, role, userID); err != nil {
+		return nil, err
+	}
+
+	err = recordAudit(ctx, tx, auditChange{
+		FamilyID: familyID, Action: models.AuditMemberRoleUpdate,
+		EntityType: "user", EntityID: userID,
+		Before: map[string]string{"role": u.Role}, After: map[string]string{"role": role},
+	})
+	if err != nil {
+		return nil, err
+	}
+	u.Role = role
+
+	return &u, tx.Commit(ctx)
+}
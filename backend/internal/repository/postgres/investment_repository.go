@@ -57,19 +57,27 @@ func (r *InvestmentRepository) CreateTrade(ctx context.Context, entry *models.En
 	entry.EntryableID = trade.ID
 
 	queryEntry := `
-		INSERT INTO entries (id, account_id, amount, date, currency, name, entryable_type, entryable_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO entries (id, account_id, amount, date, currency, name, entryable_type, entryable_id, plaid_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''))
 	`
 	_, err = tx.Exec(ctx, queryEntry,
-		entry.ID, entry.AccountID, entry.Amount, entry.Date, entry.Currency, entry.Name, entry.EntryableType, entry.EntryableID,
+		entry.ID, entry.AccountID, entry.Amount, entry.Date, entry.Currency, entry.Name, entry.EntryableType, entry.EntryableID, entry.PlaidID,
 	)
 	if err != nil {
 		return err
 	}
 
 	// 3. Update Account Balance
-	queryUpdateAcc := `UPDATE accounts SET balance = balance + $1 WHERE id = $2`
-	_, err = tx.Exec(ctx, queryUpdateAcc, entry.Amount, entry.AccountID)
+	familyID, err := updateBalance(ctx, tx, entry.AccountID, entry.Amount)
+	if err != nil {
+		return err
+	}
+
+	err = recordAudit(ctx, tx, auditChange{
+		FamilyID: familyID, Action: models.AuditTradeCreate,
+		EntityType: "entry", EntityID: entry.ID,
+		After: map[string]interface{}{"entry": newAuditEntry(entry), "trade": trade},
+	})
 	if err != nil {
 		return err
 	}
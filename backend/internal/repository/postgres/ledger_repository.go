@@ -2,9 +2,15 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rakibulbh/ai-finance-manager/internal/amortization"
 	"github.com/rakibulbh/ai-finance-manager/internal/models"
 )
 
@@ -28,7 +34,17 @@ func (r *LedgerRepository) GetOrCreateMerchant(ctx context.Context, name string,
 	return id, err
 }
 
+// CreateTransaction records a transaction entry, with its split lines if it has any.
+// Returns models.ErrSplitNotAllowed or a split validation error if the lines can't
+// divide the entry.
 func (r *LedgerRepository) CreateTransaction(ctx context.Context, entry *models.Entry, txDetail *models.Transaction) error {
+	if len(txDetail.Splits) > 0 && txDetail.Kind != "standard" {
+		return models.ErrSplitNotAllowed
+	}
+	if err := models.ValidateSplits(entry.Amount, txDetail.Splits); err != nil {
+		return err
+	}
+
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return err
@@ -47,6 +63,9 @@ func (r *LedgerRepository) CreateTransaction(ctx context.Context, entry *models.
 	if err != nil {
 		return err
 	}
+	if err := insertSplits(ctx, tx, txDetail.ID, txDetail.Splits); err != nil {
+		return err
+	}
 
 	// 2. Insert Entry
 	if entry.ID == uuid.Nil {
@@ -56,19 +75,27 @@ func (r *LedgerRepository) CreateTransaction(ctx context.Context, entry *models.
 	entry.EntryableID = txDetail.ID
 
 	queryEntry := `
-		INSERT INTO entries (id, account_id, amount, date, currency, name, entryable_type, entryable_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO entries (id, account_id, amount, date, currency, name, entryable_type, entryable_id, plaid_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''))
 	`
 	_, err = tx.Exec(ctx, queryEntry,
-		entry.ID, entry.AccountID, entry.Amount, entry.Date, entry.Currency, entry.Name, entry.EntryableType, entry.EntryableID,
+		entry.ID, entry.AccountID, entry.Amount, entry.Date, entry.Currency, entry.Name, entry.EntryableType, entry.EntryableID, entry.PlaidID,
 	)
 	if err != nil {
 		return err
 	}
 
 	// 3. Update Account Balance (Optional if we use triggers, but let's do it manually for now as per doc tip)
-	queryUpdateAcc := `UPDATE accounts SET balance = balance + $1 WHERE id = $2`
-	_, err = tx.Exec(ctx, queryUpdateAcc, entry.Amount, entry.AccountID)
+	familyID, err := updateBalance(ctx, tx, entry.AccountID, entry.Amount)
+	if err != nil {
+		return err
+	}
+
+	err = recordAudit(ctx, tx, auditChange{
+		FamilyID: familyID, Action: models.AuditTransactionCreate,
+		EntityType: "entry", EntityID: entry.ID,
+		After: map[string]interface{}{"entry": newAuditEntry(entry), "transaction": txDetail},
+	})
 	if err != nil {
 		return err
 	}
@@ -83,7 +110,13 @@ func (r *LedgerRepository) CreateTransfer(ctx context.Context, fromEntry, toEntr
 	}
 	defer tx.Rollback(ctx)
 
-	// 1. Create one Transaction row for the transfer
+	// 1. A payment into a loan only pays down the principal; the interest is an expense
+	interestEntry, err := splitLoanPayment(ctx, tx, fromEntry, toEntry)
+	if err != nil {
+		return err
+	}
+
+	// 2. Create one Transaction row for the transfer
 	txID := uuid.New()
 	queryTx := `INSERT INTO transactions (id, kind) VALUES ($1, 'transfer')`
 	_, err = tx.Exec(ctx, queryTx, txID)
@@ -91,6 +124,7 @@ func (r *LedgerRepository) CreateTransfer(ctx context.Context, fromEntry, toEntr
 		return err
 	}
 
+	var familyID uuid.UUID
 	entries := []*models.Entry{fromEntry, toEntry}
 	for _, e := range entries {
 		if e.ID == uuid.Nil {
@@ -100,23 +134,465 @@ func (r *LedgerRepository) CreateTransfer(ctx context.Context, fromEntry, toEntr
 		e.EntryableID = txID
 
 		queryEntry := `
-			INSERT INTO entries (id, account_id, amount, date, currency, name, entryable_type, entryable_id)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			INSERT INTO entries (id, account_id, amount, date, currency, name, entryable_type, entryable_id, plaid_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''))
 		`
 		_, err = tx.Exec(ctx, queryEntry,
-			e.ID, e.AccountID, e.Amount, e.Date, e.Currency, e.Name, e.EntryableType, e.EntryableID,
+			e.ID, e.AccountID, e.Amount, e.Date, e.Currency, e.Name, e.EntryableType, e.EntryableID, e.PlaidID,
 		)
 		if err != nil {
 			return err
 		}
 
 		// Update Account Balance
-		queryUpdateAcc := `UPDATE accounts SET balance = balance + $1 WHERE id = $2`
-		_, err = tx.Exec(ctx, queryUpdateAcc, e.Amount, e.AccountID)
+		familyID, err = updateBalance(ctx, tx, e.AccountID, e.Amount)
 		if err != nil {
 			return err
 		}
 	}
 
+	after := map[string]interface{}{"from": newAuditEntry(fromEntry), "to": newAuditEntry(toEntry)}
+	if interestEntry != nil {
+		after["interest"] = newAuditEntry(interestEntry)
+	}
+	err = recordAudit(ctx, tx, auditChange{
+		FamilyID: familyID, Action: models.AuditTransferCreate,
+		EntityType: "transaction", EntityID: txID,
+		After: after,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// splitLoanPayment handles a transfer into a loan account, locking the loan so concurrent
+// payments split against the right balance. The loan's balance is what is owed, so the
+// principal comes off it, and the month's interest is booked as a separate expense on the
+// paying account. It returns that expense, or nil when there is no interest or the
+// destination isn't a loan.
+func splitLoanPayment(ctx context.Context, tx pgx.Tx, fromEntry, toEntry *models.Entry) (*models.Entry, error) {
+	var accountType, name string
+	var balance, rate float64
+	query := `
+		SELECT a.type, a.name, a.balance, COALESCE(l.interest_rate, 0)
+		FROM accounts a
+		LEFT JOIN loans l ON l.account_id = a.id
+		WHERE a.id = $1
+		FOR UPDATE OF a
+	`
+	err := tx.QueryRow(ctx, query, toEntry.AccountID).Scan(&accountType, &name, &balance, &rate)
+	if err != nil {
+		return nil, err
+	}
+	// Money arriving in the loan is the negative side of the transfer
+	payment := -toEntry.Amount
+	if accountType != models.AccountTypeLoan || payment <= 0 {
+		return nil, nil
+	}
+
+	// Anything beyond the interest pays down principal, even past zero
+	_, interest := amortization.SplitPayment(math.Max(balance, 0), rate, payment)
+	principal := math.Round((payment-interest)*100) / 100
+	fromEntry.Amount = principal
+	toEntry.Amount = -principal
+	if interest <= 0 {
+		return nil, nil
+	}
+
+	txID := uuid.New()
+	_, err = tx.Exec(ctx, `INSERT INTO transactions (id, kind) VALUES ($1, 'standard')`, txID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &models.Entry{
+		ID:            uuid.New(),
+		AccountID:     fromEntry.AccountID,
+		Amount:        interest,
+		Date:          fromEntry.Date,
+		Currency:      fromEntry.Currency,
+		Name:          "Interest: " + name,
+		EntryableType: "Transaction",
+		EntryableID:   txID,
+	}
+	queryEntry := `
+		INSERT INTO entries (id, account_id, amount, date, currency, name, entryable_type, entryable_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err = tx.Exec(ctx, queryEntry,
+		entry.ID, entry.AccountID, entry.Amount, entry.Date, entry.Currency, entry.Name, entry.EntryableType, entry.EntryableID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := updateBalance(ctx, tx, entry.AccountID, entry.Amount); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// HasPlaidEntry reports whether a Plaid transaction has already been imported.
+func (r *LedgerRepository) HasPlaidEntry(ctx context.Context, plaidID string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM entries WHERE plaid_id = $1)`
+	err := r.db.QueryRow(ctx, query, plaidID).Scan(&exists)
+	return exists, err
+}
+
+// HasEntry reports whether an entry with the given ID exists.
+func (r *LedgerRepository) HasEntry(ctx context.Context, id uuid.UUID) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM entries WHERE id = $1)`
+	err := r.db.QueryRow(ctx, query, id).Scan(&exists)
+	return exists, err
+}
+
+// UpdatePlaidEntry applies a modified Plaid transaction to the entry imported for it and
+// moves the account balance by the difference.
+func (r *LedgerRepository) UpdatePlaidEntry(ctx context.Context, entry *models.Entry) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	old := models.Entry{PlaidID: entry.PlaidID}
+	query := `SELECT id, account_id, amount, date, currency, name, entryable_id FROM entries WHERE plaid_id = $1 FOR UPDATE`
+	err = tx.QueryRow(ctx, query, entry.PlaidID).Scan(&old.ID, &old.AccountID, &old.Amount, &old.Date, &old.Currency, &old.Name, &old.EntryableID)
+	if err != nil {
+		return err
+	}
+	entry.ID, entry.AccountID = old.ID, old.AccountID
+
+	// A split transaction's lines follow its amount, e.g. when a tip is added on posting
+	if entry.Amount != old.Amount {
+		if err := rescaleSplits(ctx, tx, old.EntryableID, old.Amount, entry.Amount); err != nil {
+			return err
+		}
+	}
+
+	queryUpdate := `UPDATE entries SET amount = $1, date = $2, name = $3, currency = $4 WHERE id = $5`
+	_, err = tx.Exec(ctx, queryUpdate, entry.Amount, entry.Date, entry.Name, entry.Currency, entry.ID)
+	if err != nil {
+		return err
+	}
+
+	familyID, err := updateBalance(ctx, tx, entry.AccountID, entry.Amount-old.Amount)
+	if err != nil {
+		return err
+	}
+
+	err = recordAudit(ctx, tx, auditChange{
+		FamilyID: familyID, Action: models.AuditTransactionUpdate,
+		EntityType: "entry", EntityID: entry.ID,
+		Before: newAuditEntry(&old), After: newAuditEntry(entry),
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DeletePlaidEntry removes the entry imported for a Plaid transaction, if any, along with
+// its transaction row, and reverses its effect on the account balance.
+func (r *LedgerRepository) DeletePlaidEntry(ctx context.Context, plaidID string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// The other side of a matched transfer goes back to being a transaction of its own
+	var matchID, matchFamilyID uuid.UUID
+	queryMatch := `
+		SELECT m.id, m.family_id
+		FROM transfer_matches m
+		JOIN entries e ON e.id IN (m.from_entry_id, m.to_entry_id)
+		WHERE e.plaid_id = $1 AND m.status = 'matched'
+	`
+	err = tx.QueryRow(ctx, queryMatch, plaidID).Scan(&matchID, &matchFamilyID)
+	switch {
+	case err == nil:
+		if err := unlinkTransfer(ctx, tx, matchFamilyID, matchID); err != nil {
+			return err
+		}
+	case !errors.Is(err, pgx.ErrNoRows):
+		return err
+	}
+
+	deleted := models.Entry{PlaidID: plaidID}
+	query := `DELETE FROM entries WHERE plaid_id = $1 RETURNING id, account_id, amount, date, currency, name, entryable_id`
+	err = tx.QueryRow(ctx, query, plaidID).Scan(
+		&deleted.ID, &deleted.AccountID, &deleted.Amount, &deleted.Date, &deleted.Currency, &deleted.Name, &deleted.EntryableID,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	queryTx := `
+		DELETE FROM transactions t
+		WHERE t.id = $1
+		AND NOT EXISTS (SELECT 1 FROM entries e WHERE e.entryable_id = t.id)
+	`
+	if _, err = tx.Exec(ctx, queryTx, deleted.EntryableID); err != nil {
+		return err
+	}
+
+	familyID, err := updateBalance(ctx, tx, deleted.AccountID, -deleted.Amount)
+	if err != nil {
+		return err
+	}
+
+	err = recordAudit(ctx, tx, auditChange{
+		FamilyID: familyID, Action: models.AuditTransactionDelete,
+		EntityType: "entry", EntityID: deleted.ID, Before: newAuditEntry(&deleted),
+	})
+	if err != nil {
+		return err
+	}
+
 	return tx.Commit(ctx)
 }
+
+// GetSplits returns the family's transaction entry with its split lines, none if it
+// isn't split. Returns pgx.ErrNoRows if the family has no such transaction entry.
+func (r *LedgerRepository) GetSplits(ctx context.Context, familyID, entryID uuid.UUID) (*models.Entry, []models.TransactionSplit, error) {
+	var entry models.Entry
+	query := `
+		SELECT e.id, e.account_id, e.amount, e.date, e.currency, e.name, e.entryable_type, e.entryable_id
+		FROM entries e
+		JOIN accounts a ON a.id = e.account_id
+		WHERE e.id = $1 AND a.family_id = $2 AND e.entryable_type = 'Transaction'
+	`
+	err := r.db.QueryRow(ctx, query, entryID, familyID).Scan(
+		&entry.ID, &entry.AccountID, &entry.Amount, &entry.Date, &entry.Currency, &entry.Name, &entry.EntryableType, &entry.EntryableID,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lines, err := loadSplits(ctx, r.db, entry.EntryableID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &entry, lines, nil
+}
+
+// SetSplits replaces the split lines of the family's transaction entry; no lines makes
+// it a single transaction again. Returns pgx.ErrNoRows if the family has no such
+// transaction entry, models.ErrSplitNotAllowed for transfers, or a split validation
+// error if the lines don't divide the entry's amount.
+func (r *LedgerRepository) SetSplits(ctx context.Context, familyID, entryID uuid.UUID, lines []models.TransactionSplit) ([]models.TransactionSplit, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var transactionID uuid.UUID
+	var amount float64
+	var kind string
+	query := `
+		SELECT e.entryable_id, e.amount, t.kind
+		FROM entries e
+		JOIN accounts a ON a.id = e.account_id
+		JOIN transactions t ON t.id = e.entryable_id
+		WHERE e.id = $1 AND a.family_id = $2 AND e.entryable_type = 'Transaction'
+		FOR UPDATE OF e
+	`
+	if err := tx.QueryRow(ctx, query, entryID, familyID).Scan(&transactionID, &amount, &kind); err != nil {
+		return nil, err
+	}
+	if kind != "standard" {
+		return nil, models.ErrSplitNotAllowed
+	}
+	if err := models.ValidateSplits(amount, lines); err != nil {
+		return nil, err
+	}
+
+	before, err := loadSplits(ctx, tx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM transaction_splits WHERE transaction_id = $1`, transactionID); err != nil {
+		return nil, err
+	}
+	if err := insertSplits(ctx, tx, transactionID, lines); err != nil {
+		return nil, err
+	}
+
+	err = recordAudit(ctx, tx, auditChange{
+		FamilyID: familyID, Action: models.AuditTransactionUpdate,
+		EntityType: "entry", EntityID: entryID,
+		Before: map[string]interface{}{"splits": before}, After: map[string]interface{}{"splits": lines},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return lines, tx.Commit(ctx)
+}
+
+// insertSplits writes lines in order, setting their IDs.
+func insertSplits(ctx context.Context, tx pgx.Tx, transactionID uuid.UUID, lines []models.TransactionSplit) error {
+	query := `
+		INSERT INTO transaction_splits (id, transaction_id, category_id, amount, memo, position)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	for i := range lines {
+		lines[i].ID, lines[i].TransactionID = uuid.New(), transactionID
+		_, err := tx.Exec(ctx, query, lines[i].ID, transactionID, lines[i].CategoryID, lines[i].Amount, lines[i].Memo, i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadSplits(ctx context.Context, q querier, transactionID uuid.UUID) ([]models.TransactionSplit, error) {
+	query := `
+		SELECT id, transaction_id, category_id, amount, memo
+		FROM transaction_splits
+		WHERE transaction_id = $1
+		ORDER BY position
+	`
+	rows, err := q.Query(ctx, query, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []models.TransactionSplit
+	for rows.Next() {
+		var line models.TransactionSplit
+		if err := rows.Scan(&line.ID, &line.TransactionID, &line.CategoryID, &line.Amount, &line.Memo); err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
+
+// rescaleSplits resizes a transaction's split lines, if any, for its entry's amount
+// changing from from to to. Lines can't be scaled from zero, so they are dropped then.
+func rescaleSplits(ctx context.Context, tx pgx.Tx, transactionID uuid.UUID, from, to float64) error {
+	lines, err := loadSplits(ctx, tx, transactionID)
+	if err != nil || len(lines) == 0 {
+		return err
+	}
+	if from == 0 || to == 0 {
+		_, err := tx.Exec(ctx, `DELETE FROM transaction_splits WHERE transaction_id = $1`, transactionID)
+		return err
+	}
+
+	for _, line := range models.ScaleSplits(lines, from, to) {
+		if _, err := tx.Exec(ctx, `UPDATE transaction_splits SET amount = $1 WHERE id = $2`, line.Amount, line.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListTransactions returns the family's transaction entries matching filter, newest
+// first, with their category, merchant and tags.
+func (r *LedgerRepository) ListTransactions(ctx context.Context, familyID uuid.UUID, filter models.TransactionFilter) ([]models.TransactionDetail, error) {
+	conditions := []string{"a.family_id = $1", "e.entryable_type = 'Transaction'"}
+	args := []interface{}{familyID}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.AccountID != nil {
+		add("e.account_id = $%d", *filter.AccountID)
+	}
+	if len(filter.TagIDs) > 0 {
+		args = append(args, filter.TagIDs, len(filter.TagIDs))
+		conditions = append(conditions, fmt.Sprintf(
+			"e.id IN (SELECT entry_id FROM taggings WHERE tag_id = ANY($%d) GROUP BY entry_id HAVING COUNT(*) = $%d)",
+			len(args)-1, len(args),
+		))
+	}
+	if filter.From != nil {
+		add("e.date >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("e.date <= $%d", *filter.To)
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`
+		SELECT e.id, e.account_id, e.amount, e.currency, e.date, e.name, e.entryable_type, e.entryable_id,
+			t.category_id, COALESCE(c.name, ''), COALESCE(m.name, ''), t.kind,
+			EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = t.id)
+		FROM entries e
+		JOIN accounts a ON a.id = e.account_id
+		JOIN transactions t ON t.id = e.entryable_id
+		LEFT JOIN categories c ON c.id = t.category_id
+		LEFT JOIN merchants m ON m.id = t.merchant_id
+		WHERE %s
+		ORDER BY e.date DESC, e.id
+		LIMIT $%d OFFSET $%d
+	`, strings.Join(conditions, " AND "), len(args)-1, len(args))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.TransactionDetail
+	index := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var d models.TransactionDetail
+		err := rows.Scan(
+			&d.ID, &d.AccountID, &d.Amount, &d.Currency, &d.Date, &d.Name, &d.EntryableType, &d.EntryableID,
+			&d.CategoryID, &d.CategoryName, &d.MerchantName, &d.Kind, &d.Split,
+		)
+		if err != nil {
+			return nil, err
+		}
+		d.Tags = []models.Tag{}
+		index[d.ID] = len(list)
+		list = append(list, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return list, nil
+	}
+
+	entryIDs := make([]uuid.UUID, 0, len(list))
+	for _, d := range list {
+		entryIDs = append(entryIDs, d.ID)
+	}
+	tagRows, err := r.db.Query(ctx, `
+		SELECT tg.entry_id, t.id, t.family_id, t.name, t.color, t.created_at, t.updated_at
+		FROM taggings tg
+		JOIN tags t ON t.id = tg.tag_id
+		WHERE tg.entry_id = ANY($1)
+		ORDER BY lower(t.name)
+	`, entryIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer tagRows.Close()
+
+	for tagRows.Next() {
+		var entryID uuid.UUID
+		var t models.Tag
+		if err := tagRows.Scan(&entryID, &t.ID, &t.FamilyID, &t.Name, &t.Color, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		d := &list[index[entryID]]
+		d.Tags = append(d.Tags, t)
+	}
+	return list, tagRows.Err()
+}
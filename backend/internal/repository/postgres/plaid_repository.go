@@ -17,6 +17,12 @@ func NewPlaidRepository(db *pgxpool.Pool) *PlaidRepository {
 }
 
 func (r *PlaidRepository) SaveItem(ctx context.Context, item *models.PlaidItem) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
 		INSERT INTO plaid_items (family_id, access_token, item_id, institution_id, institution_name, sync_cursor)
 		VALUES ($1, $2, $3, $4, $5, $6)
@@ -24,11 +30,25 @@ func (r *PlaidRepository) SaveItem(ctx context.Context, item *models.PlaidItem)
 			access_token = EXCLUDED.access_token,
 			sync_cursor = EXCLUDED.sync_cursor,
 			updated_at = NOW()
-		RETURNING id
+		RETURNING id, status, created_at, updated_at
 	`
-	return r.db.QueryRow(ctx, query,
+	err = tx.QueryRow(ctx, query,
 		item.FamilyID, item.AccessToken, item.ItemID, item.InstitutionID, item.InstitutionName, item.SyncCursor,
-	).Scan(&item.ID)
+	).Scan(&item.ID, &item.Status, &item.CreatedAt, &item.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	// The item's JSON leaves out the access token and cursor
+	err = recordAudit(ctx, tx, auditChange{
+		FamilyID: item.FamilyID, Action: models.AuditPlaidItemSave,
+		EntityType: "plaid_item", EntityID: item.ID, After: item,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (r *PlaidRepository) GetItemsByFamily(ctx context.Context, familyID uuid.UUID) ([]models.PlaidItem, error) {
@@ -77,8 +97,106 @@ func (r *PlaidRepository) GetItemByID(ctx context.Context, itemID string) (*mode
 	return &item, nil
 }
 
+func (r *PlaidRepository) GetAllItems(ctx context.Context) ([]models.PlaidItem, error) {
+	query := `
+		SELECT id, family_id, access_token, item_id, institution_id, institution_name, sync_cursor, status, created_at, updated_at
+		FROM plaid_items
+	`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.PlaidItem
+	for rows.Next() {
+		var item models.PlaidItem
+		err := rows.Scan(
+			&item.ID, &item.FamilyID, &item.AccessToken, &item.ItemID,
+			&item.InstitutionID, &item.InstitutionName, &item.SyncCursor,
+			&item.Status, &item.CreatedAt, &item.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// UpdateAccessToken swaps the stored token only if it still equals oldToken, so a relink
+// that happened in the meantime isn't overwritten. Reports whether the row was updated.
+func (r *PlaidRepository) UpdateAccessToken(ctx context.Context, itemID, oldToken, newToken string) (bool, error) {
+	query := `UPDATE plaid_items SET access_token = $1, updated_at = NOW() WHERE item_id = $2 AND access_token = $3`
+	tag, err := r.db.Exec(ctx, query, newToken, itemID, oldToken)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
 func (r *PlaidRepository) UpdateCursor(ctx context.Context, itemID string, cursor string) error {
 	query := `UPDATE plaid_items SET sync_cursor = $1, updated_at = NOW() WHERE item_id = $2`
 	_, err := r.db.Exec(ctx, query, cursor, itemID)
 	return err
 }
+
+func (r *PlaidRepository) UpdateItemStatus(ctx context.Context, itemID string, status string) error {
+	query := `UPDATE plaid_items SET status = $1, updated_at = NOW() WHERE item_id = $2`
+	_, err := r.db.Exec(ctx, query, status, itemID)
+	return err
+}
+
+// RemoveItem deletes the item and either archives its accounts (keeping their history)
+// or deletes them together with their ledger entries.
+func (r *PlaidRepository) RemoveItem(ctx context.Context, itemID string, purgeAccounts bool) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var item models.PlaidItem
+	query := `
+		SELECT id, family_id, item_id, institution_id, institution_name, status, created_at, updated_at
+		FROM plaid_items
+		WHERE item_id = $1
+	`
+	err = tx.QueryRow(ctx, query, itemID).Scan(
+		&item.ID, &item.FamilyID, &item.ItemID, &item.InstitutionID, &item.InstitutionName,
+		&item.Status, &item.CreatedAt, &item.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	id := item.ID
+
+	if purgeAccounts {
+		accountIDs, err := collectIDs(ctx, tx, `SELECT id FROM accounts WHERE plaid_item_id = $1`, id)
+		if err != nil {
+			return err
+		}
+		if err := deleteAccounts(ctx, tx, accountIDs); err != nil {
+			return err
+		}
+	} else {
+		if _, err = tx.Exec(ctx, `UPDATE accounts SET status = 'archived' WHERE plaid_item_id = $1`, id); err != nil {
+			return err
+		}
+	}
+
+	if _, err = tx.Exec(ctx, `DELETE FROM plaid_items WHERE id = $1`, id); err != nil {
+		return err
+	}
+
+	err = recordAudit(ctx, tx, auditChange{
+		FamilyID: item.FamilyID, Action: models.AuditPlaidItemRemove,
+		EntityType: "plaid_item", EntityID: id,
+		Before: item, After: map[string]bool{"purgedAccounts": purgeAccounts},
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
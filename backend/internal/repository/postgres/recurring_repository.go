@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/recurring"
+)
+
+type RecurringRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRecurringRepository(db *pgxpool.Pool) *RecurringRepository {
+	return &RecurringRepository{db: db}
+}
+
+const recurringColumns = `
+	id, family_id, pattern_key, merchant_id, account_id, name, cadence, amount, previous_amount,
+	currency, occurrences, last_date, next_date, status, created_at, updated_at
+`
+
+func scanRecurring(row pgx.Row) (*models.RecurringTransaction, error) {
+	var r models.RecurringTransaction
+	err := row.Scan(
+		&r.ID, &r.FamilyID, &r.PatternKey, &r.MerchantID, &r.AccountID, &r.Name, &r.Cadence, &r.Amount, &r.PreviousAmount,
+		&r.Currency, &r.Occurrences, &r.LastDate, &r.NextDate, &r.Status, &r.CreatedAt, &r.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// ListActiveFamilies returns the families with entries dated on or after since.
+func (r *RecurringRepository) ListActiveFamilies(ctx context.Context, since time.Time) ([]uuid.UUID, error) {
+	query := `
+		SELECT DISTINCT a.family_id
+		FROM entries e
+		JOIN accounts a ON a.id = e.account_id
+		WHERE e.date >= $1
+	`
+	rows, err := r.db.Query(ctx, query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ListTransactions returns the family's standard transactions dated on or after since,
+// leaving out transfers, trades and valuations.
+func (r *RecurringRepository) ListTransactions(ctx context.Context, familyID uuid.UUID, since time.Time) ([]recurring.Transaction, error) {
+	query := `
+		SELECT e.account_id, t.merchant_id, COALESCE(m.name, ''), e.name, e.amount, e.currency, e.date
+		FROM entries e
+		JOIN accounts a ON a.id = e.account_id
+		JOIN transactions t ON t.id = e.entryable_id
+		LEFT JOIN merchants m ON m.id = t.merchant_id
+		WHERE a.family_id = $1 AND e.entryable_type = 'Transaction' AND t.kind = 'standard' AND e.date >= $2
+		ORDER BY e.date
+	`
+	rows, err := r.db.Query(ctx, query, familyID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var txs []recurring.Transaction
+	for rows.Next() {
+		var tx recurring.Transaction
+		err := rows.Scan(&tx.AccountID, &tx.MerchantID, &tx.MerchantName, &tx.Name, &tx.Amount, &tx.Currency, &tx.Date)
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, tx)
+	}
+	return txs, rows.Err()
+}
+
+// SaveDetected creates or refreshes the family's detected series. A series keeps the
+// status the family gave it, so dismissed ones stay dismissed.
+func (r *RecurringRepository) SaveDetected(ctx context.Context, familyID uuid.UUID, found []models.RecurringTransaction) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO recurring_transactions (
+			family_id, pattern_key, merchant_id, account_id, name, cadence, amount, previous_amount,
+			currency, occurrences, last_date, next_date
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (family_id, pattern_key) DO UPDATE SET
+			merchant_id = EXCLUDED.merchant_id,
+			account_id = EXCLUDED.account_id,
+			name = EXCLUDED.name,
+			cadence = EXCLUDED.cadence,
+			amount = EXCLUDED.amount,
+			previous_amount = EXCLUDED.previous_amount,
+			currency = EXCLUDED.currency,
+			occurrences = EXCLUDED.occurrences,
+			last_date = EXCLUDED.last_date,
+			next_date = EXCLUDED.next_date,
+			updated_at = NOW()
+	`
+	for _, f := range found {
+		_, err := tx.Exec(ctx, query,
+			familyID, f.PatternKey, f.MerchantID, f.AccountID, f.Name, f.Cadence, f.Amount, f.PreviousAmount,
+			f.Currency, f.Occurrences, f.LastDate, f.NextDate,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// List returns the family's recurring transactions matching filter, next due first.
+func (r *RecurringRepository) List(ctx context.Context, familyID uuid.UUID, filter models.RecurringFilter) ([]models.RecurringTransaction, error) {
+	query := `SELECT ` + recurringColumns + ` FROM recurring_transactions WHERE family_id = $1 AND `
+	args := []interface{}{familyID}
+	if filter.Status != "" {
+		query += `status = $2`
+		args = append(args, filter.Status)
+	} else {
+		query += `status <> 'dismissed'`
+	}
+	query += ` ORDER BY next_date, name`
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.RecurringTransaction
+	for rows.Next() {
+		rt, err := scanRecurring(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *rt)
+	}
+	return list, rows.Err()
+}
+
+// UpdateStatus returns pgx.ErrNoRows if the family has no such recurring transaction.
+func (r *RecurringRepository) UpdateStatus(ctx context.Context, familyID, id uuid.UUID, status string) (*models.RecurringTransaction, error) {
+	query := `
+		UPDATE recurring_transactions SET status = $1, updated_at = NOW()
+		WHERE id = $2 AND family_id = $3
+		RETURNING ` + recurringColumns
+	return scanRecurring(r.db.QueryRow(ctx, query, status, id, familyID))
+}
@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+type ReminderRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewReminderRepository(db *pgxpool.Pool) *ReminderRepository {
+	return &ReminderRepository{db: db}
+}
+
+// ListCreditCards returns every family's active credit cards with their details.
+func (r *ReminderRepository) ListCreditCards(ctx context.Context) ([]models.Account, error) {
+	query := `
+		SELECT id, family_id, name, type, balance, currency, subtype, classification, status
+		FROM accounts
+		WHERE type = 'credit_card' AND status = 'active'
+	`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []models.Account
+	for rows.Next() {
+		var acc models.Account
+		err := rows.Scan(
+			&acc.ID, &acc.FamilyID, &acc.Name, &acc.Type, &acc.Balance, &acc.Currency, &acc.Subtype, &acc.Classification, &acc.Status,
+		)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, acc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := loadAccountDetails(ctx, r.db, accounts); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// RecordPaymentReminder saves reminder unless its account's due date was already
+// reminded, and reports whether it did.
+func (r *ReminderRepository) RecordPaymentReminder(ctx context.Context, reminder *models.PaymentReminder) (bool, error) {
+	query := `
+		INSERT INTO payment_reminders (family_id, account_id, due_date, amount)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (account_id, due_date) DO NOTHING
+		RETURNING id, created_at
+	`
+	err := r.db.QueryRow(ctx, query, reminder.FamilyID, reminder.AccountID, reminder.DueDate, reminder.Amount).
+		Scan(&reminder.ID, &reminder.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,211 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/reports"
+)
+
+type ReportRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewReportRepository(db *pgxpool.Pool) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+// Reports are in the family's currency; entries in others are left out.
+func (r *ReportRepository) familyCurrency(ctx context.Context, familyID uuid.UUID) (string, error) {
+	var currency string
+	err := r.db.QueryRow(ctx, `SELECT currency FROM families WHERE id = $1`, familyID).Scan(&currency)
+	return currency, err
+}
+
+// lineDirection returns an expression that is 'income' or 'expense' for the line or
+// entry amount in account a and category c, or NULL when it is neither. Entries are
+// signed as Plaid sends them, a positive amount being money going out; on a liability
+// account that adds to what is owed. The category decides where it is set. Otherwise
+// money going out is spending, and money coming in is income, except on a liability,
+// where it pays down the debt or refunds a charge.
+func lineDirection(amount string) string {
+	return fmt.Sprintf(`CASE
+		WHEN c.classification = 'income' THEN 'income'
+		WHEN c.classification IS NOT NULL OR %[1]s > 0 THEN 'expense'
+		WHEN a.classification = 'asset' THEN 'income'
+	END`, amount)
+}
+
+// CashFlowLines returns the family's currency and its transaction lines in filter's
+// range, totaled by day and category. Transfers between its accounts are left out, as
+// are entries in other currencies. Split transactions count each line in its own
+// category.
+func (r *ReportRepository) CashFlowLines(ctx context.Context, familyID uuid.UUID, filter models.CashFlowFilter) (string, []reports.Line, error) {
+	currency, err := r.familyCurrency(ctx, familyID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	direction := lineDirection("l.amount")
+	conditions := []string{
+		"a.family_id = $1", "l.kind <> 'transfer'", "l.currency = $2", "l.date >= $3", "l.date <= $4",
+		direction + " IS NOT NULL",
+	}
+	args := []interface{}{familyID, currency, filter.Start, filter.End}
+	if len(filter.TagIDs) > 0 {
+		args = append(args, filter.TagIDs, len(filter.TagIDs))
+		conditions = append(conditions, fmt.Sprintf(
+			"l.entry_id IN (SELECT entry_id FROM taggings WHERE tag_id = ANY($%d) GROUP BY entry_id HAVING COUNT(*) = $%d)",
+			len(args)-1, len(args),
+		))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT l.date, c.id, COALESCE(c.name, ''), COALESCE(c.color, ''),
+			%s = 'income', SUM(l.amount)
+		FROM transaction_lines l
+		JOIN accounts a ON a.id = l.account_id
+		LEFT JOIN categories c ON c.id = l.category_id
+		WHERE %s
+		GROUP BY 1, 2, 3, 4, 5
+		ORDER BY 1
+	`, direction, strings.Join(conditions, " AND "))
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rows.Close()
+
+	var lines []reports.Line
+	for rows.Next() {
+		var l reports.Line
+		if err := rows.Scan(&l.Date, &l.CategoryID, &l.CategoryName, &l.Color, &l.Income, &l.Amount); err != nil {
+			return "", nil, err
+		}
+		lines = append(lines, l)
+	}
+	return currency, lines, rows.Err()
+}
+
+// TopMerchants returns the merchants the family spent most at between start and end,
+// net of refunds in expense categories, leaving out transfers and merchants it got more
+// back from than it spent.
+func (r *ReportRepository) TopMerchants(ctx context.Context, familyID uuid.UUID, start, end time.Time, limit int) (string, []models.MerchantSpending, error) {
+	currency, err := r.familyCurrency(ctx, familyID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT m.id, m.name, SUM(e.amount), COUNT(*), MAX(e.date)
+		FROM entries e
+		JOIN accounts a ON a.id = e.account_id
+		JOIN transactions t ON t.id = e.entryable_id
+		JOIN merchants m ON m.id = t.merchant_id
+		LEFT JOIN categories c ON c.id = t.category_id
+		WHERE a.family_id = $1 AND e.entryable_type = 'Transaction' AND t.kind <> 'transfer'
+			AND e.currency = $2 AND e.date >= $3 AND e.date <= $4 AND %s = 'expense'
+		GROUP BY m.id, m.name
+		HAVING SUM(e.amount) > 0
+		ORDER BY 3 DESC, m.name
+		LIMIT $5
+	`, lineDirection("e.amount"))
+	rows, err := r.db.Query(ctx, query, familyID, currency, start, end, limit)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rows.Close()
+
+	var list []models.MerchantSpending
+	for rows.Next() {
+		var m models.MerchantSpending
+		if err := rows.Scan(&m.MerchantID, &m.Name, &m.Amount, &m.Transactions, &m.LastDate); err != nil {
+			return "", nil, err
+		}
+		list = append(list, m)
+	}
+	return currency, list, rows.Err()
+}
+
+// CategorySpending returns what the family spent in each expense category, month by
+// month, from start's month to end. Split transactions count each line in its own
+// category; uncategorized lines count when money went out.
+func (r *ReportRepository) CategorySpending(ctx context.Context, familyID uuid.UUID, start, end time.Time) (string, []reports.CategorySpend, error) {
+	currency, err := r.familyCurrency(ctx, familyID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT date_trunc('month', l.date)::date, c.id, COALESCE(c.name, ''), COALESCE(c.color, ''), SUM(l.amount)
+		FROM transaction_lines l
+		JOIN accounts a ON a.id = l.account_id
+		LEFT JOIN categories c ON c.id = l.category_id
+		WHERE a.family_id = $1 AND l.kind <> 'transfer' AND l.currency = $2
+			AND l.date >= date_trunc('month', $3::date) AND l.date <= $4
+			AND %s = 'expense'
+		GROUP BY 1, 2, 3, 4
+		ORDER BY 1
+	`, lineDirection("l.amount"))
+	rows, err := r.db.Query(ctx, query, familyID, currency, start, end)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rows.Close()
+
+	var spend []reports.CategorySpend
+	for rows.Next() {
+		var s reports.CategorySpend
+		if err := rows.Scan(&s.Month, &s.CategoryID, &s.CategoryName, &s.Color, &s.Amount); err != nil {
+			return "", nil, err
+		}
+		spend = append(spend, s)
+	}
+	return currency, spend, rows.Err()
+}
+
+// LargestTransactions returns the family's biggest outgoing transactions between start
+// and end, leaving out transfers.
+func (r *ReportRepository) LargestTransactions(ctx context.Context, familyID uuid.UUID, start, end time.Time, limit int) (string, []models.LargeTransaction, error) {
+	currency, err := r.familyCurrency(ctx, familyID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT e.id, e.account_id, e.date, e.name, e.amount, e.currency,
+			t.category_id, COALESCE(c.name, ''), COALESCE(m.name, '')
+		FROM entries e
+		JOIN accounts a ON a.id = e.account_id
+		JOIN transactions t ON t.id = e.entryable_id
+		LEFT JOIN categories c ON c.id = t.category_id
+		LEFT JOIN merchants m ON m.id = t.merchant_id
+		WHERE a.family_id = $1 AND e.entryable_type = 'Transaction' AND t.kind <> 'transfer'
+			AND e.currency = $2 AND e.date >= $3 AND e.date <= $4
+			AND e.amount > 0 AND %s = 'expense'
+		ORDER BY e.amount DESC, e.date DESC
+		LIMIT $5
+	`, lineDirection("e.amount"))
+	rows, err := r.db.Query(ctx, query, familyID, currency, start, end, limit)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rows.Close()
+
+	var list []models.LargeTransaction
+	for rows.Next() {
+		var l models.LargeTransaction
+		err := rows.Scan(&l.EntryID, &l.AccountID, &l.Date, &l.Name, &l.Amount, &l.Currency, &l.CategoryID, &l.CategoryName, &l.MerchantName)
+		if err != nil {
+			return "", nil, err
+		}
+		list = append(list, l)
+	}
+	return currency, list, rows.Err()
+}
@@ -0,0 +1,353 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+type ScheduledRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewScheduledRepository(db *pgxpool.Pool) *ScheduledRepository {
+	return &ScheduledRepository{db: db}
+}
+
+const scheduledColumns = `
+	id, family_id, account_id, category_id, name, amount, currency, rule, start_date, posted_through,
+	active, created_at, updated_at
+`
+
+func scanScheduled(row pgx.Row) (*models.ScheduledTransaction, error) {
+	var s models.ScheduledTransaction
+	err := row.Scan(
+		&s.ID, &s.FamilyID, &s.AccountID, &s.CategoryID, &s.Name, &s.Amount, &s.Currency, &s.Rule, &s.StartDate, &s.PostedThrough,
+		&s.Active, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// checkScheduleAccount returns the currency of the family's account, pgx.ErrNoRows if
+// the family has no such account, or models.ErrScheduleAccountLinked if it is linked
+// to Plaid, which posts its entries already.
+func checkScheduleAccount(ctx context.Context, q pgx.Tx, familyID, accountID uuid.UUID) (string, error) {
+	var currency string
+	var linked bool
+	query := `SELECT currency, plaid_item_id IS NOT NULL FROM accounts WHERE id = $1 AND family_id = $2`
+	if err := q.QueryRow(ctx, query, accountID, familyID).Scan(&currency, &linked); err != nil {
+		return "", err
+	}
+	if linked {
+		return "", models.ErrScheduleAccountLinked
+	}
+	return currency, nil
+}
+
+// Create adds a scheduled transaction, in the account's currency if s has none.
+func (r *ScheduledRepository) Create(ctx context.Context, s *models.ScheduledTransaction) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	currency, err := checkScheduleAccount(ctx, tx, s.FamilyID, s.AccountID)
+	if err != nil {
+		return err
+	}
+	if s.Currency == "" {
+		s.Currency = currency
+	}
+
+	query := `
+		INSERT INTO scheduled_transactions (family_id, account_id, category_id, name, amount, currency, rule, start_date, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING ` + scheduledColumns
+	created, err := scanScheduled(tx.QueryRow(ctx, query,
+		s.FamilyID, s.AccountID, s.CategoryID, s.Name, s.Amount, s.Currency, s.Rule, s.StartDate, s.Active,
+	))
+	if err != nil {
+		return err
+	}
+	*s = *created
+
+	err = recordAudit(ctx, tx, auditChange{
+		FamilyID: s.FamilyID, Action: models.AuditScheduleCreate,
+		EntityType: "scheduled_transaction", EntityID: s.ID, After: s,
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// List returns the family's scheduled transactions by name.
+func (r *ScheduledRepository) List(ctx context.Context, familyID uuid.UUID) ([]models.ScheduledTransaction, error) {
+	query := `SELECT ` + scheduledColumns + ` FROM scheduled_transactions WHERE family_id = $1 ORDER BY name, created_at`
+	return r.list(ctx, query, familyID)
+}
+
+// ListDue returns the active schedules on active, unlinked accounts that haven't been
+// posted through today yet.
+func (r *ScheduledRepository) ListDue(ctx context.Context, today time.Time) ([]models.ScheduledTransaction, error) {
+	query := `
+		SELECT ` + scheduledColumns + `
+		FROM scheduled_transactions
+		WHERE active AND start_date <= $1 AND (posted_through IS NULL OR posted_through < $1)
+			AND account_id IN (SELECT id FROM accounts WHERE status = 'active' AND plaid_item_id IS NULL)
+		ORDER BY created_at
+	`
+	return r.list(ctx, query, today)
+}
+
+func (r *ScheduledRepository) list(ctx context.Context, query string, args ...interface{}) ([]models.ScheduledTransaction, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.ScheduledTransaction
+	for rows.Next() {
+		s, err := scanScheduled(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *s)
+	}
+	return list, rows.Err()
+}
+
+// GetByID returns pgx.ErrNoRows if the family has no such scheduled transaction.
+func (r *ScheduledRepository) GetByID(ctx context.Context, familyID, id uuid.UUID) (*models.ScheduledTransaction, error) {
+	query := `SELECT ` + scheduledColumns + ` FROM scheduled_transactions WHERE id = $1 AND family_id = $2`
+	return scanScheduled(r.db.QueryRow(ctx, query, id, familyID))
+}
+
+// Update saves s's name, amount, category, rule, start date and whether it is active.
+// Dates already posted stay posted. Returns pgx.ErrNoRows if the family has no such
+// scheduled transaction.
+func (r *ScheduledRepository) Update(ctx context.Context, s *models.ScheduledTransaction) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queryBefore := `SELECT ` + scheduledColumns + ` FROM scheduled_transactions WHERE id = $1 AND family_id = $2 FOR UPDATE`
+	before, err := scanScheduled(tx.QueryRow(ctx, queryBefore, s.ID, s.FamilyID))
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE scheduled_transactions
+		SET name = $1, amount = $2, category_id = $3, rule = $4, start_date = $5, active = $6, updated_at = NOW()
+		WHERE id = $7 AND family_id = $8
+		RETURNING ` + scheduledColumns
+	updated, err := scanScheduled(tx.QueryRow(ctx, query,
+		s.Name, s.Amount, s.CategoryID, s.Rule, s.StartDate, s.Active, s.ID, s.FamilyID,
+	))
+	if err != nil {
+		return err
+	}
+	*s = *updated
+
+	err = recordAudit(ctx, tx, auditChange{
+		FamilyID: s.FamilyID, Action: models.AuditScheduleUpdate,
+		EntityType: "scheduled_transaction", EntityID: s.ID, Before: before, After: s,
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Delete removes a scheduled transaction; entries it posted stay. Returns pgx.ErrNoRows
+// if the family has no such scheduled transaction.
+func (r *ScheduledRepository) Delete(ctx context.Context, familyID, id uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `DELETE FROM scheduled_transactions WHERE id = $1 AND family_id = $2 RETURNING ` + scheduledColumns
+	deleted, err := scanScheduled(tx.QueryRow(ctx, query, id, familyID))
+	if err != nil {
+		return err
+	}
+
+	err = recordAudit(ctx, tx, auditChange{
+		FamilyID: familyID, Action: models.AuditScheduleDelete,
+		EntityType: "scheduled_transaction", EntityID: id, Before: deleted,
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ListOccurrences returns the recorded occurrences of a schedule dated from from to to,
+// inclusive, in date order.
+func (r *ScheduledRepository) ListOccurrences(ctx context.Context, scheduleID uuid.UUID, from, to time.Time) ([]models.ScheduledOccurrence, error) {
+	query := `
+		SELECT scheduled_transaction_id, date, status, amount, name, entry_id
+		FROM scheduled_transaction_occurrences
+		WHERE scheduled_transaction_id = $1 AND date BETWEEN $2 AND $3
+		ORDER BY date
+	`
+	rows, err := r.db.Query(ctx, query, scheduleID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.ScheduledOccurrence
+	for rows.Next() {
+		var o models.ScheduledOccurrence
+		if err := rows.Scan(&o.ScheduledTransactionID, &o.Date, &o.Status, &o.Amount, &o.Name, &o.EntryID); err != nil {
+			return nil, err
+		}
+		list = append(list, o)
+	}
+	return list, rows.Err()
+}
+
+// SetOccurrence skips or overrides one date of the family's schedule. Returns
+// pgx.ErrNoRows if the family has no such schedule, or models.ErrOccurrencePosted if
+// the date has been posted already.
+func (r *ScheduledRepository) SetOccurrence(ctx context.Context, familyID uuid.UUID, o *models.ScheduledOccurrence) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	before, err := getOccurrence(ctx, tx, o.ScheduledTransactionID, o.Date)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO scheduled_transaction_occurrences (scheduled_transaction_id, date, status, amount, name)
+		SELECT id, $3, $4, $5, $6 FROM scheduled_transactions WHERE id = $1 AND family_id = $2
+		ON CONFLICT (scheduled_transaction_id, date) DO UPDATE SET
+			status = EXCLUDED.status,
+			amount = EXCLUDED.amount,
+			name = EXCLUDED.name,
+			updated_at = NOW()
+		WHERE scheduled_transaction_occurrences.status <> 'posted'
+		RETURNING scheduled_transaction_id
+	`
+	var id uuid.UUID
+	err = tx.QueryRow(ctx, query, o.ScheduledTransactionID, familyID, o.Date, o.Status, o.Amount, o.Name).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		if _, err := r.GetByID(ctx, familyID, o.ScheduledTransactionID); err != nil {
+			return err
+		}
+		return models.ErrOccurrencePosted
+	}
+	if err != nil {
+		return err
+	}
+
+	change := auditChange{
+		FamilyID: familyID, Action: models.AuditOccurrenceSet,
+		EntityType: "scheduled_transaction", EntityID: o.ScheduledTransactionID, After: o,
+	}
+	if before != nil {
+		change.Before = before
+	}
+	if err := recordAudit(ctx, tx, change); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// getOccurrence returns the occurrence recorded for a schedule's date, nil if there is
+// none.
+func getOccurrence(ctx context.Context, tx pgx.Tx, scheduleID uuid.UUID, date time.Time) (*models.ScheduledOccurrence, error) {
+	var o models.ScheduledOccurrence
+	query := `
+		SELECT scheduled_transaction_id, date, status, amount, name, entry_id
+		FROM scheduled_transaction_occurrences
+		WHERE scheduled_transaction_id = $1 AND date = $2
+	`
+	err := tx.QueryRow(ctx, query, scheduleID, date).Scan(&o.ScheduledTransactionID, &o.Date, &o.Status, &o.Amount, &o.Name, &o.EntryID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// DeleteOccurrence undoes a skip or override. Returns pgx.ErrNoRows if the family's
+// schedule has no such occurrence, or models.ErrOccurrencePosted if it has been posted.
+func (r *ScheduledRepository) DeleteOccurrence(ctx context.Context, familyID, scheduleID uuid.UUID, date time.Time) error {
+	query := `
+		DELETE FROM scheduled_transaction_occurrences o
+		USING scheduled_transactions s
+		WHERE s.id = o.scheduled_transaction_id AND s.family_id = $1 AND o.scheduled_transaction_id = $2 AND o.date = $3
+		RETURNING o.scheduled_transaction_id, o.date, o.status, o.amount, o.name, o.entry_id
+	`
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var o models.ScheduledOccurrence
+	err = tx.QueryRow(ctx, query, familyID, scheduleID, date).Scan(&o.ScheduledTransactionID, &o.Date, &o.Status, &o.Amount, &o.Name, &o.EntryID)
+	if err != nil {
+		return err
+	}
+	if o.Status == models.OccurrenceStatusPosted {
+		return models.ErrOccurrencePosted
+	}
+
+	err = recordAudit(ctx, tx, auditChange{
+		FamilyID: familyID, Action: models.AuditOccurrenceDelete,
+		EntityType: "scheduled_transaction", EntityID: scheduleID, Before: o,
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// RecordPosted marks a date posted with the entry created for it, keeping any override.
+func (r *ScheduledRepository) RecordPosted(ctx context.Context, scheduleID uuid.UUID, date time.Time, entryID uuid.UUID) error {
+	query := `
+		INSERT INTO scheduled_transaction_occurrences (scheduled_transaction_id, date, status, entry_id)
+		VALUES ($1, $2, 'posted', $3)
+		ON CONFLICT (scheduled_transaction_id, date) DO UPDATE SET
+			status = 'posted',
+			entry_id = EXCLUDED.entry_id,
+			updated_at = NOW()
+	`
+	_, err := r.db.Exec(ctx, query, scheduleID, date, entryID)
+	return err
+}
+
+// SetPostedThrough moves a schedule's posted_through forward to date; it never moves back.
+func (r *ScheduledRepository) SetPostedThrough(ctx context.Context, scheduleID uuid.UUID, date time.Time) error {
+	query := `
+		UPDATE scheduled_transactions
+		SET posted_through = GREATEST(COALESCE(posted_through, $2), $2)
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, scheduleID, date)
+	return err
+}
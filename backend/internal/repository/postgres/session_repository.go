@@ -0,0 +1,144 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+type SessionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSessionRepository(db *pgxpool.Pool) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+func (r *SessionRepository) CreateSession(ctx context.Context, session *models.Session, tokenHash string) error {
+	query := `
+		INSERT INTO sessions (user_id, refresh_token_hash, user_agent, ip_address, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, last_used_at
+	`
+	return r.db.QueryRow(ctx, query,
+		session.UserID, tokenHash, session.UserAgent, session.IPAddress, session.ExpiresAt,
+	).Scan(&session.ID, &session.CreatedAt, &session.LastUsedAt)
+}
+
+// RotateRefreshToken swaps an active session's refresh token for a new one. Presenting a
+// token that was already rotated away revokes the session, since only a copy of it could
+// still be in circulation.
+func (r *SessionRepository) RotateRefreshToken(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (*models.Session, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var session models.Session
+	query := `
+		SELECT id, user_id, user_agent, ip_address, created_at, last_used_at, expires_at, revoked_at
+		FROM sessions
+		WHERE refresh_token_hash = $1
+		FOR UPDATE
+	`
+	err = tx.QueryRow(ctx, query, oldHash).Scan(
+		&session.ID, &session.UserID, &session.UserAgent, &session.IPAddress,
+		&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt, &session.RevokedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		tag, err := tx.Exec(ctx, `
+			UPDATE sessions SET revoked_at = NOW()
+			WHERE previous_token_hash = $1 AND revoked_at IS NULL
+		`, oldHash)
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return nil, err
+		}
+		if tag.RowsAffected() > 0 {
+			return nil, models.ErrRefreshTokenReused
+		}
+		return nil, models.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, models.ErrSessionNotFound
+	}
+
+	queryUpdate := `
+		UPDATE sessions SET
+			refresh_token_hash = $1,
+			previous_token_hash = refresh_token_hash,
+			last_used_at = NOW(),
+			expires_at = $2
+		WHERE id = $3
+		RETURNING last_used_at
+	`
+	if err := tx.QueryRow(ctx, queryUpdate, newHash, expiresAt, session.ID).Scan(&session.LastUsedAt); err != nil {
+		return nil, err
+	}
+	session.ExpiresAt = expiresAt
+
+	return &session, tx.Commit(ctx)
+}
+
+func (r *SessionRepository) ListActiveSessions(ctx context.Context, userID uuid.UUID) ([]models.Session, error) {
+	query := `
+		SELECT id, user_id, user_agent, ip_address, created_at, last_used_at, expires_at, revoked_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_used_at DESC
+	`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []models.Session
+	for rows.Next() {
+		var s models.Session
+		err := rows.Scan(
+			&s.ID, &s.UserID, &s.UserAgent, &s.IPAddress,
+			&s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt, &s.RevokedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// RevokeSession ends one of the user's sessions. Returns pgx.ErrNoRows if the user has
+// no such active session.
+func (r *SessionRepository) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	query := `UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+	tag, err := r.db.Exec(ctx, query, sessionID, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// IsSessionActive is checked by the auth middleware on every request, so access tokens
+// stop working as soon as their session is revoked.
+func (r *SessionRepository) IsSessionActive(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	var active bool
+	query := `SELECT EXISTS (SELECT 1 FROM sessions WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW())`
+	err := r.db.QueryRow(ctx, query, sessionID).Scan(&active)
+	return active, err
+}
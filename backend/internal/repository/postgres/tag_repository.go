@@ -0,0 +1,237 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+type TagRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTagRepository(db *pgxpool.Pool) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+const tagColumns = `id, family_id, name, color, created_at, updated_at`
+
+func scanTag(row pgx.Row) (*models.Tag, error) {
+	var t models.Tag
+	if err := row.Scan(&t.ID, &t.FamilyID, &t.Name, &t.Color, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// List returns the family's tags by name.
+func (r *TagRepository) List(ctx context.Context, familyID uuid.UUID) ([]models.Tag, error) {
+	query := `SELECT ` + tagColumns + ` FROM tags WHERE family_id = $1 ORDER BY lower(name)`
+	rows, err := r.db.Query(ctx, query, familyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []models.Tag
+	for rows.Next() {
+		t, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, *t)
+	}
+	return tags, rows.Err()
+}
+
+// Create returns models.ErrTagNameTaken if the family has a tag with the same name,
+// ignoring case.
+func (r *TagRepository) Create(ctx context.Context, tag *models.Tag) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO tags (family_id, name, color)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (family_id, lower(name)) DO NOTHING
+		RETURNING ` + tagColumns
+	created, err := scanTag(tx.QueryRow(ctx, query, tag.FamilyID, tag.Name, tag.Color))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.ErrTagNameTaken
+	}
+	if err != nil {
+		return err
+	}
+	*tag = *created
+
+	err = recordAudit(ctx, tx, auditChange{
+		FamilyID: tag.FamilyID, Action: models.AuditTagCreate,
+		EntityType: "tag", EntityID: tag.ID, After: tag,
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Update renames or recolors a tag. Returns pgx.ErrNoRows if the family has no such tag,
+// or models.ErrTagNameTaken if another of its tags has the new name.
+func (r *TagRepository) Update(ctx context.Context, tag *models.Tag) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	queryBefore := `SELECT ` + tagColumns + ` FROM tags WHERE id = $1 AND family_id = $2 FOR UPDATE`
+	before, err := scanTag(tx.QueryRow(ctx, queryBefore, tag.ID, tag.FamilyID))
+	if err != nil {
+		return err
+	}
+
+	var taken bool
+	query := `SELECT EXISTS (SELECT 1 FROM tags WHERE family_id = $1 AND lower(name) = lower($2) AND id <> $3)`
+	if err := tx.QueryRow(ctx, query, tag.FamilyID, tag.Name, tag.ID).Scan(&taken); err != nil {
+		return err
+	}
+	if taken {
+		return models.ErrTagNameTaken
+	}
+
+	queryUpdate := `
+		UPDATE tags SET name = $1, color = $2, updated_at = NOW()
+		WHERE id = $3 AND family_id = $4
+		RETURNING ` + tagColumns
+	updated, err := scanTag(tx.QueryRow(ctx, queryUpdate, tag.Name, tag.Color, tag.ID, tag.FamilyID))
+	if err != nil {
+		return err
+	}
+	*tag = *updated
+
+	err = recordAudit(ctx, tx, auditChange{
+		FamilyID: tag.FamilyID, Action: models.AuditTagUpdate,
+		EntityType: "tag", EntityID: tag.ID, Before: before, After: tag,
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Delete removes a tag from the family and from every entry it was on. Returns
+// pgx.ErrNoRows if the family has no such tag.
+func (r *TagRepository) Delete(ctx context.Context, familyID, id uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Count the entries it comes off before the taggings cascade away
+	var entries int
+	if err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM taggings WHERE tag_id = $1`, id).Scan(&entries); err != nil {
+		return err
+	}
+	query := `DELETE FROM tags WHERE id = $1 AND family_id = $2 RETURNING ` + tagColumns
+	deleted, err := scanTag(tx.QueryRow(ctx, query, id, familyID))
+	if err != nil {
+		return err
+	}
+
+	err = recordAudit(ctx, tx, auditChange{
+		FamilyID: familyID, Action: models.AuditTagDelete,
+		EntityType: "tag", EntityID: id,
+		Before: map[string]interface{}{"tag": deleted, "entryCount": entries},
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// TagEntries puts every tag in tagIDs on every entry in entryIDs, skipping pairs
+// already tagged, and returns how many taggings it added. Returns pgx.ErrNoRows, and
+// changes nothing, if any tag or entry isn't the family's.
+func (r *TagRepository) TagEntries(ctx context.Context, familyID uuid.UUID, tagIDs, entryIDs []uuid.UUID) (int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkTaggable(ctx, tx, familyID, tagIDs, entryIDs); err != nil {
+		return 0, err
+	}
+	query := `
+		INSERT INTO taggings (tag_id, entry_id)
+		SELECT t, e FROM unnest($1::uuid[]) t CROSS JOIN unnest($2::uuid[]) e
+		ON CONFLICT DO NOTHING
+	`
+	result, err := tx.Exec(ctx, query, tagIDs, entryIDs)
+	if err != nil {
+		return 0, err
+	}
+	if err := auditTagging(ctx, tx, familyID, models.AuditTagEntries, tagIDs, entryIDs, result.RowsAffected()); err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), tx.Commit(ctx)
+}
+
+// UntagEntries takes every tag in tagIDs off every entry in entryIDs and returns how
+// many taggings it removed. Returns pgx.ErrNoRows, and changes nothing, if any tag or
+// entry isn't the family's.
+func (r *TagRepository) UntagEntries(ctx context.Context, familyID uuid.UUID, tagIDs, entryIDs []uuid.UUID) (int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkTaggable(ctx, tx, familyID, tagIDs, entryIDs); err != nil {
+		return 0, err
+	}
+	query := `DELETE FROM taggings WHERE tag_id = ANY($1) AND entry_id = ANY($2)`
+	result, err := tx.Exec(ctx, query, tagIDs, entryIDs)
+	if err != nil {
+		return 0, err
+	}
+	if err := auditTagging(ctx, tx, familyID, models.AuditUntagEntries, tagIDs, entryIDs, result.RowsAffected()); err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), tx.Commit(ctx)
+}
+
+// auditTagging records one event for a bulk tagging change. A bulk change can touch
+// hundreds of entries, so it records the tags and how many entries and taggings there
+// were rather than every entry.
+func auditTagging(ctx context.Context, tx pgx.Tx, familyID uuid.UUID, action string, tagIDs, entryIDs []uuid.UUID, taggings int64) error {
+	return recordAudit(ctx, tx, auditChange{
+		FamilyID: familyID, Action: action, EntityType: "tag",
+		After: map[string]interface{}{"tagIds": tagIDs, "entryCount": len(entryIDs), "taggings": taggings},
+	})
+}
+
+// checkTaggable returns pgx.ErrNoRows unless every tag and entry belongs to the family.
+// The IDs are expected to be distinct.
+func checkTaggable(ctx context.Context, tx pgx.Tx, familyID uuid.UUID, tagIDs, entryIDs []uuid.UUID) error {
+	var tags, entries int
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM tags WHERE id = ANY($2) AND family_id = $1),
+			(SELECT COUNT(*) FROM entries e JOIN accounts a ON a.id = e.account_id WHERE e.id = ANY($3) AND a.family_id = $1)
+	`
+	if err := tx.QueryRow(ctx, query, familyID, tagIDs, entryIDs).Scan(&tags, &entries); err != nil {
+		return err
+	}
+	if tags != len(tagIDs) || entries != len(entryIDs) {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
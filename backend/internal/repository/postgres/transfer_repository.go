@@ -0,0 +1,405 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/transfers"
+)
+
+type TransferRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewTransferRepository(db *pgxpool.Pool) *TransferRepository {
+	return &TransferRepository{db: db}
+}
+
+const transferMatchQuery = `
+	SELECT
+		m.id, m.family_id, m.status, m.automatic, m.transaction_id, m.created_at, m.updated_at,
+		f.id, f.account_id, f.amount, f.currency, f.date, f.name, f.entryable_type, f.entryable_id,
+		t.id, t.account_id, t.amount, t.currency, t.date, t.name, t.entryable_type, t.entryable_id
+	FROM transfer_matches m
+	JOIN entries f ON f.id = m.from_entry_id
+	JOIN entries t ON t.id = m.to_entry_id
+`
+
+// listMatches runs transferMatchQuery with the given WHERE clause and ordering.
+func listMatches(ctx context.Context, q querier, where string, args ...interface{}) ([]models.TransferMatch, error) {
+	rows, err := q.Query(ctx, transferMatchQuery+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []models.TransferMatch
+	for rows.Next() {
+		var m models.TransferMatch
+		f, t := &m.FromEntry, &m.ToEntry
+		err := rows.Scan(
+			&m.ID, &m.FamilyID, &m.Status, &m.Automatic, &m.TransactionID, &m.CreatedAt, &m.UpdatedAt,
+			&f.ID, &f.AccountID, &f.Amount, &f.Currency, &f.Date, &f.Name, &f.EntryableType, &f.EntryableID,
+			&t.ID, &t.AccountID, &t.Amount, &t.Currency, &t.Date, &t.Name, &t.EntryableType, &t.EntryableID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, m)
+	}
+	return matches, rows.Err()
+}
+
+// getMatch returns pgx.ErrNoRows if the family has no such match.
+func getMatch(ctx context.Context, q querier, familyID, id uuid.UUID) (*models.TransferMatch, error) {
+	matches, err := listMatches(ctx, q, `WHERE m.id = $1 AND m.family_id = $2`, id, familyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return &matches[0], nil
+}
+
+// ListActiveFamilies returns the families with entries dated on or after since.
+func (r *TransferRepository) ListActiveFamilies(ctx context.Context, since time.Time) ([]uuid.UUID, error) {
+	query := `
+		SELECT DISTINCT a.family_id
+		FROM entries e
+		JOIN accounts a ON a.id = e.account_id
+		WHERE e.date >= $1
+	`
+	rows, err := r.db.Query(ctx, query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ListCandidates returns the family's entries dated on or after since that could be one
+// side of a transfer: unsplit standard transactions.
+func (r *TransferRepository) ListCandidates(ctx context.Context, familyID uuid.UUID, since time.Time) ([]transfers.Entry, error) {
+	query := `
+		SELECT e.id, e.account_id, e.amount, e.currency, e.date, a.classification = 'liability'
+		FROM entries e
+		JOIN accounts a ON a.id = e.account_id
+		JOIN transactions t ON t.id = e.entryable_id
+		WHERE a.family_id = $1 AND e.entryable_type = 'Transaction' AND t.kind = 'standard' AND e.date >= $2
+			AND NOT EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = t.id)
+		ORDER BY e.date
+	`
+	rows, err := r.db.Query(ctx, query, familyID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []transfers.Entry
+	for rows.Next() {
+		var e transfers.Entry
+		if err := rows.Scan(&e.ID, &e.AccountID, &e.Amount, &e.Currency, &e.Date, &e.Liability); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// ListRejected returns the pairs the family has rejected or unmatched.
+func (r *TransferRepository) ListRejected(ctx context.Context, familyID uuid.UUID) (map[transfers.Pair]bool, error) {
+	query := `SELECT from_entry_id, to_entry_id FROM transfer_matches WHERE family_id = $1 AND status = 'rejected'`
+	rows, err := r.db.Query(ctx, query, familyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rejected := make(map[transfers.Pair]bool)
+	for rows.Next() {
+		var p transfers.Pair
+		if err := rows.Scan(&p.From, &p.To); err != nil {
+			return nil, err
+		}
+		rejected[p] = true
+	}
+	return rejected, rows.Err()
+}
+
+// SaveMatches links the automatic pairs into transfers and records the rest as
+// suggestions for the family to review. Pairs already suggested stay as they are, and a
+// pair whose entry was linked in the meantime is skipped.
+func (r *TransferRepository) SaveMatches(ctx context.Context, familyID uuid.UUID, matches []transfers.Match) error {
+	for _, m := range matches {
+		err := r.saveMatch(ctx, familyID, m)
+		if err != nil && !errors.Is(err, models.ErrTransferEntryUnavailable) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *TransferRepository) saveMatch(ctx context.Context, familyID uuid.UUID, m transfers.Match) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var id uuid.UUID
+	var status string
+	query := `
+		INSERT INTO transfer_matches (family_id, from_entry_id, to_entry_id, automatic)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (from_entry_id, to_entry_id) DO UPDATE SET automatic = EXCLUDED.automatic
+		RETURNING id, status
+	`
+	err = tx.QueryRow(ctx, query, familyID, m.From, m.To, m.Automatic).Scan(&id, &status)
+	if err != nil {
+		return err
+	}
+	if m.Automatic && status == models.TransferMatchSuggested {
+		if err := linkTransfer(ctx, tx, familyID, id, m.From, m.To); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// List returns the family's matches with status, or all of them if status is empty,
+// newest first.
+func (r *TransferRepository) List(ctx context.Context, familyID uuid.UUID, status string) ([]models.TransferMatch, error) {
+	where := `WHERE m.family_id = $1`
+	args := []interface{}{familyID}
+	if status != "" {
+		where += ` AND m.status = $2`
+		args = append(args, status)
+	}
+	return listMatches(ctx, r.db, where+` ORDER BY f.date DESC, m.created_at DESC`, args...)
+}
+
+// Confirm links a suggested match's entries into a transfer. Returns pgx.ErrNoRows if
+// the family has no such match, models.ErrTransferMatchNotSuggested if it was already
+// decided, or models.ErrTransferEntryUnavailable if either entry has since become a
+// transfer or been split.
+func (r *TransferRepository) Confirm(ctx context.Context, familyID, id uuid.UUID) (*models.TransferMatch, error) {
+	return r.decide(ctx, familyID, id, func(tx pgx.Tx, from, to uuid.UUID) error {
+		return linkTransfer(ctx, tx, familyID, id, from, to)
+	})
+}
+
+// Reject marks a suggested match as not a transfer, so it isn't suggested again.
+// Returns pgx.ErrNoRows if the family has no such match, or
+// models.ErrTransferMatchNotSuggested if it was already decided.
+func (r *TransferRepository) Reject(ctx context.Context, familyID, id uuid.UUID) (*models.TransferMatch, error) {
+	return r.decide(ctx, familyID, id, func(tx pgx.Tx, from, to uuid.UUID) error {
+		query := `UPDATE transfer_matches SET status = 'rejected', updated_at = NOW() WHERE id = $1`
+		_, err := tx.Exec(ctx, query, id)
+		return err
+	})
+}
+
+// decide locks a suggested match and applies decision to it.
+func (r *TransferRepository) decide(ctx context.Context, familyID, id uuid.UUID, decision func(tx pgx.Tx, from, to uuid.UUID) error) (*models.TransferMatch, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var from, to uuid.UUID
+	var status string
+	query := `SELECT from_entry_id, to_entry_id, status FROM transfer_matches WHERE id = $1 AND family_id = $2 FOR UPDATE`
+	if err := tx.QueryRow(ctx, query, id, familyID).Scan(&from, &to, &status); err != nil {
+		return nil, err
+	}
+	if status != models.TransferMatchSuggested {
+		return nil, models.ErrTransferMatchNotSuggested
+	}
+	if err := decision(tx, from, to); err != nil {
+		return nil, err
+	}
+
+	match, err := getMatch(ctx, tx, familyID, id)
+	if err != nil {
+		return nil, err
+	}
+	return match, tx.Commit(ctx)
+}
+
+// Unmatch gives a matched transfer's entries back their own transactions and rejects
+// the match so it isn't made again. Returns pgx.ErrNoRows if the family has no such
+// match, or models.ErrTransferNotMatched if it isn't matched.
+func (r *TransferRepository) Unmatch(ctx context.Context, familyID, id uuid.UUID) (*models.TransferMatch, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var status string
+	query := `SELECT status FROM transfer_matches WHERE id = $1 AND family_id = $2 FOR UPDATE`
+	if err := tx.QueryRow(ctx, query, id, familyID).Scan(&status); err != nil {
+		return nil, err
+	}
+	if status != models.TransferMatchMatched {
+		return nil, models.ErrTransferNotMatched
+	}
+	if err := unlinkTransfer(ctx, tx, familyID, id); err != nil {
+		return nil, err
+	}
+
+	match, err := getMatch(ctx, tx, familyID, id)
+	if err != nil {
+		return nil, err
+	}
+	return match, tx.Commit(ctx)
+}
+
+// linkTransfer points both entries at a new transfer transaction, like CreateTransfer
+// makes, and marks the match matched. Their original transactions are kept for
+// unlinkTransfer, and other suggestions involving either entry are dropped. Balances
+// don't change: the entries' amounts stay as they were. Returns
+// models.ErrTransferEntryUnavailable unless both entries are the family's unsplit
+// standard transactions.
+func linkTransfer(ctx context.Context, tx pgx.Tx, familyID, matchID, fromID, toID uuid.UUID) error {
+	query := `
+		SELECT e.id, e.entryable_id
+		FROM entries e
+		JOIN accounts a ON a.id = e.account_id
+		JOIN transactions t ON t.id = e.entryable_id
+		WHERE e.id = ANY($1) AND a.family_id = $2 AND e.entryable_type = 'Transaction' AND t.kind = 'standard'
+			AND NOT EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = t.id)
+		FOR UPDATE OF e
+	`
+	rows, err := tx.Query(ctx, query, []uuid.UUID{fromID, toID}, familyID)
+	if err != nil {
+		return err
+	}
+	original := make(map[uuid.UUID]uuid.UUID)
+	for rows.Next() {
+		var entryID, transactionID uuid.UUID
+		if err := rows.Scan(&entryID, &transactionID); err != nil {
+			rows.Close()
+			return err
+		}
+		original[entryID] = transactionID
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(original) != 2 {
+		return models.ErrTransferEntryUnavailable
+	}
+
+	transferID := uuid.New()
+	if _, err := tx.Exec(ctx, `INSERT INTO transactions (id, kind) VALUES ($1, 'transfer')`, transferID); err != nil {
+		return err
+	}
+	queryEntries := `UPDATE entries SET entryable_id = $1 WHERE id = ANY($2)`
+	if _, err := tx.Exec(ctx, queryEntries, transferID, []uuid.UUID{fromID, toID}); err != nil {
+		return err
+	}
+
+	queryMatch := `
+		UPDATE transfer_matches
+		SET status = 'matched', transaction_id = $1, from_transaction_id = $2, to_transaction_id = $3, updated_at = NOW()
+		WHERE id = $4
+	`
+	if _, err := tx.Exec(ctx, queryMatch, transferID, original[fromID], original[toID], matchID); err != nil {
+		return err
+	}
+	queryOthers := `
+		DELETE FROM transfer_matches
+		WHERE family_id = $1 AND status = 'suggested' AND id <> $2
+			AND (from_entry_id = ANY($3) OR to_entry_id = ANY($3))
+	`
+	if _, err := tx.Exec(ctx, queryOthers, familyID, matchID, []uuid.UUID{fromID, toID}); err != nil {
+		return err
+	}
+
+	return recordAudit(ctx, tx, auditChange{
+		FamilyID: familyID, Action: models.AuditTransferMatch,
+		EntityType: "transaction", EntityID: transferID,
+		Before: map[string]interface{}{"fromTransactionId": original[fromID], "toTransactionId": original[toID]},
+		After:  map[string]interface{}{"matchId": matchID, "fromEntryId": fromID, "toEntryId": toID},
+	})
+}
+
+// unlinkTransfer undoes linkTransfer for a matched match: the entries go back to their
+// original transactions, the transfer transaction is removed and the match is rejected.
+// An entry whose original transaction is gone gets a new uncategorized one.
+func unlinkTransfer(ctx context.Context, tx pgx.Tx, familyID, matchID uuid.UUID) error {
+	var fromID, toID uuid.UUID
+	var transferID, fromTx, toTx *uuid.UUID
+	query := `
+		SELECT from_entry_id, to_entry_id, transaction_id, from_transaction_id, to_transaction_id
+		FROM transfer_matches WHERE id = $1
+	`
+	if err := tx.QueryRow(ctx, query, matchID).Scan(&fromID, &toID, &transferID, &fromTx, &toTx); err != nil {
+		return err
+	}
+
+	restore := func(entryID uuid.UUID, transactionID *uuid.UUID) error {
+		if transactionID == nil {
+			id := uuid.New()
+			if _, err := tx.Exec(ctx, `INSERT INTO transactions (id, kind) VALUES ($1, 'standard')`, id); err != nil {
+				return err
+			}
+			transactionID = &id
+		}
+		_, err := tx.Exec(ctx, `UPDATE entries SET entryable_id = $1 WHERE id = $2`, *transactionID, entryID)
+		return err
+	}
+	if err := restore(fromID, fromTx); err != nil {
+		return err
+	}
+	if err := restore(toID, toTx); err != nil {
+		return err
+	}
+
+	queryMatch := `
+		UPDATE transfer_matches
+		SET status = 'rejected', transaction_id = NULL, from_transaction_id = NULL, to_transaction_id = NULL, updated_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := tx.Exec(ctx, queryMatch, matchID); err != nil {
+		return err
+	}
+	if transferID != nil {
+		queryTx := `
+			DELETE FROM transactions t
+			WHERE t.id = $1
+			AND NOT EXISTS (SELECT 1 FROM entries e WHERE e.entryable_id = t.id)
+		`
+		if _, err := tx.Exec(ctx, queryTx, *transferID); err != nil {
+			return err
+		}
+	}
+
+	var entityID uuid.UUID
+	if transferID != nil {
+		entityID = *transferID
+	}
+	return recordAudit(ctx, tx, auditChange{
+		FamilyID: familyID, Action: models.AuditTransferUnmatch,
+		EntityType: "transaction", EntityID: entityID,
+		Before: map[string]interface{}{"matchId": matchID, "fromEntryId": fromID, "toEntryId": toID},
+	})
+}
@@ -2,8 +2,12 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rakibulbh/ai-finance-manager/internal/models"
 	"golang.org/x/crypto/bcrypt"
@@ -26,30 +30,540 @@ func (r *UserRepository) CreateFamily(ctx context.Context, name string) (uuid.UU
     return id, err
 }
 
-// CreateUser creates a user and returns the created object
+// CreateUser creates a user and returns the created object. The user founds the family,
+// so they are its admin.
 func (r *UserRepository) CreateUser(ctx context.Context, email, password string, familyID uuid.UUID) (*models.User, error) {
+    return insertUser(ctx, r.db, email, password, familyID, models.RoleAdmin)
+}
+
+// CreateUserFromInvite registers a new user straight into the inviting family, with the
+// role the invite grants.
+func (r *UserRepository) CreateUserFromInvite(ctx context.Context, tokenHash, email, password string) (*models.User, error) {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback(ctx)
+
+    invite, err := claimInvite(ctx, tx, tokenHash, email)
+    if err != nil {
+        return nil, err
+    }
+
+    user, err := insertUser(ctx, tx, email, password, invite.FamilyID, invite.Role)
+    if err != nil {
+        return nil, err
+    }
+
+    return user, tx.Commit(ctx)
+}
+
+// AcceptInvite moves an existing user into the inviting family. Whatever the user had in
+// their previous family stays there. Their sessions other than sessionID are revoked,
+// since those sessions' access tokens still name the old family and role.
+func (r *UserRepository) AcceptInvite(ctx context.Context, tokenHash string, userID, sessionID uuid.UUID) (*models.User, error) {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback(ctx)
+
+    var user models.User
+    query := `SELECT id, email, family_id, role FROM users WHERE id = $1 FOR UPDATE`
+    if err := tx.QueryRow(ctx, query, userID).Scan(&user.ID, &user.Email, &user.FamilyID, &user.Role); err != nil {
+        return nil, err
+    }
+
+    invite, err := claimInvite(ctx, tx, tokenHash, user.Email)
+    if err != nil {
+        return nil, err
+    }
+
+    // Don't leave the old family with members but nobody to manage them
+    if user.Role == models.RoleAdmin && user.FamilyID != invite.FamilyID {
+        var othersRemain, otherAdmins bool
+        queryCheck := `
+            SELECT
+                EXISTS (SELECT 1 FROM users WHERE family_id = $1 AND id != $2),
+                EXISTS (SELECT 1 FROM users WHERE family_id = $1 AND id != $2 AND role = 'admin')
+        `
+        if err := tx.QueryRow(ctx, queryCheck, user.FamilyID, user.ID).Scan(&othersRemain, &otherAdmins); err != nil {
+            return nil, err
+        }
+        if othersRemain && !otherAdmins {
+            return nil, models.ErrLastAdmin
+        }
+    }
+
+    queryUpdate := `UPDATE users SET family_id = $1, role = $2 WHERE id = $3`
+    if _, err := tx.Exec(ctx, queryUpdate, invite.FamilyID, invite.Role, user.ID); err != nil {
+        return nil, err
+    }
+    querySessions := `UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL`
+    if _, err := tx.Exec(ctx, querySessions, user.ID, sessionID); err != nil {
+        return nil, err
+    }
+    user.FamilyID = invite.FamilyID
+    user.Role = invite.Role
+
+    return &user, tx.Commit(ctx)
+}
+
+// CreateUserToken stores a new single-use token for the user. Any earlier unused token
+// with the same purpose stops working, so only the latest email's link is valid.
+func (r *UserRepository) CreateUserToken(ctx context.Context, userID uuid.UUID, purpose, tokenHash string, expiresAt time.Time) error {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    queryDelete := `DELETE FROM user_tokens WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`
+    if _, err := tx.Exec(ctx, queryDelete, userID, purpose); err != nil {
+        return err
+    }
+
+    query := `INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at) VALUES ($1, $2, $3, $4)`
+    if _, err := tx.Exec(ctx, query, userID, purpose, tokenHash, expiresAt); err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
+}
+
+// ResetPassword sets a new password using a password reset token, and signs the user out
+// everywhere. Returns models.ErrUserTokenInvalid if the token can't be used.
+func (r *UserRepository) ResetPassword(ctx context.Context, tokenHash, password string) (uuid.UUID, error) {
+    hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+    if err != nil {
+        return uuid.Nil, err
+    }
+
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return uuid.Nil, err
+    }
+    defer tx.Rollback(ctx)
+
+    userID, err := useToken(ctx, tx, tokenHash, models.TokenPurposePasswordReset)
+    if err != nil {
+        return uuid.Nil, err
+    }
+
+    queryUpdate := `UPDATE users SET password_digest = $1, failed_login_count = 0, locked_until = NULL WHERE id = $2`
+    if _, err := tx.Exec(ctx, queryUpdate, string(hashedPassword), userID); err != nil {
+        return uuid.Nil, err
+    }
+
+    // Whoever knew the old password may still be signed in
+    if _, err := tx.Exec(ctx, `UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, userID); err != nil {
+        return uuid.Nil, err
+    }
+
+    // Receiving the reset email proves the address, too
+    if _, err := tx.Exec(ctx, `UPDATE users SET email_verified_at = NOW() WHERE id = $1 AND email_verified_at IS NULL`, userID); err != nil {
+        return uuid.Nil, err
+    }
+
+    return userID, tx.Commit(ctx)
+}
+
+// VerifyEmail marks the user's email as verified. Returns models.ErrUserTokenInvalid if the
+// token can't be used.
+func (r *UserRepository) VerifyEmail(ctx context.Context, tokenHash string) (uuid.UUID, error) {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return uuid.Nil, err
+    }
+    defer tx.Rollback(ctx)
+
+    userID, err := useToken(ctx, tx, tokenHash, models.TokenPurposeEmailVerification)
+    if err != nil {
+        return uuid.Nil, err
+    }
+
+    if _, err := tx.Exec(ctx, `UPDATE users SET email_verified_at = NOW() WHERE id = $1 AND email_verified_at IS NULL`, userID); err != nil {
+        return uuid.Nil, err
+    }
+
+    return userID, tx.Commit(ctx)
+}
+
+// useToken marks a valid token as used and returns its user.
+func useToken(ctx context.Context, tx pgx.Tx, tokenHash, purpose string) (uuid.UUID, error) {
+    var userID uuid.UUID
+    query := `
+        UPDATE user_tokens SET used_at = NOW()
+        WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()
+        RETURNING user_id
+    `
+    err := tx.QueryRow(ctx, query, tokenHash, purpose).Scan(&userID)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return uuid.Nil, models.ErrUserTokenInvalid
+    }
+    return userID, err
+}
+
+// RecordFailedLogin counts a failed password or two-factor code and locks the account
+// according to policy. Returns when the account is locked until, if it is.
+func (r *UserRepository) RecordFailedLogin(ctx context.Context, userID uuid.UUID, policy models.LockoutPolicy) (*time.Time, error) {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback(ctx)
+
+    var failures int
+    query := `UPDATE users SET failed_login_count = failed_login_count + 1 WHERE id = $1 RETURNING failed_login_count`
+    if err := tx.QueryRow(ctx, query, userID).Scan(&failures); err != nil {
+        return nil, err
+    }
+
+    var lockedUntil *time.Time
+    if lock := policy.LockFor(failures); lock > 0 {
+        until := time.Now().Add(lock)
+        lockedUntil = &until
+        if _, err := tx.Exec(ctx, `UPDATE users SET locked_until = $1 WHERE id = $2`, until, userID); err != nil {
+            return nil, err
+        }
+    }
+
+    return lockedUntil, tx.Commit(ctx)
+}
+
+// ResetFailedLogins clears the failure count after a successful login.
+func (r *UserRepository) ResetFailedLogins(ctx context.Context, userID uuid.UUID) error {
+    query := `UPDATE users SET failed_login_count = 0, locked_until = NULL WHERE id = $1 AND (failed_login_count > 0 OR locked_until IS NOT NULL)`
+    _, err := r.db.Exec(ctx, query, userID)
+    return err
+}
+
+// SetPendingTOTPSecret stores a secret that isn't active until EnableTOTP. Returns
+// models.ErrMFAAlreadyEnabled rather than replacing an active secret.
+func (r *UserRepository) SetPendingTOTPSecret(ctx context.Context, userID uuid.UUID, encryptedSecret string) error {
+    query := `UPDATE users SET totp_secret = $1, totp_last_step = 0 WHERE id = $2 AND totp_enabled_at IS NULL`
+    tag, err := r.db.Exec(ctx, query, encryptedSecret, userID)
+    if err != nil {
+        return err
+    }
+    if tag.RowsAffected() == 0 {
+        return models.ErrMFAAlreadyEnabled
+    }
+    return nil
+}
+
+// GetTOTP returns models.ErrMFANotSetUp if the user has no secret, pending or active.
+func (r *UserRepository) GetTOTP(ctx context.Context, userID uuid.UUID) (*models.TOTPState, error) {
+    var state models.TOTPState
+    var secret *string
+    query := `SELECT totp_secret, totp_enabled_at, totp_last_step FROM users WHERE id = $1`
+    if err := r.db.QueryRow(ctx, query, userID).Scan(&secret, &state.EnabledAt, &state.LastStep); err != nil {
+        return nil, err
+    }
+    if secret == nil {
+        return nil, models.ErrMFANotSetUp
+    }
+    state.Secret = *secret
+    return &state, nil
+}
+
+// ListTOTPSecrets returns every stored TOTP secret, pending ones included.
+func (r *UserRepository) ListTOTPSecrets(ctx context.Context) ([]models.StoredTOTPSecret, error) {
+    rows, err := r.db.Query(ctx, `SELECT id, totp_secret FROM users WHERE totp_secret IS NOT NULL`)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var secrets []models.StoredTOTPSecret
+    for rows.Next() {
+        var s models.StoredTOTPSecret
+        if err := rows.Scan(&s.UserID, &s.Secret); err != nil {
+            return nil, err
+        }
+        secrets = append(secrets, s)
+    }
+    return secrets, rows.Err()
+}
+
+// UpdateTOTPSecret replaces a user's encrypted TOTP secret, as long as it is still
+// oldSecret. It reports false if the user re-enrolled or turned two-factor off meanwhile.
+func (r *UserRepository) UpdateTOTPSecret(ctx context.Context, userID uuid.UUID, oldSecret, newSecret string) (bool, error) {
+    query := `UPDATE users SET totp_secret = $3 WHERE id = $1 AND totp_secret = $2`
+    tag, err := r.db.Exec(ctx, query, userID, oldSecret, newSecret)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() == 1, nil
+}
+
+// MarkTOTPStepUsed records that a code for step was accepted. It reports false if a code
+// for this or a later step was already used.
+func (r *UserRepository) MarkTOTPStepUsed(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+    query := `UPDATE users SET totp_last_step = $1 WHERE id = $2 AND totp_last_step < $1`
+    tag, err := r.db.Exec(ctx, query, step, userID)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, nil
+}
+
+func (r *UserRepository) EnableTOTP(ctx context.Context, userID uuid.UUID, recoveryCodeHashes []string) error {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    if _, err := tx.Exec(ctx, `UPDATE users SET totp_enabled_at = NOW() WHERE id = $1`, userID); err != nil {
+        return err
+    }
+    if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
+}
+
+func (r *UserRepository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, recoveryCodeHashes []string) error {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
+}
+
+// UseRecoveryCode spends one of the user's recovery codes, reporting false if it doesn't
+// match an unused one.
+func (r *UserRepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+    query := `
+        UPDATE mfa_recovery_codes SET used_at = NOW()
+        WHERE id = (
+            SELECT id FROM mfa_recovery_codes
+            WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+            LIMIT 1
+            FOR UPDATE
+        )
+    `
+    tag, err := r.db.Exec(ctx, query, userID, codeHash)
+    if err != nil {
+        return false, err
+    }
+    return tag.RowsAffected() > 0, nil
+}
+
+func (r *UserRepository) DisableTOTP(ctx context.Context, userID uuid.UUID) error {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback(ctx)
+
+    query := `UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0 WHERE id = $1`
+    if _, err := tx.Exec(ctx, query, userID); err != nil {
+        return err
+    }
+    if _, err := tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+        return err
+    }
+
+    return tx.Commit(ctx)
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID uuid.UUID, hashes []string) error {
+    if _, err := tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+        return err
+    }
+    query := `INSERT INTO mfa_recovery_codes (user_id, code_hash) SELECT $1, unnest($2::text[])`
+    _, err := tx.Exec(ctx, query, userID, hashes)
+    return err
+}
+
+// SignInWithIdentity finds or creates the user for a single sign-on login. A known
+// identity signs in its user. Otherwise a user with the same email is linked, as long as
+// the provider verified it, and failing that a new user is provisioned. A user who never
+// verified their email loses their password and everything signed in with it when
+// linked, since whoever registered the address may not own it. Returns
+// models.ErrIdentityEmailUnverified or models.ErrIdentityDomainNotAllowed when it won't
+// do either.
+func (r *UserRepository) SignInWithIdentity(ctx context.Context, identity *models.ExternalIdentity, provisioning models.SSOProvisioning) (*models.User, error) {
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback(ctx)
+
+    var userID uuid.UUID
+    query := `
+        UPDATE user_identities SET email = $3, last_login_at = NOW()
+        WHERE issuer = $1 AND subject = $2
+        RETURNING user_id
+    `
+    err = tx.QueryRow(ctx, query, identity.Issuer, identity.Subject, identity.Email).Scan(&userID)
+    if errors.Is(err, pgx.ErrNoRows) {
+        userID, err = linkOrProvisionUser(ctx, tx, identity, provisioning)
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    if err := tx.Commit(ctx); err != nil {
+        return nil, err
+    }
+    return r.FindByID(ctx, userID)
+}
+
+func linkOrProvisionUser(ctx context.Context, tx pgx.Tx, identity *models.ExternalIdentity, provisioning models.SSOProvisioning) (uuid.UUID, error) {
+    // Anyone can claim any address at some providers, so only a verified one may take
+    // over an account
+    if !identity.EmailVerified || identity.Email == "" {
+        return uuid.Nil, models.ErrIdentityEmailUnverified
+    }
+
+    var userID uuid.UUID
+    var verified bool
+    query := `SELECT id, email_verified_at IS NOT NULL FROM users WHERE lower(email) = lower($1) FOR UPDATE`
+    err := tx.QueryRow(ctx, query, identity.Email).Scan(&userID, &verified)
+    switch {
+    case err == nil:
+        if !verified {
+            if err := resetUnverifiedUser(ctx, tx, userID); err != nil {
+                return uuid.Nil, err
+            }
+        }
+    case errors.Is(err, pgx.ErrNoRows):
+        if !provisioning.AllowsEmail(identity.Email) {
+            return uuid.Nil, models.ErrIdentityDomainNotAllowed
+        }
+        userID, err = provisionUser(ctx, tx, identity.Email, provisioning)
+        if err != nil {
+            return uuid.Nil, err
+        }
+    default:
+        return uuid.Nil, err
+    }
+
+    queryLink := `INSERT INTO user_identities (user_id, issuer, subject, email) VALUES ($1, $2, $3, $4)`
+    if _, err := tx.Exec(ctx, queryLink, userID, identity.Issuer, identity.Subject, identity.Email); err != nil {
+        return uuid.Nil, err
+    }
+    return userID, nil
+}
+
+// resetUnverifiedUser takes an account that never proved its email away from whoever
+// registered it, before the address's owner is linked to it: the password, two-factor
+// setup, pending tokens, sessions and API keys all go, and the email counts as verified.
+func resetUnverifiedUser(ctx context.Context, tx pgx.Tx, userID uuid.UUID) error {
+    queries := []string{
+        `UPDATE users SET password_digest = NULL, totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0,
+            failed_login_count = 0, locked_until = NULL, email_verified_at = NOW()
+        WHERE id = $1`,
+        `DELETE FROM mfa_recovery_codes WHERE user_id = $1`,
+        `DELETE FROM user_tokens WHERE user_id = $1 AND used_at IS NULL`,
+        `UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`,
+        `UPDATE api_keys SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`,
+    }
+    for _, query := range queries {
+        if _, err := tx.Exec(ctx, query, userID); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// provisionUser creates a user without a password, either in the configured family or
+// founding a new one.
+func provisionUser(ctx context.Context, tx pgx.Tx, email string, provisioning models.SSOProvisioning) (uuid.UUID, error) {
+    role := models.RoleAdmin
+    var familyID uuid.UUID
+    if provisioning.FamilyID != nil {
+        familyID = *provisioning.FamilyID
+        role = provisioning.Role
+        if !models.ValidRole(role) {
+            role = models.RoleMember
+        }
+    } else if err := tx.QueryRow(ctx, `INSERT INTO families (name) VALUES ($1) RETURNING id`, "Default Family").Scan(&familyID); err != nil {
+        return uuid.Nil, err
+    }
+
+    var userID uuid.UUID
+    query := `INSERT INTO users (email, family_id, role, email_verified_at) VALUES ($1, $2, $3, NOW()) RETURNING id`
+    err := tx.QueryRow(ctx, query, email, familyID, role).Scan(&userID)
+    return userID, err
+}
+
+type queryRower interface {
+    QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func insertUser(ctx context.Context, db queryRower, email, password string, familyID uuid.UUID, role string) (*models.User, error) {
     hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
     if err != nil {
         return nil, err
     }
 
     var user models.User
-    query := `INSERT INTO users (email, password_digest, family_id) VALUES ($1, $2, $3) RETURNING id, email, family_id`
-    err = r.db.QueryRow(ctx, query, email, string(hashedPassword), familyID).Scan(&user.ID, &user.Email, &user.FamilyID)
+    query := `INSERT INTO users (email, password_digest, family_id, role) VALUES ($1, $2, $3, $4) RETURNING id, email, family_id, role`
+    err = db.QueryRow(ctx, query, email, string(hashedPassword), familyID, role).Scan(&user.ID, &user.Email, &user.FamilyID, &user.Role)
     if err != nil {
         return nil, err
     }
     return &user, nil
 }
 
+// claimInvite marks a pending invite as accepted and returns it. The invite must have been
+// sent to email.
+func claimInvite(ctx context.Context, tx pgx.Tx, tokenHash, email string) (*models.FamilyInvite, error) {
+    var invite models.FamilyInvite
+    query := `
+        SELECT id, family_id, email, role
+        FROM family_invites
+        WHERE token_hash = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()
+        FOR UPDATE
+    `
+    err := tx.QueryRow(ctx, query, tokenHash).Scan(&invite.ID, &invite.FamilyID, &invite.Email, &invite.Role)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return nil, models.ErrInviteNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    if !strings.EqualFold(invite.Email, email) {
+        return nil, models.ErrInviteEmailMismatch
+    }
+
+    if _, err := tx.Exec(ctx, `UPDATE family_invites SET accepted_at = NOW() WHERE id = $1`, invite.ID); err != nil {
+        return nil, err
+    }
+    return &invite, nil
+}
+
 // FindByEmail finds a user by email (used for login)
 func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models.User, string, error) {
     var user models.User
     var passwordHash string
-    query := `SELECT id, email, family_id, password_digest FROM users WHERE email = $1`
-    err := r.db.QueryRow(ctx, query, email).Scan(&user.ID, &user.Email, &user.FamilyID, &passwordHash)
+    query := `SELECT id, email, family_id, role, totp_enabled_at IS NOT NULL, locked_until, COALESCE(password_digest, '') FROM users WHERE email = $1`
+    err := r.db.QueryRow(ctx, query, email).Scan(&user.ID, &user.Email, &user.FamilyID, &user.Role, &user.MFAEnabled, &user.LockedUntil, &passwordHash)
     if err != nil {
         return nil, "", err
     }
     return &user, passwordHash, nil
 }
+
+// FindByID finds a user by ID (used when refreshing a session)
+func (r *UserRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+    var user models.User
+    query := `SELECT id, email, family_id, role, email_verified_at, totp_enabled_at IS NOT NULL, locked_until FROM users WHERE id = $1`
+    err := r.db.QueryRow(ctx, query, id).Scan(&user.ID, &user.Email, &user.FamilyID, &user.Role, &user.EmailVerifiedAt, &user.MFAEnabled, &user.LockedUntil)
+    if err != nil {
+        return nil, err
+    }
+    return &user, nil
+}
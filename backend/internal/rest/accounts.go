@@ -3,16 +3,28 @@ package rest
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
 	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	authMW "github.com/rakibulbh/ai-finance-manager/internal/rest/middleware"
+	"go.uber.org/zap"
 )
 
 type AccountStore interface {
 	Create(ctx context.Context, acc *models.Account) error
 	ListByFamilyID(ctx context.Context, familyID uuid.UUID) ([]models.Account, error)
 	GetNetWorth(ctx context.Context, familyID uuid.UUID) (float64, error)
+	GetByID(ctx context.Context, familyID, accountID uuid.UUID) (*models.Account, error)
+	Update(ctx context.Context, familyID, accountID uuid.UUID, update models.AccountUpdate) (*models.Account, error)
+	Delete(ctx context.Context, familyID, accountID uuid.UUID) error
+	ListEntries(ctx context.Context, accountID uuid.UUID, from time.Time) ([]models.Entry, error)
 }
 
 type AccountHandler struct {
@@ -43,14 +55,28 @@ func (h *AccountHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 3. Determine Classification
-	if acc.Classification == "" {
-		if acc.Type == "credit_card" || acc.Type == "loan" {
-			acc.Classification = "liability"
-		} else {
-			acc.Classification = "asset"
+	// 3. Determine Classification from the type
+	if acc.Type == "" {
+		acc.Type = models.AccountTypeDepository
+		if acc.Classification == "liability" {
+			acc.Type = models.AccountTypeOtherLiability
 		}
 	}
+	classification, ok := models.AccountClassification(acc.Type)
+	if !ok {
+		sendError(w, http.StatusBadRequest, "Invalid account type")
+		return
+	}
+	if acc.Classification != "" && acc.Classification != classification {
+		sendError(w, http.StatusBadRequest, "A "+acc.Type+" account is a "+classification)
+		return
+	}
+	acc.Classification = classification
+
+	if msg := validateAccountDetails(acc.Type, acc.Details()); msg != "" {
+		sendError(w, http.StatusBadRequest, msg)
+		return
+	}
 
 	// 4. Create
 	if err := h.repo.Create(r.Context(), &acc); err != nil {
@@ -92,3 +118,148 @@ func (h *AccountHandler) List(w http.ResponseWriter, r *http.Request) {
 
 	sendJSON(w, http.StatusOK, response)
 }
+
+// GET /accounts/{accountID}
+// Unlike List, this also finds archived and closed accounts.
+func (h *AccountHandler) Get(w http.ResponseWriter, r *http.Request) {
+	familyID, accountID, ok := accountParams(w, r)
+	if !ok {
+		return
+	}
+
+	acc, err := h.repo.GetByID(r.Context(), familyID, accountID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			sendError(w, http.StatusNotFound, "Account not found")
+			return
+		}
+		logger.Error("DB Error (get account)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to fetch account")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": acc})
+}
+
+// PUT /accounts/{accountID}
+// Renames the account, changes its subtype or type-specific details, or moves it between
+// active, archived and closed. Changing the status needs the manage accounts permission.
+func (h *AccountHandler) Update(w http.ResponseWriter, r *http.Request) {
+	familyID, accountID, ok := accountParams(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.AccountUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			sendError(w, http.StatusBadRequest, "Name can't be empty")
+			return
+		}
+		req.Name = &name
+	}
+	if msg := validateAccountDetails("", req.AccountDetails); msg != "" {
+		sendError(w, http.StatusBadRequest, msg)
+		return
+	}
+	if req.Status != nil {
+		if !models.ValidAccountStatus(*req.Status) {
+			sendError(w, http.StatusBadRequest, "Invalid status")
+			return
+		}
+		role, _ := r.Context().Value("role").(string)
+		if !authMW.HasPermission(role, authMW.PermManageAccounts) {
+			sendError(w, http.StatusForbidden, "You don't have permission to do that")
+			return
+		}
+	}
+
+	acc, err := h.repo.Update(r.Context(), familyID, accountID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			sendError(w, http.StatusNotFound, "Account not found")
+		case errors.Is(err, models.ErrAccountBalanceNotZero):
+			sendError(w, http.StatusConflict, "Only accounts with a zero balance can be closed")
+		case errors.Is(err, models.ErrAccountDetailsMismatch):
+			sendError(w, http.StatusBadRequest, "Details don't match the account type")
+		default:
+			logger.Error("DB Error (update account)", zap.Error(err))
+			sendError(w, http.StatusInternalServerError, "Failed to update account")
+		}
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": acc})
+}
+
+// DELETE /accounts/{accountID}
+// Deletes the account and its whole history. Archive it instead to keep the history.
+func (h *AccountHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	familyID, accountID, ok := accountParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.Delete(r.Context(), familyID, accountID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			sendError(w, http.StatusNotFound, "Account not found")
+			return
+		}
+		logger.Error("DB Error (delete account)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to delete account")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]string{"message": "Account deleted"})
+}
+
+// validateAccountDetails returns what's wrong with details, or "". With accountType
+// set the details must also be that type's; otherwise the repository checks it.
+func validateAccountDetails(accountType string, details models.AccountDetails) string {
+	if accountType != "" && !details.MatchType(accountType) {
+		return "Details don't match the account type"
+	}
+	if d := details.InvestmentDetails; d != nil && !models.ValidTaxTreatment(d.TaxTreatment) {
+		return "Invalid tax treatment"
+	}
+	if d := details.PropertyDetails; d != nil && (d.Sqft < 0 || d.YearBuilt < 0) {
+		return "Square footage and year built can't be negative"
+	}
+	if d := details.VehicleDetails; d != nil && (d.Year < 0 || d.Mileage < 0) {
+		return "Vehicle year and mileage can't be negative"
+	}
+	if d := details.LoanDetails; d != nil && (d.InterestRate < 0 || d.TermMonths < 0 || d.OriginalPrincipal < 0) {
+		return "Interest rate, term and original principal can't be negative"
+	}
+	if d := details.CreditCardDetails; d != nil {
+		if d.APR < 0 || d.CreditLimit < 0 || d.MinimumPayment < 0 {
+			return "APR, credit limit and minimum payment can't be negative"
+		}
+		if d.StatementCloseDay < 0 || d.StatementCloseDay > 31 || d.PaymentDueDay < 0 || d.PaymentDueDay > 31 {
+			return "Statement close and payment due days must be days of the month"
+		}
+	}
+	return ""
+}
+
+// accountParams reads the caller's family and the account ID from the URL, writing the
+// error response itself when it returns false.
+func accountParams(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return uuid.Nil, uuid.Nil, false
+	}
+	accountID, err := uuid.Parse(chi.URLParam(r, "accountID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid account ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return familyID, accountID, true
+}
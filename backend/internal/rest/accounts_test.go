@@ -401,3 +401,193 @@ func TestAccountHandler_Create_PropertyType(t *testing.T) {
 		t.Error("Expected PropertyDetails to be set")
 	}
 }
+
+func TestAccountHandler_GetUpdateDelete(t *testing.T) {
+	store := mocks.NewAccountStore()
+	handler := NewAccountHandler(store)
+	user := &models.User{ID: uuid.New(), FamilyID: uuid.New()}
+
+	checking := models.Account{ID: uuid.New(), Name: "Checking", Balance: 250, Classification: "asset", Status: models.AccountStatusActive}
+	empty := models.Account{ID: uuid.New(), Name: "Old Savings", Classification: "asset", Status: models.AccountStatusActive}
+	store.AddAccount(user.FamilyID, checking)
+	store.AddAccount(user.FamilyID, empty)
+
+	request := func(method string, id uuid.UUID, body interface{}, role string) *http.Request {
+		req := familyRequest(method, "/accounts/"+id.String(), body, user, map[string]string{"accountID": id.String()})
+		return req.WithContext(context.WithValue(req.Context(), "role", role))
+	}
+	str := func(s string) *string { return &s }
+
+	t.Run("gets an account", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.Get(w, request("GET", checking.ID, nil, models.RoleMember))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("other family's account is not found", func(t *testing.T) {
+		other := &models.User{ID: uuid.New(), FamilyID: uuid.New()}
+		req := familyRequest("GET", "/accounts/"+checking.ID.String(), nil, other, map[string]string{"accountID": checking.ID.String()})
+		w := httptest.NewRecorder()
+		handler.Get(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("member renames", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.Update(w, request("PUT", checking.ID, models.AccountUpdate{Name: str("  Joint Checking ")}, models.RoleMember))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+		acc, _ := store.GetByID(context.Background(), user.FamilyID, checking.ID)
+		if acc.Name != "Joint Checking" {
+			t.Errorf("Expected trimmed name, got %q", acc.Name)
+		}
+	})
+
+	t.Run("member can't archive", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.Update(w, request("PUT", checking.ID, models.AccountUpdate{Status: str(models.AccountStatusArchived)}, models.RoleMember))
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403, got %d", w.Code)
+		}
+	})
+
+	t.Run("admin archives", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.Update(w, request("PUT", checking.ID, models.AccountUpdate{Status: str(models.AccountStatusArchived)}, models.RoleAdmin))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		acc, _ := store.GetByID(context.Background(), user.FamilyID, checking.ID)
+		if acc.Status != models.AccountStatusArchived {
+			t.Errorf("Expected archived, got %q", acc.Status)
+		}
+	})
+
+	t.Run("closing needs a zero balance", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.Update(w, request("PUT", checking.ID, models.AccountUpdate{Status: str(models.AccountStatusClosed)}, models.RoleAdmin))
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status 409, got %d", w.Code)
+		}
+
+		w = httptest.NewRecorder()
+		handler.Update(w, request("PUT", empty.ID, models.AccountUpdate{Status: str(models.AccountStatusClosed)}, models.RoleAdmin))
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("rejects bad updates", func(t *testing.T) {
+		for _, body := range []models.AccountUpdate{{Name: str(" ")}, {Status: str("deleted")}} {
+			w := httptest.NewRecorder()
+			handler.Update(w, request("PUT", checking.ID, body, models.RoleAdmin))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %+v, got %d", body, w.Code)
+			}
+		}
+	})
+
+	t.Run("deletes", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.Delete(w, request("DELETE", empty.ID, nil, models.RoleAdmin))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		w = httptest.NewRecorder()
+		handler.Delete(w, request("DELETE", empty.ID, nil, models.RoleAdmin))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 deleting twice, got %d", w.Code)
+		}
+	})
+}
+
+func TestAccountHandler_Create_Types(t *testing.T) {
+	store := mocks.NewAccountStore()
+	handler := NewAccountHandler(store)
+	user := &models.User{ID: uuid.New(), FamilyID: uuid.New()}
+
+	create := func(acc models.Account) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.Create(w, familyRequest("POST", "/accounts", acc, user, nil))
+		return w
+	}
+
+	t.Run("classifies by type and keeps details", func(t *testing.T) {
+		w := create(models.Account{
+			Name: "Car", Currency: "USD", Type: models.AccountTypeVehicle, Balance: 18000,
+			VehicleDetails: &models.VehicleDetails{Make: "Toyota", Model: "Corolla", Year: 2021},
+		})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d. Body: %s", w.Code, w.Body.String())
+		}
+
+		var response models.Account
+		json.NewDecoder(w.Body).Decode(&response)
+		if response.Classification != "asset" {
+			t.Errorf("Expected asset, got %q", response.Classification)
+		}
+		if response.VehicleDetails == nil || response.VehicleDetails.Model != "Corolla" {
+			t.Errorf("Expected vehicle details to round-trip, got %+v", response.VehicleDetails)
+		}
+	})
+
+	t.Run("defaults liabilities to other_liability", func(t *testing.T) {
+		w := create(models.Account{Name: "IOU", Currency: "USD", Classification: "liability"})
+		var response models.Account
+		json.NewDecoder(w.Body).Decode(&response)
+		if response.Type != models.AccountTypeOtherLiability {
+			t.Errorf("Expected other_liability, got %q", response.Type)
+		}
+	})
+
+	invalid := []struct {
+		name string
+		acc  models.Account
+	}{
+		{"unknown type", models.Account{Name: "X", Currency: "USD", Type: "brokerage"}},
+		{"classification contradicts type", models.Account{Name: "X", Currency: "USD", Type: models.AccountTypeLoan, Classification: "asset"}},
+		{"details of another type", models.Account{Name: "X", Currency: "USD", Type: models.AccountTypeLoan, PropertyDetails: &models.PropertyDetails{}}},
+		{"bad tax treatment", models.Account{Name: "X", Currency: "USD", Type: models.AccountTypeInvestment, InvestmentDetails: &models.InvestmentDetails{TaxTreatment: "offshore"}}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := create(tt.acc); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestAccountHandler_Update_Details(t *testing.T) {
+	store := mocks.NewAccountStore()
+	handler := NewAccountHandler(store)
+	user := &models.User{ID: uuid.New(), FamilyID: uuid.New()}
+	house := models.Account{ID: uuid.New(), Name: "House", Type: models.AccountTypeProperty, Classification: "asset"}
+	store.AddAccount(user.FamilyID, house)
+
+	update := func(body models.AccountUpdate) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.Update(w, familyRequest("PUT", "/accounts/"+house.ID.String(), body, user, map[string]string{"accountID": house.ID.String()}))
+		return w
+	}
+
+	w := update(models.AccountUpdate{AccountDetails: models.AccountDetails{PropertyDetails: &models.PropertyDetails{Address: "1 Elm St", Sqft: 1400}}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	acc, _ := store.GetByID(context.Background(), user.FamilyID, house.ID)
+	if acc.PropertyDetails == nil || acc.PropertyDetails.Sqft != 1400 {
+		t.Errorf("Expected property details to be saved, got %+v", acc.PropertyDetails)
+	}
+
+	w = update(models.AccountUpdate{AccountDetails: models.AccountDetails{LoanDetails: &models.LoanDetails{InterestRate: 5}}})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for loan details on a property, got %d", w.Code)
+	}
+}
@@ -0,0 +1,166 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	authMW "github.com/rakibulbh/ai-finance-manager/internal/rest/middleware"
+	"go.uber.org/zap"
+)
+
+// Every key starts with this, so leaked keys are easy to recognize (e.g. by secret scanners).
+const apiKeyPrefix = "mfk_"
+
+type APIKeyStore interface {
+	CreateAPIKey(ctx context.Context, key *models.APIKey, keyHash string) error
+	ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID) error
+}
+
+type APIKeyHandler struct {
+	repo APIKeyStore
+}
+
+func NewAPIKeyHandler(repo APIKeyStore) *APIKeyHandler {
+	return &APIKeyHandler{repo: repo}
+}
+
+type createAPIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// Optional; keys without one last until revoked
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// POST /api_keys
+// The key itself is only returned here. Scripts send it in the X-API-Key header.
+func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "User ID missing from context")
+		return
+	}
+
+	var req createAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		sendError(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+	if len(req.Scopes) == 0 {
+		sendError(w, http.StatusBadRequest, "At least one scope is required")
+		return
+	}
+	scopes := make([]string, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		if !models.ValidScope(scope) {
+			sendError(w, http.StatusBadRequest, "Invalid scope: "+scope)
+			return
+		}
+		if !containsString(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		sendError(w, http.StatusBadRequest, "Expiry must be in the future")
+		return
+	}
+
+	token, _, err := newSecretToken()
+	if err != nil {
+		sendError(w, http.StatusInternalServerError, "Failed to create API key")
+		return
+	}
+	secret := apiKeyPrefix + token
+
+	key := &models.APIKey{
+		UserID:    userID,
+		Name:      req.Name,
+		Prefix:    secret[:len(apiKeyPrefix)+6],
+		Scopes:    scopes,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := h.repo.CreateAPIKey(r.Context(), key, authMW.HashAPIKey(secret)); err != nil {
+		logger.Error("DB Error (create API key)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to create API key")
+		return
+	}
+
+	sendJSON(w, http.StatusCreated, map[string]interface{}{
+		"data": map[string]interface{}{
+			"api_key": key,
+			"key":     secret,
+		},
+	})
+}
+
+// GET /api_keys
+func (h *APIKeyHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "User ID missing from context")
+		return
+	}
+
+	keys, err := h.repo.ListAPIKeys(r.Context(), userID)
+	if err != nil {
+		logger.Error("DB Error (list API keys)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to list API keys")
+		return
+	}
+	if keys == nil {
+		keys = []models.APIKey{}
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": keys})
+}
+
+// DELETE /api_keys/{keyID}
+func (h *APIKeyHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "User ID missing from context")
+		return
+	}
+
+	keyID, err := uuid.Parse(chi.URLParam(r, "keyID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+
+	if err := h.repo.RevokeAPIKey(r.Context(), userID, keyID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			sendError(w, http.StatusNotFound, "API key not found")
+			return
+		}
+		logger.Error("DB Error (revoke API key)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to revoke API key")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]string{"message": "API key revoked"})
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	authMW "github.com/rakibulbh/ai-finance-manager/internal/rest/middleware"
+	"github.com/rakibulbh/ai-finance-manager/internal/rest/mocks"
+)
+
+func TestAPIKeyHandler_Create(t *testing.T) {
+	store := mocks.NewAPIKeyStore()
+	handler := NewAPIKeyHandler(store)
+	user := &models.User{ID: uuid.New(), FamilyID: uuid.New()}
+
+	t.Run("returns the key once and stores its hash", func(t *testing.T) {
+		body := createAPIKeyRequest{Name: "Budget script", Scopes: []string{models.ScopeReadTransactions, models.ScopeReadTransactions}}
+		w := httptest.NewRecorder()
+		handler.Create(w, familyRequest("POST", "/api_keys", body, user, nil))
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d. Body: %s", w.Code, w.Body.String())
+		}
+
+		var response struct {
+			Data struct {
+				APIKey models.APIKey `json:"api_key"`
+				Key    string        `json:"key"`
+			} `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+
+		if !strings.HasPrefix(response.Data.Key, apiKeyPrefix) {
+			t.Errorf("Expected key to start with %q, got %q", apiKeyPrefix, response.Data.Key)
+		}
+		if !strings.HasPrefix(response.Data.Key, response.Data.APIKey.Prefix) {
+			t.Errorf("Expected prefix %q to start the key", response.Data.APIKey.Prefix)
+		}
+		if store.Hashes[response.Data.APIKey.ID] != authMW.HashAPIKey(response.Data.Key) {
+			t.Error("Expected the key's hash to be stored")
+		}
+		if len(response.Data.APIKey.Scopes) != 1 {
+			t.Errorf("Expected duplicate scopes to be dropped, got %v", response.Data.APIKey.Scopes)
+		}
+	})
+
+	past := time.Now().Add(-time.Hour)
+	invalid := []struct {
+		name string
+		body createAPIKeyRequest
+	}{
+		{"missing name", createAPIKeyRequest{Scopes: []string{models.ScopeReadAccounts}}},
+		{"no scopes", createAPIKeyRequest{Name: "Script"}},
+		{"unknown scope", createAPIKeyRequest{Name: "Script", Scopes: []string{"admin"}}},
+		{"expiry in the past", createAPIKeyRequest{Name: "Script", Scopes: []string{models.ScopeReadAccounts}, ExpiresAt: &past}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.Create(w, familyRequest("POST", "/api_keys", tt.body, user, nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestAPIKeyHandler_ListAndRevoke(t *testing.T) {
+	store := mocks.NewAPIKeyStore()
+	handler := NewAPIKeyHandler(store)
+	user := &models.User{ID: uuid.New(), FamilyID: uuid.New()}
+	other := &models.User{ID: uuid.New(), FamilyID: user.FamilyID}
+
+	for _, u := range []*models.User{user, other} {
+		w := httptest.NewRecorder()
+		handler.Create(w, familyRequest("POST", "/api_keys", createAPIKeyRequest{Name: "Script", Scopes: []string{models.ScopeReadAccounts}}, u, nil))
+	}
+	keyID := store.Keys[0].ID
+	otherKeyID := store.Keys[1].ID
+
+	w := httptest.NewRecorder()
+	handler.List(w, familyRequest("GET", "/api_keys", nil, user, nil))
+	var response struct {
+		Data []models.APIKey `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+	if len(response.Data) != 1 || response.Data[0].ID != keyID {
+		t.Fatalf("Expected only the user's own key, got %+v", response.Data)
+	}
+
+	w = httptest.NewRecorder()
+	handler.Revoke(w, familyRequest("DELETE", "/api_keys/"+otherKeyID.String(), nil, user, map[string]string{"keyID": otherKeyID.String()}))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 revoking another user's key, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.Revoke(w, familyRequest("DELETE", "/api_keys/"+keyID.String(), nil, user, map[string]string{"keyID": keyID.String()}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.List(w, familyRequest("GET", "/api_keys", nil, user, nil))
+	json.NewDecoder(w.Body).Decode(&response)
+	if len(response.Data) != 0 {
+		t.Errorf("Expected revoked key to be hidden, got %d keys", len(response.Data))
+	}
+}
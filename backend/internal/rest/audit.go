@@ -0,0 +1,133 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 200
+)
+
+type AuditStore interface {
+	ListEvents(ctx context.Context, familyID uuid.UUID, filter models.AuditFilter) ([]models.AuditEvent, error)
+}
+
+type AuditHandler struct {
+	repo AuditStore
+}
+
+func NewAuditHandler(repo AuditStore) *AuditHandler {
+	return &AuditHandler{repo: repo}
+}
+
+// GET /audit
+// Filters: actor, action, entity_type, entity_id, from and to (RFC 3339 or YYYY-MM-DD),
+// plus limit and offset for paging.
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	events, err := h.repo.ListEvents(r.Context(), familyID, filter)
+	if err != nil {
+		logger.Error("DB Error (list audit events)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to list audit events")
+		return
+	}
+	if events == nil {
+		events = []models.AuditEvent{}
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": events})
+}
+
+func parseAuditFilter(r *http.Request) (models.AuditFilter, error) {
+	q := r.URL.Query()
+	filter := models.AuditFilter{
+		Action:     q.Get("action"),
+		EntityType: q.Get("entity_type"),
+		Limit:      defaultAuditLimit,
+	}
+
+	if v := q.Get("actor"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return filter, errors.New("Invalid actor")
+		}
+		filter.ActorUserID = &id
+	}
+	if v := q.Get("entity_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return filter, errors.New("Invalid entity_id")
+		}
+		filter.EntityID = &id
+	}
+	if v := q.Get("from"); v != "" {
+		from, err := parseAuditTime(v, false)
+		if err != nil {
+			return filter, errors.New("Invalid from")
+		}
+		filter.From = &from
+	}
+	if v := q.Get("to"); v != "" {
+		to, err := parseAuditTime(v, true)
+		if err != nil {
+			return filter, errors.New("Invalid to")
+		}
+		filter.To = &to
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return filter, errors.New("Invalid limit")
+		}
+		if limit > maxAuditLimit {
+			limit = maxAuditLimit
+		}
+		filter.Limit = limit
+	}
+	if v := q.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return filter, errors.New("Invalid offset")
+		}
+		filter.Offset = offset
+	}
+
+	return filter, nil
+}
+
+// parseAuditTime accepts a timestamp or a date. A date used as the end of a range
+// includes that whole day.
+func parseAuditTime(v string, endOfRange bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfRange {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
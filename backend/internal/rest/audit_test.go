@@ -0,0 +1,84 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/rest/mocks"
+)
+
+func TestAuditHandler_List(t *testing.T) {
+	store := mocks.NewAuditStore()
+	handler := NewAuditHandler(store)
+	admin := &models.User{ID: uuid.New(), FamilyID: uuid.New()}
+	member := uuid.New()
+
+	store.Events = []models.AuditEvent{
+		{ID: uuid.New(), FamilyID: admin.FamilyID, ActorUserID: &admin.ID, Action: models.AuditAccountCreate, EntityType: "account"},
+		{ID: uuid.New(), FamilyID: admin.FamilyID, ActorUserID: &member, Action: models.AuditTransactionCreate, EntityType: "entry"},
+		{ID: uuid.New(), FamilyID: uuid.New(), ActorUserID: &member, Action: models.AuditTransactionCreate, EntityType: "entry"},
+	}
+
+	list := func(target string) (*httptest.ResponseRecorder, []models.AuditEvent) {
+		w := httptest.NewRecorder()
+		handler.List(w, familyRequest("GET", target, nil, admin, nil))
+
+		var response struct {
+			Data []models.AuditEvent `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+		return w, response.Data
+	}
+
+	t.Run("lists only the family's events", func(t *testing.T) {
+		w, events := list("/audit")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+		if len(events) != 2 {
+			t.Errorf("Expected 2 events, got %d", len(events))
+		}
+		if store.LastFilter.Limit != defaultAuditLimit {
+			t.Errorf("Expected default limit %d, got %d", defaultAuditLimit, store.LastFilter.Limit)
+		}
+	})
+
+	t.Run("filters by action and actor", func(t *testing.T) {
+		_, events := list("/audit?action=transaction.create&actor=" + member.String())
+		if len(events) != 1 || events[0].Action != models.AuditTransactionCreate {
+			t.Errorf("Expected the one transaction.create event, got %+v", events)
+		}
+	})
+
+	t.Run("parses the range and caps the limit", func(t *testing.T) {
+		w, _ := list("/audit?from=2024-01-01&to=2024-01-31&limit=1000&offset=20")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		f := store.LastFilter
+		if f.Limit != maxAuditLimit || f.Offset != 20 {
+			t.Errorf("Expected limit %d offset 20, got %d/%d", maxAuditLimit, f.Limit, f.Offset)
+		}
+		if !f.From.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Unexpected from %v", f.From)
+		}
+		// A date as the end of the range includes that day
+		if !f.To.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Unexpected to %v", f.To)
+		}
+	})
+
+	for _, target := range []string{"/audit?actor=nope", "/audit?from=yesterday", "/audit?limit=0", "/audit?offset=-1"} {
+		t.Run("rejects "+target, func(t *testing.T) {
+			if w, _ := list(target); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", w.Code)
+			}
+		})
+	}
+}
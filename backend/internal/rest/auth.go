@@ -2,30 +2,73 @@ package rest
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/rakibulbh/ai-finance-manager/internal/logger"
 	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	authMW "github.com/rakibulbh/ai-finance-manager/internal/rest/middleware"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Access tokens are short-lived; clients keep a session going with the refresh token,
+// which rotates on every use.
+const (
+    accessTokenTTL  = 15 * time.Minute
+    refreshTokenTTL = 30 * 24 * time.Hour
+
+    passwordResetTTL     = time.Hour
+    emailVerificationTTL = 48 * time.Hour
+
+    // How long a user has to enter their two-factor code after their password
+    mfaChallengeTTL = 5 * time.Minute
+)
+
 type UserStore interface {
     CreateFamily(ctx context.Context, name string) (uuid.UUID, error)
     CreateUser(ctx context.Context, email, password string, familyID uuid.UUID) (*models.User, error)
     FindByEmail(ctx context.Context, email string) (*models.User, string, error)
+    FindByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+    CreateUserFromInvite(ctx context.Context, tokenHash, email, password string) (*models.User, error)
+    AcceptInvite(ctx context.Context, tokenHash string, userID, sessionID uuid.UUID) (*models.User, error)
+    CreateUserToken(ctx context.Context, userID uuid.UUID, purpose, tokenHash string, expiresAt time.Time) error
+    ResetPassword(ctx context.Context, tokenHash, password string) (uuid.UUID, error)
+    VerifyEmail(ctx context.Context, tokenHash string) (uuid.UUID, error)
+    RecordFailedLogin(ctx context.Context, userID uuid.UUID, policy models.LockoutPolicy) (*time.Time, error)
+    ResetFailedLogins(ctx context.Context, userID uuid.UUID) error
+}
+
+// AccountMailer is satisfied by *services.AccountMailer.
+type AccountMailer interface {
+    SendEmailVerification(ctx context.Context, to, token string) error
+    SendPasswordReset(ctx context.Context, to, token string) error
+}
+
+type SessionStore interface {
+    CreateSession(ctx context.Context, session *models.Session, tokenHash string) error
+    RotateRefreshToken(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (*models.Session, error)
+    ListActiveSessions(ctx context.Context, userID uuid.UUID) ([]models.Session, error)
+    RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error
 }
 
 type RegisterRequest struct {
     Email      string `json:"email"`
     Password   string `json:"password"`
     FamilyName string `json:"family_name"`
+    // Optional: join the inviting family instead of creating a new one
+    InviteToken string `json:"invite_token"`
 }
 
 type LoginRequest struct {
@@ -33,14 +76,43 @@ type LoginRequest struct {
     Password string `json:"password"`
 }
 
+type AcceptInviteRequest struct {
+    Token string `json:"token"`
+}
+
+type ForgotPasswordRequest struct {
+    Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+    Token    string `json:"token"`
+    Password string `json:"password"`
+}
+
+type VerifyEmailRequest struct {
+    Token string `json:"token"`
+}
+
+type RefreshRequest struct {
+    RefreshToken string `json:"refresh_token"`
+}
+
 type AuthHandler struct {
     repo      UserStore
+    sessions  SessionStore
+    mailer    AccountMailer
+    mfa       MFAManager
+    lockout   models.LockoutPolicy
     jwtSecret []byte
 }
 
-func NewAuthHandler(repo UserStore, jwtSecret string) *AuthHandler {
+func NewAuthHandler(repo UserStore, sessions SessionStore, mailer AccountMailer, mfa MFAManager, lockout models.LockoutPolicy, jwtSecret string) *AuthHandler {
     return &AuthHandler{
         repo:      repo,
+        sessions:  sessions,
+        mailer:    mailer,
+        mfa:       mfa,
+        lockout:   lockout,
         jwtSecret: []byte(jwtSecret),
     }
 }
@@ -58,21 +130,35 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
         sendError(w, http.StatusBadRequest, "Email and password are required")
         return
     }
-
-    // Create a family first
-    familyName := req.FamilyName
-    if familyName == "" {
-        familyName = "Default Family"
-    }
-
-    familyID, err := h.repo.CreateFamily(r.Context(), familyName)
-    if err != nil {
-		logger.Error("DB Error (Family)", zap.Error(err))
-        sendError(w, http.StatusInternalServerError, "Failed to create family")
+    if !models.ValidEmail(req.Email) {
+        sendError(w, http.StatusBadRequest, "Invalid email address")
         return
     }
 
-    user, err := h.repo.CreateUser(r.Context(), req.Email, req.Password, familyID)
+    var user *models.User
+    var err error
+    if req.InviteToken != "" {
+        user, err = h.repo.CreateUserFromInvite(r.Context(), hashSecretToken(req.InviteToken), req.Email, req.Password)
+        if errors.Is(err, models.ErrInviteNotFound) || errors.Is(err, models.ErrInviteEmailMismatch) {
+            sendError(w, http.StatusBadRequest, "Invalid or expired invite")
+            return
+        }
+    } else {
+        // Create a family first
+        familyName := req.FamilyName
+        if familyName == "" {
+            familyName = "Default Family"
+        }
+
+        familyID, famErr := h.repo.CreateFamily(r.Context(), familyName)
+        if famErr != nil {
+            logger.Error("DB Error (Family)", zap.Error(famErr))
+            sendError(w, http.StatusInternalServerError, "Failed to create family")
+            return
+        }
+
+        user, err = h.repo.CreateUser(r.Context(), req.Email, req.Password, familyID)
+    }
     if err != nil {
         if strings.Contains(err.Error(), "duplicate key value") {
             sendError(w, http.StatusConflict, "Email already registered")
@@ -83,15 +169,13 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
         return
     }
 
-    // Generate JWT for auto-login
-    token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-        "user_id":   user.ID.String(),
-        "email":     user.Email,
-        "family_id": user.FamilyID.String(),
-        "exp":       time.Now().Add(time.Hour * 24 * 7).Unix(), // 1 week
-    })
+    // The account works before it's verified, so a mail failure shouldn't fail registration
+    if err := h.sendEmailVerification(r.Context(), user); err != nil {
+        logger.Error("Failed to send verification email", zap.Error(err))
+    }
 
-    tokenString, err := token.SignedString(h.jwtSecret)
+    // Start a session for auto-login
+    accessToken, refreshToken, err := h.startSession(r, user)
     if err != nil {
         logger.Error("Failed to generate token during registration", zap.Error(err))
         sendError(w, http.StatusInternalServerError, "Registration successful but failed to generate token")
@@ -101,11 +185,14 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
     // Response format requested: { "data": { ... } }
     response := map[string]interface{}{
         "data": map[string]interface{}{
-            "token": tokenString,
+            "token":         accessToken,
+            "refresh_token": refreshToken,
+            "expires_in":    int(accessTokenTTL.Seconds()),
             "user": map[string]interface{}{
                 "id":        user.ID,
                 "email":     user.Email,
                 "family_id": user.FamilyID,
+                "role":      user.Role,
             },
             "message": "Registration successful",
         },
@@ -140,35 +227,456 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
     }
 
 
+    if h.lockedOut(w, user) {
+        return
+    }
+
     if err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(req.Password)); err != nil {
+        h.recordFailedLogin(r.Context(), user)
         sendError(w, http.StatusUnauthorized, "Invalid email or password")
         return
     }
 
-    // Generate JWT
-    token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-        "user_id":   user.ID.String(),
-        "email":     user.Email,
-        "family_id": user.FamilyID.String(),
-        "exp":       time.Now().Add(time.Hour * 24 * 7).Unix(), // 1 week
-    })
+    h.signIn(w, r, user)
+}
 
-    tokenString, err := token.SignedString(h.jwtSecret)
+// signIn finishes a first-factor login (password or SSO). With two-factor authentication
+// on, that only earns a challenge token to exchange at /auth/mfa/verify along with a code.
+func (h *AuthHandler) signIn(w http.ResponseWriter, r *http.Request, user *models.User) {
+    if user.MFAEnabled {
+        challenge, err := h.newMFAChallengeToken(user.ID)
+        if err != nil {
+            sendError(w, http.StatusInternalServerError, "Failed to generate token")
+            return
+        }
+        sendJSON(w, http.StatusOK, map[string]interface{}{
+            "data": map[string]interface{}{
+                "mfa_required": true,
+                "mfa_token":    challenge,
+                "expires_in":   int(mfaChallengeTTL.Seconds()),
+            },
+        })
+        return
+    }
+
+    h.completeLogin(w, r, user)
+}
+
+// completeLogin starts a session for an authenticated user and sends the tokens.
+func (h *AuthHandler) completeLogin(w http.ResponseWriter, r *http.Request, user *models.User) {
+    if err := h.repo.ResetFailedLogins(r.Context(), user.ID); err != nil {
+        logger.Error("DB Error (reset failed logins)", zap.Error(err))
+    }
+
+    accessToken, refreshToken, err := h.startSession(r, user)
     if err != nil {
+        logger.Error("Failed to start session", zap.Error(err))
         sendError(w, http.StatusInternalServerError, "Failed to generate token")
         return
     }
 
     response := map[string]interface{}{
         "data": map[string]interface{}{
-            "token": tokenString,
+            "token":         accessToken,
+            "refresh_token": refreshToken,
+            "expires_in":    int(accessTokenTTL.Seconds()),
             "user": map[string]interface{}{
                 "id":        user.ID,
                 "email":     user.Email,
                 "family_id": user.FamilyID,
+                "role":      user.Role,
             },
         },
     }
 
     sendJSON(w, http.StatusOK, response)
 }
+
+// POST /auth/refresh
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+    var req RefreshRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        sendError(w, http.StatusBadRequest, "Invalid request body")
+        return
+    }
+    if req.RefreshToken == "" {
+        sendError(w, http.StatusBadRequest, "Refresh token is required")
+        return
+    }
+
+    refreshToken, tokenHash, err := newSecretToken()
+    if err != nil {
+        sendError(w, http.StatusInternalServerError, "Failed to generate token")
+        return
+    }
+
+    session, err := h.sessions.RotateRefreshToken(r.Context(), hashSecretToken(req.RefreshToken), tokenHash, time.Now().Add(refreshTokenTTL))
+    if err != nil {
+        switch {
+        case errors.Is(err, models.ErrRefreshTokenReused):
+            logger.Warn("Refresh token reuse detected, session revoked")
+            sendError(w, http.StatusUnauthorized, "Invalid refresh token")
+        case errors.Is(err, models.ErrSessionNotFound):
+            sendError(w, http.StatusUnauthorized, "Invalid refresh token")
+        default:
+            logger.Error("DB Error (refresh)", zap.Error(err))
+            sendError(w, http.StatusInternalServerError, "Failed to refresh session")
+        }
+        return
+    }
+
+    user, err := h.repo.FindByID(r.Context(), session.UserID)
+    if err != nil {
+        logger.Error("DB Error (refresh user)", zap.Error(err))
+        sendError(w, http.StatusInternalServerError, "Failed to refresh session")
+        return
+    }
+
+    accessToken, err := h.newAccessToken(user, session.ID)
+    if err != nil {
+        sendError(w, http.StatusInternalServerError, "Failed to generate token")
+        return
+    }
+
+    sendJSON(w, http.StatusOK, map[string]interface{}{
+        "data": map[string]interface{}{
+            "token":         accessToken,
+            "refresh_token": refreshToken,
+            "expires_in":    int(accessTokenTTL.Seconds()),
+        },
+    })
+}
+
+// POST /auth/logout
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+    userID, ok := r.Context().Value("user_id").(uuid.UUID)
+    if !ok {
+        sendError(w, http.StatusUnauthorized, "User ID missing from context")
+        return
+    }
+    sessionID, ok := r.Context().Value("session_id").(uuid.UUID)
+    if !ok {
+        sendError(w, http.StatusUnauthorized, "Session ID missing from context")
+        return
+    }
+
+    if err := h.sessions.RevokeSession(r.Context(), userID, sessionID); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+        logger.Error("DB Error (logout)", zap.Error(err))
+        sendError(w, http.StatusInternalServerError, "Failed to log out")
+        return
+    }
+
+    sendJSON(w, http.StatusOK, map[string]string{"message": "Logged out"})
+}
+
+// POST /auth/password/forgot
+// Always answers 202 so the endpoint can't be used to find out who has an account.
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+    var req ForgotPasswordRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" {
+        sendError(w, http.StatusBadRequest, "Email is required")
+        return
+    }
+
+    accepted := map[string]string{"message": "If an account exists for that email, a password reset link is on its way"}
+
+    user, _, err := h.repo.FindByEmail(r.Context(), req.Email)
+    if err != nil {
+        if errors.Is(err, pgx.ErrNoRows) {
+            sendJSON(w, http.StatusAccepted, accepted)
+            return
+        }
+        logger.Error("DB Error (forgot password)", zap.Error(err))
+        sendError(w, http.StatusInternalServerError, "Database error")
+        return
+    }
+
+    token, tokenHash, err := newSecretToken()
+    if err != nil {
+        sendError(w, http.StatusInternalServerError, "Failed to generate token")
+        return
+    }
+    if err := h.repo.CreateUserToken(r.Context(), user.ID, models.TokenPurposePasswordReset, tokenHash, time.Now().Add(passwordResetTTL)); err != nil {
+        logger.Error("DB Error (password reset token)", zap.Error(err))
+        sendError(w, http.StatusInternalServerError, "Database error")
+        return
+    }
+
+    if err := h.mailer.SendPasswordReset(r.Context(), user.Email, token); err != nil {
+        logger.Error("Failed to send password reset email", zap.Error(err))
+        sendError(w, http.StatusInternalServerError, "Failed to send password reset email")
+        return
+    }
+
+    sendJSON(w, http.StatusAccepted, accepted)
+}
+
+// POST /auth/password/reset
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+    var req ResetPasswordRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        sendError(w, http.StatusBadRequest, "Invalid request body")
+        return
+    }
+    if req.Token == "" || req.Password == "" {
+        sendError(w, http.StatusBadRequest, "Token and password are required")
+        return
+    }
+
+    if _, err := h.repo.ResetPassword(r.Context(), hashSecretToken(req.Token), req.Password); err != nil {
+        if errors.Is(err, models.ErrUserTokenInvalid) {
+            sendError(w, http.StatusBadRequest, "Invalid or expired token")
+            return
+        }
+        logger.Error("DB Error (reset password)", zap.Error(err))
+        sendError(w, http.StatusInternalServerError, "Failed to reset password")
+        return
+    }
+
+    sendJSON(w, http.StatusOK, map[string]string{"message": "Password updated, please log in again"})
+}
+
+// POST /auth/email/verify
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+    var req VerifyEmailRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+        sendError(w, http.StatusBadRequest, "Token is required")
+        return
+    }
+
+    if _, err := h.repo.VerifyEmail(r.Context(), hashSecretToken(req.Token)); err != nil {
+        if errors.Is(err, models.ErrUserTokenInvalid) {
+            sendError(w, http.StatusBadRequest, "Invalid or expired token")
+            return
+        }
+        logger.Error("DB Error (verify email)", zap.Error(err))
+        sendError(w, http.StatusInternalServerError, "Failed to verify email")
+        return
+    }
+
+    sendJSON(w, http.StatusOK, map[string]string{"message": "Email verified"})
+}
+
+// POST /auth/email/resend
+func (h *AuthHandler) ResendEmailVerification(w http.ResponseWriter, r *http.Request) {
+    userID, ok := r.Context().Value("user_id").(uuid.UUID)
+    if !ok {
+        sendError(w, http.StatusUnauthorized, "User ID missing from context")
+        return
+    }
+
+    user, err := h.repo.FindByID(r.Context(), userID)
+    if err != nil {
+        logger.Error("DB Error (resend verification)", zap.Error(err))
+        sendError(w, http.StatusInternalServerError, "Database error")
+        return
+    }
+    if user.EmailVerifiedAt != nil {
+        sendError(w, http.StatusConflict, "Email already verified")
+        return
+    }
+
+    if err := h.sendEmailVerification(r.Context(), user); err != nil {
+        logger.Error("Failed to send verification email", zap.Error(err))
+        sendError(w, http.StatusInternalServerError, "Failed to send verification email")
+        return
+    }
+
+    sendJSON(w, http.StatusAccepted, map[string]string{"message": "Verification email sent"})
+}
+
+// POST /auth/invites/accept
+// Moves the signed-in user into the inviting family and returns an access token for it.
+func (h *AuthHandler) AcceptInvite(w http.ResponseWriter, r *http.Request) {
+    userID, ok := r.Context().Value("user_id").(uuid.UUID)
+    if !ok {
+        sendError(w, http.StatusUnauthorized, "User ID missing from context")
+        return
+    }
+    sessionID, ok := r.Context().Value("session_id").(uuid.UUID)
+    if !ok {
+        sendError(w, http.StatusUnauthorized, "Session ID missing from context")
+        return
+    }
+
+    var req AcceptInviteRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+        sendError(w, http.StatusBadRequest, "Invite token is required")
+        return
+    }
+
+    // Signs the user out everywhere else, where tokens name the old family
+    user, err := h.repo.AcceptInvite(r.Context(), hashSecretToken(req.Token), userID, sessionID)
+    if err != nil {
+        switch {
+        case errors.Is(err, models.ErrInviteNotFound):
+            sendError(w, http.StatusNotFound, "Invalid or expired invite")
+        case errors.Is(err, models.ErrInviteEmailMismatch):
+            sendError(w, http.StatusForbidden, "Invite was sent to a different email address")
+        case errors.Is(err, models.ErrLastAdmin):
+            sendError(w, http.StatusConflict, "Make another member an admin before leaving your family")
+        default:
+            logger.Error("DB Error (accept invite)", zap.Error(err))
+            sendError(w, http.StatusInternalServerError, "Failed to accept invite")
+        }
+        return
+    }
+
+    // The current token still names the old family
+    accessToken, err := h.newAccessToken(user, sessionID)
+    if err != nil {
+        sendError(w, http.StatusInternalServerError, "Failed to generate token")
+        return
+    }
+
+    sendJSON(w, http.StatusOK, map[string]interface{}{
+        "data": map[string]interface{}{
+            "token":      accessToken,
+            "expires_in": int(accessTokenTTL.Seconds()),
+            "user": map[string]interface{}{
+                "id":        user.ID,
+                "email":     user.Email,
+                "family_id": user.FamilyID,
+                "role":      user.Role,
+            },
+        },
+    })
+}
+
+type sessionResponse struct {
+    models.Session
+    Current bool `json:"current"`
+}
+
+// GET /auth/sessions
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+    userID, ok := r.Context().Value("user_id").(uuid.UUID)
+    if !ok {
+        sendError(w, http.StatusUnauthorized, "User ID missing from context")
+        return
+    }
+    currentID, _ := r.Context().Value("session_id").(uuid.UUID)
+
+    sessions, err := h.sessions.ListActiveSessions(r.Context(), userID)
+    if err != nil {
+        logger.Error("DB Error (sessions)", zap.Error(err))
+        sendError(w, http.StatusInternalServerError, "Failed to list sessions")
+        return
+    }
+
+    response := make([]sessionResponse, 0, len(sessions))
+    for _, session := range sessions {
+        response = append(response, sessionResponse{Session: session, Current: session.ID == currentID})
+    }
+
+    sendJSON(w, http.StatusOK, map[string]interface{}{"data": response})
+}
+
+// DELETE /auth/sessions/{sessionID}
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+    userID, ok := r.Context().Value("user_id").(uuid.UUID)
+    if !ok {
+        sendError(w, http.StatusUnauthorized, "User ID missing from context")
+        return
+    }
+
+    sessionID, err := uuid.Parse(chi.URLParam(r, "sessionID"))
+    if err != nil {
+        sendError(w, http.StatusBadRequest, "Invalid session ID")
+        return
+    }
+
+    if err := h.sessions.RevokeSession(r.Context(), userID, sessionID); err != nil {
+        if errors.Is(err, pgx.ErrNoRows) {
+            sendError(w, http.StatusNotFound, "Session not found")
+            return
+        }
+        logger.Error("DB Error (revoke session)", zap.Error(err))
+        sendError(w, http.StatusInternalServerError, "Failed to revoke session")
+        return
+    }
+
+    sendJSON(w, http.StatusOK, map[string]string{"message": "Session revoked"})
+}
+
+func (h *AuthHandler) sendEmailVerification(ctx context.Context, user *models.User) error {
+    token, tokenHash, err := newSecretToken()
+    if err != nil {
+        return err
+    }
+    if err := h.repo.CreateUserToken(ctx, user.ID, models.TokenPurposeEmailVerification, tokenHash, time.Now().Add(emailVerificationTTL)); err != nil {
+        return err
+    }
+    return h.mailer.SendEmailVerification(ctx, user.Email, token)
+}
+
+// lockedOut answers 429 if the account is locked after too many failed logins.
+func (h *AuthHandler) lockedOut(w http.ResponseWriter, user *models.User) bool {
+    if user.LockedUntil == nil || !time.Now().Before(*user.LockedUntil) {
+        return false
+    }
+    authMW.TooManyRequests(w, time.Until(*user.LockedUntil), "Too many failed login attempts, please try again later")
+    return true
+}
+
+func (h *AuthHandler) recordFailedLogin(ctx context.Context, user *models.User) {
+    lockedUntil, err := h.repo.RecordFailedLogin(ctx, user.ID, h.lockout)
+    if err != nil {
+        logger.Error("DB Error (record failed login)", zap.Error(err))
+        return
+    }
+    if lockedUntil != nil {
+        logger.Warn("Account locked after failed logins", zap.String("user_id", user.ID.String()), zap.Time("locked_until", *lockedUntil))
+    }
+}
+
+// startSession records a session for the requesting device and returns its access and refresh tokens.
+func (h *AuthHandler) startSession(r *http.Request, user *models.User) (string, string, error) {
+    refreshToken, tokenHash, err := newSecretToken()
+    if err != nil {
+        return "", "", err
+    }
+
+    session := &models.Session{
+        UserID:    user.ID,
+        UserAgent: r.UserAgent(),
+        IPAddress: authMW.ClientIP(r),
+        ExpiresAt: time.Now().Add(refreshTokenTTL),
+    }
+    if err := h.sessions.CreateSession(r.Context(), session, tokenHash); err != nil {
+        return "", "", err
+    }
+
+    accessToken, err := h.newAccessToken(user, session.ID)
+    if err != nil {
+        return "", "", err
+    }
+    return accessToken, refreshToken, nil
+}
+
+func (h *AuthHandler) newAccessToken(user *models.User, sessionID uuid.UUID) (string, error) {
+    token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+        "user_id":   user.ID.String(),
+        "email":     user.Email,
+        "family_id": user.FamilyID.String(),
+        "role":      user.Role,
+        "sid":       sessionID.String(),
+        "exp":       time.Now().Add(accessTokenTTL).Unix(),
+    })
+    return token.SignedString(h.jwtSecret)
+}
+
+// newSecretToken returns a random token (refresh token, invite, emailed link) and the hash we store for it.
+func newSecretToken() (string, string, error) {
+    b := make([]byte, 32)
+    if _, err := rand.Read(b); err != nil {
+        return "", "", err
+    }
+    token := base64.RawURLEncoding.EncodeToString(b)
+    return token, hashSecretToken(token), nil
+}
+
+func hashSecretToken(token string) string {
+    sum := sha256.Sum256([]byte(token))
+    return hex.EncodeToString(sum[:])
+}
@@ -2,22 +2,30 @@ package rest
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
 	"github.com/rakibulbh/ai-finance-manager/internal/rest/mocks"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // Tests based on Ruby test/specifications from maybe/test/controllers/api/v1/auth_controller_test.rb
 
+var testLockout = models.LockoutPolicy{Threshold: 3, Base: time.Minute, Max: time.Hour}
+
 // Test "should signup new user and return OAuth tokens"
 func TestAuthHandler_Register_Success(t *testing.T) {
 	store := mocks.NewUserStore()
-	handler := NewAuthHandler(store, "test-secret")
+	handler := NewAuthHandler(store, mocks.NewSessionStore(), mocks.NewAccountMailer(), mocks.NewMFAManager(), testLockout, "test-secret")
 
 	reqBody := RegisterRequest{
 		Email:      "newuser@example.com",
@@ -60,7 +68,7 @@ func TestAuthHandler_Register_Success(t *testing.T) {
 // Test "should not signup with invalid password" (weak password)
 func TestAuthHandler_Register_WeakPassword(t *testing.T) {
 	store := mocks.NewUserStore()
-	handler := NewAuthHandler(store, "test-secret")
+	handler := NewAuthHandler(store, mocks.NewSessionStore(), mocks.NewAccountMailer(), mocks.NewMFAManager(), testLockout, "test-secret")
 
 	reqBody := RegisterRequest{
 		Email:    "newuser@example.com",
@@ -92,7 +100,7 @@ func TestAuthHandler_Register_WeakPassword(t *testing.T) {
 // Test "should not signup with duplicate email"
 func TestAuthHandler_Register_DuplicateEmail(t *testing.T) {
 	store := mocks.NewUserStore()
-	handler := NewAuthHandler(store, "test-secret")
+	handler := NewAuthHandler(store, mocks.NewSessionStore(), mocks.NewAccountMailer(), mocks.NewMFAManager(), testLockout, "test-secret")
 
 	// First registration succeeds
 	reqBody := RegisterRequest{
@@ -131,7 +139,7 @@ func TestAuthHandler_Register_DuplicateEmail(t *testing.T) {
 // Test "should not signup without email or password"
 func TestAuthHandler_Register_MissingFields(t *testing.T) {
 	store := mocks.NewUserStore()
-	handler := NewAuthHandler(store, "test-secret")
+	handler := NewAuthHandler(store, mocks.NewSessionStore(), mocks.NewAccountMailer(), mocks.NewMFAManager(), testLockout, "test-secret")
 
 	reqBody := RegisterRequest{
 		Email:  "",
@@ -156,10 +164,26 @@ func TestAuthHandler_Register_MissingFields(t *testing.T) {
 	}
 }
 
+// An address with a line break could add headers to the verification email
+func TestAuthHandler_Register_InvalidEmail(t *testing.T) {
+	store := mocks.NewUserStore()
+	handler := NewAuthHandler(store, mocks.NewSessionStore(), mocks.NewAccountMailer(), mocks.NewMFAManager(), testLockout, "test-secret")
+
+	for _, email := range []string{"not-an-email", "victim@example.com\r\nBcc: everyone@example.com", "Name <user@example.com>"} {
+		body, _ := json.Marshal(RegisterRequest{Email: email, Password: "SecurePass123!"})
+		w := httptest.NewRecorder()
+		handler.Register(w, httptest.NewRequest("POST", "/register", bytes.NewBuffer(body)))
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %q, got %d", email, w.Code)
+		}
+	}
+}
+
 // Test "should login existing user and return OAuth tokens"
 func TestAuthHandler_Login_Success(t *testing.T) {
 	store := mocks.NewUserStore()
-	handler := NewAuthHandler(store, "test-secret")
+	handler := NewAuthHandler(store, mocks.NewSessionStore(), mocks.NewAccountMailer(), mocks.NewMFAManager(), testLockout, "test-secret")
 
 	// Create a test user
 	email := "test@example.com"
@@ -203,7 +227,7 @@ func TestAuthHandler_Login_Success(t *testing.T) {
 // Test "should not login with invalid password"
 func TestAuthHandler_Login_InvalidPassword(t *testing.T) {
 	store := mocks.NewUserStore()
-	handler := NewAuthHandler(store, "test-secret")
+	handler := NewAuthHandler(store, mocks.NewSessionStore(), mocks.NewAccountMailer(), mocks.NewMFAManager(), testLockout, "test-secret")
 
 	// Create a test user
 	email := "test@example.com"
@@ -238,7 +262,7 @@ func TestAuthHandler_Login_InvalidPassword(t *testing.T) {
 // Test "should not login with non-existent email"
 func TestAuthHandler_Login_NonExistentEmail(t *testing.T) {
 	store := mocks.NewUserStore()
-	handler := NewAuthHandler(store, "test-secret")
+	handler := NewAuthHandler(store, mocks.NewSessionStore(), mocks.NewAccountMailer(), mocks.NewMFAManager(), testLockout, "test-secret")
 
 	reqBody := LoginRequest{
 		Email:    "nonexistent@example.com",
@@ -266,7 +290,7 @@ func TestAuthHandler_Login_NonExistentEmail(t *testing.T) {
 // Test "should not login without email or password"
 func TestAuthHandler_Login_MissingFields(t *testing.T) {
 	store := mocks.NewUserStore()
-	handler := NewAuthHandler(store, "test-secret")
+	handler := NewAuthHandler(store, mocks.NewSessionStore(), mocks.NewAccountMailer(), mocks.NewMFAManager(), testLockout, "test-secret")
 
 	reqBody := LoginRequest{}
 	body, _ := json.Marshal(reqBody)
@@ -285,7 +309,7 @@ func TestAuthHandler_Login_MissingFields(t *testing.T) {
 // Test "should not login with invalid JSON"
 func TestAuthHandler_Login_InvalidJSON(t *testing.T) {
 	store := mocks.NewUserStore()
-	handler := NewAuthHandler(store, "test-secret")
+	handler := NewAuthHandler(store, mocks.NewSessionStore(), mocks.NewAccountMailer(), mocks.NewMFAManager(), testLockout, "test-secret")
 
 	req := httptest.NewRequest("POST", "/login", bytes.NewBuffer([]byte("invalid json")))
 	req.Header.Set("Content-Type", "application/json")
@@ -303,3 +327,381 @@ func TestAuthHandler_Login_InvalidJSON(t *testing.T) {
 		t.Errorf("Expected 'Invalid request body' error, got %v", response["error"])
 	}
 }
+
+// loginForTest logs a fresh user in and returns the login response data.
+func loginForTest(t *testing.T, handler *AuthHandler, store *mocks.UserStore) map[string]interface{} {
+	t.Helper()
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("SecurePass123!"), bcrypt.MinCost)
+	store.AddUser("test@example.com", string(hashedPassword), uuid.New())
+
+	body, _ := json.Marshal(LoginRequest{Email: "test@example.com", Password: "SecurePass123!"})
+	w := httptest.NewRecorder()
+	handler.Login(w, httptest.NewRequest("POST", "/login", bytes.NewBuffer(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Login failed with %d: %s", w.Code, w.Body.String())
+	}
+
+	var response map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&response)
+	return response["data"].(map[string]interface{})
+}
+
+func refreshForTest(handler *AuthHandler, refreshToken string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(RefreshRequest{RefreshToken: refreshToken})
+	w := httptest.NewRecorder()
+	handler.Refresh(w, httptest.NewRequest("POST", "/refresh", bytes.NewBuffer(body)))
+	return w
+}
+
+func TestAuthHandler_Login_ReturnsRefreshToken(t *testing.T) {
+	store := mocks.NewUserStore()
+	sessions := mocks.NewSessionStore()
+	handler := NewAuthHandler(store, sessions, mocks.NewAccountMailer(), mocks.NewMFAManager(), testLockout, "test-secret")
+
+	data := loginForTest(t, handler, store)
+	if data["refresh_token"] == nil || data["refresh_token"] == "" {
+		t.Error("Expected refresh_token in response")
+	}
+	if data["expires_in"] != float64(accessTokenTTL.Seconds()) {
+		t.Errorf("Expected expires_in %v, got %v", accessTokenTTL.Seconds(), data["expires_in"])
+	}
+	if len(sessions.Sessions) != 1 {
+		t.Errorf("Expected 1 session, got %d", len(sessions.Sessions))
+	}
+}
+
+// A refresh token works once; replaying a rotated token kills the whole session.
+func TestAuthHandler_Refresh_RotatesAndDetectsReuse(t *testing.T) {
+	store := mocks.NewUserStore()
+	sessions := mocks.NewSessionStore()
+	handler := NewAuthHandler(store, sessions, mocks.NewAccountMailer(), mocks.NewMFAManager(), testLockout, "test-secret")
+
+	original := loginForTest(t, handler, store)["refresh_token"].(string)
+
+	w := refreshForTest(handler, original)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var response map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&response)
+	rotated := response["data"].(map[string]interface{})["refresh_token"].(string)
+	if rotated == original {
+		t.Error("Expected refresh token to rotate")
+	}
+
+	if w := refreshForTest(handler, original); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for reused token, got %d", w.Code)
+	}
+	if w := refreshForTest(handler, rotated); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected session to be revoked after reuse, got %d", w.Code)
+	}
+}
+
+func TestAuthHandler_Refresh_UnknownToken(t *testing.T) {
+	handler := NewAuthHandler(mocks.NewUserStore(), mocks.NewSessionStore(), mocks.NewAccountMailer(), mocks.NewMFAManager(), testLockout, "test-secret")
+
+	if w := refreshForTest(handler, "not-a-token"); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", w.Code)
+	}
+	if w := refreshForTest(handler, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestAuthHandler_Logout(t *testing.T) {
+	store := mocks.NewUserStore()
+	sessions := mocks.NewSessionStore()
+	handler := NewAuthHandler(store, sessions, mocks.NewAccountMailer(), mocks.NewMFAManager(), testLockout, "test-secret")
+
+	refreshToken := loginForTest(t, handler, store)["refresh_token"].(string)
+	var session models.Session
+	for _, s := range sessions.Sessions {
+		session = s.Session()
+	}
+
+	req := httptest.NewRequest("POST", "/logout", nil)
+	ctx := context.WithValue(req.Context(), "user_id", session.UserID)
+	ctx = context.WithValue(ctx, "session_id", session.ID)
+	w := httptest.NewRecorder()
+	handler.Logout(w, req.WithContext(ctx))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	if active, _ := sessions.IsSessionActive(context.Background(), session.ID); active {
+		t.Error("Expected session to be revoked")
+	}
+	if w := refreshForTest(handler, refreshToken); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected refresh after logout to fail, got %d", w.Code)
+	}
+}
+
+func TestAuthHandler_ListAndRevokeSessions(t *testing.T) {
+	store := mocks.NewUserStore()
+	sessions := mocks.NewSessionStore()
+	handler := NewAuthHandler(store, sessions, mocks.NewAccountMailer(), mocks.NewMFAManager(), testLockout, "test-secret")
+
+	userID := uuid.New()
+	current := &models.Session{UserID: userID, ExpiresAt: time.Now().Add(time.Hour)}
+	other := &models.Session{UserID: userID, ExpiresAt: time.Now().Add(time.Hour)}
+	stranger := &models.Session{UserID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)}
+	for _, s := range []*models.Session{current, other, stranger} {
+		sessions.CreateSession(context.Background(), s, uuid.NewString())
+	}
+
+	withAuth := func(req *http.Request, sessionID string) *http.Request {
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("sessionID", sessionID)
+		ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+		ctx = context.WithValue(ctx, "user_id", userID)
+		ctx = context.WithValue(ctx, "session_id", current.ID)
+		return req.WithContext(ctx)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ListSessions(w, withAuth(httptest.NewRequest("GET", "/sessions", nil), ""))
+	var response struct {
+		Data []struct {
+			ID      uuid.UUID `json:"id"`
+			Current bool      `json:"current"`
+		} `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+	if len(response.Data) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d", len(response.Data))
+	}
+	for _, s := range response.Data {
+		if s.Current != (s.ID == current.ID) {
+			t.Errorf("Session %s has current=%v", s.ID, s.Current)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	handler.RevokeSession(w, withAuth(httptest.NewRequest("DELETE", "/sessions/"+other.ID.String(), nil), other.ID.String()))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+
+	// Another user's session looks the same as one that doesn't exist
+	w = httptest.NewRecorder()
+	handler.RevokeSession(w, withAuth(httptest.NewRequest("DELETE", "/sessions/"+stranger.ID.String(), nil), stranger.ID.String()))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.RevokeSession(w, withAuth(httptest.NewRequest("DELETE", "/sessions/bad", nil), "bad"))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestAuthHandler_Register_WithInvite(t *testing.T) {
+	store := mocks.NewUserStore()
+	handler := NewAuthHandler(store, mocks.NewSessionStore(), mocks.NewAccountMailer(), mocks.NewMFAManager(), testLockout, "test-secret")
+
+	familyID := uuid.New()
+	store.Invites[hashSecretToken("invite-token")] = &models.FamilyInvite{
+		FamilyID:  familyID,
+		Email:     "spouse@example.com",
+		Role:      models.RoleMember,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	register := func(email string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(RegisterRequest{Email: email, Password: "SecurePass123!", InviteToken: "invite-token"})
+		w := httptest.NewRecorder()
+		handler.Register(w, httptest.NewRequest("POST", "/register", bytes.NewBuffer(body)))
+		return w
+	}
+
+	if w := register("someone@example.com"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected invite for another email to be rejected, got %d", w.Code)
+	}
+
+	w := register("Spouse@example.com")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	user := store.Users["Spouse@example.com"]
+	if user.FamilyID != familyID || user.Role != models.RoleMember {
+		t.Errorf("Expected user to join family %s as member, got %s as %s", familyID, user.FamilyID, user.Role)
+	}
+
+	if w := register("spouse@example.com"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected used invite to be rejected, got %d", w.Code)
+	}
+}
+
+func TestAuthHandler_AcceptInvite(t *testing.T) {
+	store := mocks.NewUserStore()
+	handler := NewAuthHandler(store, mocks.NewSessionStore(), mocks.NewAccountMailer(), mocks.NewMFAManager(), testLockout, "test-secret")
+
+	store.AddUser("spouse@example.com", "hash", uuid.New())
+	user := store.Users["spouse@example.com"]
+
+	familyID := uuid.New()
+	store.Invites[hashSecretToken("invite-token")] = &models.FamilyInvite{
+		FamilyID:  familyID,
+		Email:     "spouse@example.com",
+		Role:      models.RoleMember,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	body, _ := json.Marshal(AcceptInviteRequest{Token: "invite-token"})
+	req := httptest.NewRequest("POST", "/invites/accept", bytes.NewBuffer(body))
+	sessionID := uuid.New()
+	ctx := context.WithValue(req.Context(), "user_id", user.ID)
+	ctx = context.WithValue(ctx, "session_id", sessionID)
+	w := httptest.NewRecorder()
+	handler.AcceptInvite(w, req.WithContext(ctx))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	var response map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&response)
+	data := response["data"].(map[string]interface{})
+
+	// The new access token must carry the new family
+	token, err := jwt.Parse(data["token"].(string), func(*jwt.Token) (interface{}, error) { return []byte("test-secret"), nil })
+	if err != nil {
+		t.Fatalf("Failed to parse token: %v", err)
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	if claims["family_id"] != familyID.String() {
+		t.Errorf("Expected family_id %s in token, got %v", familyID, claims["family_id"])
+	}
+	if claims["role"] != models.RoleMember {
+		t.Errorf("Expected role %s in token, got %v", models.RoleMember, claims["role"])
+	}
+	if store.KeptSessions[user.ID] != sessionID {
+		t.Error("Expected the user's other sessions to be revoked, keeping this one")
+	}
+}
+
+func postJSON(handler http.HandlerFunc, body interface{}) *httptest.ResponseRecorder {
+	b, _ := json.Marshal(body)
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("POST", "/", bytes.NewBuffer(b)))
+	return w
+}
+
+func TestAuthHandler_PasswordReset(t *testing.T) {
+	store := mocks.NewUserStore()
+	mailer := mocks.NewAccountMailer()
+	handler := NewAuthHandler(store, mocks.NewSessionStore(), mailer, mocks.NewMFAManager(), testLockout, "test-secret")
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("OldPass123!"), bcrypt.MinCost)
+	store.AddUser("test@example.com", string(hashedPassword), uuid.New())
+
+	// Unknown emails get the same answer and no email
+	if w := postJSON(handler.ForgotPassword, ForgotPasswordRequest{Email: "nobody@example.com"}); w.Code != http.StatusAccepted {
+		t.Errorf("Expected status 202, got %d", w.Code)
+	}
+	if len(mailer.Sent) != 0 {
+		t.Fatalf("Expected no email for unknown address, got %d", len(mailer.Sent))
+	}
+
+	if w := postJSON(handler.ForgotPassword, ForgotPasswordRequest{Email: "test@example.com"}); w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d", w.Code)
+	}
+	email := mailer.Last("password_reset")
+	if email == nil || email.To != "test@example.com" {
+		t.Fatalf("Expected a password reset email, got %+v", mailer.Sent)
+	}
+
+	w := postJSON(handler.ResetPassword, ResetPasswordRequest{Token: email.Token, Password: "NewPass456!"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(store.Passwords["test@example.com"]), []byte("NewPass456!")); err != nil {
+		t.Error("Expected the new password to be stored hashed")
+	}
+
+	if w := postJSON(handler.ResetPassword, ResetPasswordRequest{Token: email.Token, Password: "Again789!"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected reused token to be rejected, got %d", w.Code)
+	}
+}
+
+func TestAuthHandler_EmailVerification(t *testing.T) {
+	store := mocks.NewUserStore()
+	mailer := mocks.NewAccountMailer()
+	handler := NewAuthHandler(store, mocks.NewSessionStore(), mailer, mocks.NewMFAManager(), testLockout, "test-secret")
+
+	w := postJSON(handler.Register, RegisterRequest{Email: "new@example.com", Password: "SecurePass123!"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+
+	email := mailer.Last("verification")
+	if email == nil || email.To != "new@example.com" {
+		t.Fatalf("Expected a verification email, got %+v", mailer.Sent)
+	}
+
+	if w := postJSON(handler.VerifyEmail, VerifyEmailRequest{Token: "wrong"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unknown token, got %d", w.Code)
+	}
+	if w := postJSON(handler.VerifyEmail, VerifyEmailRequest{Token: email.Token}); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if store.Users["new@example.com"].EmailVerifiedAt == nil {
+		t.Error("Expected email to be marked verified")
+	}
+
+	// Nothing left to resend
+	req := httptest.NewRequest("POST", "/email/resend", nil)
+	req = req.WithContext(context.WithValue(req.Context(), "user_id", store.Users["new@example.com"].ID))
+	w = httptest.NewRecorder()
+	handler.ResendEmailVerification(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d", w.Code)
+	}
+}
+
+func TestAuthHandler_Register_MailerFailure(t *testing.T) {
+	mailer := mocks.NewAccountMailer()
+	mailer.SendError = errors.New("smtp down")
+	handler := NewAuthHandler(mocks.NewUserStore(), mocks.NewSessionStore(), mailer, mocks.NewMFAManager(), testLockout, "test-secret")
+
+	if w := postJSON(handler.Register, RegisterRequest{Email: "new@example.com", Password: "SecurePass123!"}); w.Code != http.StatusCreated {
+		t.Errorf("Expected registration to succeed without email, got %d", w.Code)
+	}
+}
+
+func TestAuthHandler_Login_LocksAfterRepeatedFailures(t *testing.T) {
+	store := mocks.NewUserStore()
+	handler := NewAuthHandler(store, mocks.NewSessionStore(), mocks.NewAccountMailer(), mocks.NewMFAManager(), testLockout, "test-secret")
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("SecurePass123!"), bcrypt.MinCost)
+	store.AddUser("test@example.com", string(hashedPassword), uuid.New())
+
+	for i := 0; i < testLockout.Threshold; i++ {
+		w := postJSON(handler.Login, LoginRequest{Email: "test@example.com", Password: "wrong_password"})
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("Attempt %d: expected status 401, got %d", i+1, w.Code)
+		}
+	}
+
+	// Locked now, even with the right password
+	w := postJSON(handler.Login, LoginRequest{Email: "test@example.com", Password: "SecurePass123!"})
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "60" {
+		t.Errorf("Expected Retry-After 60, got %q", w.Header().Get("Retry-After"))
+	}
+
+	// Once the lock runs out a good password gets in and clears the count
+	past := time.Now().Add(-time.Second)
+	store.Users["test@example.com"].LockedUntil = &past
+	w = postJSON(handler.Login, LoginRequest{Email: "test@example.com", Password: "SecurePass123!"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 after the lock expired, got %d", w.Code)
+	}
+	if store.FailedLogins[store.Users["test@example.com"].ID] != 0 || store.Users["test@example.com"].LockedUntil != nil {
+		t.Error("Expected a successful login to reset the failure count")
+	}
+}
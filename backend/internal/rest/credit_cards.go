@@ -0,0 +1,164 @@
+package rest
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/statements"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultStatementCount = 6
+	maxStatementCount     = 24
+
+	defaultUpcomingDays = 30
+	maxUpcomingDays     = 365
+)
+
+type CreditCardSummary struct {
+	Balance          float64                `json:"balance"`
+	CreditLimit      float64                `json:"creditLimit"`
+	AvailableCredit  float64                `json:"availableCredit"`
+	Utilization      float64                `json:"utilization"`
+	StatementBalance float64                `json:"statementBalance"`
+	MinimumPayment   float64                `json:"minimumPayment"`
+	NextDueDate      *time.Time             `json:"nextDueDate,omitempty"`
+	CurrentPeriod    *statements.Statement  `json:"currentPeriod,omitempty"`
+	Statements       []statements.Statement `json:"statements"`
+}
+
+type UpcomingPayment struct {
+	AccountID      uuid.UUID `json:"accountId"`
+	AccountName    string    `json:"accountName"`
+	AccountType    string    `json:"accountType"`
+	DueDate        time.Time `json:"dueDate"`
+	MinimumPayment float64   `json:"minimumPayment"`
+	Balance        float64   `json:"balance"`
+}
+
+// GET /accounts/{accountID}/statements?count=6
+// Utilization and the statement balance against the current one, plus the open period
+// and the last count statements worked out from the card's entries. Cards without a
+// statement close day get the summary only.
+func (h *AccountHandler) Statements(w http.ResponseWriter, r *http.Request) {
+	count, err := queryInt(r, "count", defaultStatementCount, maxStatementCount)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid count")
+		return
+	}
+
+	acc, ok := h.typedAccount(w, r, models.AccountTypeCreditCard)
+	if !ok {
+		return
+	}
+
+	card := acc.CreditCardDetails
+	if card == nil {
+		card = &models.CreditCardDetails{}
+	}
+	now := time.Now()
+	summary := CreditCardSummary{
+		Balance:          acc.Balance,
+		CreditLimit:      card.CreditLimit,
+		Utilization:      statements.Utilization(acc.Balance, card.CreditLimit),
+		StatementBalance: card.LastStatementBalance,
+		MinimumPayment:   card.MinimumPayment,
+		Statements:       []statements.Statement{},
+	}
+	if card.CreditLimit > 0 {
+		summary.AvailableCredit = roundCents(card.CreditLimit - acc.Balance)
+	}
+	if due, ok := statements.NextDue(card, now); ok {
+		summary.NextDueDate = &due
+	}
+
+	if card.StatementCloseDay > 0 {
+		periods := statements.Periods(card.StatementCloseDay, card.PaymentDueDay, now, count)
+		entries, err := h.repo.ListEntries(r.Context(), acc.ID, periods[len(periods)-1].Start)
+		if err != nil {
+			logger.Error("DB Error (list card entries)", zap.Error(err))
+			sendError(w, http.StatusInternalServerError, "Failed to fetch statements")
+			return
+		}
+
+		built := statements.Build(periods, acc.Balance, entries)
+		summary.CurrentPeriod = &built[0]
+		summary.Statements = built[1:]
+		if len(summary.Statements) > 0 {
+			summary.StatementBalance = summary.Statements[0].ClosingBalance
+		}
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": summary})
+}
+
+// GET /accounts/upcoming_payments?days=30
+// Credit card and loan payments due in the next days days, soonest first.
+func (h *AccountHandler) UpcomingPayments(w http.ResponseWriter, r *http.Request) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+	days, err := queryInt(r, "days", defaultUpcomingDays, maxUpcomingDays)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid days")
+		return
+	}
+
+	accounts, err := h.repo.ListByFamilyID(r.Context(), familyID)
+	if err != nil {
+		logger.Error("DB Error (list accounts)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to fetch accounts")
+		return
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	cutoff := today.AddDate(0, 0, days)
+	payments := []UpcomingPayment{}
+	for _, acc := range accounts {
+		payment := UpcomingPayment{AccountID: acc.ID, AccountName: acc.Name, AccountType: acc.Type, Balance: acc.Balance}
+		switch {
+		case acc.CreditCardDetails != nil:
+			due, ok := statements.NextDue(acc.CreditCardDetails, now)
+			if !ok {
+				continue
+			}
+			payment.DueDate, payment.MinimumPayment = due, acc.CreditCardDetails.MinimumPayment
+		case acc.LoanDetails != nil && acc.LoanDetails.NextPaymentDueDate != nil:
+			payment.DueDate, payment.MinimumPayment = *acc.LoanDetails.NextPaymentDueDate, acc.LoanDetails.MinimumPayment
+		default:
+			continue
+		}
+		if payment.DueDate.Before(today) || payment.DueDate.After(cutoff) {
+			continue
+		}
+		payments = append(payments, payment)
+	}
+	sort.Slice(payments, func(i, j int) bool { return payments[i].DueDate.Before(payments[j].DueDate) })
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": payments})
+}
+
+// queryInt reads a positive integer query parameter, capped at max.
+func queryInt(r *http.Request, name string, def, max int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return 0, strconv.ErrSyntax
+	}
+	if n > max {
+		n = max
+	}
+	return n, nil
+}
@@ -0,0 +1,145 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/rest/mocks"
+)
+
+func TestAccountHandler_Statements(t *testing.T) {
+	store := mocks.NewAccountStore()
+	handler := NewAccountHandler(store)
+	user := &models.User{ID: uuid.New(), FamilyID: uuid.New()}
+
+	card := models.Account{
+		ID: uuid.New(), Name: "Visa", Type: models.AccountTypeCreditCard, Classification: "liability", Balance: 1250,
+		CreditCardDetails: &models.CreditCardDetails{CreditLimit: 5000, StatementCloseDay: 20, PaymentDueDay: 15, MinimumPayment: 35},
+	}
+	plain := models.Account{
+		ID: uuid.New(), Name: "Store Card", Type: models.AccountTypeCreditCard, Classification: "liability", Balance: 80,
+		CreditCardDetails: &models.CreditCardDetails{LastStatementBalance: 60},
+	}
+	checking := models.Account{ID: uuid.New(), Name: "Checking", Type: models.AccountTypeDepository, Classification: "asset"}
+	for _, acc := range []models.Account{card, plain, checking} {
+		store.AddAccount(user.FamilyID, acc)
+	}
+
+	// A charge in the open period, so the last statement closed 200 lower
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	store.Entries[card.ID] = []models.Entry{{AccountID: card.ID, Date: today, Amount: 200}}
+
+	request := func(id uuid.UUID, query string) *http.Request {
+		return familyRequest("GET", "/accounts/"+id.String()+"/statements"+query, nil, user, map[string]string{"accountID": id.String()})
+	}
+	decode := func(t *testing.T, w *httptest.ResponseRecorder) CreditCardSummary {
+		var resp struct {
+			Data CreditCardSummary `json:"data"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return resp.Data
+	}
+
+	t.Run("card with a statement cycle", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.Statements(w, request(card.ID, "?count=3"))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+
+		summary := decode(t, w)
+		if summary.Utilization != 25 || summary.AvailableCredit != 3750 {
+			t.Errorf("Expected 25%% utilization and 3750 available, got %v and %v", summary.Utilization, summary.AvailableCredit)
+		}
+		if len(summary.Statements) != 3 || summary.CurrentPeriod == nil {
+			t.Fatalf("Expected the open period and 3 statements, got %+v", summary)
+		}
+		if summary.CurrentPeriod.Charges != 200 {
+			t.Errorf("Expected 200 charged this period, got %v", summary.CurrentPeriod.Charges)
+		}
+		if summary.StatementBalance != 1050 {
+			t.Errorf("Expected statement balance 1050, got %v", summary.StatementBalance)
+		}
+		if summary.NextDueDate == nil {
+			t.Error("Expected a next due date")
+		}
+	})
+
+	t.Run("card without a statement cycle", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.Statements(w, request(plain.ID, ""))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		summary := decode(t, w)
+		if summary.CurrentPeriod != nil || len(summary.Statements) != 0 || summary.StatementBalance != 60 {
+			t.Errorf("Expected the reported statement balance only, got %+v", summary)
+		}
+	})
+
+	t.Run("not a card", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.Statements(w, request(checking.ID, ""))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("invalid count", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.Statements(w, request(card.ID, "?count=0"))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+}
+
+func TestAccountHandler_UpcomingPayments(t *testing.T) {
+	store := mocks.NewAccountStore()
+	handler := NewAccountHandler(store)
+	user := &models.User{ID: uuid.New(), FamilyID: uuid.New()}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	inTen, inFive, inSixty, yesterday := today.AddDate(0, 0, 10), today.AddDate(0, 0, 5), today.AddDate(0, 0, 60), today.AddDate(0, 0, -1)
+	accounts := []models.Account{
+		{ID: uuid.New(), Name: "Visa", Type: models.AccountTypeCreditCard, CreditCardDetails: &models.CreditCardDetails{NextPaymentDueDate: &inTen, MinimumPayment: 35}},
+		{ID: uuid.New(), Name: "Mortgage", Type: models.AccountTypeLoan, LoanDetails: &models.LoanDetails{NextPaymentDueDate: &inFive, MinimumPayment: 1500}},
+		{ID: uuid.New(), Name: "Car Loan", Type: models.AccountTypeLoan, LoanDetails: &models.LoanDetails{NextPaymentDueDate: &inSixty}},
+		{ID: uuid.New(), Name: "Old Loan", Type: models.AccountTypeLoan, LoanDetails: &models.LoanDetails{NextPaymentDueDate: &yesterday}},
+		{ID: uuid.New(), Name: "Checking", Type: models.AccountTypeDepository},
+	}
+	for _, acc := range accounts {
+		store.AddAccount(user.FamilyID, acc)
+	}
+
+	w := httptest.NewRecorder()
+	handler.UpcomingPayments(w, familyRequest("GET", "/accounts/upcoming_payments", nil, user, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var resp struct {
+		Data []UpcomingPayment `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if len(resp.Data) != 2 {
+		t.Fatalf("Expected 2 payments in the next 30 days, got %+v", resp.Data)
+	}
+	if resp.Data[0].AccountName != "Mortgage" || resp.Data[1].AccountName != "Visa" {
+		t.Errorf("Expected soonest first, got %s then %s", resp.Data[0].AccountName, resp.Data[1].AccountName)
+	}
+
+	w = httptest.NewRecorder()
+	handler.UpcomingPayments(w, familyRequest("GET", "/accounts/upcoming_payments?days=90", nil, user, nil))
+	json.NewDecoder(w.Body).Decode(&resp)
+	if len(resp.Data) != 3 {
+		t.Errorf("Expected 3 payments in the next 90 days, got %d", len(resp.Data))
+	}
+}
@@ -0,0 +1,244 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"go.uber.org/zap"
+)
+
+const inviteTTL = 7 * 24 * time.Hour
+
+type FamilyStore interface {
+	CreateInvite(ctx context.Context, invite *models.FamilyInvite, tokenHash string) error
+	ListPendingInvites(ctx context.Context, familyID uuid.UUID) ([]models.FamilyInvite, error)
+	RevokeInvite(ctx context.Context, familyID, inviteID uuid.UUID) error
+	ListMembers(ctx context.Context, familyID uuid.UUID) ([]models.User, error)
+	GetMember(ctx context.Context, familyID, userID uuid.UUID) (*models.User, error)
+	UpdateMemberRole(ctx context.Context, familyID, userID uuid.UUID, role string) (*models.User, error)
+}
+
+type FamilyHandler struct {
+	repo FamilyStore
+}
+
+func NewFamilyHandler(repo FamilyStore) *FamilyHandler {
+	return &FamilyHandler{repo: repo}
+}
+
+type createInviteRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type updateMemberRequest struct {
+	Role string `json:"role"`
+}
+
+// POST /family/invites
+// The token is only returned here; the invitee passes it to /auth/register or /auth/invites/accept.
+func (h *FamilyHandler) CreateInvite(w http.ResponseWriter, r *http.Request) {
+	admin, ok := h.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var req createInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req.Email = strings.TrimSpace(req.Email)
+	if req.Email == "" {
+		sendError(w, http.StatusBadRequest, "Email is required")
+		return
+	}
+	if !models.ValidEmail(req.Email) {
+		sendError(w, http.StatusBadRequest, "Invalid email address")
+		return
+	}
+	if req.Role == "" {
+		req.Role = models.RoleMember
+	}
+	if !models.ValidRole(req.Role) {
+		sendError(w, http.StatusBadRequest, "Invalid role")
+		return
+	}
+
+	token, tokenHash, err := newSecretToken()
+	if err != nil {
+		sendError(w, http.StatusInternalServerError, "Failed to create invite")
+		return
+	}
+
+	invite := &models.FamilyInvite{
+		FamilyID:  admin.FamilyID,
+		Email:     req.Email,
+		Role:      req.Role,
+		InvitedBy: &admin.ID,
+		ExpiresAt: time.Now().Add(inviteTTL),
+	}
+	if err := h.repo.CreateInvite(r.Context(), invite, tokenHash); err != nil {
+		logger.Error("DB Error (create invite)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to create invite")
+		return
+	}
+
+	sendJSON(w, http.StatusCreated, map[string]interface{}{
+		"data": map[string]interface{}{
+			"invite": invite,
+			"token":  token,
+		},
+	})
+}
+
+// GET /family/invites
+func (h *FamilyHandler) ListInvites(w http.ResponseWriter, r *http.Request) {
+	admin, ok := h.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	invites, err := h.repo.ListPendingInvites(r.Context(), admin.FamilyID)
+	if err != nil {
+		logger.Error("DB Error (list invites)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to list invites")
+		return
+	}
+	if invites == nil {
+		invites = []models.FamilyInvite{}
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": invites})
+}
+
+// DELETE /family/invites/{inviteID}
+func (h *FamilyHandler) RevokeInvite(w http.ResponseWriter, r *http.Request) {
+	admin, ok := h.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	inviteID, err := uuid.Parse(chi.URLParam(r, "inviteID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid invite ID")
+		return
+	}
+
+	if err := h.repo.RevokeInvite(r.Context(), admin.FamilyID, inviteID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			sendError(w, http.StatusNotFound, "Invite not found")
+			return
+		}
+		logger.Error("DB Error (revoke invite)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to revoke invite")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]string{"message": "Invite revoked"})
+}
+
+// GET /family/members
+func (h *FamilyHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+
+	members, err := h.repo.ListMembers(r.Context(), familyID)
+	if err != nil {
+		logger.Error("DB Error (list members)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to list members")
+		return
+	}
+	if members == nil {
+		members = []models.User{}
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": members})
+}
+
+// PUT /family/members/{userID}
+func (h *FamilyHandler) UpdateMemberRole(w http.ResponseWriter, r *http.Request) {
+	admin, ok := h.requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	memberID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var req updateMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !models.ValidRole(req.Role) {
+		sendError(w, http.StatusBadRequest, "Invalid role")
+		return
+	}
+
+	member, err := h.repo.UpdateMemberRole(r.Context(), admin.FamilyID, memberID, req.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			sendError(w, http.StatusNotFound, "Member not found")
+		case errors.Is(err, models.ErrLastAdmin):
+			sendError(w, http.StatusConflict, "Family must keep at least one admin")
+		default:
+			logger.Error("DB Error (update member role)", zap.Error(err))
+			sendError(w, http.StatusInternalServerError, "Failed to update member")
+		}
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": member})
+}
+
+// requireAdmin loads the caller and makes sure they administer their family. The role is
+// read from the database rather than the token so a demotion takes effect immediately.
+// It writes the error response itself when it returns false.
+func (h *FamilyHandler) requireAdmin(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	userID, ok := r.Context().Value("user_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "User ID missing from context")
+		return nil, false
+	}
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return nil, false
+	}
+
+	user, err := h.repo.GetMember(r.Context(), familyID, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// The user has since moved to another family
+			sendError(w, http.StatusForbidden, "Admin access required")
+		} else {
+			logger.Error("DB Error (family member)", zap.Error(err))
+			sendError(w, http.StatusInternalServerError, "Failed to load user")
+		}
+		return nil, false
+	}
+
+	if user.Role != models.RoleAdmin {
+		sendError(w, http.StatusForbidden, "Admin access required")
+		return nil, false
+	}
+	return user, true
+}
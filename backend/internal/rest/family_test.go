@@ -0,0 +1,155 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/rest/mocks"
+)
+
+func familyRequest(method, target string, body interface{}, user *models.User, params map[string]string) *http.Request {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, target, &buf)
+
+	rctx := chi.NewRouteContext()
+	for k, v := range params {
+		rctx.URLParams.Add(k, v)
+	}
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = context.WithValue(ctx, "user_id", user.ID)
+	ctx = context.WithValue(ctx, "family_id", user.FamilyID)
+	return req.WithContext(ctx)
+}
+
+func TestFamilyHandler_CreateInvite(t *testing.T) {
+	store := mocks.NewFamilyStore()
+	handler := NewFamilyHandler(store)
+
+	familyID := uuid.New()
+	admin := store.AddMember(familyID, "admin@example.com", models.RoleAdmin)
+	member := store.AddMember(familyID, "member@example.com", models.RoleMember)
+
+	t.Run("admin creates invite", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.CreateInvite(w, familyRequest("POST", "/family/invites", createInviteRequest{Email: "spouse@example.com"}, admin, nil))
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d. Body: %s", w.Code, w.Body.String())
+		}
+
+		var response struct {
+			Data struct {
+				Invite models.FamilyInvite `json:"invite"`
+				Token  string              `json:"token"`
+			} `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&response)
+
+		if response.Data.Token == "" {
+			t.Error("Expected invite token in response")
+		}
+		if response.Data.Invite.Role != models.RoleMember {
+			t.Errorf("Expected default role member, got %s", response.Data.Invite.Role)
+		}
+		if store.TokenHashes[response.Data.Invite.ID] != hashSecretToken(response.Data.Token) {
+			t.Error("Expected only the token hash to be stored")
+		}
+	})
+
+	t.Run("member cannot invite", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.CreateInvite(w, familyRequest("POST", "/family/invites", createInviteRequest{Email: "x@example.com"}, member, nil))
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403, got %d", w.Code)
+		}
+	})
+
+	t.Run("invalid role", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.CreateInvite(w, familyRequest("POST", "/family/invites", createInviteRequest{Email: "x@example.com", Role: "owner"}, admin, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+}
+
+func TestFamilyHandler_RevokeInvite(t *testing.T) {
+	store := mocks.NewFamilyStore()
+	handler := NewFamilyHandler(store)
+
+	admin := store.AddMember(uuid.New(), "admin@example.com", models.RoleAdmin)
+	invite := &models.FamilyInvite{FamilyID: admin.FamilyID, Email: "spouse@example.com", Role: models.RoleMember}
+	store.CreateInvite(context.Background(), invite, "hash")
+
+	w := httptest.NewRecorder()
+	handler.RevokeInvite(w, familyRequest("DELETE", "/", nil, admin, map[string]string{"inviteID": invite.ID.String()}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ListInvites(w, familyRequest("GET", "/family/invites", nil, admin, nil))
+	var response struct {
+		Data []models.FamilyInvite `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+	if len(response.Data) != 0 {
+		t.Errorf("Expected no pending invites, got %d", len(response.Data))
+	}
+
+	// Another family's admin can't see or revoke it
+	stranger := store.AddMember(uuid.New(), "other@example.com", models.RoleAdmin)
+	w = httptest.NewRecorder()
+	handler.RevokeInvite(w, familyRequest("DELETE", "/", nil, stranger, map[string]string{"inviteID": invite.ID.String()}))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestFamilyHandler_UpdateMemberRole(t *testing.T) {
+	store := mocks.NewFamilyStore()
+	handler := NewFamilyHandler(store)
+
+	familyID := uuid.New()
+	admin := store.AddMember(familyID, "admin@example.com", models.RoleAdmin)
+	member := store.AddMember(familyID, "member@example.com", models.RoleMember)
+
+	update := func(caller, target *models.User, role string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.UpdateMemberRole(w, familyRequest("PUT", "/", updateMemberRequest{Role: role}, caller, map[string]string{"userID": target.ID.String()}))
+		return w
+	}
+
+	if w := update(member, member, models.RoleAdmin); w.Code != http.StatusForbidden {
+		t.Errorf("Expected member self-promotion to be forbidden, got %d", w.Code)
+	}
+	if w := update(admin, admin, models.RoleMember); w.Code != http.StatusConflict {
+		t.Errorf("Expected demoting the last admin to conflict, got %d", w.Code)
+	}
+	if w := update(admin, member, models.RoleAdmin); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	if w := update(admin, admin, models.RoleMember); w.Code != http.StatusOK {
+		t.Errorf("Expected demotion with another admin to succeed, got %d", w.Code)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ListMembers(w, familyRequest("GET", "/family/members", nil, admin, nil))
+	var response struct {
+		Data []models.User `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+	if len(response.Data) != 2 {
+		t.Errorf("Expected 2 members, got %d", len(response.Data))
+	}
+}
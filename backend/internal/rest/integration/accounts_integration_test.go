@@ -6,21 +6,25 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
 	"github.com/rakibulbh/ai-finance-manager/internal/repository/postgres"
 	"github.com/rakibulbh/ai-finance-manager/internal/rest"
+	"github.com/rakibulbh/ai-finance-manager/internal/services"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestAccounts_Integration(t *testing.T) {
 	// Initialize handlers
 	userRepo := postgres.NewUserRepository(testDB)
+	sessionRepo := postgres.NewSessionRepository(testDB)
 	accountRepo := postgres.NewAccountRepository(testDB)
-	authHandler := rest.NewAuthHandler(userRepo, testCfg.JWTSecret)
+	authHandler := rest.NewAuthHandler(userRepo, sessionRepo, services.NewAccountMailer(services.NewLogMailer("", ""), ""), nil, models.LockoutPolicy{}, testCfg.JWTSecret)
 	accountHandler := rest.NewAccountHandler(accountRepo)
 
 	router := rest.NewRouter(rest.RouterConfig{
 		AuthHandler:    authHandler,
 		AccountHandler: accountHandler,
+		Sessions:       sessionRepo,
 		JWTSecret:      testCfg.JWTSecret,
 	})
 
@@ -6,18 +6,22 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
 	"github.com/rakibulbh/ai-finance-manager/internal/repository/postgres"
 	"github.com/rakibulbh/ai-finance-manager/internal/rest"
+	"github.com/rakibulbh/ai-finance-manager/internal/services"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestAuth_Integration(t *testing.T) {
 	// Initialize handlers with real repositories
 	userRepo := postgres.NewUserRepository(testDB)
-	authHandler := rest.NewAuthHandler(userRepo, testCfg.JWTSecret)
+	sessionRepo := postgres.NewSessionRepository(testDB)
+	authHandler := rest.NewAuthHandler(userRepo, sessionRepo, services.NewAccountMailer(services.NewLogMailer("", ""), ""), nil, models.LockoutPolicy{}, testCfg.JWTSecret)
 
 	router := rest.NewRouter(rest.RouterConfig{
 		AuthHandler: authHandler,
+		Sessions:    sessionRepo,
 		JWTSecret:   testCfg.JWTSecret,
 	})
 
@@ -8,16 +8,19 @@ import (
 	"testing"
 	"time"
 
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
 	"github.com/rakibulbh/ai-finance-manager/internal/repository/postgres"
 	"github.com/rakibulbh/ai-finance-manager/internal/rest"
+	"github.com/rakibulbh/ai-finance-manager/internal/services"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestInvestments_Integration(t *testing.T) {
 	userRepo := postgres.NewUserRepository(testDB)
+	sessionRepo := postgres.NewSessionRepository(testDB)
 	accountRepo := postgres.NewAccountRepository(testDB)
 	investmentRepo := postgres.NewInvestmentRepository(testDB)
-	authHandler := rest.NewAuthHandler(userRepo, testCfg.JWTSecret)
+	authHandler := rest.NewAuthHandler(userRepo, sessionRepo, services.NewAccountMailer(services.NewLogMailer("", ""), ""), nil, models.LockoutPolicy{}, testCfg.JWTSecret)
 	accountHandler := rest.NewAccountHandler(accountRepo)
 	investmentHandler := rest.NewInvestmentHandler(investmentRepo)
 
@@ -25,6 +28,7 @@ func TestInvestments_Integration(t *testing.T) {
 		AuthHandler:       authHandler,
 		AccountHandler:    accountHandler,
 		InvestmentHandler: investmentHandler,
+		Sessions:          sessionRepo,
 		JWTSecret:         testCfg.JWTSecret,
 	})
 
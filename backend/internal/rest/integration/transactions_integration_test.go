@@ -8,16 +8,19 @@ import (
 	"testing"
 	"time"
 
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
 	"github.com/rakibulbh/ai-finance-manager/internal/repository/postgres"
 	"github.com/rakibulbh/ai-finance-manager/internal/rest"
+	"github.com/rakibulbh/ai-finance-manager/internal/services"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestTransactions_Integration(t *testing.T) {
 	userRepo := postgres.NewUserRepository(testDB)
+	sessionRepo := postgres.NewSessionRepository(testDB)
 	accountRepo := postgres.NewAccountRepository(testDB)
 	ledgerRepo := postgres.NewLedgerRepository(testDB)
-	authHandler := rest.NewAuthHandler(userRepo, testCfg.JWTSecret)
+	authHandler := rest.NewAuthHandler(userRepo, sessionRepo, services.NewAccountMailer(services.NewLogMailer("", ""), ""), nil, models.LockoutPolicy{}, testCfg.JWTSecret)
 	accountHandler := rest.NewAccountHandler(accountRepo)
 	transactionHandler := rest.NewTransactionHandler(ledgerRepo)
 
@@ -25,6 +28,7 @@ func TestTransactions_Integration(t *testing.T) {
 		AuthHandler:        authHandler,
 		AccountHandler:     accountHandler,
 		TransactionHandler: transactionHandler,
+		Sessions:           sessionRepo,
 		JWTSecret:          testCfg.JWTSecret,
 	})
 
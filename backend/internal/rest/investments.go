@@ -55,12 +55,13 @@ func (h *InvestmentHandler) CreateTrade(w http.ResponseWriter, r *http.Request)
 	}
 
 	// 2. Calculate Amount
-	// Buy: -$1500 (money leaves account)
-	// Sell: +$1500 (money enters account)
+	// Buy: +$1500 (money leaves account)
+	// Sell: -$1500 (money enters account)
 	amount := req.Qty * req.Price
 	if req.Kind == "buy" || req.Kind == "" {
-		amount = -amount
 		req.Kind = "buy"
+	} else {
+		amount = -amount
 	}
 
 	// 3. Prepare Entry
@@ -0,0 +1,198 @@
+package rest
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rakibulbh/ai-finance-manager/internal/amortization"
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"go.uber.org/zap"
+)
+
+// Most extra-payment scenarios one payoff request can compare.
+const maxPayoffScenarios = 10
+
+type AmortizationResponse struct {
+	MonthlyPayment  float64                `json:"monthlyPayment"`
+	ExpectedBalance float64                `json:"expectedBalance"`
+	ActualBalance   float64                `json:"actualBalance"`
+	PayoffDate      time.Time              `json:"payoffDate"`
+	TotalInterest   float64                `json:"totalInterest"`
+	Schedule        []amortization.Payment `json:"schedule"`
+}
+
+type PayoffScenario struct {
+	amortization.Projection
+	MonthsSaved   int     `json:"monthsSaved"`
+	InterestSaved float64 `json:"interestSaved"`
+}
+
+type PayoffResponse struct {
+	Balance        float64                 `json:"balance"`
+	MonthlyPayment float64                 `json:"monthlyPayment"`
+	Baseline       amortization.Projection `json:"baseline"`
+	Scenarios      []PayoffScenario        `json:"scenarios"`
+}
+
+// GET /accounts/{accountID}/amortization
+// The loan's schedule from its original principal, rate, term and start date, with the
+// balance the schedule expects today next to the one actually owed.
+func (h *AccountHandler) Amortization(w http.ResponseWriter, r *http.Request) {
+	acc, ok := h.loanAccount(w, r)
+	if !ok {
+		return
+	}
+
+	loan := acc.LoanDetails
+	if loan.OriginalPrincipal <= 0 || loan.TermMonths <= 0 || loan.StartDate == nil {
+		sendError(w, http.StatusUnprocessableEntity, "The loan needs an original principal, term and start date")
+		return
+	}
+	schedule, err := amortization.Schedule(amortization.Loan{
+		Principal:  loan.OriginalPrincipal,
+		AnnualRate: loan.InterestRate,
+		TermMonths: loan.TermMonths,
+		Start:      *loan.StartDate,
+	})
+	if err != nil {
+		sendError(w, http.StatusUnprocessableEntity, "The loan can't be amortized")
+		return
+	}
+
+	resp := AmortizationResponse{
+		MonthlyPayment:  schedule[0].Payment,
+		ExpectedBalance: amortization.BalanceAt(schedule, loan.OriginalPrincipal, time.Now()),
+		ActualBalance:   acc.Balance,
+		PayoffDate:      schedule[len(schedule)-1].Date,
+		Schedule:        schedule,
+	}
+	for _, p := range schedule {
+		resp.TotalInterest += p.Interest
+	}
+	resp.TotalInterest = roundCents(resp.TotalInterest)
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": resp})
+}
+
+// GET /accounts/{accountID}/amortization/payoff?extra=100,250
+// Projects paying off what is owed today at the loan's monthly payment, and with each
+// extra monthly amount on top.
+func (h *AccountHandler) Payoff(w http.ResponseWriter, r *http.Request) {
+	extras, err := parseExtraPayments(r.URL.Query().Get("extra"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	acc, ok := h.loanAccount(w, r)
+	if !ok {
+		return
+	}
+
+	loan := acc.LoanDetails
+	payment := loan.MinimumPayment
+	if payment <= 0 {
+		payment = amortization.MonthlyPayment(loan.OriginalPrincipal, loan.InterestRate, loan.TermMonths)
+	}
+	if payment <= 0 {
+		sendError(w, http.StatusUnprocessableEntity, "The loan needs a minimum payment, or an original principal and term")
+		return
+	}
+
+	now := time.Now()
+	baseline, err := amortization.Project(acc.Balance, loan.InterestRate, payment, 0, now)
+	if err != nil {
+		sendError(w, http.StatusUnprocessableEntity, "The monthly payment doesn't cover the interest")
+		return
+	}
+
+	resp := PayoffResponse{
+		Balance:        acc.Balance,
+		MonthlyPayment: payment,
+		Baseline:       baseline,
+		Scenarios:      []PayoffScenario{},
+	}
+	for _, extra := range extras {
+		projection, err := amortization.Project(acc.Balance, loan.InterestRate, payment, extra, now)
+		if err != nil {
+			sendError(w, http.StatusUnprocessableEntity, "The monthly payment doesn't cover the interest")
+			return
+		}
+		resp.Scenarios = append(resp.Scenarios, PayoffScenario{
+			Projection:    projection,
+			MonthsSaved:   baseline.Months - projection.Months,
+			InterestSaved: roundCents(baseline.TotalInterest - projection.TotalInterest),
+		})
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": resp})
+}
+
+// loanAccount fetches the loan account named in the URL, writing the error response
+// itself when it returns false.
+func (h *AccountHandler) loanAccount(w http.ResponseWriter, r *http.Request) (*models.Account, bool) {
+	acc, ok := h.typedAccount(w, r, models.AccountTypeLoan)
+	if !ok {
+		return nil, false
+	}
+	if acc.LoanDetails == nil {
+		sendError(w, http.StatusUnprocessableEntity, "The loan has no details yet")
+		return nil, false
+	}
+	return acc, true
+}
+
+// typedAccount fetches the account named in the URL, which must be of accountType,
+// writing the error response itself when it returns false.
+func (h *AccountHandler) typedAccount(w http.ResponseWriter, r *http.Request, accountType string) (*models.Account, bool) {
+	familyID, accountID, ok := accountParams(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	acc, err := h.repo.GetByID(r.Context(), familyID, accountID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			sendError(w, http.StatusNotFound, "Account not found")
+			return nil, false
+		}
+		logger.Error("DB Error (get account)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to fetch account")
+		return nil, false
+	}
+	if acc.Type != accountType {
+		sendError(w, http.StatusBadRequest, "Account is not a "+strings.ReplaceAll(accountType, "_", " "))
+		return nil, false
+	}
+	return acc, true
+}
+
+// parseExtraPayments reads a comma-separated list of extra monthly payments.
+func parseExtraPayments(v string) ([]float64, error) {
+	if v == "" {
+		return nil, nil
+	}
+	parts := strings.Split(v, ",")
+	if len(parts) > maxPayoffScenarios {
+		return nil, errors.New("At most " + strconv.Itoa(maxPayoffScenarios) + " extra payments can be compared")
+	}
+	extras := make([]float64, 0, len(parts))
+	for _, part := range parts {
+		extra, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || extra < 0 || math.IsNaN(extra) || math.IsInf(extra, 0) {
+			return nil, errors.New("Invalid extra payment")
+		}
+		extras = append(extras, extra)
+	}
+	return extras, nil
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,118 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/rest/mocks"
+)
+
+func TestAccountHandler_Amortization(t *testing.T) {
+	store := mocks.NewAccountStore()
+	handler := NewAccountHandler(store)
+	user := &models.User{ID: uuid.New(), FamilyID: uuid.New()}
+
+	start := time.Now().AddDate(-1, 0, 0)
+	mortgage := models.Account{
+		ID: uuid.New(), Name: "Mortgage", Type: models.AccountTypeLoan, Classification: "liability", Balance: 197000,
+		LoanDetails: &models.LoanDetails{InterestRate: 6, TermMonths: 360, OriginalPrincipal: 200000, StartDate: &start},
+	}
+	bare := models.Account{ID: uuid.New(), Name: "Car Loan", Type: models.AccountTypeLoan, Classification: "liability", Balance: 9000}
+	checking := models.Account{ID: uuid.New(), Name: "Checking", Type: models.AccountTypeDepository, Classification: "asset"}
+	for _, acc := range []models.Account{mortgage, bare, checking} {
+		store.AddAccount(user.FamilyID, acc)
+	}
+
+	request := func(id uuid.UUID, target string) *http.Request {
+		return familyRequest("GET", "/accounts/"+id.String()+target, nil, user, map[string]string{"accountID": id.String()})
+	}
+
+	t.Run("schedule", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.Amortization(w, request(mortgage.ID, "/amortization"))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+
+		var resp struct {
+			Data AmortizationResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp)
+		if len(resp.Data.Schedule) != 360 {
+			t.Errorf("Expected 360 payments, got %d", len(resp.Data.Schedule))
+		}
+		if resp.Data.MonthlyPayment != 1199.10 {
+			t.Errorf("Expected monthly payment 1199.10, got %v", resp.Data.MonthlyPayment)
+		}
+		if resp.Data.ActualBalance != 197000 {
+			t.Errorf("Expected actual balance 197000, got %v", resp.Data.ActualBalance)
+		}
+		// Twelve payments in, a 30-year loan has paid off a little under $2,500
+		if resp.Data.ExpectedBalance < 197000 || resp.Data.ExpectedBalance > 198000 {
+			t.Errorf("Expected balance after a year around 197500, got %v", resp.Data.ExpectedBalance)
+		}
+	})
+
+	t.Run("loan without origination", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.Amortization(w, request(bare.ID, "/amortization"))
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected status 422, got %d", w.Code)
+		}
+	})
+
+	t.Run("not a loan", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.Amortization(w, request(checking.ID, "/amortization"))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("payoff with extra payments", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.Payoff(w, request(mortgage.ID, "/amortization/payoff?extra=100,500"))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+
+		var resp struct {
+			Data PayoffResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp)
+		if resp.Data.MonthlyPayment != 1199.10 {
+			t.Errorf("Expected the computed payment without a minimum payment, got %v", resp.Data.MonthlyPayment)
+		}
+		if len(resp.Data.Scenarios) != 2 {
+			t.Fatalf("Expected 2 scenarios, got %d", len(resp.Data.Scenarios))
+		}
+		small, big := resp.Data.Scenarios[0], resp.Data.Scenarios[1]
+		if small.MonthsSaved <= 0 || big.MonthsSaved <= small.MonthsSaved {
+			t.Errorf("Expected bigger extra payments to save more months, got %d and %d", small.MonthsSaved, big.MonthsSaved)
+		}
+		if big.InterestSaved <= small.InterestSaved {
+			t.Errorf("Expected bigger extra payments to save more interest, got %v and %v", small.InterestSaved, big.InterestSaved)
+		}
+	})
+
+	t.Run("payoff needs a payment", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.Payoff(w, request(bare.ID, "/amortization/payoff"))
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected status 422, got %d", w.Code)
+		}
+	})
+
+	t.Run("invalid extra payment", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.Payoff(w, request(mortgage.ID, "/amortization/payoff?extra=-5"))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,221 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"go.uber.org/zap"
+)
+
+// Audience of MFA challenge tokens. They carry no session, so AuthMiddleware rejects them.
+const mfaChallengeAudience = "mfa"
+
+// MFAManager is satisfied by *services.MFAService.
+type MFAManager interface {
+	BeginSetup(ctx context.Context, userID uuid.UUID, email string) (string, string, error)
+	Enable(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	Verify(ctx context.Context, userID uuid.UUID, code string) error
+	Disable(ctx context.Context, userID uuid.UUID, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code"`
+}
+
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+// POST /auth/mfa/verify
+// Second login step: trades the challenge token from Login plus a TOTP or recovery code
+// for a session.
+func (h *AuthHandler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	var req MFAVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.MFAToken == "" || req.Code == "" {
+		sendError(w, http.StatusBadRequest, "MFA token and code are required")
+		return
+	}
+
+	userID, err := h.parseMFAChallengeToken(req.MFAToken)
+	if err != nil {
+		sendError(w, http.StatusUnauthorized, "Invalid or expired MFA token")
+		return
+	}
+
+	user, err := h.repo.FindByID(r.Context(), userID)
+	if err != nil {
+		logger.Error("DB Error (MFA login)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	// Wrong codes count towards the same lockout as wrong passwords
+	if h.lockedOut(w, user) {
+		return
+	}
+
+	if err := h.mfa.Verify(r.Context(), userID, req.Code); err != nil {
+		if errors.Is(err, models.ErrInvalidMFACode) || errors.Is(err, models.ErrMFANotEnabled) {
+			h.recordFailedLogin(r.Context(), user)
+			sendError(w, http.StatusUnauthorized, "Invalid code")
+			return
+		}
+		logger.Error("MFA verification failed", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to verify code")
+		return
+	}
+
+	h.completeLogin(w, r, user)
+}
+
+// POST /auth/mfa/setup
+func (h *AuthHandler) SetupMFA(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "User ID missing from context")
+		return
+	}
+
+	user, err := h.repo.FindByID(r.Context(), userID)
+	if err != nil {
+		logger.Error("DB Error (MFA setup)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	secret, uri, err := h.mfa.BeginSetup(r.Context(), user.ID, user.Email)
+	if err != nil {
+		if errors.Is(err, models.ErrMFAAlreadyEnabled) {
+			sendError(w, http.StatusConflict, "Two-factor authentication is already enabled")
+			return
+		}
+		logger.Error("MFA setup failed", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to set up two-factor authentication")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]string{
+			"secret":           secret,
+			"provisioning_uri": uri,
+		},
+	})
+}
+
+// POST /auth/mfa/enable
+func (h *AuthHandler) EnableMFA(w http.ResponseWriter, r *http.Request) {
+	userID, code, ok := h.mfaCodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := h.mfa.Enable(r.Context(), userID, code)
+	if err != nil {
+		h.sendMFAError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{"recovery_codes": codes},
+	})
+}
+
+// POST /auth/mfa/disable
+func (h *AuthHandler) DisableMFA(w http.ResponseWriter, r *http.Request) {
+	userID, code, ok := h.mfaCodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.mfa.Disable(r.Context(), userID, code); err != nil {
+		h.sendMFAError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]string{"message": "Two-factor authentication disabled"})
+}
+
+// POST /auth/mfa/recovery_codes
+func (h *AuthHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userID, code, ok := h.mfaCodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := h.mfa.RegenerateRecoveryCodes(r.Context(), userID, code)
+	if err != nil {
+		h.sendMFAError(w, err)
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{"recovery_codes": codes},
+	})
+}
+
+// mfaCodeRequest reads the caller and the code from the body. It writes the error response
+// itself when it returns false.
+func (h *AuthHandler) mfaCodeRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, string, bool) {
+	userID, ok := r.Context().Value("user_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "User ID missing from context")
+		return uuid.Nil, "", false
+	}
+
+	var req MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		sendError(w, http.StatusBadRequest, "Code is required")
+		return uuid.Nil, "", false
+	}
+	return userID, req.Code, true
+}
+
+func (h *AuthHandler) sendMFAError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidMFACode):
+		sendError(w, http.StatusBadRequest, "Invalid code")
+	case errors.Is(err, models.ErrMFANotSetUp):
+		sendError(w, http.StatusBadRequest, "Start two-factor setup first")
+	case errors.Is(err, models.ErrMFANotEnabled):
+		sendError(w, http.StatusConflict, "Two-factor authentication is not enabled")
+	case errors.Is(err, models.ErrMFAAlreadyEnabled):
+		sendError(w, http.StatusConflict, "Two-factor authentication is already enabled")
+	default:
+		logger.Error("MFA error", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Two-factor authentication error")
+	}
+}
+
+func (h *AuthHandler) newMFAChallengeToken(userID uuid.UUID) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": userID.String(),
+		"aud":     mfaChallengeAudience,
+		"exp":     time.Now().Add(mfaChallengeTTL).Unix(),
+	})
+	return token.SignedString(h.jwtSecret)
+}
+
+func (h *AuthHandler) parseMFAChallengeToken(tokenString string) (uuid.UUID, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return h.jwtSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithAudience(mfaChallengeAudience), jwt.WithExpirationRequired())
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return uuid.Nil, errors.New("invalid MFA token claims")
+	}
+	userIDStr, _ := claims["user_id"].(string)
+	return uuid.Parse(userIDStr)
+}
@@ -0,0 +1,130 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/rest/mocks"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAuthHandler_MFALogin(t *testing.T) {
+	store := mocks.NewUserStore()
+	mfa := mocks.NewMFAManager()
+	handler := NewAuthHandler(store, mocks.NewSessionStore(), mocks.NewAccountMailer(), mfa, testLockout, "test-secret")
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("SecurePass123!"), bcrypt.MinCost)
+	store.AddUser("test@example.com", string(hashedPassword), uuid.New())
+	user := store.Users["test@example.com"]
+	user.MFAEnabled = true
+	mfa.Enabled[user.ID] = true
+	mfa.RecoveryCodes[user.ID] = []string{"aaaaa-bbbbb"}
+
+	w := postJSON(handler.Login, LoginRequest{Email: "test@example.com", Password: "SecurePass123!"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var response map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&response)
+	data := response["data"].(map[string]interface{})
+
+	if data["token"] != nil || data["refresh_token"] != nil {
+		t.Fatal("Expected no session before the second factor")
+	}
+	if data["mfa_required"] != true {
+		t.Fatal("Expected mfa_required")
+	}
+	challenge := data["mfa_token"].(string)
+
+	if w := postJSON(handler.VerifyMFA, MFAVerifyRequest{MFAToken: challenge, Code: "000000"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected wrong code to be rejected, got %d", w.Code)
+	}
+
+	// An access token can't stand in for the challenge token
+	accessToken, _ := handler.newAccessToken(user, uuid.New())
+	if w := postJSON(handler.VerifyMFA, MFAVerifyRequest{MFAToken: accessToken, Code: mocks.ValidMFACode}); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected access token to be rejected as MFA token, got %d", w.Code)
+	}
+
+	w = postJSON(handler.VerifyMFA, MFAVerifyRequest{MFAToken: challenge, Code: mocks.ValidMFACode})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+	if response["data"].(map[string]interface{})["refresh_token"] == nil {
+		t.Error("Expected a session after the second factor")
+	}
+
+	// Recovery codes are single use
+	if w := postJSON(handler.VerifyMFA, MFAVerifyRequest{MFAToken: challenge, Code: "aaaaa-bbbbb"}); w.Code != http.StatusOK {
+		t.Errorf("Expected recovery code to work, got %d", w.Code)
+	}
+	if w := postJSON(handler.VerifyMFA, MFAVerifyRequest{MFAToken: challenge, Code: "aaaaa-bbbbb"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected used recovery code to be rejected, got %d", w.Code)
+	}
+}
+
+func TestAuthHandler_MFAEnrollment(t *testing.T) {
+	store := mocks.NewUserStore()
+	mfa := mocks.NewMFAManager()
+	handler := NewAuthHandler(store, mocks.NewSessionStore(), mocks.NewAccountMailer(), mfa, testLockout, "test-secret")
+
+	store.AddUser("test@example.com", "hash", uuid.New())
+	user := store.Users["test@example.com"]
+
+	authed := func(handle http.HandlerFunc, body interface{}) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest("POST", "/", bytes.NewReader(b))
+		req = req.WithContext(context.WithValue(req.Context(), "user_id", user.ID))
+		w := httptest.NewRecorder()
+		handle(w, req)
+		return w
+	}
+
+	if w := authed(handler.EnableMFA, MFACodeRequest{Code: mocks.ValidMFACode}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected enabling before setup to fail, got %d", w.Code)
+	}
+
+	w := authed(handler.SetupMFA, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var setup struct {
+		Data map[string]string `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&setup)
+	if setup.Data["provisioning_uri"] == "" || setup.Data["secret"] == "" {
+		t.Errorf("Expected secret and provisioning URI, got %v", setup.Data)
+	}
+
+	if w := authed(handler.EnableMFA, MFACodeRequest{Code: "000000"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected wrong code to be rejected, got %d", w.Code)
+	}
+
+	w = authed(handler.EnableMFA, MFACodeRequest{Code: mocks.ValidMFACode})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var enabled struct {
+		Data struct {
+			RecoveryCodes []string `json:"recovery_codes"`
+		} `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&enabled)
+	if len(enabled.Data.RecoveryCodes) == 0 {
+		t.Error("Expected recovery codes")
+	}
+
+	if w := authed(handler.SetupMFA, nil); w.Code != http.StatusConflict {
+		t.Errorf("Expected setup to conflict once enabled, got %d", w.Code)
+	}
+
+	if w := authed(handler.DisableMFA, MFACodeRequest{Code: mocks.ValidMFACode}); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/audit"
+)
+
+// AuditContext attributes changes made by the request to the authenticated user, for
+// the repositories' audit events. It runs after AuthMiddleware.
+func AuditContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor := audit.Actor{
+			RequestID: middleware.GetReqID(r.Context()),
+			IPAddress: ClientIP(r),
+		}
+		if userID, ok := r.Context().Value("user_id").(uuid.UUID); ok {
+			actor.UserID = &userID
+		}
+		next.ServeHTTP(w, r.WithContext(audit.WithActor(r.Context(), actor)))
+	})
+}
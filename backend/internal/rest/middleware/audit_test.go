@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/audit"
+)
+
+func TestAuditContext(t *testing.T) {
+	userID := uuid.New()
+
+	var actor audit.Actor
+	handler := middleware.RequestID(AuditContext(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor = audit.ActorFrom(r.Context())
+	})))
+
+	req := httptest.NewRequest("POST", "/", nil)
+	req.RemoteAddr = "203.0.113.7:51234"
+	req.Header.Set(middleware.RequestIDHeader, "req-42")
+	req = req.WithContext(context.WithValue(req.Context(), "user_id", userID))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if actor.UserID == nil || *actor.UserID != userID {
+		t.Errorf("Expected actor %s, got %v", userID, actor.UserID)
+	}
+	if actor.RequestID != "req-42" {
+		t.Errorf("Expected request ID req-42, got %q", actor.RequestID)
+	}
+	if actor.IPAddress != "203.0.113.7" {
+		t.Errorf("Expected IP 203.0.113.7, got %q", actor.IPAddress)
+	}
+}
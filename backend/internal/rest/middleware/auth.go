@@ -2,16 +2,47 @@ package middleware
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"go.uber.org/zap"
 )
 
-func AuthMiddleware(jwtSecret []byte) func(http.Handler) http.Handler {
+// SessionChecker reports whether the session an access token was issued for is still live.
+type SessionChecker interface {
+    IsSessionActive(ctx context.Context, sessionID uuid.UUID) (bool, error)
+}
+
+// APIKeyAuthenticator resolves an API key hash to the user it acts for, returning
+// models.ErrAPIKeyInvalid for unknown, expired or revoked keys.
+type APIKeyAuthenticator interface {
+    AuthenticateAPIKey(ctx context.Context, keyHash string) (*models.APIKeyPrincipal, error)
+}
+
+// APIKeyHeader carries a personal API key in place of a Bearer token.
+const APIKeyHeader = "X-API-Key"
+
+// HashAPIKey is how API keys are stored and looked up.
+func HashAPIKey(key string) string {
+    sum := sha256.Sum256([]byte(key))
+    return hex.EncodeToString(sum[:])
+}
+
+func AuthMiddleware(jwtSecret []byte, sessions SessionChecker, apiKeys APIKeyAuthenticator) func(http.Handler) http.Handler {
     return func(next http.Handler) http.Handler {
         return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            if key := r.Header.Get(APIKeyHeader); key != "" {
+                authenticateAPIKey(w, r, next, apiKeys, key)
+                return
+            }
+
             authHeader := r.Header.Get("Authorization")
             if authHeader == "" {
                 sendError(w, http.StatusUnauthorized, "Authorization header missing")
@@ -65,9 +96,41 @@ func AuthMiddleware(jwtSecret []byte) func(http.Handler) http.Handler {
                     return
                 }
 
-                // Pass user_id and family_id down via context
+                role, ok := claims["role"].(string)
+                if !ok || !models.ValidRole(role) {
+                    sendError(w, http.StatusUnauthorized, "Invalid token claims")
+                    return
+                }
+
+                // Access tokens are tied to a session so they can be revoked before they expire
+                sessionIDStr, ok := claims["sid"].(string)
+                if !ok {
+                    sendError(w, http.StatusUnauthorized, "Invalid token claims")
+                    return
+                }
+
+                sessionID, err := uuid.Parse(sessionIDStr)
+                if err != nil {
+                    sendError(w, http.StatusUnauthorized, "Invalid session ID in token")
+                    return
+                }
+
+                active, err := sessions.IsSessionActive(r.Context(), sessionID)
+                if err != nil {
+                    logger.Error("DB Error (session check)", zap.Error(err))
+                    sendError(w, http.StatusInternalServerError, "Failed to verify session")
+                    return
+                }
+                if !active {
+                    sendError(w, http.StatusUnauthorized, "Session has been revoked")
+                    return
+                }
+
+                // Pass user_id, family_id, role and session_id down via context
                 ctx := context.WithValue(r.Context(), "user_id", userID)
                 ctx = context.WithValue(ctx, "family_id", familyID)
+                ctx = context.WithValue(ctx, "role", role)
+                ctx = context.WithValue(ctx, "session_id", sessionID)
                 next.ServeHTTP(w, r.WithContext(ctx))
             } else {
                 sendError(w, http.StatusUnauthorized, "Invalid token")
@@ -75,3 +138,25 @@ func AuthMiddleware(jwtSecret []byte) func(http.Handler) http.Handler {
         })
     }
 }
+
+// authenticateAPIKey sets the same context as a session token, plus "api_key_id" and
+// "scopes", which RequireScope and RequireSession look at.
+func authenticateAPIKey(w http.ResponseWriter, r *http.Request, next http.Handler, apiKeys APIKeyAuthenticator, key string) {
+    principal, err := apiKeys.AuthenticateAPIKey(r.Context(), HashAPIKey(key))
+    if errors.Is(err, models.ErrAPIKeyInvalid) {
+        sendError(w, http.StatusUnauthorized, "Invalid API key")
+        return
+    }
+    if err != nil {
+        logger.Error("DB Error (API key check)", zap.Error(err))
+        sendError(w, http.StatusInternalServerError, "Failed to verify API key")
+        return
+    }
+
+    ctx := context.WithValue(r.Context(), "user_id", principal.UserID)
+    ctx = context.WithValue(ctx, "family_id", principal.FamilyID)
+    ctx = context.WithValue(ctx, "role", principal.Role)
+    ctx = context.WithValue(ctx, "api_key_id", principal.KeyID)
+    ctx = context.WithValue(ctx, "scopes", principal.Scopes)
+    next.ServeHTTP(w, r.WithContext(ctx))
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+type apiKeyTable map[string]*models.APIKeyPrincipal
+
+func (t apiKeyTable) AuthenticateAPIKey(ctx context.Context, keyHash string) (*models.APIKeyPrincipal, error) {
+	p, ok := t[keyHash]
+	if !ok {
+		return nil, models.ErrAPIKeyInvalid
+	}
+	return p, nil
+}
+
+func TestAuthMiddleware_APIKey(t *testing.T) {
+	principal := &models.APIKeyPrincipal{
+		KeyID:    uuid.New(),
+		UserID:   uuid.New(),
+		FamilyID: uuid.New(),
+		Role:     models.RoleMember,
+		Scopes:   []string{models.ScopeReadAccounts},
+	}
+	keys := apiKeyTable{HashAPIKey("mfk_valid"): principal}
+
+	var got context.Context
+	handler := AuthMiddleware([]byte("secret"), nil, keys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Context()
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(APIKeyHeader, "mfk_valid")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if got.Value("user_id") != principal.UserID || got.Value("family_id") != principal.FamilyID || got.Value("role") != principal.Role {
+		t.Error("expected the key owner in the request context")
+	}
+	if got.Value("api_key_id") != principal.KeyID {
+		t.Error("expected api_key_id in the request context")
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(APIKeyHeader, "mfk_unknown")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for an unknown key, got %d", w.Code)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/rakibulbh/ai-finance-manager/internal/logger"
 	"go.uber.org/zap"
 )
@@ -56,6 +57,7 @@ func Logger(next http.Handler) http.Handler {
 			zap.Int("status", wrapped.Status()),
 			zap.Duration("duration", duration),
 			zap.String("user_agent", r.UserAgent()),
+			zap.String("request_id", middleware.GetReqID(r.Context())),
 		}
 
 		if wrapped.Status() >= 500 {
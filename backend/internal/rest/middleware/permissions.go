@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+type Permission string
+
+const (
+	// Create and edit accounts, transactions and trades
+	PermWrite Permission = "write"
+	// Delete or close accounts
+	PermManageAccounts Permission = "manage_accounts"
+	// Link, sync, relink and remove Plaid items
+	PermManagePlaid Permission = "manage_plaid"
+	// Invite users and change member roles
+	PermManageFamily Permission = "manage_family"
+)
+
+// Viewers (e.g. the family's accountant) can only read.
+var rolePermissions = map[string][]Permission{
+	models.RoleAdmin:  {PermWrite, PermManageAccounts, PermManagePlaid, PermManageFamily},
+	models.RoleMember: {PermWrite},
+	models.RoleViewer: {},
+}
+
+// HasPermission reports whether role grants perm. Unknown roles grant nothing.
+func HasPermission(role string, perm Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// RequirePermission rejects requests whose role (set by AuthMiddleware) lacks perm.
+func RequirePermission(perm Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, _ := r.Context().Value("role").(string)
+			if !HasPermission(role, perm) {
+				sendError(w, http.StatusForbidden, "You don't have permission to do that")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireScope limits requests made with an API key to keys granted scope. Session
+// requests aren't scoped.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scopes, isAPIKey := r.Context().Value("scopes").([]string)
+			if isAPIKey && !hasScope(scopes, scope) {
+				sendError(w, http.StatusForbidden, "API key is missing the "+scope+" scope")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSession rejects API keys, for endpoints no scope covers, such as managing
+// sessions, keys and the family.
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, isAPIKey := r.Context().Value("api_key_id").(uuid.UUID); isAPIKey {
+			sendError(w, http.StatusForbidden, "This endpoint can't be used with an API key")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		role string
+		perm Permission
+		want int
+	}{
+		{models.RoleAdmin, PermManagePlaid, http.StatusOK},
+		{models.RoleAdmin, PermManageFamily, http.StatusOK},
+		{models.RoleMember, PermWrite, http.StatusOK},
+		{models.RoleMember, PermManageAccounts, http.StatusForbidden},
+		{models.RoleMember, PermManagePlaid, http.StatusForbidden},
+		{models.RoleMember, PermManageFamily, http.StatusForbidden},
+		{models.RoleViewer, PermWrite, http.StatusForbidden},
+		{"", PermWrite, http.StatusForbidden},
+	}
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/", nil)
+		req = req.WithContext(context.WithValue(req.Context(), "role", tt.role))
+		w := httptest.NewRecorder()
+
+		RequirePermission(tt.perm)(ok).ServeHTTP(w, req)
+
+		if w.Code != tt.want {
+			t.Errorf("role %q, permission %q: expected %d, got %d", tt.role, tt.perm, tt.want, w.Code)
+		}
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	handler := RequireScope(models.ScopeWriteTransactions)(ok)
+
+	tests := []struct {
+		name   string
+		scopes interface{}
+		want   int
+	}{
+		{"session request", nil, http.StatusOK},
+		{"key with scope", []string{models.ScopeReadTransactions, models.ScopeWriteTransactions}, http.StatusOK},
+		{"key without scope", []string{models.ScopeReadTransactions}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/", nil)
+		if tt.scopes != nil {
+			req = req.WithContext(context.WithValue(req.Context(), "scopes", tt.scopes))
+		}
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if w.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, w.Code)
+		}
+	}
+}
+
+func TestRequireSession(t *testing.T) {
+	handler := RequireSession(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), "api_key_id", uuid.New()))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for an API key, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 for a session, got %d", w.Code)
+	}
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
+	"go.uber.org/zap"
+)
+
+// RateLimitStore is satisfied by *ratelimit.MemoryStore and *ratelimit.RedisStore.
+type RateLimitStore interface {
+	Allow(ctx context.Context, key string, limit int, period time.Duration) (bool, time.Duration, error)
+}
+
+// KeyFunc picks what a request is counted against. An empty key isn't limited.
+type KeyFunc func(r *http.Request) string
+
+// RateLimit allows limit requests per period for each key, answering 429 with a
+// Retry-After header once a key runs out. name keeps the counters of different limits
+// apart. If the store fails, requests are let through rather than locking everyone out.
+func RateLimit(store RateLimitStore, name string, limit int, period time.Duration, key KeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			k := key(r)
+			if k == "" || limit <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			allowed, retryAfter, err := store.Allow(r.Context(), name+":"+k, limit, period)
+			if err != nil {
+				logger.Error("Rate limit store error", zap.String("limit", name), zap.Error(err))
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !allowed {
+				TooManyRequests(w, retryAfter, "Too many requests, please try again later")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// TooManyRequests sends a 429 with Retry-After rounded up to whole seconds.
+func TooManyRequests(w http.ResponseWriter, retryAfter time.Duration, message string) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	sendError(w, http.StatusTooManyRequests, message)
+}
+
+// ClientIP is the address the request came from. Behind a proxy, TrustedProxies must
+// run first for this to be the client's address.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// JSONFieldKey keys requests on a string field of the JSON body, e.g. the email being
+// logged into. The body is restored for the handler.
+func JSONFieldKey(field string) KeyFunc {
+	return func(r *http.Request) string {
+		if r.Body == nil {
+			return ""
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return ""
+		}
+
+		var fields map[string]interface{}
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return ""
+		}
+		value, _ := fields[field].(string)
+		return strings.ToLower(strings.TrimSpace(value))
+	}
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rakibulbh/ai-finance-manager/internal/ratelimit"
+)
+
+func TestRateLimit(t *testing.T) {
+	var bodies []string
+	handler := RateLimit(ratelimit.NewMemoryStore(), "login", 2, time.Minute, JSONFieldKey("email"))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(b))
+			w.WriteHeader(http.StatusOK)
+		}))
+
+	send := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader(body)))
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := send(`{"email":"a@example.com"}`); w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i+1, w.Code)
+		}
+	}
+	if bodies[0] != `{"email":"a@example.com"}` {
+		t.Errorf("expected the handler to still read the body, got %q", bodies[0])
+	}
+
+	// Case and whitespace don't get around the limit
+	w := send(`{"email":" A@example.com "}`)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected a Retry-After header")
+	}
+
+	if w := send(`{"email":"b@example.com"}`); w.Code != http.StatusOK {
+		t.Errorf("expected another email to be counted separately, got %d", w.Code)
+	}
+	if w := send(`not json`); w.Code != http.StatusOK {
+		t.Errorf("expected requests without a key to pass, got %d", w.Code)
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Allow(ctx context.Context, key string, limit int, period time.Duration) (bool, time.Duration, error) {
+	return false, 0, errors.New("store unavailable")
+}
+
+func TestRateLimit_StoreErrorFailsOpen(t *testing.T) {
+	handler := RateLimit(failingStore{}, "ip", 1, time.Minute, ClientIP)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 when the store fails, got %d", w.Code)
+	}
+}
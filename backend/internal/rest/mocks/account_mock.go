@@ -2,14 +2,17 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/rakibulbh/ai-finance-manager/internal/models"
 )
 
 // AccountStore is a mock implementation of AccountStore for testing
 type AccountStore struct {
 	Accounts      map[uuid.UUID][]models.Account
+	Entries       map[uuid.UUID][]models.Entry // by account
 	NetWorth      map[uuid.UUID]float64
 	CreateError   error
 	ListError     error
@@ -19,6 +22,7 @@ type AccountStore struct {
 func NewAccountStore() *AccountStore {
 	return &AccountStore{
 		Accounts: make(map[uuid.UUID][]models.Account),
+		Entries:  make(map[uuid.UUID][]models.Entry),
 		NetWorth: make(map[uuid.UUID]float64),
 	}
 }
@@ -29,6 +33,7 @@ func (m *AccountStore) Create(ctx context.Context, acc *models.Account) error {
 	}
 
 	acc.ID = uuid.New()
+	acc.Status = models.AccountStatusActive
 	if m.Accounts[acc.FamilyID] == nil {
 		m.Accounts[acc.FamilyID] = []models.Account{}
 	}
@@ -60,6 +65,56 @@ func (m *AccountStore) GetNetWorth(ctx context.Context, familyID uuid.UUID) (flo
 	return m.NetWorth[familyID], nil
 }
 
+func (m *AccountStore) GetByID(ctx context.Context, familyID, accountID uuid.UUID) (*models.Account, error) {
+	for _, acc := range m.Accounts[familyID] {
+		if acc.ID == accountID {
+			return &acc, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (m *AccountStore) Update(ctx context.Context, familyID, accountID uuid.UUID, update models.AccountUpdate) (*models.Account, error) {
+	for i := range m.Accounts[familyID] {
+		acc := &m.Accounts[familyID][i]
+		if acc.ID != accountID {
+			continue
+		}
+		if update.Status != nil && *update.Status == models.AccountStatusClosed && acc.Balance != 0 {
+			return nil, models.ErrAccountBalanceNotZero
+		}
+		if !update.MatchType(acc.Type) {
+			return nil, models.ErrAccountDetailsMismatch
+		}
+		if update.Name != nil {
+			acc.Name = *update.Name
+		}
+		if update.Subtype != nil {
+			acc.Subtype = *update.Subtype
+		}
+		if update.Status != nil {
+			acc.Status = *update.Status
+		}
+		if update.PropertyDetails != nil {
+			acc.PropertyDetails = update.PropertyDetails
+		}
+		updated := *acc
+		return &updated, nil
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (m *AccountStore) Delete(ctx context.Context, familyID, accountID uuid.UUID) error {
+	accounts := m.Accounts[familyID]
+	for i, acc := range accounts {
+		if acc.ID == accountID {
+			m.Accounts[familyID] = append(accounts[:i], accounts[i+1:]...)
+			return nil
+		}
+	}
+	return pgx.ErrNoRows
+}
+
 func (m *AccountStore) AddAccount(familyID uuid.UUID, acc models.Account) {
 	if m.Accounts[familyID] == nil {
 		m.Accounts[familyID] = []models.Account{}
@@ -72,3 +127,13 @@ func (m *AccountStore) AddAccount(familyID uuid.UUID, acc models.Account) {
 		m.NetWorth[familyID] -= acc.Balance
 	}
 }
+
+func (m *AccountStore) ListEntries(ctx context.Context, accountID uuid.UUID, from time.Time) ([]models.Entry, error) {
+	var entries []models.Entry
+	for _, e := range m.Entries[accountID] {
+		if !e.Date.Before(from) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
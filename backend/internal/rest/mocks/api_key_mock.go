@@ -0,0 +1,49 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+// APIKeyStore is an in-memory implementation of APIKeyStore for testing
+type APIKeyStore struct {
+	Keys   []*models.APIKey
+	Hashes map[uuid.UUID]string // key ID -> key hash
+}
+
+func NewAPIKeyStore() *APIKeyStore {
+	return &APIKeyStore{Hashes: make(map[uuid.UUID]string)}
+}
+
+func (m *APIKeyStore) CreateAPIKey(ctx context.Context, key *models.APIKey, keyHash string) error {
+	key.ID = uuid.New()
+	key.CreatedAt = time.Now()
+	m.Keys = append(m.Keys, key)
+	m.Hashes[key.ID] = keyHash
+	return nil
+}
+
+func (m *APIKeyStore) ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	for _, k := range m.Keys {
+		if k.UserID == userID && k.RevokedAt == nil {
+			keys = append(keys, *k)
+		}
+	}
+	return keys, nil
+}
+
+func (m *APIKeyStore) RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID) error {
+	for _, k := range m.Keys {
+		if k.ID == keyID && k.UserID == userID && k.RevokedAt == nil {
+			now := time.Now()
+			k.RevokedAt = &now
+			return nil
+		}
+	}
+	return pgx.ErrNoRows
+}
@@ -0,0 +1,37 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+// AuditStore is an in-memory implementation of AuditStore for testing
+type AuditStore struct {
+	Events     []models.AuditEvent
+	LastFilter models.AuditFilter
+}
+
+func NewAuditStore() *AuditStore {
+	return &AuditStore{}
+}
+
+func (m *AuditStore) ListEvents(ctx context.Context, familyID uuid.UUID, filter models.AuditFilter) ([]models.AuditEvent, error) {
+	m.LastFilter = filter
+
+	var events []models.AuditEvent
+	for _, e := range m.Events {
+		if e.FamilyID != familyID {
+			continue
+		}
+		if filter.Action != "" && e.Action != filter.Action {
+			continue
+		}
+		if filter.ActorUserID != nil && (e.ActorUserID == nil || *e.ActorUserID != *filter.ActorUserID) {
+			continue
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
@@ -2,6 +2,8 @@ package mocks
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -9,18 +11,39 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+type UserToken struct {
+	UserID    uuid.UUID
+	Purpose   string
+	ExpiresAt time.Time
+	Used      bool
+}
+
 // UserStore is a mock implementation of UserStore for testing
 type UserStore struct {
-	Users       map[string]*models.User
-	Passwords   map[string]string
-	CreateError error
-	FindError   error
+	Users     map[string]*models.User
+	Passwords map[string]string
+	Invites   map[string]*models.FamilyInvite // keyed by token hash
+	Tokens    map[string]*UserToken           // keyed by token hash
+	// Consecutive failed logins per user
+	FailedLogins map[uuid.UUID]int
+	// SSO identities, keyed by issuer + " " + subject
+	Identities map[string]uuid.UUID
+	// The one session each user kept on accepting an invite; the rest were revoked
+	KeptSessions map[uuid.UUID]uuid.UUID
+	CreateError  error
+	FindError    error
 }
 
 func NewUserStore() *UserStore {
 	return &UserStore{
 		Users:     make(map[string]*models.User),
 		Passwords: make(map[string]string),
+		Invites:   make(map[string]*models.FamilyInvite),
+		Tokens:    make(map[string]*UserToken),
+
+		FailedLogins: make(map[uuid.UUID]int),
+		Identities:   make(map[string]uuid.UUID),
+		KeptSessions: make(map[uuid.UUID]uuid.UUID),
 	}
 }
 
@@ -70,6 +93,167 @@ func (m *UserStore) FindByEmail(ctx context.Context, email string) (*models.User
 	return user, password, nil
 }
 
+func (m *UserStore) FindByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	if m.FindError != nil {
+		return nil, m.FindError
+	}
+
+	for _, user := range m.Users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (m *UserStore) CreateUserFromInvite(ctx context.Context, tokenHash, email, password string) (*models.User, error) {
+	invite, err := m.claimInvite(tokenHash, email)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := m.CreateUser(ctx, email, password, invite.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	user.Role = invite.Role
+	return user, nil
+}
+
+func (m *UserStore) AcceptInvite(ctx context.Context, tokenHash string, userID, sessionID uuid.UUID) (*models.User, error) {
+	user, err := m.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	invite, err := m.claimInvite(tokenHash, user.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	user.FamilyID = invite.FamilyID
+	user.Role = invite.Role
+	m.KeptSessions[user.ID] = sessionID
+	return user, nil
+}
+
+func (m *UserStore) claimInvite(tokenHash, email string) (*models.FamilyInvite, error) {
+	invite, ok := m.Invites[tokenHash]
+	if !ok || invite.AcceptedAt != nil || invite.RevokedAt != nil || time.Now().After(invite.ExpiresAt) {
+		return nil, models.ErrInviteNotFound
+	}
+	if !strings.EqualFold(invite.Email, email) {
+		return nil, models.ErrInviteEmailMismatch
+	}
+
+	now := time.Now()
+	invite.AcceptedAt = &now
+	return invite, nil
+}
+
+func (m *UserStore) CreateUserToken(ctx context.Context, userID uuid.UUID, purpose, tokenHash string, expiresAt time.Time) error {
+	for hash, t := range m.Tokens {
+		if t.UserID == userID && t.Purpose == purpose && !t.Used {
+			delete(m.Tokens, hash)
+		}
+	}
+	m.Tokens[tokenHash] = &UserToken{UserID: userID, Purpose: purpose, ExpiresAt: expiresAt}
+	return nil
+}
+
+func (m *UserStore) ResetPassword(ctx context.Context, tokenHash, password string) (uuid.UUID, error) {
+	user, err := m.useToken(tokenHash, models.TokenPurposePasswordReset)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	m.Passwords[user.Email] = string(hashedPassword)
+	return user.ID, nil
+}
+
+func (m *UserStore) VerifyEmail(ctx context.Context, tokenHash string) (uuid.UUID, error) {
+	user, err := m.useToken(tokenHash, models.TokenPurposeEmailVerification)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	return user.ID, nil
+}
+
+func (m *UserStore) useToken(tokenHash, purpose string) (*models.User, error) {
+	t, ok := m.Tokens[tokenHash]
+	if !ok || t.Purpose != purpose || t.Used || time.Now().After(t.ExpiresAt) {
+		return nil, models.ErrUserTokenInvalid
+	}
+	t.Used = true
+	return m.FindByID(context.Background(), t.UserID)
+}
+
+func (m *UserStore) RecordFailedLogin(ctx context.Context, userID uuid.UUID, policy models.LockoutPolicy) (*time.Time, error) {
+	user, err := m.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.FailedLogins[userID]++
+	lock := policy.LockFor(m.FailedLogins[userID])
+	if lock == 0 {
+		return nil, nil
+	}
+	until := time.Now().Add(lock)
+	user.LockedUntil = &until
+	return &until, nil
+}
+
+func (m *UserStore) ResetFailedLogins(ctx context.Context, userID uuid.UUID) error {
+	delete(m.FailedLogins, userID)
+	for _, user := range m.Users {
+		if user.ID == userID {
+			user.LockedUntil = nil
+		}
+	}
+	return nil
+}
+
+func (m *UserStore) SignInWithIdentity(ctx context.Context, identity *models.ExternalIdentity, provisioning models.SSOProvisioning) (*models.User, error) {
+	key := identity.Issuer + " " + identity.Subject
+	if userID, ok := m.Identities[key]; ok {
+		return m.FindByID(ctx, userID)
+	}
+	if !identity.EmailVerified {
+		return nil, models.ErrIdentityEmailUnverified
+	}
+
+	user, ok := m.Users[identity.Email]
+	if !ok {
+		if !provisioning.AllowsEmail(identity.Email) {
+			return nil, models.ErrIdentityDomainNotAllowed
+		}
+		user = &models.User{ID: uuid.New(), Email: identity.Email, FamilyID: uuid.New(), Role: models.RoleAdmin}
+		if provisioning.FamilyID != nil {
+			user.FamilyID = *provisioning.FamilyID
+			user.Role = provisioning.Role
+		}
+		m.Users[identity.Email] = user
+	} else if user.EmailVerifiedAt == nil {
+		delete(m.Passwords, identity.Email)
+		user.MFAEnabled = false
+	}
+
+	now := time.Now()
+	if user.EmailVerifiedAt == nil {
+		user.EmailVerifiedAt = &now
+	}
+	m.Identities[key] = user.ID
+	return user, nil
+}
+
 func (m *UserStore) AddUser(email, hashedPassword string, familyID uuid.UUID) {
 	user := &models.User{
 		ID:       uuid.New(),
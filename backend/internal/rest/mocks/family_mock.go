@@ -0,0 +1,99 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+// FamilyStore is an in-memory implementation of FamilyStore for testing
+type FamilyStore struct {
+	Members     map[uuid.UUID]*models.User
+	Invites     []*models.FamilyInvite
+	TokenHashes map[uuid.UUID]string // invite ID -> token hash
+}
+
+func NewFamilyStore() *FamilyStore {
+	return &FamilyStore{
+		Members:     make(map[uuid.UUID]*models.User),
+		TokenHashes: make(map[uuid.UUID]string),
+	}
+}
+
+func (m *FamilyStore) AddMember(familyID uuid.UUID, email, role string) *models.User {
+	user := &models.User{ID: uuid.New(), FamilyID: familyID, Email: email, Role: role}
+	m.Members[user.ID] = user
+	return user
+}
+
+func (m *FamilyStore) CreateInvite(ctx context.Context, invite *models.FamilyInvite, tokenHash string) error {
+	invite.ID = uuid.New()
+	invite.CreatedAt = time.Now()
+	m.Invites = append(m.Invites, invite)
+	m.TokenHashes[invite.ID] = tokenHash
+	return nil
+}
+
+func (m *FamilyStore) ListPendingInvites(ctx context.Context, familyID uuid.UUID) ([]models.FamilyInvite, error) {
+	var invites []models.FamilyInvite
+	for _, i := range m.Invites {
+		if i.FamilyID == familyID && i.AcceptedAt == nil && i.RevokedAt == nil {
+			invites = append(invites, *i)
+		}
+	}
+	return invites, nil
+}
+
+func (m *FamilyStore) RevokeInvite(ctx context.Context, familyID, inviteID uuid.UUID) error {
+	for _, i := range m.Invites {
+		if i.ID == inviteID && i.FamilyID == familyID && i.AcceptedAt == nil && i.RevokedAt == nil {
+			now := time.Now()
+			i.RevokedAt = &now
+			return nil
+		}
+	}
+	return pgx.ErrNoRows
+}
+
+func (m *FamilyStore) ListMembers(ctx context.Context, familyID uuid.UUID) ([]models.User, error) {
+	var members []models.User
+	for _, u := range m.Members {
+		if u.FamilyID == familyID {
+			members = append(members, *u)
+		}
+	}
+	return members, nil
+}
+
+func (m *FamilyStore) GetMember(ctx context.Context, familyID, userID uuid.UUID) (*models.User, error) {
+	u, ok := m.Members[userID]
+	if !ok || u.FamilyID != familyID {
+		return nil, pgx.ErrNoRows
+	}
+	return u, nil
+}
+
+func (m *FamilyStore) UpdateMemberRole(ctx context.Context, familyID, userID uuid.UUID, role string) (*models.User, error) {
+	u, err := m.GetMember(ctx, familyID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Role == models.RoleAdmin && role != models.RoleAdmin {
+		otherAdmins := false
+		for _, other := range m.Members {
+			if other.FamilyID == familyID && other.ID != userID && other.Role == models.RoleAdmin {
+				otherAdmins = true
+			}
+		}
+		if !otherAdmins {
+			return nil, models.ErrLastAdmin
+		}
+	}
+
+	u.Role = role
+	return u, nil
+}
@@ -0,0 +1,45 @@
+package mocks
+
+import "context"
+
+type SentEmail struct {
+	Kind  string // "verification" or "password_reset"
+	To    string
+	Token string
+}
+
+// AccountMailer records emails instead of sending them
+type AccountMailer struct {
+	Sent      []SentEmail
+	SendError error
+}
+
+func NewAccountMailer() *AccountMailer {
+	return &AccountMailer{}
+}
+
+func (m *AccountMailer) SendEmailVerification(ctx context.Context, to, token string) error {
+	if m.SendError != nil {
+		return m.SendError
+	}
+	m.Sent = append(m.Sent, SentEmail{Kind: "verification", To: to, Token: token})
+	return nil
+}
+
+func (m *AccountMailer) SendPasswordReset(ctx context.Context, to, token string) error {
+	if m.SendError != nil {
+		return m.SendError
+	}
+	m.Sent = append(m.Sent, SentEmail{Kind: "password_reset", To: to, Token: token})
+	return nil
+}
+
+// Last returns the most recent email of the given kind, or nil.
+func (m *AccountMailer) Last(kind string) *SentEmail {
+	for i := len(m.Sent) - 1; i >= 0; i-- {
+		if m.Sent[i].Kind == kind {
+			return &m.Sent[i]
+		}
+	}
+	return nil
+}
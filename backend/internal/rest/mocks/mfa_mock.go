@@ -0,0 +1,83 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+// ValidMFACode is the only TOTP code MFAManager accepts
+const ValidMFACode = "123456"
+
+// MFAManager is a mock implementation of MFAManager for testing
+type MFAManager struct {
+	Pending       map[uuid.UUID]bool
+	Enabled       map[uuid.UUID]bool
+	RecoveryCodes map[uuid.UUID][]string
+}
+
+func NewMFAManager() *MFAManager {
+	return &MFAManager{
+		Pending:       make(map[uuid.UUID]bool),
+		Enabled:       make(map[uuid.UUID]bool),
+		RecoveryCodes: make(map[uuid.UUID][]string),
+	}
+}
+
+func (m *MFAManager) BeginSetup(ctx context.Context, userID uuid.UUID, email string) (string, string, error) {
+	if m.Enabled[userID] {
+		return "", "", models.ErrMFAAlreadyEnabled
+	}
+	m.Pending[userID] = true
+	return "JBSWY3DPEHPK3PXP", "otpauth://totp/Test:" + email + "?secret=JBSWY3DPEHPK3PXP", nil
+}
+
+func (m *MFAManager) Enable(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	if m.Enabled[userID] {
+		return nil, models.ErrMFAAlreadyEnabled
+	}
+	if !m.Pending[userID] {
+		return nil, models.ErrMFANotSetUp
+	}
+	if code != ValidMFACode {
+		return nil, models.ErrInvalidMFACode
+	}
+	m.Enabled[userID] = true
+	m.RecoveryCodes[userID] = []string{"aaaaa-bbbbb", "ccccc-ddddd"}
+	return m.RecoveryCodes[userID], nil
+}
+
+func (m *MFAManager) Verify(ctx context.Context, userID uuid.UUID, code string) error {
+	if !m.Enabled[userID] {
+		return models.ErrMFANotEnabled
+	}
+	if code == ValidMFACode {
+		return nil
+	}
+	for i, rc := range m.RecoveryCodes[userID] {
+		if rc == code {
+			m.RecoveryCodes[userID] = append(m.RecoveryCodes[userID][:i], m.RecoveryCodes[userID][i+1:]...)
+			return nil
+		}
+	}
+	return models.ErrInvalidMFACode
+}
+
+func (m *MFAManager) Disable(ctx context.Context, userID uuid.UUID, code string) error {
+	if err := m.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+	delete(m.Enabled, userID)
+	delete(m.Pending, userID)
+	delete(m.RecoveryCodes, userID)
+	return nil
+}
+
+func (m *MFAManager) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	if err := m.Verify(ctx, userID, code); err != nil {
+		return nil, err
+	}
+	m.RecoveryCodes[userID] = []string{"eeeee-fffff"}
+	return m.RecoveryCodes[userID], nil
+}
@@ -0,0 +1,150 @@
+package mocks
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+// PlaidStore is a mock implementation of the Plaid item storage for testing
+type PlaidStore struct {
+	Items       map[string]*models.PlaidItem
+	Removed     map[string]bool // item ID -> whether accounts were purged
+	SaveError   error
+	FindError   error
+	UpdateError error
+	RemoveError error
+}
+
+func NewPlaidStore() *PlaidStore {
+	return &PlaidStore{
+		Items:   make(map[string]*models.PlaidItem),
+		Removed: make(map[string]bool),
+	}
+}
+
+func (m *PlaidStore) SaveItem(ctx context.Context, item *models.PlaidItem) error {
+	if m.SaveError != nil {
+		return m.SaveError
+	}
+
+	m.Items[item.ItemID] = item
+	return nil
+}
+
+func (m *PlaidStore) GetItemsByFamily(ctx context.Context, familyID uuid.UUID) ([]models.PlaidItem, error) {
+	if m.FindError != nil {
+		return nil, m.FindError
+	}
+
+	var items []models.PlaidItem
+	for _, item := range m.Items {
+		if item.FamilyID == familyID {
+			items = append(items, *item)
+		}
+	}
+	return items, nil
+}
+
+func (m *PlaidStore) GetItemByID(ctx context.Context, itemID string) (*models.PlaidItem, error) {
+	if m.FindError != nil {
+		return nil, m.FindError
+	}
+
+	item, exists := m.Items[itemID]
+	if !exists {
+		return nil, pgx.ErrNoRows
+	}
+	return item, nil
+}
+
+func (m *PlaidStore) UpdateItemStatus(ctx context.Context, itemID string, status string) error {
+	if m.UpdateError != nil {
+		return m.UpdateError
+	}
+
+	if item, exists := m.Items[itemID]; exists {
+		item.Status = status
+	}
+	return nil
+}
+
+func (m *PlaidStore) RemoveItem(ctx context.Context, itemID string, purgeAccounts bool) error {
+	if m.RemoveError != nil {
+		return m.RemoveError
+	}
+
+	delete(m.Items, itemID)
+	m.Removed[itemID] = purgeAccounts
+	return nil
+}
+
+// PlaidManager is a mock implementation of PlaidManager for testing.
+// Tokens are "encrypted" by prefixing them so tests can assert on them.
+type PlaidManager struct {
+	RemovedTokens []string
+	LinkError     error
+	RemoveError   error
+}
+
+func (m *PlaidManager) CreateLinkToken(ctx context.Context, userID, clientName string) (string, error) {
+	if m.LinkError != nil {
+		return "", m.LinkError
+	}
+	return "link-token-" + userID, nil
+}
+
+func (m *PlaidManager) CreateUpdateLinkToken(ctx context.Context, userID, clientName, accessToken string) (string, error) {
+	if m.LinkError != nil {
+		return "", m.LinkError
+	}
+	return "update-link-token-" + accessToken, nil
+}
+
+func (m *PlaidManager) ExchangePublicToken(ctx context.Context, publicToken string) (string, string, error) {
+	return "access-" + publicToken, "item-" + publicToken, nil
+}
+
+func (m *PlaidManager) RemoveItem(ctx context.Context, accessToken string) error {
+	if m.RemoveError != nil {
+		return m.RemoveError
+	}
+	m.RemovedTokens = append(m.RemovedTokens, accessToken)
+	return nil
+}
+
+func (m *PlaidManager) EncryptToken(token string) (string, error) {
+	return "enc:" + token, nil
+}
+
+func (m *PlaidManager) DecryptToken(encryptedToken string) (string, error) {
+	return strings.TrimPrefix(encryptedToken, "enc:"), nil
+}
+
+// TaskQueue records enqueued tasks instead of sending them to Redis
+type TaskQueue struct {
+	Tasks        []*asynq.Task
+	EnqueueError error
+}
+
+func (m *TaskQueue) Enqueue(task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+	if m.EnqueueError != nil {
+		return nil, m.EnqueueError
+	}
+
+	m.Tasks = append(m.Tasks, task)
+	return &asynq.TaskInfo{Type: task.Type()}, nil
+}
+
+// WebhookVerifier accepts every webhook unless VerifyError is set
+type WebhookVerifier struct {
+	VerifyError error
+}
+
+func (m *WebhookVerifier) Verify(ctx context.Context, signedJWT string, body []byte) error {
+	return m.VerifyError
+}
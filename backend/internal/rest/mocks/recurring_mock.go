@@ -0,0 +1,44 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+// RecurringStore is a mock implementation of RecurringStore for testing
+type RecurringStore struct {
+	Recurring map[uuid.UUID][]models.RecurringTransaction // by family
+}
+
+func NewRecurringStore() *RecurringStore {
+	return &RecurringStore{Recurring: make(map[uuid.UUID][]models.RecurringTransaction)}
+}
+
+func (m *RecurringStore) List(ctx context.Context, familyID uuid.UUID, filter models.RecurringFilter) ([]models.RecurringTransaction, error) {
+	var list []models.RecurringTransaction
+	for _, r := range m.Recurring[familyID] {
+		if filter.Status == "" && r.Status == models.RecurringStatusDismissed {
+			continue
+		}
+		if filter.Status != "" && r.Status != filter.Status {
+			continue
+		}
+		list = append(list, r)
+	}
+	return list, nil
+}
+
+func (m *RecurringStore) UpdateStatus(ctx context.Context, familyID, id uuid.UUID, status string) (*models.RecurringTransaction, error) {
+	for i := range m.Recurring[familyID] {
+		r := &m.Recurring[familyID][i]
+		if r.ID == id {
+			r.Status = status
+			updated := *r
+			return &updated, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
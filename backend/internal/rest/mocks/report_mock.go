@@ -0,0 +1,74 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/reports"
+)
+
+// ReportStore is a mock implementation of ReportStore for testing
+type ReportStore struct {
+	Currency   string
+	Lines      map[uuid.UUID][]reports.Line // by family
+	LastFilter models.CashFlowFilter
+
+	Merchants map[uuid.UUID][]models.MerchantSpending // by family, largest first
+	Spending  map[uuid.UUID][]reports.CategorySpend
+	Largest   map[uuid.UUID][]models.LargeTransaction // by family, largest first
+	LastStart time.Time
+	LastEnd   time.Time
+	LastLimit int
+}
+
+func NewReportStore() *ReportStore {
+	return &ReportStore{
+		Currency:  "USD",
+		Lines:     make(map[uuid.UUID][]reports.Line),
+		Merchants: make(map[uuid.UUID][]models.MerchantSpending),
+		Spending:  make(map[uuid.UUID][]reports.CategorySpend),
+		Largest:   make(map[uuid.UUID][]models.LargeTransaction),
+	}
+}
+
+func (m *ReportStore) CashFlowLines(ctx context.Context, familyID uuid.UUID, filter models.CashFlowFilter) (string, []reports.Line, error) {
+	m.LastFilter = filter
+	var lines []reports.Line
+	for _, l := range m.Lines[familyID] {
+		if !l.Date.Before(filter.Start) && !l.Date.After(filter.End) {
+			lines = append(lines, l)
+		}
+	}
+	return m.Currency, lines, nil
+}
+
+func (m *ReportStore) TopMerchants(ctx context.Context, familyID uuid.UUID, start, end time.Time, limit int) (string, []models.MerchantSpending, error) {
+	m.LastStart, m.LastEnd, m.LastLimit = start, end, limit
+	list := m.Merchants[familyID]
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return m.Currency, list, nil
+}
+
+func (m *ReportStore) CategorySpending(ctx context.Context, familyID uuid.UUID, start, end time.Time) (string, []reports.CategorySpend, error) {
+	m.LastStart, m.LastEnd = start, end
+	var spend []reports.CategorySpend
+	for _, s := range m.Spending[familyID] {
+		if !s.Month.After(end) && !s.Month.Before(reports.PeriodStart(start, models.ReportIntervalMonth)) {
+			spend = append(spend, s)
+		}
+	}
+	return m.Currency, spend, nil
+}
+
+func (m *ReportStore) LargestTransactions(ctx context.Context, familyID uuid.UUID, start, end time.Time, limit int) (string, []models.LargeTransaction, error) {
+	m.LastStart, m.LastEnd, m.LastLimit = start, end, limit
+	list := m.Largest[familyID]
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return m.Currency, list, nil
+}
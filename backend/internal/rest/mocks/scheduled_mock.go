@@ -0,0 +1,125 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+// ScheduledStore is a mock implementation of ScheduledStore for testing
+type ScheduledStore struct {
+	Scheduled      map[uuid.UUID]*models.ScheduledTransaction
+	Occurrences    map[uuid.UUID][]models.ScheduledOccurrence // by schedule
+	Accounts       map[uuid.UUID]uuid.UUID                    // account -> family
+	LinkedAccounts map[uuid.UUID]bool
+}
+
+func NewScheduledStore() *ScheduledStore {
+	return &ScheduledStore{
+		Scheduled:      make(map[uuid.UUID]*models.ScheduledTransaction),
+		Occurrences:    make(map[uuid.UUID][]models.ScheduledOccurrence),
+		Accounts:       make(map[uuid.UUID]uuid.UUID),
+		LinkedAccounts: make(map[uuid.UUID]bool),
+	}
+}
+
+func (m *ScheduledStore) Create(ctx context.Context, s *models.ScheduledTransaction) error {
+	if m.Accounts[s.AccountID] != s.FamilyID {
+		return pgx.ErrNoRows
+	}
+	if m.LinkedAccounts[s.AccountID] {
+		return models.ErrScheduleAccountLinked
+	}
+	if s.Currency == "" {
+		s.Currency = "USD"
+	}
+	s.ID = uuid.New()
+	stored := *s
+	m.Scheduled[s.ID] = &stored
+	return nil
+}
+
+func (m *ScheduledStore) List(ctx context.Context, familyID uuid.UUID) ([]models.ScheduledTransaction, error) {
+	var list []models.ScheduledTransaction
+	for _, s := range m.Scheduled {
+		if s.FamilyID == familyID {
+			list = append(list, *s)
+		}
+	}
+	return list, nil
+}
+
+func (m *ScheduledStore) GetByID(ctx context.Context, familyID, id uuid.UUID) (*models.ScheduledTransaction, error) {
+	s, ok := m.Scheduled[id]
+	if !ok || s.FamilyID != familyID {
+		return nil, pgx.ErrNoRows
+	}
+	found := *s
+	return &found, nil
+}
+
+func (m *ScheduledStore) Update(ctx context.Context, s *models.ScheduledTransaction) error {
+	if _, err := m.GetByID(ctx, s.FamilyID, s.ID); err != nil {
+		return err
+	}
+	stored := *s
+	m.Scheduled[s.ID] = &stored
+	return nil
+}
+
+func (m *ScheduledStore) Delete(ctx context.Context, familyID, id uuid.UUID) error {
+	if _, err := m.GetByID(ctx, familyID, id); err != nil {
+		return err
+	}
+	delete(m.Scheduled, id)
+	delete(m.Occurrences, id)
+	return nil
+}
+
+func (m *ScheduledStore) ListOccurrences(ctx context.Context, scheduleID uuid.UUID, from, to time.Time) ([]models.ScheduledOccurrence, error) {
+	var list []models.ScheduledOccurrence
+	for _, o := range m.Occurrences[scheduleID] {
+		if !o.Date.Before(from) && !o.Date.After(to) {
+			list = append(list, o)
+		}
+	}
+	return list, nil
+}
+
+func (m *ScheduledStore) SetOccurrence(ctx context.Context, familyID uuid.UUID, o *models.ScheduledOccurrence) error {
+	if _, err := m.GetByID(ctx, familyID, o.ScheduledTransactionID); err != nil {
+		return err
+	}
+	occurrences := m.Occurrences[o.ScheduledTransactionID]
+	for i := range occurrences {
+		if occurrences[i].Date.Equal(o.Date) {
+			if occurrences[i].Status == models.OccurrenceStatusPosted {
+				return models.ErrOccurrencePosted
+			}
+			occurrences[i] = *o
+			return nil
+		}
+	}
+	m.Occurrences[o.ScheduledTransactionID] = append(occurrences, *o)
+	return nil
+}
+
+func (m *ScheduledStore) DeleteOccurrence(ctx context.Context, familyID, scheduleID uuid.UUID, date time.Time) error {
+	if _, err := m.GetByID(ctx, familyID, scheduleID); err != nil {
+		return err
+	}
+	occurrences := m.Occurrences[scheduleID]
+	for i := range occurrences {
+		if occurrences[i].Date.Equal(date) {
+			if occurrences[i].Status == models.OccurrenceStatusPosted {
+				return models.ErrOccurrencePosted
+			}
+			m.Occurrences[scheduleID] = append(occurrences[:i], occurrences[i+1:]...)
+			return nil
+		}
+	}
+	return pgx.ErrNoRows
+}
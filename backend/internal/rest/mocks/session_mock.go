@@ -0,0 +1,89 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+type storedSession struct {
+	session      models.Session
+	tokenHash    string
+	previousHash string
+}
+
+// SessionStore is an in-memory implementation of SessionStore for testing
+type SessionStore struct {
+	Sessions map[uuid.UUID]*storedSession
+}
+
+func NewSessionStore() *SessionStore {
+	return &SessionStore{Sessions: make(map[uuid.UUID]*storedSession)}
+}
+
+func (m *SessionStore) CreateSession(ctx context.Context, session *models.Session, tokenHash string) error {
+	now := time.Now()
+	session.ID = uuid.New()
+	session.CreatedAt = now
+	session.LastUsedAt = now
+
+	m.Sessions[session.ID] = &storedSession{session: *session, tokenHash: tokenHash}
+	return nil
+}
+
+func (m *SessionStore) RotateRefreshToken(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (*models.Session, error) {
+	for _, s := range m.Sessions {
+		if s.previousHash == oldHash && s.session.RevokedAt == nil {
+			now := time.Now()
+			s.session.RevokedAt = &now
+			return nil, models.ErrRefreshTokenReused
+		}
+		if s.tokenHash != oldHash {
+			continue
+		}
+		if s.session.RevokedAt != nil || time.Now().After(s.session.ExpiresAt) {
+			return nil, models.ErrSessionNotFound
+		}
+
+		s.previousHash = s.tokenHash
+		s.tokenHash = newHash
+		s.session.ExpiresAt = expiresAt
+		s.session.LastUsedAt = time.Now()
+		session := s.session
+		return &session, nil
+	}
+	return nil, models.ErrSessionNotFound
+}
+
+func (m *SessionStore) ListActiveSessions(ctx context.Context, userID uuid.UUID) ([]models.Session, error) {
+	var sessions []models.Session
+	for _, s := range m.Sessions {
+		if s.session.UserID == userID && s.session.RevokedAt == nil {
+			sessions = append(sessions, s.session)
+		}
+	}
+	return sessions, nil
+}
+
+func (m *SessionStore) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	s, ok := m.Sessions[sessionID]
+	if !ok || s.session.UserID != userID || s.session.RevokedAt != nil {
+		return pgx.ErrNoRows
+	}
+	now := time.Now()
+	s.session.RevokedAt = &now
+	return nil
+}
+
+func (m *SessionStore) IsSessionActive(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	s, ok := m.Sessions[sessionID]
+	return ok && s.session.RevokedAt == nil, nil
+}
+
+// Session returns a copy of the stored session.
+func (s *storedSession) Session() models.Session {
+	return s.session
+}
@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+// SSOProvider is a fake OpenID Connect provider for testing. Exchange accepts ValidSSOCode
+// with the verifier and nonce from the last AuthCodeURL, and returns Identity.
+type SSOProvider struct {
+	Identity *models.ExternalIdentity
+	last     *models.OIDCAuthRequest
+}
+
+const ValidSSOCode = "valid-code"
+
+func NewSSOProvider(identity *models.ExternalIdentity) *SSOProvider {
+	return &SSOProvider{Identity: identity}
+}
+
+func (m *SSOProvider) AuthCodeURL() (*models.OIDCAuthRequest, error) {
+	m.last = &models.OIDCAuthRequest{
+		URL:      "https://idp.example.com/authorize?state=state-1",
+		State:    "state-1",
+		Nonce:    "nonce-1",
+		Verifier: "verifier-1",
+	}
+	return m.last, nil
+}
+
+func (m *SSOProvider) Exchange(ctx context.Context, code, verifier, nonce string) (*models.ExternalIdentity, error) {
+	if m.last == nil || code != ValidSSOCode || verifier != m.last.Verifier || nonce != m.last.Nonce {
+		return nil, errors.New("invalid_grant")
+	}
+	return m.Identity, nil
+}
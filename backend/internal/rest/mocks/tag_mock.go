@@ -0,0 +1,128 @@
+package mocks
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+// TagStore is a mock implementation of TagStore for testing
+type TagStore struct {
+	Tags     map[uuid.UUID]*models.Tag
+	Entries  map[uuid.UUID]uuid.UUID          // entry -> family
+	Taggings map[uuid.UUID]map[uuid.UUID]bool // entry -> tags
+}
+
+func NewTagStore() *TagStore {
+	return &TagStore{
+		Tags:     make(map[uuid.UUID]*models.Tag),
+		Entries:  make(map[uuid.UUID]uuid.UUID),
+		Taggings: make(map[uuid.UUID]map[uuid.UUID]bool),
+	}
+}
+
+func (m *TagStore) List(ctx context.Context, familyID uuid.UUID) ([]models.Tag, error) {
+	var tags []models.Tag
+	for _, t := range m.Tags {
+		if t.FamilyID == familyID {
+			tags = append(tags, *t)
+		}
+	}
+	return tags, nil
+}
+
+func (m *TagStore) nameTaken(tag *models.Tag) bool {
+	for _, t := range m.Tags {
+		if t.FamilyID == tag.FamilyID && t.ID != tag.ID && strings.EqualFold(t.Name, tag.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *TagStore) Create(ctx context.Context, tag *models.Tag) error {
+	if m.nameTaken(tag) {
+		return models.ErrTagNameTaken
+	}
+	tag.ID = uuid.New()
+	stored := *tag
+	m.Tags[tag.ID] = &stored
+	return nil
+}
+
+func (m *TagStore) Update(ctx context.Context, tag *models.Tag) error {
+	existing, ok := m.Tags[tag.ID]
+	if !ok || existing.FamilyID != tag.FamilyID {
+		return pgx.ErrNoRows
+	}
+	if m.nameTaken(tag) {
+		return models.ErrTagNameTaken
+	}
+	stored := *tag
+	m.Tags[tag.ID] = &stored
+	return nil
+}
+
+func (m *TagStore) Delete(ctx context.Context, familyID, id uuid.UUID) error {
+	existing, ok := m.Tags[id]
+	if !ok || existing.FamilyID != familyID {
+		return pgx.ErrNoRows
+	}
+	delete(m.Tags, id)
+	for _, tags := range m.Taggings {
+		delete(tags, id)
+	}
+	return nil
+}
+
+func (m *TagStore) check(familyID uuid.UUID, tagIDs, entryIDs []uuid.UUID) error {
+	for _, id := range tagIDs {
+		if t, ok := m.Tags[id]; !ok || t.FamilyID != familyID {
+			return pgx.ErrNoRows
+		}
+	}
+	for _, id := range entryIDs {
+		if family, ok := m.Entries[id]; !ok || family != familyID {
+			return pgx.ErrNoRows
+		}
+	}
+	return nil
+}
+
+func (m *TagStore) TagEntries(ctx context.Context, familyID uuid.UUID, tagIDs, entryIDs []uuid.UUID) (int64, error) {
+	if err := m.check(familyID, tagIDs, entryIDs); err != nil {
+		return 0, err
+	}
+	var count int64
+	for _, entryID := range entryIDs {
+		if m.Taggings[entryID] == nil {
+			m.Taggings[entryID] = make(map[uuid.UUID]bool)
+		}
+		for _, tagID := range tagIDs {
+			if !m.Taggings[entryID][tagID] {
+				m.Taggings[entryID][tagID] = true
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+func (m *TagStore) UntagEntries(ctx context.Context, familyID uuid.UUID, tagIDs, entryIDs []uuid.UUID) (int64, error) {
+	if err := m.check(familyID, tagIDs, entryIDs); err != nil {
+		return 0, err
+	}
+	var count int64
+	for _, entryID := range entryIDs {
+		for _, tagID := range tagIDs {
+			if m.Taggings[entryID][tagID] {
+				delete(m.Taggings[entryID], tagID)
+				count++
+			}
+		}
+	}
+	return count, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/rakibulbh/ai-finance-manager/internal/models"
 )
 
@@ -11,6 +12,10 @@ import (
 type TransactionStore struct {
 	Merchants     map[string]uuid.UUID
 	Transactions  []models.Entry
+	Splits        map[uuid.UUID][]models.TransactionSplit // by entry
+	Transfers     map[uuid.UUID]bool
+	EntryTags     map[uuid.UUID][]models.Tag // by entry
+	LastFilter    models.TransactionFilter
 	CreateError   error
 	MerchantError error
 	TransferError error
@@ -20,9 +25,44 @@ func NewTransactionStore() *TransactionStore {
 	return &TransactionStore{
 		Merchants:    make(map[string]uuid.UUID),
 		Transactions: []models.Entry{},
+		Splits:       make(map[uuid.UUID][]models.TransactionSplit),
+		Transfers:    make(map[uuid.UUID]bool),
+		EntryTags:    make(map[uuid.UUID][]models.Tag),
 	}
 }
 
+func (m *TransactionStore) ListTransactions(ctx context.Context, familyID uuid.UUID, filter models.TransactionFilter) ([]models.TransactionDetail, error) {
+	m.LastFilter = filter
+	var list []models.TransactionDetail
+	for _, entry := range m.Transactions {
+		if filter.AccountID != nil && entry.AccountID != *filter.AccountID {
+			continue
+		}
+		if !hasTags(m.EntryTags[entry.ID], filter.TagIDs) {
+			continue
+		}
+		tags := m.EntryTags[entry.ID]
+		if tags == nil {
+			tags = []models.Tag{}
+		}
+		list = append(list, models.TransactionDetail{Entry: entry, Kind: "standard", Split: len(m.Splits[entry.ID]) > 0, Tags: tags})
+	}
+	return list, nil
+}
+
+func hasTags(tags []models.Tag, want []uuid.UUID) bool {
+	for _, id := range want {
+		found := false
+		for _, tag := range tags {
+			found = found || tag.ID == id
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
 func (m *TransactionStore) GetOrCreateMerchant(ctx context.Context, name string, familyID uuid.UUID) (uuid.UUID, error) {
 	if m.MerchantError != nil {
 		return uuid.Nil, m.MerchantError
@@ -42,8 +82,15 @@ func (m *TransactionStore) CreateTransaction(ctx context.Context, entry *models.
 		return m.CreateError
 	}
 
+	if err := models.ValidateSplits(entry.Amount, txDetail.Splits); err != nil {
+		return err
+	}
+
 	entry.ID = uuid.New()
 	m.Transactions = append(m.Transactions, *entry)
+	if len(txDetail.Splits) > 0 {
+		m.Splits[entry.ID] = txDetail.Splits
+	}
 	return nil
 }
 
@@ -55,5 +102,34 @@ func (m *TransactionStore) CreateTransfer(ctx context.Context, fromEntry, toEntr
 	fromEntry.ID = uuid.New()
 	toEntry.ID = uuid.New()
 	m.Transactions = append(m.Transactions, *fromEntry, *toEntry)
+	m.Transfers[fromEntry.ID], m.Transfers[toEntry.ID] = true, true
 	return nil
 }
+
+func (m *TransactionStore) GetSplits(ctx context.Context, familyID, entryID uuid.UUID) (*models.Entry, []models.TransactionSplit, error) {
+	for i := range m.Transactions {
+		if m.Transactions[i].ID == entryID {
+			entry := m.Transactions[i]
+			return &entry, m.Splits[entryID], nil
+		}
+	}
+	return nil, nil, pgx.ErrNoRows
+}
+
+func (m *TransactionStore) SetSplits(ctx context.Context, familyID, entryID uuid.UUID, lines []models.TransactionSplit) ([]models.TransactionSplit, error) {
+	entry, _, err := m.GetSplits(ctx, familyID, entryID)
+	if err != nil {
+		return nil, err
+	}
+	if m.Transfers[entryID] {
+		return nil, models.ErrSplitNotAllowed
+	}
+	if err := models.ValidateSplits(entry.Amount, lines); err != nil {
+		return nil, err
+	}
+	for i := range lines {
+		lines[i].ID = uuid.New()
+	}
+	m.Splits[entryID] = lines
+	return lines, nil
+}
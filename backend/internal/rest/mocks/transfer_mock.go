@@ -0,0 +1,95 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+// TransferStore is a mock implementation of TransferStore for testing
+type TransferStore struct {
+	Matches map[uuid.UUID]*models.TransferMatch
+}
+
+func NewTransferStore() *TransferStore {
+	return &TransferStore{Matches: make(map[uuid.UUID]*models.TransferMatch)}
+}
+
+func (m *TransferStore) List(ctx context.Context, familyID uuid.UUID, status string) ([]models.TransferMatch, error) {
+	var list []models.TransferMatch
+	for _, match := range m.Matches {
+		if match.FamilyID == familyID && (status == "" || match.Status == status) {
+			list = append(list, *match)
+		}
+	}
+	return list, nil
+}
+
+func (m *TransferStore) Confirm(ctx context.Context, familyID, id uuid.UUID) (*models.TransferMatch, error) {
+	match, err := m.suggested(familyID, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range m.Matches {
+		if other.Status == models.TransferMatchMatched && sharesEntry(other, match) {
+			return nil, models.ErrTransferEntryUnavailable
+		}
+	}
+	transactionID := uuid.New()
+	match.Status, match.TransactionID = models.TransferMatchMatched, &transactionID
+	for otherID, other := range m.Matches {
+		if other.Status == models.TransferMatchSuggested && sharesEntry(other, match) {
+			delete(m.Matches, otherID)
+		}
+	}
+	updated := *match
+	return &updated, nil
+}
+
+func (m *TransferStore) Reject(ctx context.Context, familyID, id uuid.UUID) (*models.TransferMatch, error) {
+	match, err := m.suggested(familyID, id)
+	if err != nil {
+		return nil, err
+	}
+	match.Status = models.TransferMatchRejected
+	updated := *match
+	return &updated, nil
+}
+
+func (m *TransferStore) Unmatch(ctx context.Context, familyID, id uuid.UUID) (*models.TransferMatch, error) {
+	match, ok := m.Matches[id]
+	if !ok || match.FamilyID != familyID {
+		return nil, pgx.ErrNoRows
+	}
+	if match.Status != models.TransferMatchMatched {
+		return nil, models.ErrTransferNotMatched
+	}
+	match.Status, match.TransactionID = models.TransferMatchRejected, nil
+	updated := *match
+	return &updated, nil
+}
+
+func (m *TransferStore) suggested(familyID, id uuid.UUID) (*models.TransferMatch, error) {
+	match, ok := m.Matches[id]
+	if !ok || match.FamilyID != familyID {
+		return nil, pgx.ErrNoRows
+	}
+	if match.Status != models.TransferMatchSuggested {
+		return nil, models.ErrTransferMatchNotSuggested
+	}
+	return match, nil
+}
+
+func sharesEntry(a, b *models.TransferMatch) bool {
+	if a.ID == b.ID {
+		return false
+	}
+	for _, x := range []uuid.UUID{a.FromEntry.ID, a.ToEntry.ID} {
+		if x == b.FromEntry.ID || x == b.ToEntry.ID {
+			return true
+		}
+	}
+	return false
+}
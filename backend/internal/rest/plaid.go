@@ -3,31 +3,47 @@ package rest
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5"
 	"github.com/rakibulbh/ai-finance-manager/internal/jobs"
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
 	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"go.uber.org/zap"
 )
 
 type PlaidManager interface {
 	CreateLinkToken(ctx context.Context, userID, clientName string) (string, error)
+	CreateUpdateLinkToken(ctx context.Context, userID, clientName, accessToken string) (string, error)
 	ExchangePublicToken(ctx context.Context, publicToken string) (string, string, error)
+	RemoveItem(ctx context.Context, accessToken string) error
 	EncryptToken(token string) (string, error)
+	DecryptToken(encryptedToken string) (string, error)
 }
 
 type PlaidDB interface {
 	SaveItem(ctx context.Context, item *models.PlaidItem) error
+	GetItemsByFamily(ctx context.Context, familyID uuid.UUID) ([]models.PlaidItem, error)
+	GetItemByID(ctx context.Context, itemID string) (*models.PlaidItem, error)
+	RemoveItem(ctx context.Context, itemID string, purgeAccounts bool) error
+}
+
+// TaskQueue is satisfied by *asynq.Client.
+type TaskQueue interface {
+	Enqueue(task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error)
 }
 
 type PlaidHandler struct {
 	manager PlaidManager
 	repo    PlaidDB
-	queue   *asynq.Client
+	queue   TaskQueue
 }
 
-func NewPlaidHandler(manager PlaidManager, repo PlaidDB, queue *asynq.Client) *PlaidHandler {
+func NewPlaidHandler(manager PlaidManager, repo PlaidDB, queue TaskQueue) *PlaidHandler {
 	return &PlaidHandler{
 		manager: manager,
 		repo:    repo,
@@ -35,7 +51,6 @@ func NewPlaidHandler(manager PlaidManager, repo PlaidDB, queue *asynq.Client) *P
 	}
 }
 
-
 func (h *PlaidHandler) CreateLinkToken(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value("user_id").(uuid.UUID)
 	if !ok {
@@ -98,15 +113,155 @@ func (h *PlaidHandler) ExchangePublicToken(w http.ResponseWriter, r *http.Reques
 	}
 
 	// 6. Enqueue initial sync
-	task, err := jobs.NewSyncAccountTask(familyID, itemID)
-	if err == nil {
-		_, err = h.queue.Enqueue(task)
-	}
-	if err != nil {
+	if err := h.enqueueItemSync(familyID, itemID); err != nil {
 		// Just log, the account is linked anyway
-		http.Error(w, "", 0) // dummy to avoid unused variable if need be, but let's just log
-		println("Failed to enqueue initial sync:", err.Error())
+		logger.Error("Failed to enqueue initial sync", zap.String("item_id", itemID), zap.Error(err))
 	}
 
 	sendJSON(w, http.StatusOK, map[string]string{"message": "Account linked successfully", "item_id": itemID})
 }
+
+// GET /plaid/items
+func (h *PlaidHandler) ListItems(w http.ResponseWriter, r *http.Request) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+
+	items, err := h.repo.GetItemsByFamily(r.Context(), familyID)
+	if err != nil {
+		sendError(w, http.StatusInternalServerError, "Failed to fetch plaid items")
+		return
+	}
+	if items == nil {
+		items = []models.PlaidItem{}
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": items})
+}
+
+// POST /plaid/items/{itemID}/sync
+func (h *PlaidHandler) SyncItem(w http.ResponseWriter, r *http.Request) {
+	item, ok := h.familyItem(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.enqueueItemSync(item.FamilyID, item.ItemID); err != nil {
+		logger.Error("Failed to enqueue manual sync", zap.String("item_id", item.ItemID), zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to enqueue sync")
+		return
+	}
+
+	sendJSON(w, http.StatusAccepted, map[string]string{"message": "Sync started", "item_id": item.ItemID})
+}
+
+// POST /plaid/items/{itemID}/update_link_token
+func (h *PlaidHandler) CreateUpdateLinkToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "User ID missing from context")
+		return
+	}
+
+	item, ok := h.familyItem(w, r)
+	if !ok {
+		return
+	}
+
+	accessToken, err := h.manager.DecryptToken(item.AccessToken)
+	if err != nil {
+		logger.Error("Failed to decrypt access token", zap.String("item_id", item.ItemID), zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to create link token")
+		return
+	}
+
+	token, err := h.manager.CreateUpdateLinkToken(r.Context(), userID.String(), "Maybe Finance", accessToken)
+	if err != nil {
+		sendError(w, http.StatusInternalServerError, "Failed to create link token")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]string{"link_token": token})
+}
+
+// DELETE /plaid/items/{itemID}?accounts=archive|delete
+func (h *PlaidHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	mode := r.URL.Query().Get("accounts")
+	if mode == "" {
+		mode = "archive"
+	}
+	if mode != "archive" && mode != "delete" {
+		sendError(w, http.StatusBadRequest, "accounts must be 'archive' or 'delete'")
+		return
+	}
+
+	item, ok := h.familyItem(w, r)
+	if !ok {
+		return
+	}
+
+	accessToken, err := h.manager.DecryptToken(item.AccessToken)
+	if err != nil {
+		logger.Error("Failed to decrypt access token", zap.String("item_id", item.ItemID), zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to remove plaid item")
+		return
+	}
+
+	if err := h.manager.RemoveItem(r.Context(), accessToken); err != nil {
+		logger.Error("Plaid item/remove failed", zap.String("item_id", item.ItemID), zap.Error(err))
+		sendError(w, http.StatusBadGateway, "Failed to remove item at Plaid")
+		return
+	}
+
+	if err := h.repo.RemoveItem(r.Context(), item.ItemID, mode == "delete"); err != nil {
+		logger.Error("DB Error (Plaid item removal)", zap.String("item_id", item.ItemID), zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to remove plaid item")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]string{"message": "Item removed", "item_id": item.ItemID})
+}
+
+// enqueueItemSync queues transactions, investments and liabilities syncs for an item.
+func (h *PlaidHandler) enqueueItemSync(familyID uuid.UUID, itemID string) error {
+	tasks, err := jobs.NewItemSyncTasks(familyID, itemID)
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		if _, err := h.queue.Enqueue(task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// familyItem loads the item named in the URL and makes sure it belongs to the caller's family.
+// It writes the error response itself when it returns false.
+func (h *PlaidHandler) familyItem(w http.ResponseWriter, r *http.Request) (*models.PlaidItem, bool) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return nil, false
+	}
+
+	item, err := h.repo.GetItemByID(r.Context(), chi.URLParam(r, "itemID"))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			sendError(w, http.StatusNotFound, "Plaid item not found")
+		} else {
+			sendError(w, http.StatusInternalServerError, "Failed to load plaid item")
+		}
+		return nil, false
+	}
+
+	// Don't reveal that another family's item exists
+	if item.FamilyID != familyID {
+		sendError(w, http.StatusNotFound, "Plaid item not found")
+		return nil, false
+	}
+
+	return item, true
+}
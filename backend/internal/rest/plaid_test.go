@@ -0,0 +1,183 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/jobs"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/rest/mocks"
+)
+
+func newPlaidTestHandler(familyID uuid.UUID) (*PlaidHandler, *mocks.PlaidManager, *mocks.PlaidStore, *mocks.TaskQueue) {
+	manager := &mocks.PlaidManager{}
+	store := mocks.NewPlaidStore()
+	store.Items["item-1"] = &models.PlaidItem{
+		FamilyID:    familyID,
+		ItemID:      "item-1",
+		AccessToken: "enc:access-1",
+		Status:      models.PlaidItemStatusLoginRequired,
+	}
+	queue := &mocks.TaskQueue{}
+	return NewPlaidHandler(manager, store, queue), manager, store, queue
+}
+
+// plaidItemRequest builds a request with auth context and the {itemID} URL param set.
+func plaidItemRequest(method, target, itemID string, familyID uuid.UUID) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("itemID", itemID)
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = context.WithValue(ctx, "user_id", uuid.New())
+	ctx = context.WithValue(ctx, "family_id", familyID)
+	return req.WithContext(ctx)
+}
+
+func TestPlaidHandler_ListItems(t *testing.T) {
+	familyID := uuid.New()
+	handler, _, store, _ := newPlaidTestHandler(familyID)
+	store.Items["other"] = &models.PlaidItem{FamilyID: uuid.New(), ItemID: "other"}
+
+	w := httptest.NewRecorder()
+	handler.ListItems(w, plaidItemRequest("GET", "/plaid/items", "", familyID))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var response struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+	if len(response.Data) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(response.Data))
+	}
+	if response.Data[0]["itemId"] != "item-1" {
+		t.Errorf("Expected item-1, got %v", response.Data[0]["itemId"])
+	}
+	if _, leaked := response.Data[0]["accessToken"]; leaked {
+		t.Error("Access token must not be serialized")
+	}
+}
+
+func TestPlaidHandler_SyncItem(t *testing.T) {
+	familyID := uuid.New()
+	handler, _, _, queue := newPlaidTestHandler(familyID)
+
+	w := httptest.NewRecorder()
+	handler.SyncItem(w, plaidItemRequest("POST", "/plaid/items/item-1/sync", "item-1", familyID))
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d", w.Code)
+	}
+	want := []string{jobs.TypeSyncAccount, jobs.TypeSyncInvestments, jobs.TypeSyncLiabilities}
+	if len(queue.Tasks) != len(want) {
+		t.Fatalf("Expected %d tasks, got %d", len(want), len(queue.Tasks))
+	}
+	for i, typ := range want {
+		if queue.Tasks[i].Type() != typ {
+			t.Errorf("Expected task %d to be %s, got %s", i, typ, queue.Tasks[i].Type())
+		}
+	}
+}
+
+func TestPlaidHandler_SyncItem_OtherFamily(t *testing.T) {
+	handler, _, _, queue := newPlaidTestHandler(uuid.New())
+
+	w := httptest.NewRecorder()
+	handler.SyncItem(w, plaidItemRequest("POST", "/plaid/items/item-1/sync", "item-1", uuid.New()))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+	if len(queue.Tasks) != 0 {
+		t.Errorf("Expected no enqueued tasks, got %d", len(queue.Tasks))
+	}
+}
+
+func TestPlaidHandler_CreateUpdateLinkToken(t *testing.T) {
+	familyID := uuid.New()
+	handler, _, _, _ := newPlaidTestHandler(familyID)
+
+	w := httptest.NewRecorder()
+	handler.CreateUpdateLinkToken(w, plaidItemRequest("POST", "/plaid/items/item-1/update_link_token", "item-1", familyID))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var response map[string]string
+	json.NewDecoder(w.Body).Decode(&response)
+	if response["link_token"] != "update-link-token-access-1" {
+		t.Errorf("Expected update-mode token for decrypted access token, got %q", response["link_token"])
+	}
+}
+
+func TestPlaidHandler_RemoveItem(t *testing.T) {
+	cases := []struct {
+		query string
+		purge bool
+	}{
+		{"", false},
+		{"?accounts=archive", false},
+		{"?accounts=delete", true},
+	}
+
+	for _, tc := range cases {
+		t.Run("mode"+tc.query, func(t *testing.T) {
+			familyID := uuid.New()
+			handler, manager, store, _ := newPlaidTestHandler(familyID)
+
+			w := httptest.NewRecorder()
+			handler.RemoveItem(w, plaidItemRequest("DELETE", "/plaid/items/item-1"+tc.query, "item-1", familyID))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+			}
+			if len(manager.RemovedTokens) != 1 || manager.RemovedTokens[0] != "access-1" {
+				t.Errorf("Expected Plaid item/remove with decrypted token, got %v", manager.RemovedTokens)
+			}
+			purged, removed := store.Removed["item-1"]
+			if !removed || purged != tc.purge {
+				t.Errorf("Expected item removed with purge=%v, got removed=%v purge=%v", tc.purge, removed, purged)
+			}
+		})
+	}
+}
+
+func TestPlaidHandler_RemoveItem_InvalidMode(t *testing.T) {
+	familyID := uuid.New()
+	handler, manager, _, _ := newPlaidTestHandler(familyID)
+
+	w := httptest.NewRecorder()
+	handler.RemoveItem(w, plaidItemRequest("DELETE", "/plaid/items/item-1?accounts=keep", "item-1", familyID))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+	if len(manager.RemovedTokens) != 0 {
+		t.Error("Plaid should not be called for an invalid request")
+	}
+}
+
+func TestPlaidHandler_RemoveItem_PlaidFailureKeepsItem(t *testing.T) {
+	familyID := uuid.New()
+	handler, manager, store, _ := newPlaidTestHandler(familyID)
+	manager.RemoveError = errors.New("plaid down")
+
+	w := httptest.NewRecorder()
+	handler.RemoveItem(w, plaidItemRequest("DELETE", "/plaid/items/item-1", "item-1", familyID))
+
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("Expected status 502, got %d", w.Code)
+	}
+	if _, exists := store.Items["item-1"]; !exists {
+		t.Error("Item should be kept when Plaid removal fails")
+	}
+}
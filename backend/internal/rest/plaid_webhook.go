@@ -0,0 +1,150 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5"
+	"github.com/rakibulbh/ai-finance-manager/internal/jobs"
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"go.uber.org/zap"
+)
+
+// Plaid webhook bodies are small; anything bigger is not from Plaid.
+const maxWebhookBodySize = 1 << 20
+
+type WebhookVerifier interface {
+	Verify(ctx context.Context, signedJWT string, body []byte) error
+}
+
+type PlaidWebhookStore interface {
+	GetItemByID(ctx context.Context, itemID string) (*models.PlaidItem, error)
+	UpdateItemStatus(ctx context.Context, itemID string, status string) error
+}
+
+type PlaidWebhookHandler struct {
+	verifier WebhookVerifier
+	repo     PlaidWebhookStore
+	queue    TaskQueue
+}
+
+func NewPlaidWebhookHandler(verifier WebhookVerifier, repo PlaidWebhookStore, queue TaskQueue) *PlaidWebhookHandler {
+	return &PlaidWebhookHandler{
+		verifier: verifier,
+		repo:     repo,
+		queue:    queue,
+	}
+}
+
+type plaidWebhookError struct {
+	ErrorCode string `json:"error_code"`
+}
+
+type PlaidWebhook struct {
+	WebhookType string             `json:"webhook_type"`
+	WebhookCode string             `json:"webhook_code"`
+	ItemID      string             `json:"item_id"`
+	Error       *plaidWebhookError `json:"error,omitempty"`
+}
+
+// POST /plaid/webhook
+func (h *PlaidWebhookHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodySize))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if err := h.verifier.Verify(r.Context(), r.Header.Get("Plaid-Verification"), body); err != nil {
+		logger.Warn("Rejected plaid webhook", zap.Error(err))
+		sendError(w, http.StatusUnauthorized, "Invalid webhook signature")
+		return
+	}
+
+	var hook PlaidWebhook
+	if err := json.Unmarshal(body, &hook); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	item, err := h.repo.GetItemByID(r.Context(), hook.ItemID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Nothing to do for an item we don't know about; don't make Plaid retry.
+			logger.Warn("Plaid webhook for unknown item", zap.String("item_id", hook.ItemID))
+			sendJSON(w, http.StatusOK, map[string]string{"message": "ignored"})
+			return
+		}
+		logger.Error("DB Error (Plaid webhook)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to load plaid item")
+		return
+	}
+
+	switch {
+	case hook.WebhookType == "ITEM":
+		status := itemStatusForWebhook(hook)
+		if status == "" {
+			break
+		}
+		if err := h.repo.UpdateItemStatus(r.Context(), item.ItemID, status); err != nil {
+			logger.Error("DB Error (Plaid item status)", zap.Error(err))
+			sendError(w, http.StatusInternalServerError, "Failed to update plaid item")
+			return
+		}
+
+	default:
+		newTask := syncTaskForWebhook(hook)
+		if newTask == nil {
+			break
+		}
+		task, err := newTask(item.FamilyID, item.ItemID)
+		if err == nil {
+			_, err = h.queue.Enqueue(task)
+		}
+		if err != nil {
+			logger.Error("Failed to enqueue webhook sync", zap.String("item_id", item.ItemID), zap.Error(err))
+			sendError(w, http.StatusInternalServerError, "Failed to enqueue sync")
+			return
+		}
+	}
+
+	sendJSON(w, http.StatusOK, map[string]string{"message": "ok"})
+}
+
+// syncTaskForWebhook returns the constructor for the sync task a product webhook calls for, or nil.
+func syncTaskForWebhook(hook PlaidWebhook) func(uuid.UUID, string) (*asynq.Task, error) {
+	switch {
+	case hook.WebhookType == "TRANSACTIONS" && hook.WebhookCode == "SYNC_UPDATES_AVAILABLE":
+		return jobs.NewSyncAccountTask
+	case hook.WebhookType == "HOLDINGS" && hook.WebhookCode == "DEFAULT_UPDATE",
+		hook.WebhookType == "INVESTMENTS_TRANSACTIONS" && hook.WebhookCode == "DEFAULT_UPDATE":
+		return jobs.NewSyncInvestmentsTask
+	case hook.WebhookType == "LIABILITIES" && hook.WebhookCode == "DEFAULT_UPDATE":
+		return jobs.NewSyncLiabilitiesTask
+	}
+	return nil
+}
+
+// itemStatusForWebhook maps an ITEM webhook to the status we store, or "" if it doesn't change it.
+func itemStatusForWebhook(hook PlaidWebhook) string {
+	switch hook.WebhookCode {
+	case "ERROR":
+		if hook.Error != nil && hook.Error.ErrorCode == "ITEM_LOGIN_REQUIRED" {
+			return models.PlaidItemStatusLoginRequired
+		}
+		return models.PlaidItemStatusError
+	case "PENDING_EXPIRATION", "PENDING_DISCONNECT":
+		return models.PlaidItemStatusPendingExpiration
+	case "USER_PERMISSION_REVOKED", "USER_ACCOUNT_REVOKED":
+		return models.PlaidItemStatusRevoked
+	case "LOGIN_REPAIRED":
+		return models.PlaidItemStatusActive
+	}
+	return ""
+}
@@ -0,0 +1,156 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/jobs"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/rest/mocks"
+)
+
+func newWebhookTestHandler() (*PlaidWebhookHandler, *mocks.PlaidStore, *mocks.TaskQueue, *mocks.WebhookVerifier) {
+	store := mocks.NewPlaidStore()
+	store.Items["item-1"] = &models.PlaidItem{
+		FamilyID: uuid.New(),
+		ItemID:   "item-1",
+		Status:   models.PlaidItemStatusActive,
+	}
+	queue := &mocks.TaskQueue{}
+	verifier := &mocks.WebhookVerifier{}
+	return NewPlaidWebhookHandler(verifier, store, queue), store, queue, verifier
+}
+
+func postWebhook(handler *PlaidWebhookHandler, hook PlaidWebhook) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(hook)
+	req := httptest.NewRequest("POST", "/plaid/webhook", bytes.NewBuffer(body))
+	req.Header.Set("Plaid-Verification", "signed-jwt")
+	w := httptest.NewRecorder()
+	handler.Handle(w, req)
+	return w
+}
+
+func TestPlaidWebhookHandler_SyncUpdatesAvailable(t *testing.T) {
+	handler, store, queue, _ := newWebhookTestHandler()
+
+	w := postWebhook(handler, PlaidWebhook{WebhookType: "TRANSACTIONS", WebhookCode: "SYNC_UPDATES_AVAILABLE", ItemID: "item-1"})
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if len(queue.Tasks) != 1 {
+		t.Fatalf("Expected 1 enqueued task, got %d", len(queue.Tasks))
+	}
+	if queue.Tasks[0].Type() != jobs.TypeSyncAccount {
+		t.Errorf("Expected task type %s, got %s", jobs.TypeSyncAccount, queue.Tasks[0].Type())
+	}
+
+	var payload jobs.SyncAccountPayload
+	json.Unmarshal(queue.Tasks[0].Payload(), &payload)
+	if payload.FamilyID != store.Items["item-1"].FamilyID {
+		t.Errorf("Expected payload family %s, got %s", store.Items["item-1"].FamilyID, payload.FamilyID)
+	}
+}
+
+func TestPlaidWebhookHandler_ProductUpdates(t *testing.T) {
+	cases := []struct {
+		hook     PlaidWebhook
+		taskType string
+	}{
+		{PlaidWebhook{WebhookType: "HOLDINGS", WebhookCode: "DEFAULT_UPDATE"}, jobs.TypeSyncInvestments},
+		{PlaidWebhook{WebhookType: "INVESTMENTS_TRANSACTIONS", WebhookCode: "DEFAULT_UPDATE"}, jobs.TypeSyncInvestments},
+		{PlaidWebhook{WebhookType: "LIABILITIES", WebhookCode: "DEFAULT_UPDATE"}, jobs.TypeSyncLiabilities},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.hook.WebhookType, func(t *testing.T) {
+			handler, _, queue, _ := newWebhookTestHandler()
+			tc.hook.ItemID = "item-1"
+
+			w := postWebhook(handler, tc.hook)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", w.Code)
+			}
+			if len(queue.Tasks) != 1 || queue.Tasks[0].Type() != tc.taskType {
+				t.Errorf("Expected one %s task, got %v", tc.taskType, queue.Tasks)
+			}
+		})
+	}
+}
+
+func TestPlaidWebhookHandler_ItemStatus(t *testing.T) {
+	cases := []struct {
+		name   string
+		hook   PlaidWebhook
+		status string
+	}{
+		{"login required", PlaidWebhook{WebhookType: "ITEM", WebhookCode: "ERROR", Error: &plaidWebhookError{ErrorCode: "ITEM_LOGIN_REQUIRED"}}, models.PlaidItemStatusLoginRequired},
+		{"other error", PlaidWebhook{WebhookType: "ITEM", WebhookCode: "ERROR", Error: &plaidWebhookError{ErrorCode: "INTERNAL_SERVER_ERROR"}}, models.PlaidItemStatusError},
+		{"pending expiration", PlaidWebhook{WebhookType: "ITEM", WebhookCode: "PENDING_EXPIRATION"}, models.PlaidItemStatusPendingExpiration},
+		{"permission revoked", PlaidWebhook{WebhookType: "ITEM", WebhookCode: "USER_PERMISSION_REVOKED"}, models.PlaidItemStatusRevoked},
+		{"unhandled code", PlaidWebhook{WebhookType: "ITEM", WebhookCode: "WEBHOOK_UPDATE_ACKNOWLEDGED"}, models.PlaidItemStatusActive},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, store, queue, _ := newWebhookTestHandler()
+			tc.hook.ItemID = "item-1"
+
+			w := postWebhook(handler, tc.hook)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", w.Code)
+			}
+			if got := store.Items["item-1"].Status; got != tc.status {
+				t.Errorf("Expected status %q, got %q", tc.status, got)
+			}
+			if len(queue.Tasks) != 0 {
+				t.Errorf("Expected no enqueued tasks, got %d", len(queue.Tasks))
+			}
+		})
+	}
+}
+
+func TestPlaidWebhookHandler_InvalidSignature(t *testing.T) {
+	handler, _, queue, verifier := newWebhookTestHandler()
+	verifier.VerifyError = errors.New("bad signature")
+
+	w := postWebhook(handler, PlaidWebhook{WebhookType: "TRANSACTIONS", WebhookCode: "SYNC_UPDATES_AVAILABLE", ItemID: "item-1"})
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", w.Code)
+	}
+	if len(queue.Tasks) != 0 {
+		t.Errorf("Expected no enqueued tasks, got %d", len(queue.Tasks))
+	}
+}
+
+func TestPlaidWebhookHandler_UnknownItem(t *testing.T) {
+	handler, _, queue, _ := newWebhookTestHandler()
+
+	w := postWebhook(handler, PlaidWebhook{WebhookType: "TRANSACTIONS", WebhookCode: "SYNC_UPDATES_AVAILABLE", ItemID: "missing"})
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	if len(queue.Tasks) != 0 {
+		t.Errorf("Expected no enqueued tasks, got %d", len(queue.Tasks))
+	}
+}
+
+func TestPlaidWebhookHandler_EnqueueFailure(t *testing.T) {
+	handler, _, queue, _ := newWebhookTestHandler()
+	queue.EnqueueError = errors.New("redis down")
+
+	w := postWebhook(handler, PlaidWebhook{WebhookType: "TRANSACTIONS", WebhookCode: "SYNC_UPDATES_AVAILABLE", ItemID: "item-1"})
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 so Plaid retries, got %d", w.Code)
+	}
+}
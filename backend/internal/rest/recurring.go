@@ -0,0 +1,106 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/recurring"
+	"go.uber.org/zap"
+)
+
+type RecurringStore interface {
+	List(ctx context.Context, familyID uuid.UUID, filter models.RecurringFilter) ([]models.RecurringTransaction, error)
+	UpdateStatus(ctx context.Context, familyID, id uuid.UUID, status string) (*models.RecurringTransaction, error)
+}
+
+type RecurringHandler struct {
+	repo RecurringStore
+}
+
+func NewRecurringHandler(repo RecurringStore) *RecurringHandler {
+	return &RecurringHandler{repo: repo}
+}
+
+type updateRecurringRequest struct {
+	Status string `json:"status"`
+}
+
+// GET /recurring?status=confirmed
+// Detected subscriptions and bills, next due first, flagged when an occurrence is
+// overdue or the amount changed. Dismissed ones only show when asked for by status.
+func (h *RecurringHandler) List(w http.ResponseWriter, r *http.Request) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+
+	filter := models.RecurringFilter{Status: r.URL.Query().Get("status")}
+	if filter.Status != "" && !models.ValidRecurringStatus(filter.Status) {
+		sendError(w, http.StatusBadRequest, "Invalid status")
+		return
+	}
+
+	list, err := h.repo.List(r.Context(), familyID, filter)
+	if err != nil {
+		logger.Error("DB Error (list recurring)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to list recurring transactions")
+		return
+	}
+	if list == nil {
+		list = []models.RecurringTransaction{}
+	}
+	now := time.Now()
+	for i := range list {
+		recurring.Annotate(&list[i], now)
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": list})
+}
+
+// PUT /recurring/{recurringID}
+// Confirms or dismisses a detected recurring transaction, or puts it back to detected.
+func (h *RecurringHandler) Update(w http.ResponseWriter, r *http.Request) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "recurringID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid recurring transaction ID")
+		return
+	}
+
+	var req updateRecurringRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !models.ValidRecurringStatus(req.Status) {
+		sendError(w, http.StatusBadRequest, "Invalid status")
+		return
+	}
+
+	rt, err := h.repo.UpdateStatus(r.Context(), familyID, id, req.Status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			sendError(w, http.StatusNotFound, "Recurring transaction not found")
+			return
+		}
+		logger.Error("DB Error (update recurring)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to update recurring transaction")
+		return
+	}
+	recurring.Annotate(rt, time.Now())
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": rt})
+}
@@ -0,0 +1,107 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/rest/mocks"
+)
+
+func TestRecurringHandler(t *testing.T) {
+	store := mocks.NewRecurringStore()
+	handler := NewRecurringHandler(store)
+	user := &models.User{ID: uuid.New(), FamilyID: uuid.New()}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	oldPrice := 15.49
+	streaming := models.RecurringTransaction{
+		ID: uuid.New(), Name: "Streaming", Cadence: models.RecurringCadenceMonthly, Amount: 17.99, PreviousAmount: &oldPrice,
+		NextDate: today.AddDate(0, 0, 10), Status: models.RecurringStatusDetected,
+	}
+	gym := models.RecurringTransaction{
+		ID: uuid.New(), Name: "Gym", Cadence: models.RecurringCadenceMonthly, Amount: 40,
+		NextDate: today.AddDate(0, 0, -20), Status: models.RecurringStatusConfirmed,
+	}
+	store.Recurring[user.FamilyID] = []models.RecurringTransaction{streaming, gym}
+
+	list := func(t *testing.T, query string) []models.RecurringTransaction {
+		w := httptest.NewRecorder()
+		handler.List(w, familyRequest("GET", "/recurring"+query, nil, user, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		var resp struct {
+			Data []models.RecurringTransaction `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp.Data
+	}
+
+	t.Run("lists with flags", func(t *testing.T) {
+		got := list(t, "")
+		if len(got) != 2 {
+			t.Fatalf("Expected 2 recurring transactions, got %d", len(got))
+		}
+		for _, r := range got {
+			switch r.Name {
+			case "Streaming":
+				if !r.PriceChanged || r.Missed {
+					t.Errorf("Expected streaming to have a price change and not be missed, got %+v", r)
+				}
+			case "Gym":
+				if !r.Missed || r.PriceChanged {
+					t.Errorf("Expected gym to be missed without a price change, got %+v", r)
+				}
+			}
+		}
+	})
+
+	t.Run("dismiss", func(t *testing.T) {
+		req := familyRequest("PUT", "/recurring/"+streaming.ID.String(), updateRecurringRequest{Status: models.RecurringStatusDismissed},
+			user, map[string]string{"recurringID": streaming.ID.String()})
+		w := httptest.NewRecorder()
+		handler.Update(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+
+		if got := list(t, ""); len(got) != 1 || got[0].Name != "Gym" {
+			t.Errorf("Expected dismissed to be hidden, got %+v", got)
+		}
+		if got := list(t, "?status=dismissed"); len(got) != 1 || got[0].Name != "Streaming" {
+			t.Errorf("Expected dismissed when asked for, got %+v", got)
+		}
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		req := familyRequest("PUT", "/recurring/"+gym.ID.String(), updateRecurringRequest{Status: "paused"},
+			user, map[string]string{"recurringID": gym.ID.String()})
+		w := httptest.NewRecorder()
+		handler.Update(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+
+		w = httptest.NewRecorder()
+		handler.List(w, familyRequest("GET", "/recurring?status=paused", nil, user, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("other family's is not found", func(t *testing.T) {
+		other := &models.User{ID: uuid.New(), FamilyID: uuid.New()}
+		req := familyRequest("PUT", "/recurring/"+gym.ID.String(), updateRecurringRequest{Status: models.RecurringStatusConfirmed},
+			other, map[string]string{"recurringID": gym.ID.String()})
+		w := httptest.NewRecorder()
+		handler.Update(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,279 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/reports"
+	"go.uber.org/zap"
+)
+
+type ReportStore interface {
+	CashFlowLines(ctx context.Context, familyID uuid.UUID, filter models.CashFlowFilter) (string, []reports.Line, error)
+	TopMerchants(ctx context.Context, familyID uuid.UUID, start, end time.Time, limit int) (string, []models.MerchantSpending, error)
+	CategorySpending(ctx context.Context, familyID uuid.UUID, start, end time.Time) (string, []reports.CategorySpend, error)
+	LargestTransactions(ctx context.Context, familyID uuid.UUID, start, end time.Time, limit int) (string, []models.LargeTransaction, error)
+}
+
+const (
+	defaultInsightLimit = 10
+	maxInsightLimit     = 100
+)
+
+type ReportHandler struct {
+	repo ReportStore
+}
+
+func NewReportHandler(repo ReportStore) *ReportHandler {
+	return &ReportHandler{repo: repo}
+}
+
+// InsightsResponse is a spending insight with the dates and currency it covers.
+type InsightsResponse struct {
+	Start    time.Time   `json:"start"`
+	End      time.Time   `json:"end"`
+	Currency string      `json:"currency"`
+	Items    interface{} `json:"items"`
+}
+
+// GET /reports/cashflow?start=2024-01-01&end=2024-12-31&interval=month
+// Income and spending by category, in the family's currency, with transfers between its
+// accounts left out. Dates are inclusive and default to the last twelve months; interval
+// is day, week, month (the default), quarter or year. tag narrows it to entries with
+// every one of the tags, as on the transaction list.
+func (h *ReportHandler) CashFlow(w http.ResponseWriter, r *http.Request) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+
+	filter, err := parseCashFlowFilter(r, time.Now())
+	if err != nil {
+		sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	currency, lines, err := h.repo.CashFlowLines(r.Context(), familyID, filter)
+	if err != nil {
+		logger.Error("DB Error (cash flow report)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to build cash flow report")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": reports.CashFlow(lines, filter, currency)})
+}
+
+func parseCashFlowFilter(r *http.Request, now time.Time) (models.CashFlowFilter, error) {
+	q := r.URL.Query()
+	filter := models.CashFlowFilter{Interval: models.ReportIntervalMonth}
+
+	var err error
+	filter.Start, filter.End, err = parseReportRange(r, now)
+	if err != nil {
+		return filter, err
+	}
+	if v := q.Get("interval"); v != "" {
+		if !models.ValidReportInterval(v) {
+			return filter, errors.New("Invalid interval")
+		}
+		filter.Interval = v
+	}
+	if reports.CountPeriods(filter.Start, filter.End, filter.Interval) > reports.MaxPeriods {
+		return filter, errors.New("Too many periods; use a longer interval or a shorter range")
+	}
+
+	tagIDs, err := parseTagIDs(q["tag"])
+	if err != nil {
+		return filter, err
+	}
+	filter.TagIDs = tagIDs
+	return filter, nil
+}
+
+// GET /reports/top_merchants?start=&end=&limit=10
+// The merchants the family spent most at, net of refunds. Dates default as for the
+// cash-flow report.
+func (h *ReportHandler) TopMerchants(w http.ResponseWriter, r *http.Request) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+	start, end, err := parseReportRange(r, time.Now())
+	if err != nil {
+		sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit, err := parseInsightLimit(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	currency, merchants, err := h.repo.TopMerchants(r.Context(), familyID, start, end, limit)
+	if err != nil {
+		logger.Error("DB Error (top merchants)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to fetch top merchants")
+		return
+	}
+	if merchants == nil {
+		merchants = []models.MerchantSpending{}
+	}
+
+	resp := InsightsResponse{Start: start, End: end, Currency: currency, Items: merchants}
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": resp})
+}
+
+// GET /reports/category_trends?start=&end=
+// Spending in each category month by month, with the change from the month before.
+func (h *ReportHandler) CategoryTrends(w http.ResponseWriter, r *http.Request) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+	start, end, err := parseReportRange(r, time.Now())
+	if err != nil {
+		sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if reports.CountPeriods(start, end, models.ReportIntervalMonth) > reports.MaxPeriods {
+		sendError(w, http.StatusBadRequest, "Too many months; use a shorter range")
+		return
+	}
+
+	currency, spend, err := h.repo.CategorySpending(r.Context(), familyID, start, end)
+	if err != nil {
+		logger.Error("DB Error (category trends)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to fetch category trends")
+		return
+	}
+
+	resp := InsightsResponse{Start: start, End: end, Currency: currency, Items: reports.CategoryTrends(spend, start, end)}
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": resp})
+}
+
+// GET /reports/largest_transactions?start=&end=&limit=10
+// The biggest outgoing transactions, transfers aside.
+func (h *ReportHandler) LargestTransactions(w http.ResponseWriter, r *http.Request) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+	start, end, err := parseReportRange(r, time.Now())
+	if err != nil {
+		sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit, err := parseInsightLimit(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	currency, list, err := h.repo.LargestTransactions(r.Context(), familyID, start, end, limit)
+	if err != nil {
+		logger.Error("DB Error (largest transactions)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to fetch largest transactions")
+		return
+	}
+	if list == nil {
+		list = []models.LargeTransaction{}
+	}
+
+	resp := InsightsResponse{Start: start, End: end, Currency: currency, Items: list}
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": resp})
+}
+
+// GET /reports/anomalies?month=2024-07&months=6
+// Categories where the month's spending (this month's by default) was more than two
+// standard deviations above their average over the trailing months.
+func (h *ReportHandler) Anomalies(w http.ResponseWriter, r *http.Request) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+
+	q := r.URL.Query()
+	month := reports.PeriodStart(time.Now(), models.ReportIntervalMonth)
+	if v := q.Get("month"); v != "" {
+		parsed, err := time.Parse("2006-01", v)
+		if err != nil {
+			sendError(w, http.StatusBadRequest, "Invalid month")
+			return
+		}
+		month = parsed
+	}
+	history := reports.DefaultAnomalyHistory
+	if v := q.Get("months"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < reports.MinAnomalyHistory || n > reports.MaxAnomalyHistory {
+			sendError(w, http.StatusBadRequest, "Invalid months")
+			return
+		}
+		history = n
+	}
+
+	start, end := month.AddDate(0, -history, 0), month.AddDate(0, 1, -1)
+	currency, spend, err := h.repo.CategorySpending(r.Context(), familyID, start, end)
+	if err != nil {
+		logger.Error("DB Error (spending anomalies)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to fetch spending anomalies")
+		return
+	}
+
+	resp := InsightsResponse{Start: start, End: end, Currency: currency, Items: reports.Anomalies(spend, month, history)}
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": resp})
+}
+
+// parseReportRange reads the inclusive start and end dates (YYYY-MM-DD) of a report,
+// which default to the last twelve months.
+func parseReportRange(r *http.Request, now time.Time) (time.Time, time.Time, error) {
+	q := r.URL.Query()
+	y, m, d := now.Date()
+	start := time.Date(y, m-11, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	if v := q.Get("start"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return start, end, errors.New("Invalid start")
+		}
+		start = parsed
+	}
+	if v := q.Get("end"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return start, end, errors.New("Invalid end")
+		}
+		end = parsed
+	}
+	if end.Before(start) {
+		return start, end, errors.New("End must not be before start")
+	}
+	return start, end, nil
+}
+
+func parseInsightLimit(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return defaultInsightLimit, nil
+	}
+	limit, err := strconv.Atoi(v)
+	if err != nil || limit < 1 {
+		return 0, errors.New("Invalid limit")
+	}
+	if limit > maxInsightLimit {
+		limit = maxInsightLimit
+	}
+	return limit, nil
+}
@@ -0,0 +1,171 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/reports"
+	"github.com/rakibulbh/ai-finance-manager/internal/rest/mocks"
+)
+
+func TestReportHandler_CashFlow(t *testing.T) {
+	store := mocks.NewReportStore()
+	handler := NewReportHandler(store)
+	user := &models.User{ID: uuid.New(), FamilyID: uuid.New()}
+
+	salary, rent := uuid.New(), uuid.New()
+	store.Lines[user.FamilyID] = []reports.Line{
+		{Date: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), CategoryID: &salary, CategoryName: "Salary", Income: true, Amount: -5000},
+		{Date: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), CategoryID: &rent, CategoryName: "Rent", Amount: 2000},
+		{Date: time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC), CategoryID: &rent, CategoryName: "Rent", Amount: 2000},
+	}
+
+	w := httptest.NewRecorder()
+	handler.CashFlow(w, familyRequest("GET", "/reports/cashflow?start=2024-01-01&end=2024-03-31&interval=month", nil, user, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data models.CashFlowReport `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	report := resp.Data
+	if report.Income != 5000 || report.Expense != 4000 || report.SavingsRate == nil || *report.SavingsRate != 0.2 {
+		t.Errorf("Expected 5000 in, 4000 out and a 20%% savings rate, got %+v", report)
+	}
+	if len(report.Periods) != 3 || report.Currency != "USD" {
+		t.Errorf("Expected three months in USD, got %d periods in %s", len(report.Periods), report.Currency)
+	}
+	if len(report.Sankey.Links) != 3 {
+		t.Errorf("Expected salary in, rent out and savings, got %+v", report.Sankey.Links)
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.CashFlow(w, familyRequest("GET", "/reports/cashflow", nil, user, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+		f := store.LastFilter
+		if f.Interval != models.ReportIntervalMonth || f.Start.Day() != 1 || f.End.Sub(f.Start) > 366*24*time.Hour {
+			t.Errorf("Expected the last twelve months by month, got %+v", f)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, query := range []string{
+			"?start=01/01/2024",
+			"?start=2024-02-01&end=2024-01-01",
+			"?interval=hourly",
+			"?start=2000-01-01&end=2024-01-01&interval=day",
+			"?tag=vacation",
+		} {
+			w := httptest.NewRecorder()
+			handler.CashFlow(w, familyRequest("GET", "/reports/cashflow"+query, nil, user, nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %s, got %d", query, w.Code)
+			}
+		}
+	})
+}
+
+func TestReportHandler_Insights(t *testing.T) {
+	store := mocks.NewReportStore()
+	handler := NewReportHandler(store)
+	user := &models.User{ID: uuid.New(), FamilyID: uuid.New()}
+
+	get := func(t *testing.T, serve http.HandlerFunc, target string) InsightsResponse {
+		w := httptest.NewRecorder()
+		serve(w, familyRequest("GET", target, nil, user, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data InsightsResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp.Data
+	}
+
+	t.Run("top merchants", func(t *testing.T) {
+		for i := 0; i < 15; i++ {
+			store.Merchants[user.FamilyID] = append(store.Merchants[user.FamilyID], models.MerchantSpending{MerchantID: uuid.New(), Amount: float64(1000 - i)})
+		}
+		resp := get(t, handler.TopMerchants, "/reports/top_merchants?start=2024-01-01&end=2024-06-30")
+		if items, _ := resp.Items.([]interface{}); len(items) != defaultInsightLimit {
+			t.Errorf("Expected the default of %d merchants, got %d", defaultInsightLimit, len(items))
+		}
+		if !store.LastStart.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) || resp.Currency != "USD" {
+			t.Errorf("Expected the requested range in USD, got %v %s", store.LastStart, resp.Currency)
+		}
+
+		get(t, handler.TopMerchants, "/reports/top_merchants?limit=1000")
+		if store.LastLimit != maxInsightLimit {
+			t.Errorf("Expected the limit capped at %d, got %d", maxInsightLimit, store.LastLimit)
+		}
+	})
+
+	t.Run("largest transactions", func(t *testing.T) {
+		store.Largest[user.FamilyID] = []models.LargeTransaction{{EntryID: uuid.New(), Name: "Flights", Amount: 1800}}
+		resp := get(t, handler.LargestTransactions, "/reports/largest_transactions?limit=5")
+		if items, _ := resp.Items.([]interface{}); len(items) != 1 || store.LastLimit != 5 {
+			t.Errorf("Expected one transaction with limit 5, got %d (limit %d)", len(items), store.LastLimit)
+		}
+	})
+
+	groceries := uuid.New()
+	for i, amount := range []float64{400, 420, 390, 410, 405, 415, 900} {
+		store.Spending[user.FamilyID] = append(store.Spending[user.FamilyID], reports.CategorySpend{
+			Month: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC).AddDate(0, i, 0), CategoryID: &groceries, CategoryName: "Groceries", Amount: amount,
+		})
+	}
+
+	t.Run("category trends", func(t *testing.T) {
+		resp := get(t, handler.CategoryTrends, "/reports/category_trends?start=2024-05-01&end=2024-07-31")
+		trends, _ := resp.Items.([]interface{})
+		if len(trends) != 1 {
+			t.Fatalf("Expected one category, got %+v", resp.Items)
+		}
+		months, _ := trends[0].(map[string]interface{})["months"].([]interface{})
+		if len(months) != 3 {
+			t.Errorf("Expected May to July, got %d months", len(months))
+		}
+	})
+
+	t.Run("anomalies", func(t *testing.T) {
+		resp := get(t, handler.Anomalies, "/reports/anomalies?month=2024-07")
+		anomalies, _ := resp.Items.([]interface{})
+		if len(anomalies) != 1 {
+			t.Fatalf("Expected July's groceries to stand out, got %+v", resp.Items)
+		}
+		if !store.LastStart.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) || !store.LastEnd.Equal(time.Date(2024, 7, 31, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected six trailing months through July, got %v to %v", store.LastStart, store.LastEnd)
+		}
+
+		resp = get(t, handler.Anomalies, "/reports/anomalies?month=2024-06")
+		if anomalies, _ := resp.Items.([]interface{}); len(anomalies) != 0 {
+			t.Errorf("Expected nothing unusual in June, got %+v", anomalies)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for target, serve := range map[string]http.HandlerFunc{
+			"/reports/top_merchants?limit=0":                           handler.TopMerchants,
+			"/reports/largest_transactions?end=2024-13-01":             handler.LargestTransactions,
+			"/reports/category_trends?start=2024-03-01&end=2024-01-01": handler.CategoryTrends,
+			"/reports/anomalies?month=July":                            handler.Anomalies,
+			"/reports/anomalies?months=2":                              handler.Anomalies,
+		} {
+			w := httptest.NewRecorder()
+			serve(w, familyRequest("GET", target, nil, user, nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %s, got %d", target, w.Code)
+			}
+		}
+	})
+}
@@ -1,68 +1,252 @@
 package rest
 
 import (
+	"net"
+	"net/http"
+	"time"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
 	authMW "github.com/rakibulbh/ai-finance-manager/internal/rest/middleware"
 )
 
 type RouterConfig struct {
-	AuthHandler        *AuthHandler
-	AccountHandler     *AccountHandler
-	TransactionHandler *TransactionHandler
-	InvestmentHandler  *InvestmentHandler
-	PlaidHandler       *PlaidHandler
-	JWTSecret         string
+	AuthHandler         *AuthHandler
+	SSOHandler          *SSOHandler // nil when single sign-on isn't configured
+	FamilyHandler       *FamilyHandler
+	AccountHandler      *AccountHandler
+	TransactionHandler  *TransactionHandler
+	InvestmentHandler   *InvestmentHandler
+	PlaidHandler        *PlaidHandler
+	PlaidWebhookHandler *PlaidWebhookHandler
+	APIKeyHandler       *APIKeyHandler
+	AuditHandler        *AuditHandler
+	RecurringHandler    *RecurringHandler
+	ScheduledHandler    *ScheduledHandler
+	TagHandler          *TagHandler
+	TransferHandler     *TransferHandler
+	ReportHandler       *ReportHandler
+	Sessions            authMW.SessionChecker
+	APIKeys             authMW.APIKeyAuthenticator
+	JWTSecret           string
+	// Reverse proxies whose forwarding headers give the client's address
+	TrustedProxies []*net.IPNet
+
+	// Limits for the public auth endpoints. Nil RateLimitStore turns limiting off.
+	RateLimitStore authMW.RateLimitStore
+	AuthRateLimit  AuthRateLimit
+}
+
+type AuthRateLimit struct {
+	PerIP      int
+	PerAccount int
+	Period     time.Duration
 }
 
 func NewRouter(cfg RouterConfig) *chi.Mux {
 	r := chi.NewRouter()
 
+	r.Use(middleware.RequestID)
+	r.Use(authMW.TrustedProxies(cfg.TrustedProxies))
 	r.Use(authMW.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://*", "http://*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", authMW.APIKeyHeader},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 
+	requireAuth := authMW.AuthMiddleware([]byte(cfg.JWTSecret), cfg.Sessions, cfg.APIKeys)
+	scope := authMW.RequireScope
+
 	r.Route("/api", func(r chi.Router) {
 		// Public Routes
 		r.Route("/auth", func(r chi.Router) {
-			r.Post("/register", cfg.AuthHandler.Register)
-			r.Post("/login", cfg.AuthHandler.Login)
+			r.Group(func(r chi.Router) {
+				perIP, perAccount := authRateLimits(cfg)
+				r.Use(perIP)
+
+				r.With(perAccount).Post("/register", cfg.AuthHandler.Register)
+				r.With(perAccount).Post("/login", cfg.AuthHandler.Login)
+				r.Post("/refresh", cfg.AuthHandler.Refresh)
+				r.With(perAccount).Post("/password/forgot", cfg.AuthHandler.ForgotPassword)
+				r.Post("/password/reset", cfg.AuthHandler.ResetPassword)
+				r.Post("/email/verify", cfg.AuthHandler.VerifyEmail)
+				r.Post("/mfa/verify", cfg.AuthHandler.VerifyMFA)
+
+				if cfg.SSOHandler != nil {
+					r.Post("/oidc/start", cfg.SSOHandler.Start)
+					r.Post("/oidc/callback", cfg.SSOHandler.Callback)
+				}
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(requireAuth, authMW.RequireSession)
+				r.Post("/logout", cfg.AuthHandler.Logout)
+				r.Get("/sessions", cfg.AuthHandler.ListSessions)
+				r.Delete("/sessions/{sessionID}", cfg.AuthHandler.RevokeSession)
+				r.Post("/invites/accept", cfg.AuthHandler.AcceptInvite)
+				r.Post("/email/resend", cfg.AuthHandler.ResendEmailVerification)
+
+				r.Post("/mfa/setup", cfg.AuthHandler.SetupMFA)
+				r.Post("/mfa/enable", cfg.AuthHandler.EnableMFA)
+				r.Post("/mfa/disable", cfg.AuthHandler.DisableMFA)
+				r.Post("/mfa/recovery_codes", cfg.AuthHandler.RegenerateRecoveryCodes)
+			})
 		})
 
+		// Plaid authenticates its webhooks with a signed JWT, not a user token
+		r.Post("/plaid/webhook", cfg.PlaidWebhookHandler.Handle)
+
 		// Protected Routes
 		r.Group(func(r chi.Router) {
-			r.Use(authMW.AuthMiddleware([]byte(cfg.JWTSecret)))
+			r.Use(requireAuth, authMW.AuditContext)
+
+			canWrite := authMW.RequirePermission(authMW.PermWrite)
+			canManageAccounts := authMW.RequirePermission(authMW.PermManageAccounts)
+			canManageFamily := authMW.RequirePermission(authMW.PermManageFamily)
+			canManagePlaid := authMW.RequirePermission(authMW.PermManagePlaid)
+
+			r.Route("/family", func(r chi.Router) {
+				r.Use(authMW.RequireSession)
+				r.Get("/members", cfg.FamilyHandler.ListMembers)
+				r.With(canManageFamily).Put("/members/{userID}", cfg.FamilyHandler.UpdateMemberRole)
+
+				r.Group(func(r chi.Router) {
+					r.Use(canManageFamily)
+					r.Post("/invites", cfg.FamilyHandler.CreateInvite)
+					r.Get("/invites", cfg.FamilyHandler.ListInvites)
+					r.Delete("/invites/{inviteID}", cfg.FamilyHandler.RevokeInvite)
+				})
+			})
+
+			r.With(authMW.RequireSession, canManageFamily).Get("/audit", cfg.AuditHandler.List)
+
+			r.Route("/api_keys", func(r chi.Router) {
+				r.Use(authMW.RequireSession)
+				r.Post("/", cfg.APIKeyHandler.Create)
+				r.Get("/", cfg.APIKeyHandler.List)
+				r.Delete("/{keyID}", cfg.APIKeyHandler.Revoke)
+			})
 
 			r.Route("/accounts", func(r chi.Router) {
-				r.Post("/", cfg.AccountHandler.Create)
-				r.Get("/", cfg.AccountHandler.List)
+				r.With(canWrite, scope(models.ScopeWriteAccounts)).Post("/", cfg.AccountHandler.Create)
+				r.With(scope(models.ScopeReadAccounts)).Get("/", cfg.AccountHandler.List)
+				r.With(scope(models.ScopeReadAccounts)).Get("/upcoming_payments", cfg.AccountHandler.UpcomingPayments)
+
+				r.Route("/{accountID}", func(r chi.Router) {
+					r.With(scope(models.ScopeReadAccounts)).Get("/", cfg.AccountHandler.Get)
+					r.With(canWrite, scope(models.ScopeWriteAccounts)).Put("/", cfg.AccountHandler.Update)
+					r.With(canManageAccounts, scope(models.ScopeWriteAccounts)).Delete("/", cfg.AccountHandler.Delete)
+
+					r.Route("/amortization", func(r chi.Router) {
+						r.Use(scope(models.ScopeReadAccounts))
+						r.Get("/", cfg.AccountHandler.Amortization)
+						r.Get("/payoff", cfg.AccountHandler.Payoff)
+					})
+					r.With(scope(models.ScopeReadAccounts)).Get("/statements", cfg.AccountHandler.Statements)
+				})
 			})
 
 			r.Route("/transactions", func(r chi.Router) {
-				r.Post("/", cfg.TransactionHandler.Create)
+				r.With(scope(models.ScopeReadTransactions)).Get("/", cfg.TransactionHandler.List)
+				r.With(canWrite, scope(models.ScopeWriteTransactions)).Post("/", cfg.TransactionHandler.Create)
+				r.With(canWrite, scope(models.ScopeWriteTransactions)).Post("/bulk_tag", cfg.TagHandler.BulkTag)
+				r.With(canWrite, scope(models.ScopeWriteTransactions)).Post("/bulk_untag", cfg.TagHandler.BulkUntag)
+
+				r.Route("/{entryID}/splits", func(r chi.Router) {
+					r.With(scope(models.ScopeReadTransactions)).Get("/", cfg.TransactionHandler.GetSplits)
+					r.With(canWrite, scope(models.ScopeWriteTransactions)).Put("/", cfg.TransactionHandler.SetSplits)
+				})
+			})
+
+			r.Route("/tags", func(r chi.Router) {
+				r.With(scope(models.ScopeReadTransactions)).Get("/", cfg.TagHandler.List)
+				r.With(canWrite, scope(models.ScopeWriteTransactions)).Post("/", cfg.TagHandler.Create)
+				r.With(canWrite, scope(models.ScopeWriteTransactions)).Put("/{tagID}", cfg.TagHandler.Update)
+				r.With(canWrite, scope(models.ScopeWriteTransactions)).Delete("/{tagID}", cfg.TagHandler.Delete)
+			})
+
+			r.Route("/transfer_matches", func(r chi.Router) {
+				r.With(scope(models.ScopeReadTransactions)).Get("/", cfg.TransferHandler.List)
+				r.With(canWrite, scope(models.ScopeWriteTransactions)).Post("/{matchID}/confirm", cfg.TransferHandler.Confirm)
+				r.With(canWrite, scope(models.ScopeWriteTransactions)).Post("/{matchID}/reject", cfg.TransferHandler.Reject)
+				r.With(canWrite, scope(models.ScopeWriteTransactions)).Post("/{matchID}/unmatch", cfg.TransferHandler.Unmatch)
+			})
+
+			r.Route("/reports", func(r chi.Router) {
+				r.Use(scope(models.ScopeReadTransactions))
+				r.Get("/cashflow", cfg.ReportHandler.CashFlow)
+				r.Get("/top_merchants", cfg.ReportHandler.TopMerchants)
+				r.Get("/category_trends", cfg.ReportHandler.CategoryTrends)
+				r.Get("/largest_transactions", cfg.ReportHandler.LargestTransactions)
+				r.Get("/anomalies", cfg.ReportHandler.Anomalies)
+			})
+
+			r.Route("/recurring", func(r chi.Router) {
+				r.With(scope(models.ScopeReadTransactions)).Get("/", cfg.RecurringHandler.List)
+				r.With(canWrite, scope(models.ScopeWriteTransactions)).Put("/{recurringID}", cfg.RecurringHandler.Update)
+			})
+
+			r.Route("/scheduled_transactions", func(r chi.Router) {
+				r.With(scope(models.ScopeReadTransactions)).Get("/", cfg.ScheduledHandler.List)
+				r.With(canWrite, scope(models.ScopeWriteTransactions)).Post("/", cfg.ScheduledHandler.Create)
+
+				r.Route("/{scheduledID}", func(r chi.Router) {
+					r.With(scope(models.ScopeReadTransactions)).Get("/", cfg.ScheduledHandler.Get)
+					r.With(canWrite, scope(models.ScopeWriteTransactions)).Put("/", cfg.ScheduledHandler.Update)
+					r.With(canWrite, scope(models.ScopeWriteTransactions)).Delete("/", cfg.ScheduledHandler.Delete)
+
+					r.With(scope(models.ScopeReadTransactions)).Get("/occurrences", cfg.ScheduledHandler.Occurrences)
+					r.With(canWrite, scope(models.ScopeWriteTransactions)).Put("/occurrences/{date}", cfg.ScheduledHandler.SetOccurrence)
+					r.With(canWrite, scope(models.ScopeWriteTransactions)).Delete("/occurrences/{date}", cfg.ScheduledHandler.DeleteOccurrence)
+				})
 			})
 
 			r.Route("/transfers", func(r chi.Router) {
-				r.Post("/", cfg.TransactionHandler.CreateTransfer)
+				r.With(canWrite, scope(models.ScopeWriteTransactions)).Post("/", cfg.TransactionHandler.CreateTransfer)
 			})
 
 			r.Route("/investments", func(r chi.Router) {
-				r.Post("/trade", cfg.InvestmentHandler.CreateTrade)
+				r.With(canWrite, scope(models.ScopeWriteInvestments)).Post("/trade", cfg.InvestmentHandler.CreateTrade)
 			})
 
 			r.Route("/plaid", func(r chi.Router) {
-				r.Post("/create_link_token", cfg.PlaidHandler.CreateLinkToken)
-				r.Post("/exchange_public_token", cfg.PlaidHandler.ExchangePublicToken)
+				r.Use(authMW.RequireSession)
+				r.Get("/items", cfg.PlaidHandler.ListItems)
+
+				r.Group(func(r chi.Router) {
+					r.Use(canManagePlaid)
+					r.Post("/create_link_token", cfg.PlaidHandler.CreateLinkToken)
+					r.Post("/exchange_public_token", cfg.PlaidHandler.ExchangePublicToken)
+
+					r.Route("/items/{itemID}", func(r chi.Router) {
+						r.Delete("/", cfg.PlaidHandler.RemoveItem)
+						r.Post("/sync", cfg.PlaidHandler.SyncItem)
+						r.Post("/update_link_token", cfg.PlaidHandler.CreateUpdateLinkToken)
+					})
+				})
 			})
 		})
 	})
 
 	return r
 }
+
+// authRateLimits returns the per-IP limit for all public auth endpoints and the
+// per-account limit for those that take an email address.
+func authRateLimits(cfg RouterConfig) (func(http.Handler) http.Handler, func(http.Handler) http.Handler) {
+	if cfg.RateLimitStore == nil {
+		noop := func(next http.Handler) http.Handler { return next }
+		return noop, noop
+	}
+
+	limits := cfg.AuthRateLimit
+	perIP := authMW.RateLimit(cfg.RateLimitStore, "auth-ip", limits.PerIP, limits.Period, authMW.ClientIP)
+	perAccount := authMW.RateLimit(cfg.RateLimitStore, "auth-account", limits.PerAccount, limits.Period, authMW.JSONFieldKey("email"))
+	return perIP, perAccount
+}
@@ -0,0 +1,471 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/schedule"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultOccurrenceDays = 90
+	maxOccurrenceDays     = 366
+)
+
+// Status of an occurrence that hasn't been posted, skipped or overridden.
+const occurrenceStatusScheduled = "scheduled"
+
+type ScheduledStore interface {
+	Create(ctx context.Context, s *models.ScheduledTransaction) error
+	List(ctx context.Context, familyID uuid.UUID) ([]models.ScheduledTransaction, error)
+	GetByID(ctx context.Context, familyID, id uuid.UUID) (*models.ScheduledTransaction, error)
+	Update(ctx context.Context, s *models.ScheduledTransaction) error
+	Delete(ctx context.Context, familyID, id uuid.UUID) error
+	ListOccurrences(ctx context.Context, scheduleID uuid.UUID, from, to time.Time) ([]models.ScheduledOccurrence, error)
+	SetOccurrence(ctx context.Context, familyID uuid.UUID, o *models.ScheduledOccurrence) error
+	DeleteOccurrence(ctx context.Context, familyID, scheduleID uuid.UUID, date time.Time) error
+}
+
+type ScheduledHandler struct {
+	repo ScheduledStore
+}
+
+func NewScheduledHandler(repo ScheduledStore) *ScheduledHandler {
+	return &ScheduledHandler{repo: repo}
+}
+
+// Amount is signed like a transaction entry's. Dates are YYYY-MM-DD.
+type createScheduledRequest struct {
+	AccountID  uuid.UUID  `json:"accountId"`
+	CategoryID *uuid.UUID `json:"categoryId"`
+	Name       string     `json:"name"`
+	Amount     float64    `json:"amount"`
+	Currency   string     `json:"currency"`
+	Rule       string     `json:"rule"`
+	StartDate  string     `json:"startDate"`
+}
+
+// Fields left out keep their current values.
+type updateScheduledRequest struct {
+	CategoryID *uuid.UUID `json:"categoryId"`
+	Name       *string    `json:"name"`
+	Amount     *float64   `json:"amount"`
+	Rule       *string    `json:"rule"`
+	StartDate  *string    `json:"startDate"`
+	Active     *bool      `json:"active"`
+}
+
+// Skip leaves the occurrence out; otherwise Amount and Name replace the schedule's own
+// for that date.
+type occurrenceRequest struct {
+	Skip   bool     `json:"skip"`
+	Amount *float64 `json:"amount"`
+	Name   *string  `json:"name"`
+}
+
+// OccurrenceResponse is one date of a schedule: scheduled, skipped, overridden or posted.
+type OccurrenceResponse struct {
+	Date    time.Time  `json:"date"`
+	Status  string     `json:"status"`
+	Amount  float64    `json:"amount"`
+	Name    string     `json:"name"`
+	EntryID *uuid.UUID `json:"entryId,omitempty"`
+}
+
+// POST /scheduled_transactions
+// Only for accounts not linked to Plaid; Plaid posts linked accounts' entries itself.
+// A start date in the past posts the occurrences since then on the worker's next run.
+func (h *ScheduledHandler) Create(w http.ResponseWriter, r *http.Request) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+
+	var req createScheduledRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.AccountID == uuid.Nil {
+		sendError(w, http.StatusBadRequest, "Account ID is required")
+		return
+	}
+
+	s := &models.ScheduledTransaction{
+		FamilyID:   familyID,
+		AccountID:  req.AccountID,
+		CategoryID: req.CategoryID,
+		Name:       strings.TrimSpace(req.Name),
+		Amount:     req.Amount,
+		Currency:   strings.ToUpper(strings.TrimSpace(req.Currency)),
+		Rule:       req.Rule,
+		Active:     true,
+	}
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid start date")
+		return
+	}
+	s.StartDate = startDate
+	if msg := validateScheduled(s); msg != "" {
+		sendError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	if err := h.repo.Create(r.Context(), s); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			sendError(w, http.StatusNotFound, "Account not found")
+		case errors.Is(err, models.ErrScheduleAccountLinked):
+			sendError(w, http.StatusBadRequest, "Accounts linked to Plaid can't have scheduled transactions")
+		default:
+			logger.Error("DB Error (create scheduled transaction)", zap.Error(err))
+			sendError(w, http.StatusInternalServerError, "Failed to create scheduled transaction")
+		}
+		return
+	}
+
+	sendJSON(w, http.StatusCreated, map[string]interface{}{"data": s})
+}
+
+// GET /scheduled_transactions
+func (h *ScheduledHandler) List(w http.ResponseWriter, r *http.Request) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+
+	list, err := h.repo.List(r.Context(), familyID)
+	if err != nil {
+		logger.Error("DB Error (list scheduled transactions)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to list scheduled transactions")
+		return
+	}
+	if list == nil {
+		list = []models.ScheduledTransaction{}
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": list})
+}
+
+// GET /scheduled_transactions/{scheduledID}
+func (h *ScheduledHandler) Get(w http.ResponseWriter, r *http.Request) {
+	s, ok := h.scheduled(w, r)
+	if !ok {
+		return
+	}
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": s})
+}
+
+// PUT /scheduled_transactions/{scheduledID}
+// Changes apply to occurrences not posted yet; set active to false to pause.
+func (h *ScheduledHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req updateScheduledRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	s, ok := h.scheduled(w, r)
+	if !ok {
+		return
+	}
+	if req.CategoryID != nil {
+		s.CategoryID = req.CategoryID
+	}
+	if req.Name != nil {
+		s.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Amount != nil {
+		s.Amount = *req.Amount
+	}
+	if req.Rule != nil {
+		s.Rule = *req.Rule
+	}
+	if req.StartDate != nil {
+		startDate, err := time.Parse("2006-01-02", *req.StartDate)
+		if err != nil {
+			sendError(w, http.StatusBadRequest, "Invalid start date")
+			return
+		}
+		s.StartDate = startDate
+	}
+	if req.Active != nil {
+		s.Active = *req.Active
+	}
+	if msg := validateScheduled(s); msg != "" {
+		sendError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	if err := h.repo.Update(r.Context(), s); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			sendError(w, http.StatusNotFound, "Scheduled transaction not found")
+			return
+		}
+		logger.Error("DB Error (update scheduled transaction)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to update scheduled transaction")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": s})
+}
+
+// DELETE /scheduled_transactions/{scheduledID}
+// Entries already posted stay.
+func (h *ScheduledHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "scheduledID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid scheduled transaction ID")
+		return
+	}
+
+	if err := h.repo.Delete(r.Context(), familyID, id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			sendError(w, http.StatusNotFound, "Scheduled transaction not found")
+			return
+		}
+		logger.Error("DB Error (delete scheduled transaction)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to delete scheduled transaction")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]string{"message": "Scheduled transaction deleted"})
+}
+
+// GET /scheduled_transactions/{scheduledID}/occurrences?from=2024-01-01&to=2024-03-31
+// The schedule's dates in the range, by default the next 90 days, with what was posted,
+// skipped or overridden for each.
+func (h *ScheduledHandler) Occurrences(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if v := r.URL.Query().Get("from"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			sendError(w, http.StatusBadRequest, "Invalid from date")
+			return
+		}
+		from = t
+	}
+	to := from.AddDate(0, 0, defaultOccurrenceDays)
+	if v := r.URL.Query().Get("to"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil || t.Before(from) {
+			sendError(w, http.StatusBadRequest, "Invalid to date")
+			return
+		}
+		to = t
+	}
+	if to.After(from.AddDate(0, 0, maxOccurrenceDays)) {
+		sendError(w, http.StatusBadRequest, "Date range is too long")
+		return
+	}
+
+	s, ok := h.scheduled(w, r)
+	if !ok {
+		return
+	}
+	rule, err := schedule.Parse(s.Rule)
+	if err != nil {
+		logger.Error("Invalid stored schedule rule", zap.String("schedule_id", s.ID.String()), zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to list occurrences")
+		return
+	}
+	recorded, err := h.repo.ListOccurrences(r.Context(), s.ID, from, to)
+	if err != nil {
+		logger.Error("DB Error (list scheduled occurrences)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to list occurrences")
+		return
+	}
+
+	byDate := make(map[time.Time]OccurrenceResponse)
+	for _, d := range rule.Between(s.StartDate, from, to) {
+		byDate[d] = OccurrenceResponse{Date: d, Status: occurrenceStatusScheduled, Amount: s.Amount, Name: s.Name}
+	}
+	// Recorded occurrences show even when a later rule change moved the schedule off them
+	for _, o := range recorded {
+		d := o.Date.UTC()
+		occ := OccurrenceResponse{Date: d, Status: o.Status, Amount: s.Amount, Name: s.Name, EntryID: o.EntryID}
+		if o.Amount != nil {
+			occ.Amount = *o.Amount
+		}
+		if o.Name != nil {
+			occ.Name = *o.Name
+		}
+		byDate[d] = occ
+	}
+
+	occurrences := make([]OccurrenceResponse, 0, len(byDate))
+	for _, occ := range byDate {
+		occurrences = append(occurrences, occ)
+	}
+	sort.Slice(occurrences, func(i, j int) bool { return occurrences[i].Date.Before(occurrences[j].Date) })
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": occurrences})
+}
+
+// PUT /scheduled_transactions/{scheduledID}/occurrences/{date}
+// Skips one occurrence, or changes its amount or name, before it is posted.
+func (h *ScheduledHandler) SetOccurrence(w http.ResponseWriter, r *http.Request) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+	date, err := time.Parse("2006-01-02", chi.URLParam(r, "date"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid date")
+		return
+	}
+
+	var req occurrenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	o := &models.ScheduledOccurrence{Date: date, Status: models.OccurrenceStatusSkipped}
+	if !req.Skip {
+		if req.Amount == nil && req.Name == nil {
+			sendError(w, http.StatusBadRequest, "Skip the occurrence or give an amount or name")
+			return
+		}
+		if req.Name != nil {
+			name := strings.TrimSpace(*req.Name)
+			if name == "" {
+				sendError(w, http.StatusBadRequest, "Name can't be empty")
+				return
+			}
+			o.Name = &name
+		}
+		o.Status, o.Amount = models.OccurrenceStatusOverridden, req.Amount
+	}
+
+	s, ok := h.scheduled(w, r)
+	if !ok {
+		return
+	}
+	if !isOccurrence(s, date) {
+		sendError(w, http.StatusBadRequest, "Date is not an occurrence of the schedule")
+		return
+	}
+	o.ScheduledTransactionID = s.ID
+
+	if err := h.repo.SetOccurrence(r.Context(), familyID, o); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			sendError(w, http.StatusNotFound, "Scheduled transaction not found")
+		case errors.Is(err, models.ErrOccurrencePosted):
+			sendError(w, http.StatusConflict, "Occurrence has already been posted")
+		default:
+			logger.Error("DB Error (set scheduled occurrence)", zap.Error(err))
+			sendError(w, http.StatusInternalServerError, "Failed to update occurrence")
+		}
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": o})
+}
+
+// DELETE /scheduled_transactions/{scheduledID}/occurrences/{date}
+// Undoes a skip or override, so the occurrence posts as scheduled.
+func (h *ScheduledHandler) DeleteOccurrence(w http.ResponseWriter, r *http.Request) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "scheduledID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid scheduled transaction ID")
+		return
+	}
+	date, err := time.Parse("2006-01-02", chi.URLParam(r, "date"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid date")
+		return
+	}
+
+	if err := h.repo.DeleteOccurrence(r.Context(), familyID, id, date); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			sendError(w, http.StatusNotFound, "Occurrence not found")
+		case errors.Is(err, models.ErrOccurrencePosted):
+			sendError(w, http.StatusConflict, "Occurrence has already been posted")
+		default:
+			logger.Error("DB Error (delete scheduled occurrence)", zap.Error(err))
+			sendError(w, http.StatusInternalServerError, "Failed to update occurrence")
+		}
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]string{"message": "Occurrence restored"})
+}
+
+// scheduled loads the family's schedule named in the URL, writing the error response
+// if it can't.
+func (h *ScheduledHandler) scheduled(w http.ResponseWriter, r *http.Request) (*models.ScheduledTransaction, bool) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return nil, false
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "scheduledID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid scheduled transaction ID")
+		return nil, false
+	}
+
+	s, err := h.repo.GetByID(r.Context(), familyID, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			sendError(w, http.StatusNotFound, "Scheduled transaction not found")
+			return nil, false
+		}
+		logger.Error("DB Error (get scheduled transaction)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to fetch scheduled transaction")
+		return nil, false
+	}
+	return s, true
+}
+
+// validateScheduled returns a message for the first problem with s, or "".
+func validateScheduled(s *models.ScheduledTransaction) string {
+	switch {
+	case s.Name == "":
+		return "Name is required"
+	case s.Amount == 0:
+		return "Amount is required"
+	case s.Currency != "" && len(s.Currency) != 3:
+		return "Invalid currency"
+	}
+	if _, err := schedule.Parse(s.Rule); err != nil {
+		return "Invalid rule: " + err.Error()
+	}
+	return ""
+}
+
+func isOccurrence(s *models.ScheduledTransaction, date time.Time) bool {
+	rule, err := schedule.Parse(s.Rule)
+	if err != nil {
+		return false
+	}
+	return len(rule.Between(s.StartDate, date, date)) == 1
+}
@@ -0,0 +1,181 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/rest/mocks"
+)
+
+func TestScheduledHandler_Create(t *testing.T) {
+	store := mocks.NewScheduledStore()
+	handler := NewScheduledHandler(store)
+	user := &models.User{ID: uuid.New(), FamilyID: uuid.New()}
+
+	manual, linked := uuid.New(), uuid.New()
+	store.Accounts[manual] = user.FamilyID
+	store.Accounts[linked] = user.FamilyID
+	store.LinkedAccounts[linked] = true
+
+	create := func(req createScheduledRequest) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.Create(w, familyRequest("POST", "/scheduled_transactions", req, user, nil))
+		return w
+	}
+	rent := createScheduledRequest{
+		AccountID: manual, Name: "Rent", Amount: 1200, Rule: "FREQ=MONTHLY;BYMONTHDAY=1", StartDate: "2024-01-01",
+	}
+
+	t.Run("success", func(t *testing.T) {
+		w := create(rent)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d. Body: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data models.ScheduledTransaction `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp)
+		if resp.Data.ID == uuid.Nil || !resp.Data.Active || resp.Data.Currency != "USD" {
+			t.Errorf("Expected an active schedule in the account's currency, got %+v", resp.Data)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for name, mutate := range map[string]func(*createScheduledRequest){
+			"rule":       func(r *createScheduledRequest) { r.Rule = "FREQ=HOURLY" },
+			"start date": func(r *createScheduledRequest) { r.StartDate = "01/01/2024" },
+			"name":       func(r *createScheduledRequest) { r.Name = " " },
+			"amount":     func(r *createScheduledRequest) { r.Amount = 0 },
+		} {
+			req := rent
+			mutate(&req)
+			if w := create(req); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for an invalid %s, got %d", name, w.Code)
+			}
+		}
+	})
+
+	t.Run("linked account", func(t *testing.T) {
+		req := rent
+		req.AccountID = linked
+		if w := create(req); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for a Plaid account, got %d", w.Code)
+		}
+	})
+
+	t.Run("other family's account", func(t *testing.T) {
+		req := rent
+		req.AccountID = uuid.New()
+		if w := create(req); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
+
+func TestScheduledHandler_Occurrences(t *testing.T) {
+	store := mocks.NewScheduledStore()
+	handler := NewScheduledHandler(store)
+	user := &models.User{ID: uuid.New(), FamilyID: uuid.New()}
+
+	rent := &models.ScheduledTransaction{
+		ID: uuid.New(), FamilyID: user.FamilyID, AccountID: uuid.New(), Name: "Rent", Amount: 1200, Currency: "USD",
+		Rule: "FREQ=MONTHLY;BYMONTHDAY=1", StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Active: true,
+	}
+	store.Scheduled[rent.ID] = rent
+	entryID := uuid.New()
+	store.Occurrences[rent.ID] = []models.ScheduledOccurrence{
+		{ScheduledTransactionID: rent.ID, Date: rent.StartDate, Status: models.OccurrenceStatusPosted, EntryID: &entryID},
+	}
+
+	set := func(date string, body occurrenceRequest) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := familyRequest("PUT", "/scheduled_transactions/"+rent.ID.String()+"/occurrences/"+date, body, user,
+			map[string]string{"scheduledID": rent.ID.String(), "date": date})
+		handler.SetOccurrence(w, req)
+		return w
+	}
+	list := func(t *testing.T) []OccurrenceResponse {
+		w := httptest.NewRecorder()
+		handler.Occurrences(w, familyRequest("GET", "/scheduled_transactions/"+rent.ID.String()+"/occurrences?from=2024-01-01&to=2024-04-30",
+			nil, user, map[string]string{"scheduledID": rent.ID.String()}))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data []OccurrenceResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp.Data
+	}
+
+	t.Run("skip and override", func(t *testing.T) {
+		if w := set("2024-02-01", occurrenceRequest{Skip: true}); w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+		amount := 1250.0
+		if w := set("2024-03-01", occurrenceRequest{Amount: &amount}); w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+
+		got := list(t)
+		want := []struct {
+			status string
+			amount float64
+		}{
+			{models.OccurrenceStatusPosted, 1200},
+			{models.OccurrenceStatusSkipped, 1200},
+			{models.OccurrenceStatusOverridden, 1250},
+			{occurrenceStatusScheduled, 1200},
+		}
+		if len(got) != len(want) {
+			t.Fatalf("Expected %d occurrences, got %+v", len(want), got)
+		}
+		for i, w := range want {
+			if got[i].Status != w.status || got[i].Amount != w.amount {
+				t.Errorf("Occurrence %d: expected %s for %.2f, got %+v", i, w.status, w.amount, got[i])
+			}
+		}
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		if w := set("2024-02-15", occurrenceRequest{Skip: true}); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for a date off the schedule, got %d", w.Code)
+		}
+		if w := set("2024-04-01", occurrenceRequest{}); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for an empty override, got %d", w.Code)
+		}
+		if w := set("2024-01-01", occurrenceRequest{Skip: true}); w.Code != http.StatusConflict {
+			t.Errorf("Expected status 409 for a posted occurrence, got %d", w.Code)
+		}
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.DeleteOccurrence(w, familyRequest("DELETE", "/scheduled_transactions/"+rent.ID.String()+"/occurrences/2024-02-01",
+			nil, user, map[string]string{"scheduledID": rent.ID.String(), "date": "2024-02-01"}))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+		if got := list(t); got[1].Status != occurrenceStatusScheduled {
+			t.Errorf("Expected the skip to be undone, got %+v", got[1])
+		}
+	})
+
+	t.Run("pause", func(t *testing.T) {
+		active := false
+		w := httptest.NewRecorder()
+		handler.Update(w, familyRequest("PUT", "/scheduled_transactions/"+rent.ID.String(), updateScheduledRequest{Active: &active},
+			user, map[string]string{"scheduledID": rent.ID.String()}))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+		if store.Scheduled[rent.ID].Active || store.Scheduled[rent.ID].Name != "Rent" {
+			t.Errorf("Expected the schedule paused with its other fields kept, got %+v", store.Scheduled[rent.ID])
+		}
+	})
+}
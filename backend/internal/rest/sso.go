@@ -0,0 +1,152 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	// Audience of the state tokens that carry an SSO login from start to callback
+	ssoStateAudience = "oidc"
+	// How long the user has to sign in at the identity provider
+	ssoStateTTL = 10 * time.Minute
+)
+
+// SSOProvider is satisfied by *services.OIDCProvider.
+type SSOProvider interface {
+	AuthCodeURL() (*models.OIDCAuthRequest, error)
+	Exchange(ctx context.Context, code, verifier, nonce string) (*models.ExternalIdentity, error)
+}
+
+// IdentityStore is satisfied by *postgres.UserRepository.
+type IdentityStore interface {
+	SignInWithIdentity(ctx context.Context, identity *models.ExternalIdentity, provisioning models.SSOProvisioning) (*models.User, error)
+}
+
+// SSOHandler signs users in through an OpenID Connect provider, alongside password
+// login. Sessions are started by the AuthHandler, so SSO logins still go through
+// two-factor authentication when the user has it on.
+type SSOHandler struct {
+	auth         *AuthHandler
+	provider     SSOProvider
+	repo         IdentityStore
+	provisioning models.SSOProvisioning
+}
+
+func NewSSOHandler(auth *AuthHandler, provider SSOProvider, repo IdentityStore, provisioning models.SSOProvisioning) *SSOHandler {
+	return &SSOHandler{auth: auth, provider: provider, repo: repo, provisioning: provisioning}
+}
+
+type SSOCallbackRequest struct {
+	Code       string `json:"code"`
+	State      string `json:"state"`
+	StateToken string `json:"state_token"`
+}
+
+// POST /auth/oidc/start
+// Returns the provider URL to send the user to and a state token the client keeps until
+// the provider redirects back with a code.
+func (h *SSOHandler) Start(w http.ResponseWriter, r *http.Request) {
+	authRequest, err := h.provider.AuthCodeURL()
+	if err != nil {
+		logger.Error("Failed to start SSO login", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to start single sign-on")
+		return
+	}
+
+	stateToken, err := h.newStateToken(authRequest)
+	if err != nil {
+		sendError(w, http.StatusInternalServerError, "Failed to start single sign-on")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"authorization_url": authRequest.URL,
+			"state_token":       stateToken,
+			"expires_in":        int(ssoStateTTL.Seconds()),
+		},
+	})
+}
+
+// POST /auth/oidc/callback
+// Trades the code and state from the provider's redirect, plus the state token from
+// Start, for a session (or an MFA challenge, like Login).
+func (h *SSOHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	var req SSOCallbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" || req.State == "" || req.StateToken == "" {
+		sendError(w, http.StatusBadRequest, "Code, state and state token are required")
+		return
+	}
+
+	claims, err := h.parseStateToken(req.StateToken)
+	if err != nil || claims.State != req.State {
+		sendError(w, http.StatusUnauthorized, "Invalid or expired single sign-on attempt")
+		return
+	}
+
+	identity, err := h.provider.Exchange(r.Context(), req.Code, claims.Verifier, claims.Nonce)
+	if err != nil {
+		logger.Warn("SSO code exchange failed", zap.Error(err))
+		sendError(w, http.StatusUnauthorized, "Single sign-on failed")
+		return
+	}
+
+	user, err := h.repo.SignInWithIdentity(r.Context(), identity, h.provisioning)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrIdentityEmailUnverified):
+			sendError(w, http.StatusForbidden, "Your identity provider hasn't verified your email address")
+		case errors.Is(err, models.ErrIdentityDomainNotAllowed):
+			sendError(w, http.StatusForbidden, "Your email domain can't sign up with single sign-on")
+		default:
+			logger.Error("DB Error (SSO login)", zap.Error(err))
+			sendError(w, http.StatusInternalServerError, "Failed to sign in")
+		}
+		return
+	}
+
+	h.auth.signIn(w, r, user)
+}
+
+type ssoStateClaims struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	jwt.RegisteredClaims
+}
+
+// newStateToken signs what the callback needs to check the provider's response. The
+// PKCE verifier travels with it: only the client that started the login holds the token,
+// just as a public client would hold the verifier itself.
+func (h *SSOHandler) newStateToken(authRequest *models.OIDCAuthRequest) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, ssoStateClaims{
+		State:    authRequest.State,
+		Nonce:    authRequest.Nonce,
+		Verifier: authRequest.Verifier,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{ssoStateAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ssoStateTTL)),
+		},
+	})
+	return token.SignedString(h.auth.jwtSecret)
+}
+
+func (h *SSOHandler) parseStateToken(tokenString string) (*ssoStateClaims, error) {
+	var claims ssoStateClaims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
+		return h.auth.jwtSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithAudience(ssoStateAudience), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
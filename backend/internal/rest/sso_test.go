@@ -0,0 +1,154 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/rest/mocks"
+)
+
+func newTestSSOHandler(identity *models.ExternalIdentity, provisioning models.SSOProvisioning) (*SSOHandler, *mocks.UserStore) {
+	store := mocks.NewUserStore()
+	auth := NewAuthHandler(store, mocks.NewSessionStore(), mocks.NewAccountMailer(), mocks.NewMFAManager(), testLockout, "test-secret")
+	return NewSSOHandler(auth, mocks.NewSSOProvider(identity), store, provisioning), store
+}
+
+// startSSO runs Start and returns the state token.
+func startSSO(t *testing.T, handler *SSOHandler) string {
+	t.Helper()
+	w := postJSON(handler.Start, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 from start, got %d", w.Code)
+	}
+	var response struct {
+		Data struct {
+			AuthorizationURL string `json:"authorization_url"`
+			StateToken       string `json:"state_token"`
+		} `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+	if response.Data.AuthorizationURL == "" || response.Data.StateToken == "" {
+		t.Fatal("Expected an authorization URL and state token")
+	}
+	return response.Data.StateToken
+}
+
+func TestSSOHandler_LinksVerifiedEmail(t *testing.T) {
+	identity := &models.ExternalIdentity{Issuer: "https://idp.example.com", Subject: "sub-1", Email: "test@example.com", EmailVerified: true}
+	handler, store := newTestSSOHandler(identity, models.SSOProvisioning{})
+	store.AddUser("test@example.com", "hash", uuid.New())
+	user := store.Users["test@example.com"]
+	verifiedAt := time.Now()
+	user.EmailVerifiedAt = &verifiedAt
+
+	stateToken := startSSO(t, handler)
+
+	// The state from the provider's redirect must match the one the login started with
+	w := postJSON(handler.Callback, SSOCallbackRequest{Code: mocks.ValidSSOCode, State: "other-state", StateToken: stateToken})
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected mismatched state to be rejected, got %d", w.Code)
+	}
+
+	w = postJSON(handler.Callback, SSOCallbackRequest{Code: mocks.ValidSSOCode, State: "state-1", StateToken: stateToken})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Data struct {
+			RefreshToken string `json:"refresh_token"`
+			User         struct {
+				ID uuid.UUID `json:"id"`
+			} `json:"user"`
+		} `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+	if response.Data.RefreshToken == "" || response.Data.User.ID != user.ID {
+		t.Errorf("Expected a session for the existing user, got %+v", response.Data)
+	}
+	if store.Identities[identity.Issuer+" "+identity.Subject] != user.ID {
+		t.Error("Expected the identity to be linked to the existing user")
+	}
+	if store.Passwords["test@example.com"] != "hash" {
+		t.Error("Expected a verified user to keep their password")
+	}
+}
+
+func TestSSOHandler_LinkingUnverifiedUserDropsPassword(t *testing.T) {
+	// Someone registered the address first, without proving they own it
+	identity := &models.ExternalIdentity{Issuer: "https://idp.example.com", Subject: "sub-1", Email: "test@example.com", EmailVerified: true}
+	handler, store := newTestSSOHandler(identity, models.SSOProvisioning{})
+	store.AddUser("test@example.com", "hash", uuid.New())
+	user := store.Users["test@example.com"]
+
+	w := postJSON(handler.Callback, SSOCallbackRequest{Code: mocks.ValidSSOCode, State: "state-1", StateToken: startSSO(t, handler)})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if store.Identities[identity.Issuer+" "+identity.Subject] != user.ID {
+		t.Error("Expected the identity to be linked to the existing user")
+	}
+	if _, ok := store.Passwords["test@example.com"]; ok {
+		t.Error("Expected the unverified password to stop working")
+	}
+	if user.EmailVerifiedAt == nil {
+		t.Error("Expected the email to count as verified")
+	}
+}
+
+func TestSSOHandler_UnverifiedEmail(t *testing.T) {
+	identity := &models.ExternalIdentity{Issuer: "https://idp.example.com", Subject: "sub-1", Email: "test@example.com"}
+	handler, store := newTestSSOHandler(identity, models.SSOProvisioning{})
+	store.AddUser("test@example.com", "hash", uuid.New())
+
+	w := postJSON(handler.Callback, SSOCallbackRequest{Code: mocks.ValidSSOCode, State: "state-1", StateToken: startSSO(t, handler)})
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", w.Code)
+	}
+	if len(store.Identities) != 0 {
+		t.Error("Expected no identity to be linked")
+	}
+}
+
+func TestSSOHandler_ProvisionsIntoFamily(t *testing.T) {
+	familyID := uuid.New()
+	provisioning := models.SSOProvisioning{FamilyID: &familyID, Role: models.RoleMember, AllowedDomains: []string{"example.com"}}
+
+	identity := &models.ExternalIdentity{Issuer: "https://idp.example.com", Subject: "sub-1", Email: "new@example.com", EmailVerified: true}
+	handler, store := newTestSSOHandler(identity, provisioning)
+
+	w := postJSON(handler.Callback, SSOCallbackRequest{Code: mocks.ValidSSOCode, State: "state-1", StateToken: startSSO(t, handler)})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	user := store.Users["new@example.com"]
+	if user == nil || user.FamilyID != familyID || user.Role != models.RoleMember {
+		t.Errorf("Expected a member of the configured family, got %+v", user)
+	}
+
+	identity.Email, identity.Subject = "someone@elsewhere.com", "sub-2"
+	w = postJSON(handler.Callback, SSOCallbackRequest{Code: mocks.ValidSSOCode, State: "state-1", StateToken: startSSO(t, handler)})
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected other domains to be refused, got %d", w.Code)
+	}
+}
+
+func TestSSOHandler_RequiresMFA(t *testing.T) {
+	identity := &models.ExternalIdentity{Issuer: "https://idp.example.com", Subject: "sub-1", Email: "test@example.com", EmailVerified: true}
+	handler, store := newTestSSOHandler(identity, models.SSOProvisioning{})
+	store.AddUser("test@example.com", "hash", uuid.New())
+	verifiedAt := time.Now()
+	store.Users["test@example.com"].EmailVerifiedAt = &verifiedAt
+	store.Users["test@example.com"].MFAEnabled = true
+
+	w := postJSON(handler.Callback, SSOCallbackRequest{Code: mocks.ValidSSOCode, State: "state-1", StateToken: startSSO(t, handler)})
+	var response map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&response)
+	data, _ := response["data"].(map[string]interface{})
+	if data["mfa_required"] != true || data["refresh_token"] != nil {
+		t.Errorf("Expected an MFA challenge instead of a session, got %v", data)
+	}
+}
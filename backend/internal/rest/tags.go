@@ -0,0 +1,263 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	maxTagNameLength = 50
+
+	// Most tags and transactions in one bulk tag or untag request
+	maxBulkTags    = 20
+	maxBulkEntries = 500
+)
+
+var tagColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+type TagStore interface {
+	List(ctx context.Context, familyID uuid.UUID) ([]models.Tag, error)
+	Create(ctx context.Context, tag *models.Tag) error
+	Update(ctx context.Context, tag *models.Tag) error
+	Delete(ctx context.Context, familyID, id uuid.UUID) error
+	TagEntries(ctx context.Context, familyID uuid.UUID, tagIDs, entryIDs []uuid.UUID) (int64, error)
+	UntagEntries(ctx context.Context, familyID uuid.UUID, tagIDs, entryIDs []uuid.UUID) (int64, error)
+}
+
+type TagHandler struct {
+	repo TagStore
+}
+
+func NewTagHandler(repo TagStore) *TagHandler {
+	return &TagHandler{repo: repo}
+}
+
+// Color is optional, as #rrggbb.
+type tagRequest struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+type BulkTagRequest struct {
+	EntryIDs []uuid.UUID `json:"entry_ids"`
+	TagIDs   []uuid.UUID `json:"tag_ids"`
+}
+
+// GET /tags
+func (h *TagHandler) List(w http.ResponseWriter, r *http.Request) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+
+	tags, err := h.repo.List(r.Context(), familyID)
+	if err != nil {
+		logger.Error("DB Error (list tags)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to list tags")
+		return
+	}
+	if tags == nil {
+		tags = []models.Tag{}
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": tags})
+}
+
+// POST /tags
+func (h *TagHandler) Create(w http.ResponseWriter, r *http.Request) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+
+	tag, ok := decodeTag(w, r)
+	if !ok {
+		return
+	}
+	tag.FamilyID = familyID
+
+	if err := h.repo.Create(r.Context(), tag); err != nil {
+		if errors.Is(err, models.ErrTagNameTaken) {
+			sendError(w, http.StatusConflict, "A tag with this name already exists")
+			return
+		}
+		logger.Error("DB Error (create tag)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to create tag")
+		return
+	}
+
+	sendJSON(w, http.StatusCreated, map[string]interface{}{"data": tag})
+}
+
+// PUT /tags/{tagID}
+func (h *TagHandler) Update(w http.ResponseWriter, r *http.Request) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "tagID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid tag ID")
+		return
+	}
+
+	tag, ok := decodeTag(w, r)
+	if !ok {
+		return
+	}
+	tag.ID, tag.FamilyID = id, familyID
+
+	if err := h.repo.Update(r.Context(), tag); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			sendError(w, http.StatusNotFound, "Tag not found")
+		case errors.Is(err, models.ErrTagNameTaken):
+			sendError(w, http.StatusConflict, "A tag with this name already exists")
+		default:
+			logger.Error("DB Error (update tag)", zap.Error(err))
+			sendError(w, http.StatusInternalServerError, "Failed to update tag")
+		}
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": tag})
+}
+
+// DELETE /tags/{tagID}
+// Also takes the tag off every transaction.
+func (h *TagHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "tagID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid tag ID")
+		return
+	}
+
+	if err := h.repo.Delete(r.Context(), familyID, id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			sendError(w, http.StatusNotFound, "Tag not found")
+			return
+		}
+		logger.Error("DB Error (delete tag)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to delete tag")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]string{"message": "Tag deleted"})
+}
+
+// POST /transactions/bulk_tag
+// Puts every tag on every transaction; ones already tagged are left as they are.
+func (h *TagHandler) BulkTag(w http.ResponseWriter, r *http.Request) {
+	h.bulk(w, r, h.repo.TagEntries, "tagged")
+}
+
+// POST /transactions/bulk_untag
+func (h *TagHandler) BulkUntag(w http.ResponseWriter, r *http.Request) {
+	h.bulk(w, r, h.repo.UntagEntries, "untagged")
+}
+
+func (h *TagHandler) bulk(w http.ResponseWriter, r *http.Request,
+	apply func(ctx context.Context, familyID uuid.UUID, tagIDs, entryIDs []uuid.UUID) (int64, error), verb string) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+
+	var req BulkTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	tagIDs, entryIDs := distinctIDs(req.TagIDs), distinctIDs(req.EntryIDs)
+	switch {
+	case len(tagIDs) == 0 || len(entryIDs) == 0:
+		sendError(w, http.StatusBadRequest, "Give at least one tag and one transaction")
+		return
+	case len(tagIDs) > maxBulkTags || len(entryIDs) > maxBulkEntries:
+		sendError(w, http.StatusBadRequest, "Too many tags or transactions")
+		return
+	}
+
+	count, err := apply(r.Context(), familyID, tagIDs, entryIDs)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			sendError(w, http.StatusNotFound, "Tag or transaction not found")
+			return
+		}
+		logger.Error("DB Error (bulk "+verb+")", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to update tags")
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": map[string]int64{verb: count}})
+}
+
+// decodeTag reads and checks a tag from the request body, writing the error response
+// if it can't.
+func decodeTag(w http.ResponseWriter, r *http.Request) (*models.Tag, bool) {
+	var req tagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return nil, false
+	}
+
+	tag := &models.Tag{Name: strings.TrimSpace(req.Name), Color: req.Color}
+	switch {
+	case tag.Name == "":
+		sendError(w, http.StatusBadRequest, "Name is required")
+		return nil, false
+	case utf8.RuneCountInString(tag.Name) > maxTagNameLength:
+		sendError(w, http.StatusBadRequest, "Name is too long")
+		return nil, false
+	case tag.Color != "" && !tagColor.MatchString(tag.Color):
+		sendError(w, http.StatusBadRequest, "Invalid color")
+		return nil, false
+	}
+	return tag, true
+}
+
+// parseTagIDs reads the tag IDs of a ?tag= filter, which may be repeated.
+func parseTagIDs(values []string) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for _, v := range values {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return nil, errors.New("Invalid tag")
+		}
+		ids = append(ids, id)
+	}
+	return distinctIDs(ids), nil
+}
+
+func distinctIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	var distinct []uuid.UUID
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			distinct = append(distinct, id)
+		}
+	}
+	return distinct
+}
@@ -0,0 +1,129 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/rest/mocks"
+)
+
+func TestTagHandler_CRUD(t *testing.T) {
+	store := mocks.NewTagStore()
+	handler := NewTagHandler(store)
+	user := &models.User{ID: uuid.New(), FamilyID: uuid.New()}
+
+	create := func(req tagRequest) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.Create(w, familyRequest("POST", "/tags", req, user, nil))
+		return w
+	}
+
+	w := create(tagRequest{Name: " vacation-2026 ", Color: "#1e90ff"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data models.Tag `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	vacation := resp.Data
+	if vacation.Name != "vacation-2026" || vacation.FamilyID != user.FamilyID {
+		t.Errorf("Expected a trimmed tag in the user's family, got %+v", vacation)
+	}
+
+	if w := create(tagRequest{Name: "Vacation-2026"}); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 for a name differing only in case, got %d", w.Code)
+	}
+	if w := create(tagRequest{Name: "reimbursable", Color: "blue"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid color, got %d", w.Code)
+	}
+	if w := create(tagRequest{Name: " "}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an empty name, got %d", w.Code)
+	}
+
+	t.Run("update", func(t *testing.T) {
+		params := map[string]string{"tagID": vacation.ID.String()}
+		w := httptest.NewRecorder()
+		handler.Update(w, familyRequest("PUT", "/tags/"+vacation.ID.String(), tagRequest{Name: "summer-2026"}, user, params))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+		if store.Tags[vacation.ID].Name != "summer-2026" {
+			t.Errorf("Expected the tag renamed, got %+v", store.Tags[vacation.ID])
+		}
+
+		other := &models.User{ID: uuid.New(), FamilyID: uuid.New()}
+		w = httptest.NewRecorder()
+		handler.Update(w, familyRequest("PUT", "/tags/"+vacation.ID.String(), tagRequest{Name: "mine"}, other, params))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for another family's tag, got %d", w.Code)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		params := map[string]string{"tagID": vacation.ID.String()}
+		w := httptest.NewRecorder()
+		handler.Delete(w, familyRequest("DELETE", "/tags/"+vacation.ID.String(), nil, user, params))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if _, ok := store.Tags[vacation.ID]; ok {
+			t.Error("Expected the tag to be deleted")
+		}
+	})
+}
+
+func TestTagHandler_Bulk(t *testing.T) {
+	store := mocks.NewTagStore()
+	handler := NewTagHandler(store)
+	user := &models.User{ID: uuid.New(), FamilyID: uuid.New()}
+
+	reimbursable := &models.Tag{ID: uuid.New(), FamilyID: user.FamilyID, Name: "reimbursable"}
+	store.Tags[reimbursable.ID] = reimbursable
+	first, second := uuid.New(), uuid.New()
+	store.Entries[first] = user.FamilyID
+	store.Entries[second] = user.FamilyID
+	otherFamilys := uuid.New()
+	store.Entries[otherFamilys] = uuid.New()
+
+	bulk := func(tag bool, req BulkTagRequest) (*httptest.ResponseRecorder, map[string]int64) {
+		w := httptest.NewRecorder()
+		if tag {
+			handler.BulkTag(w, familyRequest("POST", "/transactions/bulk_tag", req, user, nil))
+		} else {
+			handler.BulkUntag(w, familyRequest("POST", "/transactions/bulk_untag", req, user, nil))
+		}
+		var resp struct {
+			Data map[string]int64 `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w, resp.Data
+	}
+
+	w, counts := bulk(true, BulkTagRequest{TagIDs: []uuid.UUID{reimbursable.ID}, EntryIDs: []uuid.UUID{first, second, first}})
+	if w.Code != http.StatusOK || counts["tagged"] != 2 {
+		t.Fatalf("Expected both transactions tagged once, got %d %v", w.Code, counts)
+	}
+
+	w, _ = bulk(true, BulkTagRequest{TagIDs: []uuid.UUID{reimbursable.ID}, EntryIDs: []uuid.UUID{first, otherFamilys}})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 when a transaction isn't the family's, got %d", w.Code)
+	}
+
+	w, _ = bulk(true, BulkTagRequest{EntryIDs: []uuid.UUID{first}})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without tags, got %d", w.Code)
+	}
+
+	w, counts = bulk(false, BulkTagRequest{TagIDs: []uuid.UUID{reimbursable.ID}, EntryIDs: []uuid.UUID{second}})
+	if w.Code != http.StatusOK || counts["untagged"] != 1 {
+		t.Fatalf("Expected one transaction untagged, got %d %v", w.Code, counts)
+	}
+	if !store.Taggings[first][reimbursable.ID] || store.Taggings[second][reimbursable.ID] {
+		t.Errorf("Expected only the first transaction to keep the tag, got %+v", store.Taggings)
+	}
+}
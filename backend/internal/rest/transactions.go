@@ -3,17 +3,32 @@ package rest
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
 	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultTransactionLimit = 50
+	maxTransactionLimit     = 500
 )
 
 type TransactionStore interface {
+	ListTransactions(ctx context.Context, familyID uuid.UUID, filter models.TransactionFilter) ([]models.TransactionDetail, error)
 	GetOrCreateMerchant(ctx context.Context, name string, familyID uuid.UUID) (uuid.UUID, error)
 	CreateTransaction(ctx context.Context, entry *models.Entry, txDetail *models.Transaction) error
 	CreateTransfer(ctx context.Context, fromEntry, toEntry *models.Entry) error
+	GetSplits(ctx context.Context, familyID, entryID uuid.UUID) (*models.Entry, []models.TransactionSplit, error)
+	SetSplits(ctx context.Context, familyID, entryID uuid.UUID, lines []models.TransactionSplit) ([]models.TransactionSplit, error)
 }
 
 type TransactionHandler struct {
@@ -31,6 +46,55 @@ type CreateTransactionRequest struct {
 	Name         string     `json:"name"`
 	CategoryID   *uuid.UUID `json:"category_id"`
 	MerchantName string     `json:"merchant_name"`
+
+	// Divides the transaction across categories; the amounts must add up to Amount
+	Splits []SplitLineRequest `json:"splits"`
+}
+
+type SplitLineRequest struct {
+	CategoryID *uuid.UUID `json:"category_id"`
+	Amount     float64    `json:"amount"`
+	Memo       string     `json:"memo"`
+}
+
+type SetSplitsRequest struct {
+	Splits []SplitLineRequest `json:"splits"`
+}
+
+type SplitsResponse struct {
+	EntryID  uuid.UUID                 `json:"entryId"`
+	Amount   float64                   `json:"amount"`
+	Currency string                    `json:"currency"`
+	Splits   []models.TransactionSplit `json:"splits"`
+}
+
+// GET /transactions
+// Filters: account_id, tag (repeat it for entries with every one of the tags), from and
+// to (YYYY-MM-DD, inclusive), plus limit and offset for paging. Newest first.
+func (h *TransactionHandler) List(w http.ResponseWriter, r *http.Request) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+
+	filter, err := parseTransactionFilter(r)
+	if err != nil {
+		sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	list, err := h.repo.ListTransactions(r.Context(), familyID, filter)
+	if err != nil {
+		logger.Error("DB Error (list transactions)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to list transactions")
+		return
+	}
+	if list == nil {
+		list = []models.TransactionDetail{}
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": list})
 }
 
 func (h *TransactionHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -74,10 +138,15 @@ func (h *TransactionHandler) Create(w http.ResponseWriter, r *http.Request) {
 		CategoryID: req.CategoryID,
 		MerchantID: merchantID,
 		Kind:       "standard",
+		Splits:     splitLines(req.Splits),
 	}
 
 	// 3. Create in DB
 	if err := h.repo.CreateTransaction(r.Context(), entry, txDetail); err != nil {
+		if msg, ok := splitErrorMessage(err); ok {
+			sendError(w, http.StatusBadRequest, msg)
+			return
+		}
 		sendError(w, http.StatusInternalServerError, "Failed to create transaction")
 		return
 	}
@@ -104,9 +173,10 @@ func (h *TransactionHandler) CreateTransfer(w http.ResponseWriter, r *http.Reque
 		req.Date = time.Now()
 	}
 
+	// Signed as in the ledger: positive on the account the money leaves
 	fromEntry := &models.Entry{
 		AccountID: req.FromAccountID,
-		Amount:    -req.Amount,
+		Amount:    req.Amount,
 		Date:      req.Date,
 		Name:      req.Name,
 		Currency:  "USD",
@@ -114,7 +184,7 @@ func (h *TransactionHandler) CreateTransfer(w http.ResponseWriter, r *http.Reque
 
 	toEntry := &models.Entry{
 		AccountID: req.ToAccountID,
-		Amount:    req.Amount,
+		Amount:    -req.Amount,
 		Date:      req.Date,
 		Name:      req.Name,
 		Currency:  "USD",
@@ -127,3 +197,156 @@ func (h *TransactionHandler) CreateTransfer(w http.ResponseWriter, r *http.Reque
 
 	sendJSON(w, http.StatusCreated, map[string]string{"message": "Transfer successful"})
 }
+
+// GET /transactions/{entryID}/splits
+// The transaction's split lines, or none if it isn't split.
+func (h *TransactionHandler) GetSplits(w http.ResponseWriter, r *http.Request) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+	entryID, err := uuid.Parse(chi.URLParam(r, "entryID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	entry, lines, err := h.repo.GetSplits(r.Context(), familyID, entryID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			sendError(w, http.StatusNotFound, "Transaction not found")
+			return
+		}
+		logger.Error("DB Error (get splits)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to fetch splits")
+		return
+	}
+	if lines == nil {
+		lines = []models.TransactionSplit{}
+	}
+
+	resp := SplitsResponse{EntryID: entry.ID, Amount: entry.Amount, Currency: entry.Currency, Splits: lines}
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": resp})
+}
+
+// PUT /transactions/{entryID}/splits
+// Replaces the transaction's split lines, which must add up to its amount. An empty
+// list un-splits it, so its own category applies again.
+func (h *TransactionHandler) SetSplits(w http.ResponseWriter, r *http.Request) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+	entryID, err := uuid.Parse(chi.URLParam(r, "entryID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid transaction ID")
+		return
+	}
+
+	var req SetSplitsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	lines, err := h.repo.SetSplits(r.Context(), familyID, entryID, splitLines(req.Splits))
+	if err != nil {
+		if msg, ok := splitErrorMessage(err); ok {
+			sendError(w, http.StatusBadRequest, msg)
+			return
+		}
+		if errors.Is(err, pgx.ErrNoRows) {
+			sendError(w, http.StatusNotFound, "Transaction not found")
+			return
+		}
+		logger.Error("DB Error (set splits)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to save splits")
+		return
+	}
+	if lines == nil {
+		lines = []models.TransactionSplit{}
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": lines})
+}
+
+func splitLines(reqs []SplitLineRequest) []models.TransactionSplit {
+	var lines []models.TransactionSplit
+	for _, req := range reqs {
+		lines = append(lines, models.TransactionSplit{
+			CategoryID: req.CategoryID,
+			Amount:     req.Amount,
+			Memo:       strings.TrimSpace(req.Memo),
+		})
+	}
+	return lines
+}
+
+// splitErrorMessage returns the response message for split lines the repository
+// rejected, or false if err isn't about them.
+func splitErrorMessage(err error) (string, bool) {
+	switch {
+	case errors.Is(err, models.ErrSplitTooFewLines):
+		return "A split needs at least two lines", true
+	case errors.Is(err, models.ErrSplitZeroAmount):
+		return "Split lines need a non-zero amount", true
+	case errors.Is(err, models.ErrSplitTotalMismatch):
+		return "Split amounts must add up to the transaction amount", true
+	case errors.Is(err, models.ErrSplitNotAllowed):
+		return "Only standard transactions can be split", true
+	}
+	return "", false
+}
+
+func parseTransactionFilter(r *http.Request) (models.TransactionFilter, error) {
+	q := r.URL.Query()
+	filter := models.TransactionFilter{Limit: defaultTransactionLimit}
+
+	if v := q.Get("account_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return filter, errors.New("Invalid account_id")
+		}
+		filter.AccountID = &id
+	}
+	tagIDs, err := parseTagIDs(q["tag"])
+	if err != nil {
+		return filter, err
+	}
+	filter.TagIDs = tagIDs
+	if v := q.Get("from"); v != "" {
+		from, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return filter, errors.New("Invalid from")
+		}
+		filter.From = &from
+	}
+	if v := q.Get("to"); v != "" {
+		to, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return filter, errors.New("Invalid to")
+		}
+		filter.To = &to
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return filter, errors.New("Invalid limit")
+		}
+		if limit > maxTransactionLimit {
+			limit = maxTransactionLimit
+		}
+		filter.Limit = limit
+	}
+	if v := q.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return filter, errors.New("Invalid offset")
+		}
+		filter.Offset = offset
+	}
+
+	return filter, nil
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/rakibulbh/ai-finance-manager/internal/models"
 	"github.com/rakibulbh/ai-finance-manager/internal/rest/mocks"
+	"github.com/rakibulbh/ai-finance-manager/internal/transfers"
 )
 
 // Tests based on Ruby test/specifications from maybe/test/controllers/api/v1/transactions_controller_test.rb
@@ -248,6 +249,40 @@ func TestTransactionHandler_CreateTransfer_Success(t *testing.T) {
 	}
 }
 
+// A transfer recorded by hand is signed the same way as one the matcher pairs up: the
+// matcher takes its two entries for a transfer in the same direction.
+func TestTransactionHandler_CreateTransfer_SignedLikeMatchedTransfers(t *testing.T) {
+	store := mocks.NewTransactionStore()
+	handler := NewTransactionHandler(store)
+
+	checking, card := uuid.New(), uuid.New()
+	body, _ := json.Marshal(CreateTransferRequest{FromAccountID: checking, ToAccountID: card, Amount: 300, Name: "Card payment"})
+	w := httptest.NewRecorder()
+	handler.CreateTransfer(w, httptest.NewRequest("POST", "/transfers", bytes.NewBuffer(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if len(store.Transactions) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(store.Transactions))
+	}
+	from, to := store.Transactions[0], store.Transactions[1]
+	if from.AccountID != checking || from.Amount != 300 || to.AccountID != card || to.Amount != -300 {
+		t.Errorf("Expected +300 out of checking and -300 into the card, got %+v and %+v", from, to)
+	}
+
+	// The same two entries imported unlinked would be paired in the same direction
+	var entries []transfers.Entry
+	for _, e := range []models.Entry{from, to} {
+		entries = append(entries, transfers.Entry{
+			ID: e.ID, AccountID: e.AccountID, Amount: e.Amount, Currency: e.Currency, Date: e.Date, Liability: e.AccountID == card,
+		})
+	}
+	matches := transfers.Find(entries, nil)
+	if len(matches) != 1 || matches[0].From != from.ID || matches[0].To != to.ID || !matches[0].Automatic {
+		t.Errorf("Expected the matcher to pair checking into the card, got %+v", matches)
+	}
+}
+
 // Test "should default date when creating transfer"
 func TestTransactionHandler_CreateTransfer_DefaultDate(t *testing.T) {
 	store := mocks.NewTransactionStore()
@@ -455,3 +490,149 @@ type TransferError struct {
 func (e *TransferError) Error() string {
 	return e.Message
 }
+
+func TestTransactionHandler_Splits(t *testing.T) {
+	store := mocks.NewTransactionStore()
+	handler := NewTransactionHandler(store)
+	user := &models.User{ID: uuid.New(), FamilyID: uuid.New()}
+	groceries, household, pharmacy := uuid.New(), uuid.New(), uuid.New()
+
+	create := func(req CreateTransactionRequest) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.Create(w, familyRequest("POST", "/transactions", req, user, nil))
+		return w
+	}
+	costco := CreateTransactionRequest{
+		AccountID: uuid.New(), Amount: 124.75, Name: "Costco",
+		Splits: []SplitLineRequest{
+			{CategoryID: &groceries, Amount: 82.15},
+			{CategoryID: &household, Amount: 30.10, Memo: " Paper towels "},
+			{CategoryID: &pharmacy, Amount: 12.50},
+		},
+	}
+
+	t.Run("create split", func(t *testing.T) {
+		w := create(costco)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d. Body: %s", w.Code, w.Body.String())
+		}
+		entry := store.Transactions[len(store.Transactions)-1]
+		if lines := store.Splits[entry.ID]; len(lines) != 3 || lines[1].Memo != "Paper towels" {
+			t.Errorf("Expected 3 split lines saved, got %+v", lines)
+		}
+	})
+
+	t.Run("create with lines that don't add up", func(t *testing.T) {
+		req := costco
+		req.Amount = 130
+		if w := create(req); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("set and get", func(t *testing.T) {
+		plain := costco
+		plain.Splits = nil
+		create(plain)
+		id := store.Transactions[len(store.Transactions)-1].ID.String()
+		params := map[string]string{"entryID": id}
+
+		set := func(lines []SplitLineRequest) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			handler.SetSplits(w, familyRequest("PUT", "/transactions/"+id+"/splits", SetSplitsRequest{Splits: lines}, user, params))
+			return w
+		}
+		if w := set(costco.Splits[:1]); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for a single line, got %d", w.Code)
+		}
+		if w := set(costco.Splits); w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+
+		w := httptest.NewRecorder()
+		handler.GetSplits(w, familyRequest("GET", "/transactions/"+id+"/splits", nil, user, params))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		var resp struct {
+			Data SplitsResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp)
+		if resp.Data.Amount != 124.75 || len(resp.Data.Splits) != 3 || *resp.Data.Splits[2].CategoryID != pharmacy {
+			t.Errorf("Expected the 3 lines back, got %+v", resp.Data)
+		}
+
+		// An empty list un-splits it
+		if w := set([]SplitLineRequest{}); w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+		if lines := store.Splits[resp.Data.EntryID]; len(lines) != 0 {
+			t.Errorf("Expected no lines left, got %+v", lines)
+		}
+	})
+
+	t.Run("transfer", func(t *testing.T) {
+		from, to := &models.Entry{Amount: 50}, &models.Entry{Amount: -50}
+		store.CreateTransfer(context.Background(), from, to)
+		w := httptest.NewRecorder()
+		handler.SetSplits(w, familyRequest("PUT", "/transactions/"+from.ID.String()+"/splits",
+			SetSplitsRequest{Splits: []SplitLineRequest{{Amount: 25}, {Amount: 25}}}, user, map[string]string{"entryID": from.ID.String()}))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for a transfer, got %d", w.Code)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		id := uuid.New().String()
+		w := httptest.NewRecorder()
+		handler.GetSplits(w, familyRequest("GET", "/transactions/"+id+"/splits", nil, user, map[string]string{"entryID": id}))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
+
+func TestTransactionHandler_List(t *testing.T) {
+	store := mocks.NewTransactionStore()
+	handler := NewTransactionHandler(store)
+	user := &models.User{ID: uuid.New(), FamilyID: uuid.New()}
+
+	vacation := models.Tag{ID: uuid.New(), FamilyID: user.FamilyID, Name: "vacation-2026"}
+	hotel := models.Entry{ID: uuid.New(), AccountID: uuid.New(), Amount: 320, Name: "Hotel"}
+	groceries := models.Entry{ID: uuid.New(), AccountID: uuid.New(), Amount: 80, Name: "Groceries"}
+	store.Transactions = []models.Entry{hotel, groceries}
+	store.EntryTags[hotel.ID] = []models.Tag{vacation}
+
+	list := func(t *testing.T, query string) []models.TransactionDetail {
+		w := httptest.NewRecorder()
+		handler.List(w, familyRequest("GET", "/transactions"+query, nil, user, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data []models.TransactionDetail `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp.Data
+	}
+
+	if got := list(t, ""); len(got) != 2 || store.LastFilter.Limit != defaultTransactionLimit {
+		t.Errorf("Expected both transactions with the default limit, got %d (filter %+v)", len(got), store.LastFilter)
+	}
+
+	got := list(t, "?tag="+vacation.ID.String()+"&tag="+vacation.ID.String()+"&from=2026-01-01&limit=1000")
+	if len(got) != 1 || got[0].Name != "Hotel" || len(got[0].Tags) != 1 {
+		t.Errorf("Expected only the tagged hotel, got %+v", got)
+	}
+	if f := store.LastFilter; len(f.TagIDs) != 1 || f.From == nil || f.Limit != maxTransactionLimit {
+		t.Errorf("Expected a deduplicated tag, a from date and a capped limit, got %+v", f)
+	}
+
+	for _, query := range []string{"?tag=vacation", "?from=01/01/2026", "?limit=0", "?account_id=1"} {
+		w := httptest.NewRecorder()
+		handler.List(w, familyRequest("GET", "/transactions"+query, nil, user, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", query, w.Code)
+		}
+	}
+}
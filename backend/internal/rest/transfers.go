@@ -0,0 +1,110 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"go.uber.org/zap"
+)
+
+type TransferStore interface {
+	List(ctx context.Context, familyID uuid.UUID, status string) ([]models.TransferMatch, error)
+	Confirm(ctx context.Context, familyID, id uuid.UUID) (*models.TransferMatch, error)
+	Reject(ctx context.Context, familyID, id uuid.UUID) (*models.TransferMatch, error)
+	Unmatch(ctx context.Context, familyID, id uuid.UUID) (*models.TransferMatch, error)
+}
+
+type TransferHandler struct {
+	repo TransferStore
+}
+
+func NewTransferHandler(repo TransferStore) *TransferHandler {
+	return &TransferHandler{repo: repo}
+}
+
+// GET /transfer_matches?status=suggested
+// Pairs of entries found to be two sides of one transfer. Suggested ones are the review
+// queue; matched ones can be unmatched.
+func (h *TransferHandler) List(w http.ResponseWriter, r *http.Request) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status != "" && !models.ValidTransferMatchStatus(status) {
+		sendError(w, http.StatusBadRequest, "Invalid status")
+		return
+	}
+
+	matches, err := h.repo.List(r.Context(), familyID, status)
+	if err != nil {
+		logger.Error("DB Error (list transfer matches)", zap.Error(err))
+		sendError(w, http.StatusInternalServerError, "Failed to list transfer matches")
+		return
+	}
+	if matches == nil {
+		matches = []models.TransferMatch{}
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": matches})
+}
+
+// POST /transfer_matches/{matchID}/confirm
+// Links a suggested pair into a transfer.
+func (h *TransferHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, h.repo.Confirm, "confirm")
+}
+
+// POST /transfer_matches/{matchID}/reject
+// Marks a suggested pair as not a transfer, so it isn't suggested again.
+func (h *TransferHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, h.repo.Reject, "reject")
+}
+
+// POST /transfer_matches/{matchID}/unmatch
+// Turns a matched transfer back into two transactions with their old categories.
+func (h *TransferHandler) Unmatch(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, h.repo.Unmatch, "unmatch")
+}
+
+func (h *TransferHandler) decide(w http.ResponseWriter, r *http.Request,
+	apply func(ctx context.Context, familyID, id uuid.UUID) (*models.TransferMatch, error), verb string) {
+	familyID, ok := r.Context().Value("family_id").(uuid.UUID)
+	if !ok {
+		sendError(w, http.StatusUnauthorized, "Family ID missing from context")
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "matchID"))
+	if err != nil {
+		sendError(w, http.StatusBadRequest, "Invalid transfer match ID")
+		return
+	}
+
+	match, err := apply(r.Context(), familyID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			sendError(w, http.StatusNotFound, "Transfer match not found")
+		case errors.Is(err, models.ErrTransferMatchNotSuggested):
+			sendError(w, http.StatusConflict, "This match has already been confirmed or rejected")
+		case errors.Is(err, models.ErrTransferNotMatched):
+			sendError(w, http.StatusConflict, "Only matched transfers can be unmatched")
+		case errors.Is(err, models.ErrTransferEntryUnavailable):
+			sendError(w, http.StatusConflict, "One of the transactions is already a transfer or is split")
+		default:
+			logger.Error("DB Error ("+verb+" transfer match)", zap.Error(err))
+			sendError(w, http.StatusInternalServerError, "Failed to "+verb+" transfer match")
+		}
+		return
+	}
+
+	sendJSON(w, http.StatusOK, map[string]interface{}{"data": match})
+}
@@ -0,0 +1,105 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/rakibulbh/ai-finance-manager/internal/rest/mocks"
+)
+
+func TestTransferHandler_Review(t *testing.T) {
+	store := mocks.NewTransferStore()
+	handler := NewTransferHandler(store)
+	user := &models.User{ID: uuid.New(), FamilyID: uuid.New()}
+
+	// One payment out of checking that could be either the card payment or the savings deposit
+	payment := models.Entry{ID: uuid.New(), AccountID: uuid.New(), Amount: 200, Name: "Online transfer"}
+	toCard := &models.TransferMatch{
+		ID: uuid.New(), FamilyID: user.FamilyID, Status: models.TransferMatchSuggested,
+		FromEntry: payment, ToEntry: models.Entry{ID: uuid.New(), AccountID: uuid.New(), Amount: -200, Name: "Payment thank you"},
+	}
+	toSavings := &models.TransferMatch{
+		ID: uuid.New(), FamilyID: user.FamilyID, Status: models.TransferMatchSuggested,
+		FromEntry: payment, ToEntry: models.Entry{ID: uuid.New(), AccountID: uuid.New(), Amount: -200, Name: "Deposit"},
+	}
+	store.Matches[toCard.ID] = toCard
+	store.Matches[toSavings.ID] = toSavings
+
+	decide := func(action string, id uuid.UUID, u *models.User) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := familyRequest("POST", "/transfer_matches/"+id.String()+"/"+action, nil, u, map[string]string{"matchID": id.String()})
+		switch action {
+		case "confirm":
+			handler.Confirm(w, req)
+		case "reject":
+			handler.Reject(w, req)
+		case "unmatch":
+			handler.Unmatch(w, req)
+		}
+		return w
+	}
+
+	t.Run("queue", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.List(w, familyRequest("GET", "/transfer_matches?status=suggested", nil, user, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data []models.TransferMatch `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp)
+		if len(resp.Data) != 2 {
+			t.Errorf("Expected both suggestions in the queue, got %d", len(resp.Data))
+		}
+
+		w = httptest.NewRecorder()
+		handler.List(w, familyRequest("GET", "/transfer_matches?status=pending", nil, user, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for an unknown status, got %d", w.Code)
+		}
+	})
+
+	t.Run("other family", func(t *testing.T) {
+		other := &models.User{ID: uuid.New(), FamilyID: uuid.New()}
+		if w := decide("confirm", toCard.ID, other); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("confirm", func(t *testing.T) {
+		w := decide("confirm", toCard.ID, user)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data models.TransferMatch `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp)
+		if resp.Data.Status != models.TransferMatchMatched || resp.Data.TransactionID == nil {
+			t.Errorf("Expected the pair linked into a transfer, got %+v", resp.Data)
+		}
+		if _, ok := store.Matches[toSavings.ID]; ok {
+			t.Error("Expected the competing suggestion to be dropped")
+		}
+		if w := decide("confirm", toCard.ID, user); w.Code != http.StatusConflict {
+			t.Errorf("Expected status 409 confirming twice, got %d", w.Code)
+		}
+	})
+
+	t.Run("unmatch", func(t *testing.T) {
+		if w := decide("unmatch", toCard.ID, user); w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+		if store.Matches[toCard.ID].Status != models.TransferMatchRejected {
+			t.Errorf("Expected the unmatched pair to be rejected, got %s", store.Matches[toCard.ID].Status)
+		}
+		if w := decide("unmatch", toCard.ID, user); w.Code != http.StatusConflict {
+			t.Errorf("Expected status 409 unmatching twice, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,215 @@
+// Package schedule parses and expands a subset of iCalendar recurrence rules (RFC 5545
+// RRULE), enough for schedules like "the 1st of every month" (FREQ=MONTHLY;BYMONTHDAY=1)
+// or "every other Friday" (FREQ=WEEKLY;INTERVAL=2;BYDAY=FR). Supported parts are FREQ
+// (DAILY, WEEKLY, MONTHLY or YEARLY), INTERVAL, BYDAY (weekly rules only), BYMONTHDAY
+// (monthly rules only, negative counting from the month's end), COUNT and UNTIL. Like
+// RFC 5545, dates that don't exist in a month, such as the 31st in April, are skipped;
+// use BYMONTHDAY=-1 for the last day of every month. Dates are calendar days in UTC.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Values for FREQ
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+	Yearly  = "YEARLY"
+)
+
+// Most occurrences expanded at once, so a daily rule started long ago can't run away.
+const maxOccurrences = 5000
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// Rule is a parsed recurrence rule. Its occurrences start from a separate start date,
+// which also supplies the weekday or day of month when BYDAY or BYMONTHDAY is missing.
+type Rule struct {
+	Freq       string
+	Interval   int
+	ByDay      []time.Weekday
+	ByMonthDay []int
+	Count      int
+	Until      *time.Time
+}
+
+// Parse reads a rule like "FREQ=WEEKLY;INTERVAL=2;BYDAY=FR". A leading "RRULE:" is
+// allowed.
+func Parse(s string) (Rule, error) {
+	rule := Rule{Interval: 1}
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return rule, errors.New("rule is empty")
+	}
+
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return rule, fmt.Errorf("invalid rule part %q", part)
+		}
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			switch freq := strings.ToUpper(value); freq {
+			case Daily, Weekly, Monthly, Yearly:
+				rule.Freq = freq
+			default:
+				return rule, fmt.Errorf("unsupported FREQ %q", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return rule, fmt.Errorf("invalid INTERVAL %q", value)
+			}
+			rule.Interval = n
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekday, ok := weekdays[strings.ToUpper(day)]
+				if !ok {
+					return rule, fmt.Errorf("invalid BYDAY %q", day)
+				}
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(day)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return rule, fmt.Errorf("invalid BYMONTHDAY %q", day)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, n)
+			}
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return rule, fmt.Errorf("invalid COUNT %q", value)
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return rule, fmt.Errorf("invalid UNTIL %q", value)
+			}
+			rule.Until = &until
+		default:
+			return rule, fmt.Errorf("unsupported rule part %q", name)
+		}
+	}
+
+	switch {
+	case rule.Freq == "":
+		return rule, errors.New("rule needs a FREQ")
+	case len(rule.ByDay) > 0 && rule.Freq != Weekly:
+		return rule, errors.New("BYDAY is only supported with FREQ=WEEKLY")
+	case len(rule.ByMonthDay) > 0 && rule.Freq != Monthly:
+		return rule, errors.New("BYMONTHDAY is only supported with FREQ=MONTHLY")
+	case rule.Count > 0 && rule.Until != nil:
+		return rule, errors.New("rule can't have both COUNT and UNTIL")
+	}
+	return rule, nil
+}
+
+// Between returns the rule's occurrences from start that fall between from and to,
+// inclusive, in order.
+func (r Rule) Between(start, from, to time.Time) []time.Time {
+	start, from, to = toDay(start), toDay(from), toDay(to)
+	if r.Until != nil && r.Until.Before(to) {
+		to = toDay(*r.Until)
+	}
+
+	var dates []time.Time
+	seen := 0
+	for period := 0; seen < maxOccurrences; period += r.Interval {
+		candidates, past := r.period(start, period)
+		if past.After(to) {
+			break
+		}
+		for _, d := range candidates {
+			if d.Before(start) || d.After(to) {
+				continue
+			}
+			seen++
+			if r.Count > 0 && seen > r.Count {
+				return dates
+			}
+			if !d.Before(from) {
+				dates = append(dates, d)
+			}
+		}
+	}
+	return dates
+}
+
+// period returns the candidate dates in the period-th day, week, month or year after
+// start's, in order, and the first day of that period.
+func (r Rule) period(start time.Time, period int) ([]time.Time, time.Time) {
+	switch r.Freq {
+	case Daily:
+		d := start.AddDate(0, 0, period)
+		return []time.Time{d}, d
+	case Weekly:
+		// Weeks start on Monday, as RFC 5545 assumes
+		monday := start.AddDate(0, 0, -((int(start.Weekday())+6)%7)+7*period)
+		days := r.ByDay
+		if len(days) == 0 {
+			days = []time.Weekday{start.Weekday()}
+		}
+		var dates []time.Time
+		for offset := 0; offset < 7; offset++ {
+			d := monday.AddDate(0, 0, offset)
+			for _, day := range days {
+				if d.Weekday() == day {
+					dates = append(dates, d)
+					break
+				}
+			}
+		}
+		return dates, monday
+	case Monthly:
+		first := time.Date(start.Year(), start.Month()+time.Month(period), 1, 0, 0, 0, 0, time.UTC)
+		days := r.ByMonthDay
+		if len(days) == 0 {
+			days = []int{start.Day()}
+		}
+		last := first.AddDate(0, 1, -1).Day()
+		var dates []time.Time
+		for day := 1; day <= last; day++ {
+			for _, want := range days {
+				if want == day || want < 0 && last+want+1 == day {
+					dates = append(dates, first.AddDate(0, 0, day-1))
+					break
+				}
+			}
+		}
+		return dates, first
+	default:
+		year := start.Year() + period
+		d := time.Date(year, start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+		first := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+		if d.Month() != start.Month() {
+			// February 29th in a year without one
+			return nil, first
+		}
+		return []time.Time{d}, first
+	}
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102", "20060102T150405Z", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return toDay(t), nil
+		}
+	}
+	return time.Time{}, errors.New("unrecognized date")
+}
+
+func toDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
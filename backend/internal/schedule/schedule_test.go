@@ -0,0 +1,110 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func between(t *testing.T, rule string, start, from, to time.Time) []time.Time {
+	t.Helper()
+	r, err := Parse(rule)
+	require.NoError(t, err)
+	return r.Between(start, from, to)
+}
+
+func TestParse(t *testing.T) {
+	r, err := Parse("RRULE:FREQ=weekly;INTERVAL=2;BYDAY=MO,FR;UNTIL=20241231")
+	require.NoError(t, err)
+	assert.Equal(t, Weekly, r.Freq)
+	assert.Equal(t, 2, r.Interval)
+	assert.Equal(t, []time.Weekday{time.Monday, time.Friday}, r.ByDay)
+	require.NotNil(t, r.Until)
+	assert.Equal(t, date(2024, 12, 31), *r.Until)
+
+	r, err = Parse("FREQ=MONTHLY")
+	require.NoError(t, err)
+	assert.Equal(t, 1, r.Interval)
+
+	for _, bad := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYDAY=FR",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=MONTHLY;COUNT=3;UNTIL=20250101",
+		"FREQ=DAILY;BYHOUR=9",
+		"FREQ",
+	} {
+		_, err := Parse(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestBetween_MonthlyOnTheFirst(t *testing.T) {
+	got := between(t, "FREQ=MONTHLY;BYMONTHDAY=1", date(2024, 1, 15), date(2024, 1, 1), date(2024, 4, 30))
+	assert.Equal(t, []time.Time{date(2024, 2, 1), date(2024, 3, 1), date(2024, 4, 1)}, got)
+}
+
+func TestBetween_EveryOtherFriday(t *testing.T) {
+	// 2024-01-05 is a Friday
+	got := between(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=FR", date(2024, 1, 5), date(2024, 1, 1), date(2024, 2, 29))
+	assert.Equal(t, []time.Time{date(2024, 1, 5), date(2024, 1, 19), date(2024, 2, 2), date(2024, 2, 16)}, got)
+
+	// Starting mid-week, the first week still counts
+	got = between(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", date(2024, 1, 3), date(2024, 1, 1), date(2024, 1, 22))
+	assert.Equal(t, []time.Time{date(2024, 1, 5), date(2024, 1, 15), date(2024, 1, 19)}, got)
+}
+
+func TestBetween_DefaultsFromStart(t *testing.T) {
+	// Weekly on the start's weekday, monthly and yearly on its day
+	got := between(t, "FREQ=WEEKLY", date(2024, 1, 3), date(2024, 1, 1), date(2024, 1, 20))
+	assert.Equal(t, []time.Time{date(2024, 1, 3), date(2024, 1, 10), date(2024, 1, 17)}, got)
+
+	got = between(t, "FREQ=MONTHLY;INTERVAL=3", date(2024, 1, 10), date(2024, 1, 1), date(2024, 12, 31))
+	assert.Equal(t, []time.Time{date(2024, 1, 10), date(2024, 4, 10), date(2024, 7, 10), date(2024, 10, 10)}, got)
+
+	got = between(t, "FREQ=YEARLY", date(2024, 2, 29), date(2024, 1, 1), date(2028, 12, 31))
+	assert.Equal(t, []time.Time{date(2024, 2, 29), date(2028, 2, 29)}, got, "leap day only in leap years")
+}
+
+func TestBetween_EndOfMonth(t *testing.T) {
+	got := between(t, "FREQ=MONTHLY;BYMONTHDAY=-1", date(2024, 1, 1), date(2024, 1, 1), date(2024, 4, 30))
+	assert.Equal(t, []time.Time{date(2024, 1, 31), date(2024, 2, 29), date(2024, 3, 31), date(2024, 4, 30)}, got)
+
+	// The 31st doesn't exist in every month and is skipped there
+	got = between(t, "FREQ=MONTHLY;BYMONTHDAY=31", date(2024, 1, 1), date(2024, 1, 1), date(2024, 4, 30))
+	assert.Equal(t, []time.Time{date(2024, 1, 31), date(2024, 3, 31)}, got)
+
+	got = between(t, "FREQ=MONTHLY;BYMONTHDAY=15,-1", date(2024, 2, 1), date(2024, 2, 1), date(2024, 2, 29))
+	assert.Equal(t, []time.Time{date(2024, 2, 15), date(2024, 2, 29)}, got)
+}
+
+func TestBetween_CountAndUntil(t *testing.T) {
+	// COUNT counts from the start, not from the window
+	got := between(t, "FREQ=DAILY;COUNT=5", date(2024, 1, 1), date(2024, 1, 4), date(2024, 1, 31))
+	assert.Equal(t, []time.Time{date(2024, 1, 4), date(2024, 1, 5)}, got)
+
+	got = between(t, "FREQ=WEEKLY;UNTIL=20240115", date(2024, 1, 1), date(2024, 1, 1), date(2024, 12, 31))
+	assert.Equal(t, []time.Time{date(2024, 1, 1), date(2024, 1, 8), date(2024, 1, 15)}, got)
+}
+
+func TestBetween_Window(t *testing.T) {
+	assert.Empty(t, between(t, "FREQ=DAILY", date(2024, 3, 1), date(2024, 1, 1), date(2024, 2, 1)), "window before start")
+
+	got := between(t, "FREQ=DAILY;INTERVAL=10", date(2024, 1, 1), date(2024, 1, 15), date(2024, 2, 1))
+	assert.Equal(t, []time.Time{date(2024, 1, 21), date(2024, 1, 31)}, got)
+
+	// Times of day are ignored
+	got = between(t, "FREQ=DAILY", date(2024, 1, 1).Add(18*time.Hour), date(2024, 1, 2).Add(9*time.Hour), date(2024, 1, 2))
+	assert.Equal(t, []time.Time{date(2024, 1, 2)}, got)
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rakibulbh/ai-finance-manager/internal/logger"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"go.uber.org/zap"
+)
+
+// Values for MAILER
+const (
+	MailerSMTP = "smtp"
+	MailerLog  = "log"
+)
+
+// ErrInvalidEmailHeader is returned for a recipient that isn't a plain address, or a
+// header value with a line break in it that would let it add headers of its own.
+var ErrInvalidEmailHeader = errors.New("invalid email header")
+
+// smtpTimeout bounds a delivery when the caller's context has no deadline of its own.
+const smtpTimeout = 30 * time.Second
+
+type Email struct {
+	To      string
+	Subject string
+	Body    string // plain text
+}
+
+// Mailer delivers outgoing email. Use SMTPMailer in production and LogMailer locally.
+type Mailer interface {
+	Send(ctx context.Context, email Email) error
+}
+
+type SMTPMailer struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	if port == "" {
+		port = "587"
+	}
+	return &SMTPMailer{
+		addr:     net.JoinHostPort(host, port),
+		host:     host,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+// Send delivers email like smtp.SendMail, using STARTTLS when the server offers it, but
+// gives up once ctx is done.
+func (m *SMTPMailer) Send(ctx context.Context, email Email) error {
+	msg, err := formatEmail(m.from, email)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, smtpTimeout)
+		defer cancel()
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	// Unblock the conversation if ctx is cancelled before the deadline
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return err
+		}
+	}
+	if m.username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(m.from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(email.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// LogMailer doesn't deliver anything: it writes each message to dir as an .eml file, or
+// to the server log when dir is empty.
+type LogMailer struct {
+	dir  string
+	from string
+}
+
+func NewLogMailer(dir, from string) *LogMailer {
+	return &LogMailer{dir: dir, from: from}
+}
+
+var unsafeFilenameChars = regexp.MustCompile(`[^a-zA-Z0-9@._-]`)
+
+func (m *LogMailer) Send(ctx context.Context, email Email) error {
+	if m.dir == "" {
+		logger.Warn("Email not sent (log mailer)",
+			zap.String("to", email.To),
+			zap.String("subject", email.Subject),
+			zap.String("body", email.Body),
+		)
+		return nil
+	}
+
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return err
+	}
+	msg, err := formatEmail(m.from, email)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405.000000000"), unsafeFilenameChars.ReplaceAllString(email.To, "_"))
+	return os.WriteFile(filepath.Join(m.dir, name), msg, 0644)
+}
+
+// formatEmail builds the message, refusing headers that could smuggle in others.
+func formatEmail(from string, email Email) ([]byte, error) {
+	if addr, err := mail.ParseAddress(email.To); err != nil || addr.Address != email.To {
+		return nil, fmt.Errorf("%w: recipient %q", ErrInvalidEmailHeader, email.To)
+	}
+	if strings.ContainsAny(from, "\r\n") || strings.ContainsAny(email.Subject, "\r\n") {
+		return nil, fmt.Errorf("%w: line break in header", ErrInvalidEmailHeader)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", email.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", email.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(email.Body, "\n", "\r\n"))
+	return []byte(b.String()), nil
+}
+
+// AccountMailer writes the emails for verifying an address and resetting a password,
+// and reminders of payments coming due. Links point at the web app, which posts any
+// token back to the API.
+type AccountMailer struct {
+	mailer Mailer
+	appURL string
+}
+
+func NewAccountMailer(mailer Mailer, appURL string) *AccountMailer {
+	return &AccountMailer{mailer: mailer, appURL: strings.TrimRight(appURL, "/")}
+}
+
+func (m *AccountMailer) SendEmailVerification(ctx context.Context, to, token string) error {
+	return m.mailer.Send(ctx, Email{
+		To:      to,
+		Subject: "Confirm your email address",
+		Body: "Welcome to Maybe Finance!\n\n" +
+			"Confirm your email address by opening the link below:\n\n" +
+			m.link("/verify-email", token) + "\n\n" +
+			"If you didn't create an account, you can ignore this email.\n",
+	})
+}
+
+func (m *AccountMailer) SendPasswordReset(ctx context.Context, to, token string) error {
+	return m.mailer.Send(ctx, Email{
+		To:      to,
+		Subject: "Reset your password",
+		Body: "Someone asked to reset the password for your Maybe Finance account.\n\n" +
+			"Choose a new password by opening the link below. It expires in one hour:\n\n" +
+			m.link("/reset-password", token) + "\n\n" +
+			"If this wasn't you, you can ignore this email and your password won't change.\n",
+	})
+}
+
+func (m *AccountMailer) SendPaymentReminder(ctx context.Context, to, accountName string, reminder models.PaymentReminder) error {
+	amount := "Check your statement for the amount due."
+	if reminder.Amount > 0 {
+		amount = fmt.Sprintf("The minimum payment is $%.2f.", reminder.Amount)
+	}
+	return m.mailer.Send(ctx, Email{
+		To:      to,
+		Subject: "Payment due " + reminder.DueDate.Format("Jan 2") + ": " + accountName,
+		Body: "A payment on " + accountName + " is due on " + reminder.DueDate.Format("Monday, January 2") + ".\n\n" +
+			amount + "\n\n" +
+			"See the account at " + m.appURL + "/accounts/" + reminder.AccountID.String() + "\n",
+	})
+}
+
+func (m *AccountMailer) link(path, token string) string {
+	return m.appURL + path + "?token=" + url.QueryEscape(token)
+}
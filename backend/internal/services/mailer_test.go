@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogMailer_WritesEmlFiles(t *testing.T) {
+	dir := t.TempDir()
+	mailer := NewLogMailer(dir, "noreply@example.com")
+
+	err := mailer.Send(context.Background(), Email{To: "user@example.com", Subject: "Hello", Body: "Line one\nLine two"})
+	require.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	content, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "From: noreply@example.com\r\n")
+	assert.Contains(t, string(content), "To: user@example.com\r\n")
+	assert.Contains(t, string(content), "Subject: Hello\r\n")
+	assert.True(t, strings.HasSuffix(string(content), "Line one\r\nLine two"))
+}
+
+func TestLogMailer_RejectsHeaderInjection(t *testing.T) {
+	mailer := NewLogMailer(t.TempDir(), "noreply@example.com")
+
+	for _, email := range []Email{
+		{To: "user@example.com\r\nBcc: everyone@example.com", Subject: "Hello"},
+		{To: "Name <user@example.com>", Subject: "Hello"},
+		{To: "user@example.com", Subject: "Hello\r\nBcc: everyone@example.com"},
+	} {
+		assert.ErrorIs(t, mailer.Send(context.Background(), email), ErrInvalidEmailHeader, "%+v", email)
+	}
+}
+
+func TestSMTPMailer_GivesUpWithContext(t *testing.T) {
+	// A server that accepts the connection but never greets
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	mailer := NewSMTPMailer(host, port, "", "", "noreply@example.com")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = mailer.Send(ctx, Email{To: "user@example.com", Subject: "Hello", Body: "Hi"})
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+type recordingMailer struct {
+	sent []Email
+}
+
+func (m *recordingMailer) Send(ctx context.Context, email Email) error {
+	m.sent = append(m.sent, email)
+	return nil
+}
+
+func TestAccountMailer_Links(t *testing.T) {
+	rec := &recordingMailer{}
+	mailer := NewAccountMailer(rec, "https://app.example.com/")
+
+	require.NoError(t, mailer.SendPasswordReset(context.Background(), "user@example.com", "a+b/c"))
+	require.NoError(t, mailer.SendEmailVerification(context.Background(), "user@example.com", "tok"))
+
+	require.Len(t, rec.sent, 2)
+	assert.Contains(t, rec.sent[0].Body, "https://app.example.com/reset-password?token=a%2Bb%2Fc")
+	assert.Contains(t, rec.sent[1].Body, "https://app.example.com/verify-email?token=tok")
+}
+
+func TestAccountMailer_PaymentReminder(t *testing.T) {
+	rec := &recordingMailer{}
+	mailer := NewAccountMailer(rec, "https://app.example.com")
+
+	reminder := models.PaymentReminder{AccountID: uuid.New(), DueDate: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), Amount: 35}
+	require.NoError(t, mailer.SendPaymentReminder(context.Background(), "user@example.com", "Visa", reminder))
+
+	require.Len(t, rec.sent, 1)
+	assert.Equal(t, "Payment due Mar 15: Visa", rec.sent[0].Subject)
+	assert.Contains(t, rec.sent[0].Body, "The minimum payment is $35.00.")
+	assert.Contains(t, rec.sent[0].Body, "https://app.example.com/accounts/"+reminder.AccountID.String())
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+const recoveryCodeCount = 10
+
+// MFAStorage is satisfied by *postgres.UserRepository.
+type MFAStorage interface {
+	SetPendingTOTPSecret(ctx context.Context, userID uuid.UUID, encryptedSecret string) error
+	GetTOTP(ctx context.Context, userID uuid.UUID) (*models.TOTPState, error)
+	MarkTOTPStepUsed(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	EnableTOTP(ctx context.Context, userID uuid.UUID, recoveryCodeHashes []string) error
+	ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, recoveryCodeHashes []string) error
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error)
+	DisableTOTP(ctx context.Context, userID uuid.UUID) error
+}
+
+// MFAService manages TOTP two-factor authentication. Enrollment is two steps: BeginSetup
+// stores a pending secret, and Enable switches it on once the user enters a valid code.
+// Wherever a code is asked for, a one-time recovery code works too.
+type MFAService struct {
+	store  MFAStorage
+	cipher *TokenCipher
+	issuer string
+	now    func() time.Time
+}
+
+func NewMFAService(store MFAStorage, cipher *TokenCipher, issuer string) *MFAService {
+	return &MFAService{store: store, cipher: cipher, issuer: issuer, now: time.Now}
+}
+
+// BeginSetup generates a new secret for the user and returns it along with the otpauth://
+// URI to show as a QR code. Starting over replaces any pending secret.
+func (s *MFAService) BeginSetup(ctx context.Context, userID uuid.UUID, email string) (string, string, error) {
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	encrypted, err := s.cipher.Encrypt(secret)
+	if err != nil {
+		return "", "", err
+	}
+	if err := s.store.SetPendingTOTPSecret(ctx, userID, encrypted); err != nil {
+		return "", "", err
+	}
+
+	return secret, totpURI(s.issuer, email, secret), nil
+}
+
+// Enable turns two-factor authentication on after checking a code from the pending secret,
+// and returns the user's recovery codes. They are only ever shown this once.
+func (s *MFAService) Enable(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	state, err := s.store.GetTOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if state.EnabledAt != nil {
+		return nil, models.ErrMFAAlreadyEnabled
+	}
+
+	if err := s.checkTOTP(ctx, userID, state, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.EnableTOTP(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify checks a TOTP or recovery code for a user with two-factor authentication enabled.
+// Returns models.ErrInvalidMFACode if it doesn't match.
+func (s *MFAService) Verify(ctx context.Context, userID uuid.UUID, code string) error {
+	state, err := s.store.GetTOTP(ctx, userID)
+	if errors.Is(err, models.ErrMFANotSetUp) {
+		return models.ErrMFANotEnabled
+	}
+	if err != nil {
+		return err
+	}
+	if state.EnabledAt == nil {
+		return models.ErrMFANotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if totpCodePattern.MatchString(code) {
+		return s.checkTOTP(ctx, userID, state, code)
+	}
+
+	used, err := s.store.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return models.ErrInvalidMFACode
+	}
+	return nil
+}
+
+// Disable turns two-factor authentication off. It asks for a code so a stolen session
+// alone can't remove the second factor.
+func (s *MFAService) Disable(ctx context.Context, userID uuid.UUID, code string) error {
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+	return s.store.DisableTOTP(ctx, userID)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes with a fresh set.
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+var totpCodePattern = regexp.MustCompile(`^[0-9]{6}$`)
+
+func (s *MFAService) checkTOTP(ctx context.Context, userID uuid.UUID, state *models.TOTPState, code string) error {
+	secret, err := s.cipher.Decrypt(state.Secret)
+	if err != nil {
+		return err
+	}
+
+	step, ok := matchTOTP(secret, code, s.now())
+	if !ok || step <= state.LastStep {
+		return models.ErrInvalidMFACode
+	}
+
+	// Recorded atomically, so the same code can't be used twice even concurrently
+	fresh, err := s.store.MarkTOTPStepUsed(ctx, userID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return models.ErrInvalidMFACode
+	}
+	return nil
+}
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCodes returns codes like "k3x9q-7mfa2" and the hashes to store for them.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))[:10]
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode ignores case, spaces and dashes, which people get wrong when typing
+// codes back in.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
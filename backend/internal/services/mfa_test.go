@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RFC 6238 appendix B vectors (SHA1), truncated to six digits
+func TestTOTPCode_RFCVectors(t *testing.T) {
+	secret := []byte("12345678901234567890")
+
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range vectors {
+		assert.Equal(t, want, totpCode(secret, totpStep(time.Unix(unix, 0))), "time %d", unix)
+	}
+}
+
+func TestMatchTOTP_AllowsOneStepOfDrift(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111111, 0)
+	key, _ := totpEncoding.DecodeString(secret)
+
+	for _, offset := range []int64{-1, 0, 1} {
+		_, ok := matchTOTP(secret, totpCode(key, totpStep(now)+offset), now)
+		assert.True(t, ok, "offset %d", offset)
+	}
+	_, ok := matchTOTP(secret, totpCode(key, totpStep(now)+2), now)
+	assert.False(t, ok)
+}
+
+type memoryMFAStore struct {
+	states   map[uuid.UUID]*models.TOTPState
+	recovery map[uuid.UUID]map[string]bool // hash -> used
+}
+
+func newMemoryMFAStore() *memoryMFAStore {
+	return &memoryMFAStore{
+		states:   make(map[uuid.UUID]*models.TOTPState),
+		recovery: make(map[uuid.UUID]map[string]bool),
+	}
+}
+
+func (m *memoryMFAStore) SetPendingTOTPSecret(ctx context.Context, userID uuid.UUID, secret string) error {
+	if s, ok := m.states[userID]; ok && s.EnabledAt != nil {
+		return models.ErrMFAAlreadyEnabled
+	}
+	m.states[userID] = &models.TOTPState{Secret: secret}
+	return nil
+}
+
+func (m *memoryMFAStore) GetTOTP(ctx context.Context, userID uuid.UUID) (*models.TOTPState, error) {
+	s, ok := m.states[userID]
+	if !ok {
+		return nil, models.ErrMFANotSetUp
+	}
+	state := *s
+	return &state, nil
+}
+
+func (m *memoryMFAStore) MarkTOTPStepUsed(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	s := m.states[userID]
+	if s.LastStep >= step {
+		return false, nil
+	}
+	s.LastStep = step
+	return true, nil
+}
+
+func (m *memoryMFAStore) EnableTOTP(ctx context.Context, userID uuid.UUID, hashes []string) error {
+	now := time.Now()
+	m.states[userID].EnabledAt = &now
+	return m.ReplaceRecoveryCodes(ctx, userID, hashes)
+}
+
+func (m *memoryMFAStore) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, hashes []string) error {
+	m.recovery[userID] = make(map[string]bool)
+	for _, h := range hashes {
+		m.recovery[userID][h] = false
+	}
+	return nil
+}
+
+func (m *memoryMFAStore) UseRecoveryCode(ctx context.Context, userID uuid.UUID, hash string) (bool, error) {
+	used, ok := m.recovery[userID][hash]
+	if !ok || used {
+		return false, nil
+	}
+	m.recovery[userID][hash] = true
+	return true, nil
+}
+
+func (m *memoryMFAStore) DisableTOTP(ctx context.Context, userID uuid.UUID) error {
+	delete(m.states, userID)
+	delete(m.recovery, userID)
+	return nil
+}
+
+func TestMFAService_EnrollVerifyDisable(t *testing.T) {
+	ctx := context.Background()
+	cipher, err := NewTokenCipher("", "0123456789abcdef0123456789abcdef")
+	require.NoError(t, err)
+
+	store := newMemoryMFAStore()
+	service := NewMFAService(store, cipher, "Maybe Finance")
+	now := time.Unix(1700000000, 0)
+	service.now = func() time.Time { return now }
+
+	userID := uuid.New()
+	secret, uri, err := service.BeginSetup(ctx, userID, "user@example.com")
+	require.NoError(t, err)
+	assert.Contains(t, uri, "otpauth://totp/Maybe%20Finance:user@example.com?")
+	assert.Contains(t, uri, "secret="+secret)
+	assert.NotEqual(t, secret, store.states[userID].Secret, "secret should be stored encrypted")
+
+	codeAt := func(t time.Time) string {
+		key, _ := totpEncoding.DecodeString(secret)
+		return totpCode(key, totpStep(t))
+	}
+
+	// Not enabled until a code is confirmed
+	assert.ErrorIs(t, service.Verify(ctx, userID, codeAt(now)), models.ErrMFANotEnabled)
+
+	_, err = service.Enable(ctx, userID, "000000")
+	assert.ErrorIs(t, err, models.ErrInvalidMFACode)
+
+	codes, err := service.Enable(ctx, userID, codeAt(now))
+	require.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+
+	// The code used to enable can't be replayed to log in
+	assert.ErrorIs(t, service.Verify(ctx, userID, codeAt(now)), models.ErrInvalidMFACode)
+
+	now = now.Add(totpPeriod * time.Second)
+	assert.NoError(t, service.Verify(ctx, userID, codeAt(now)))
+
+	// Recovery codes work once, however they're typed
+	assert.NoError(t, service.Verify(ctx, userID, " "+codes[0]+" "))
+	assert.ErrorIs(t, service.Verify(ctx, userID, codes[0]), models.ErrInvalidMFACode)
+	assert.NoError(t, service.Verify(ctx, userID, "  "+codes[1][:5]+codes[1][6:]))
+
+	// Starting setup again can't replace an active secret
+	_, _, err = service.BeginSetup(ctx, userID, "user@example.com")
+	assert.ErrorIs(t, err, models.ErrMFAAlreadyEnabled)
+
+	require.NoError(t, service.Disable(ctx, userID, codes[2]))
+	assert.ErrorIs(t, service.Verify(ctx, userID, codes[3]), models.ErrMFANotEnabled)
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+// mockOIDCServer is a minimal OpenID Connect provider: discovery, JWKS and a token
+// endpoint that checks PKCE and issues RS256 ID tokens.
+type mockOIDCServer struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+	// Authorization codes handed out, by code
+	codes map[string]mockAuthCode
+	// Overrides for the next ID token's claims
+	claims jwt.MapClaims
+	// Times the JWKS has been fetched
+	jwksFetches atomic.Int32
+}
+
+type mockAuthCode struct {
+	challenge string
+	nonce     string
+}
+
+func newMockOIDCServer(t *testing.T) *mockOIDCServer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	m := &mockOIDCServer{key: key, codes: make(map[string]mockAuthCode), claims: jwt.MapClaims{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.URL,
+			"authorization_endpoint": m.URL + "/authorize",
+			"token_endpoint":         m.URL + "/token",
+			"jwks_uri":               m.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		m.jwksFetches.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "key-1",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		code, ok := m.codes[r.PostForm.Get("code")]
+		if !ok || oauth2.S256ChallengeFromVerifier(r.PostForm.Get("code_verifier")) != code.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		claims := jwt.MapClaims{
+			"iss":            m.URL,
+			"aud":            "client-1",
+			"sub":            "user-123",
+			"email":          "jane@example.com",
+			"email_verified": true,
+			"nonce":          code.nonce,
+			"exp":            time.Now().Add(time.Minute).Unix(),
+		}
+		for k, v := range m.claims {
+			claims[k] = v
+		}
+		idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		idToken.Header["kid"] = "key-1"
+		signed, _ := idToken.SignedString(key)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"expires_in":   60,
+			"id_token":     signed,
+		})
+	})
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+// authorize stands in for the user signing in at the provider, returning the code it
+// would redirect back with.
+func (m *mockOIDCServer) authorize(t *testing.T, authURL string) string {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	q := u.Query()
+	require.Equal(t, "S256", q.Get("code_challenge_method"))
+
+	code := "code-" + q.Get("state")
+	m.codes[code] = mockAuthCode{challenge: q.Get("code_challenge"), nonce: q.Get("nonce")}
+	return code
+}
+
+func newTestOIDCProvider(t *testing.T, m *mockOIDCServer) *OIDCProvider {
+	t.Helper()
+	provider, err := NewOIDCProvider(context.Background(), m.URL, "client-1", "secret", "http://localhost:3000/sso/callback")
+	require.NoError(t, err)
+	return provider
+}
+
+func TestOIDCProvider_Login(t *testing.T) {
+	m := newMockOIDCServer(t)
+	provider := newTestOIDCProvider(t, m)
+
+	authRequest, err := provider.AuthCodeURL()
+	require.NoError(t, err)
+	assert.Contains(t, authRequest.URL, m.URL+"/authorize?")
+	assert.Contains(t, authRequest.URL, "scope=openid+email+profile")
+	code := m.authorize(t, authRequest.URL)
+
+	identity, err := provider.Exchange(context.Background(), code, authRequest.Verifier, authRequest.Nonce)
+	require.NoError(t, err)
+	assert.Equal(t, m.URL, identity.Issuer)
+	assert.Equal(t, "user-123", identity.Subject)
+	assert.Equal(t, "jane@example.com", identity.Email)
+	assert.True(t, identity.EmailVerified)
+}
+
+func TestOIDCProvider_Rejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		claims   jwt.MapClaims
+		verifier string
+		nonce    string
+	}{
+		{name: "wrong PKCE verifier", verifier: oauth2.GenerateVerifier()},
+		{name: "wrong nonce", nonce: "other-nonce"},
+		{name: "other audience", claims: jwt.MapClaims{"aud": "client-2"}},
+		{name: "other issuer", claims: jwt.MapClaims{"iss": "https://evil.example.com"}},
+		{name: "expired", claims: jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockOIDCServer(t)
+			provider := newTestOIDCProvider(t, m)
+			m.claims = tt.claims
+
+			authRequest, err := provider.AuthCodeURL()
+			require.NoError(t, err)
+			code := m.authorize(t, authRequest.URL)
+
+			verifier, nonce := authRequest.Verifier, authRequest.Nonce
+			if tt.verifier != "" {
+				verifier = tt.verifier
+			}
+			if tt.nonce != "" {
+				nonce = tt.nonce
+			}
+
+			_, err = provider.Exchange(context.Background(), code, verifier, nonce)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestOIDCProvider_UnknownKeyRefetchRateLimited(t *testing.T) {
+	m := newMockOIDCServer(t)
+	provider := newTestOIDCProvider(t, m)
+	now := time.Now()
+	provider.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, err := provider.key(ctx, "key-1")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), m.jwksFetches.Load())
+
+	// Made-up key IDs don't each cost a fetch
+	for i := 0; i < 3; i++ {
+		_, err = provider.key(ctx, "forged")
+		assert.Error(t, err)
+	}
+	assert.Equal(t, int32(1), m.jwksFetches.Load())
+
+	// Known keys keep working in between
+	_, err = provider.key(ctx, "key-1")
+	assert.NoError(t, err)
+
+	now = now.Add(jwksRefreshInterval)
+	_, err = provider.key(ctx, "forged")
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), m.jwksFetches.Load())
+}
+
+func TestOIDCProvider_EmailVerifiedAsString(t *testing.T) {
+	m := newMockOIDCServer(t)
+	provider := newTestOIDCProvider(t, m)
+	m.claims = jwt.MapClaims{"email_verified": "false"}
+
+	authRequest, err := provider.AuthCodeURL()
+	require.NoError(t, err)
+	identity, err := provider.Exchange(context.Background(), m.authorize(t, authRequest.URL), authRequest.Verifier, authRequest.Nonce)
+	require.NoError(t, err)
+	assert.False(t, identity.EmailVerified)
+}
+
+func TestNewOIDCProvider_IssuerMismatch(t *testing.T) {
+	m := newMockOIDCServer(t)
+
+	// Same server under another name: discovery works but names a different issuer
+	_, err := NewOIDCProvider(context.Background(), strings.Replace(m.URL, "127.0.0.1", "localhost", 1), "client-1", "secret", "")
+	assert.ErrorContains(t, err, "doesn't match")
+}
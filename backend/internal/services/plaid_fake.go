@@ -0,0 +1,416 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/plaid/plaid-go/v20/plaid"
+)
+
+// PlaidEnvFake is the PLAID_ENV value that swaps Plaid for FakePlaidService.
+const PlaidEnvFake = "fake"
+
+const (
+	fakeAccessTokenPrefix = "access-fake-"
+	fakeItemIDPrefix      = "item-fake-"
+	fakeCursorPrefix      = "fake-cursor-"
+	fakeWebhookKeyID      = "fake-webhook-key"
+
+	// Public tokens of the form "error_<CODE>" link an item whose every call fails with
+	// that Plaid error code, like Plaid's sandbox test credentials.
+	fakeErrorTokenPrefix = "error_"
+)
+
+// FakePlaidService is an in-process stand-in for Plaid, selected with PLAID_ENV=fake.
+// Everything it returns is derived from the item ID and sync cursor, so the API and the
+// worker agree on the data without sharing state and without network access.
+type FakePlaidService struct {
+	tokens     *TokenCipher
+	webhookKey *ecdsa.PrivateKey
+
+	mu         sync.Mutex
+	errors     map[string]string // access token -> error code for every call
+	syncErrors map[string]string // access token -> error code for the next transactions/sync call
+}
+
+func NewFakePlaidService(tokens *TokenCipher) (*FakePlaidService, error) {
+	webhookKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FakePlaidService{
+		tokens:     tokens,
+		webhookKey: webhookKey,
+		errors:     make(map[string]string),
+		syncErrors: make(map[string]string),
+	}, nil
+}
+
+// SetError makes every call for the access token fail with the given Plaid error code.
+// An empty code clears it.
+func (s *FakePlaidService) SetError(accessToken, errorCode string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if errorCode == "" {
+		delete(s.errors, accessToken)
+		return
+	}
+	s.errors[accessToken] = errorCode
+}
+
+// FailNextSync makes only the next transactions/sync call for the access token fail,
+// e.g. with TRANSACTIONS_SYNC_MUTATION_DURING_PAGINATION.
+func (s *FakePlaidService) FailNextSync(accessToken, errorCode string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.syncErrors[accessToken] = errorCode
+}
+
+func (s *FakePlaidService) CreateLinkToken(ctx context.Context, userID, clientName string) (string, error) {
+	return "link-fake-" + userID, nil
+}
+
+func (s *FakePlaidService) CreateUpdateLinkToken(ctx context.Context, userID, clientName, accessToken string) (string, error) {
+	if _, err := s.itemForToken(accessToken); err != nil {
+		return "", err
+	}
+	return "link-fake-update-" + userID, nil
+}
+
+// ExchangePublicToken accepts any public token; the item ID is derived from it so the
+// same token always links the same item.
+func (s *FakePlaidService) ExchangePublicToken(ctx context.Context, publicToken string) (string, string, error) {
+	if publicToken == "" {
+		return "", "", fakePlaidError("INVALID_PUBLIC_TOKEN")
+	}
+
+	itemID := fakeItemIDPrefix + publicToken
+	return fakeAccessTokenPrefix + itemID, itemID, nil
+}
+
+func (s *FakePlaidService) RemoveItem(ctx context.Context, accessToken string) error {
+	_, err := s.itemForToken(accessToken)
+	return err
+}
+
+func (s *FakePlaidService) EncryptToken(token string) (string, error) {
+	return s.tokens.Encrypt(token)
+}
+
+func (s *FakePlaidService) DecryptToken(encryptedToken string) (string, error) {
+	return s.tokens.Decrypt(encryptedToken)
+}
+
+func (s *FakePlaidService) GetWebhookVerificationKey(ctx context.Context, keyID string) (*ecdsa.PublicKey, error) {
+	if keyID != fakeWebhookKeyID {
+		return nil, fmt.Errorf("unknown webhook key %s", keyID)
+	}
+	return &s.webhookKey.PublicKey, nil
+}
+
+// SignWebhook returns a Plaid-Verification header value for the body, for posting
+// webhooks to a local server by hand.
+func (s *FakePlaidService) SignWebhook(body []byte) (string, error) {
+	sum := sha256.Sum256(body)
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iat":                 time.Now().Unix(),
+		"request_body_sha256": hex.EncodeToString(sum[:]),
+	})
+	token.Header["kid"] = fakeWebhookKeyID
+	return token.SignedString(s.webhookKey)
+}
+
+func (s *FakePlaidService) GetAccounts(ctx context.Context, accessToken string) ([]plaid.AccountBase, error) {
+	itemID, err := s.itemForToken(accessToken)
+	if err != nil {
+		return nil, err
+	}
+	return fakeAccounts(itemID), nil
+}
+
+// SyncTransactions serves a fixed stream of pages. The first sync drains pages 0-1
+// (HasMore on page 0); the next one picks up pages 2-3, which modify and remove
+// transactions from the first batch. After that the stream is caught up.
+func (s *FakePlaidService) SyncTransactions(ctx context.Context, accessToken string, cursor string) (plaid.TransactionsSyncResponse, error) {
+	itemID, err := s.itemForToken(accessToken)
+	if err != nil {
+		return plaid.TransactionsSyncResponse{}, err
+	}
+	if err := s.takeSyncError(accessToken); err != nil {
+		return plaid.TransactionsSyncResponse{}, err
+	}
+
+	page := 0
+	if cursor != "" {
+		n, err := strconv.Atoi(strings.TrimPrefix(cursor, fakeCursorPrefix))
+		if err != nil || !strings.HasPrefix(cursor, fakeCursorPrefix) || n < 0 {
+			return plaid.TransactionsSyncResponse{}, fakePlaidError("INVALID_FIELD")
+		}
+		page = n
+	}
+
+	pages := fakeSyncPages(itemID)
+	if page >= len(pages) {
+		return plaid.TransactionsSyncResponse{NextCursor: cursor}, nil
+	}
+
+	resp := pages[page]
+	resp.NextCursor = fakeCursorPrefix + strconv.Itoa(page+1)
+	return resp, nil
+}
+
+func (s *FakePlaidService) GetInvestmentHoldings(ctx context.Context, accessToken string) (plaid.InvestmentsHoldingsGetResponse, error) {
+	itemID, err := s.itemForToken(accessToken)
+	if err != nil {
+		return plaid.InvestmentsHoldingsGetResponse{}, err
+	}
+
+	brokerage := itemID + "-brokerage"
+	return plaid.InvestmentsHoldingsGetResponse{
+		Accounts: fakeAccounts(itemID),
+		Holdings: []plaid.Holding{
+			{AccountId: brokerage, SecurityId: "fake-sec-aapl", InstitutionPrice: 190.00, InstitutionValue: 1900.00, Quantity: 10},
+			{AccountId: brokerage, SecurityId: "fake-sec-vti", InstitutionPrice: 240.50, InstitutionValue: 4810.00, Quantity: 20},
+		},
+		Securities: fakeSecurities(),
+	}, nil
+}
+
+// GetInvestmentTransactions dates its transactions relative to end so they fall inside
+// whatever window the caller asks for.
+func (s *FakePlaidService) GetInvestmentTransactions(ctx context.Context, accessToken string, start, end time.Time) ([]plaid.InvestmentTransaction, []plaid.Security, error) {
+	itemID, err := s.itemForToken(accessToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	brokerage := itemID + "-brokerage"
+	all := []plaid.InvestmentTransaction{
+		{
+			InvestmentTransactionId: itemID + "-inv-1",
+			AccountId:               brokerage,
+			SecurityId:              *plaid.NewNullableString(plaid.PtrString("fake-sec-aapl")),
+			Date:                    end.AddDate(0, 0, -30).Format("2006-01-02"),
+			Name:                    "BUY Apple Inc.",
+			Quantity:                10,
+			Price:                   150.00,
+			Amount:                  1500.00,
+			Type:                    plaid.INVESTMENTTRANSACTIONTYPE_BUY,
+			Subtype:                 plaid.INVESTMENTTRANSACTIONSUBTYPE_BUY,
+			IsoCurrencyCode:         *plaid.NewNullableString(plaid.PtrString("USD")),
+		},
+		{
+			InvestmentTransactionId: itemID + "-inv-2",
+			AccountId:               brokerage,
+			SecurityId:              *plaid.NewNullableString(plaid.PtrString("fake-sec-vti")),
+			Date:                    end.AddDate(0, 0, -15).Format("2006-01-02"),
+			Name:                    "DIVIDEND Vanguard Total Stock Market ETF",
+			Amount:                  -12.34,
+			Type:                    plaid.INVESTMENTTRANSACTIONTYPE_CASH,
+			Subtype:                 plaid.INVESTMENTTRANSACTIONSUBTYPE_DIVIDEND,
+			IsoCurrencyCode:         *plaid.NewNullableString(plaid.PtrString("USD")),
+		},
+	}
+
+	var transactions []plaid.InvestmentTransaction
+	for _, tx := range all {
+		date, _ := time.Parse("2006-01-02", tx.Date)
+		if date.Before(start.Truncate(24 * time.Hour)) {
+			continue
+		}
+		transactions = append(transactions, tx)
+	}
+	return transactions, fakeSecurities(), nil
+}
+
+func (s *FakePlaidService) GetLiabilities(ctx context.Context, accessToken string) (plaid.LiabilitiesGetResponse, error) {
+	itemID, err := s.itemForToken(accessToken)
+	if err != nil {
+		return plaid.LiabilitiesGetResponse{}, err
+	}
+
+	var mortgageRate plaid.MortgageInterestRate
+	mortgageRate.SetPercentage(6.125)
+
+	return plaid.LiabilitiesGetResponse{
+		Accounts: fakeAccounts(itemID),
+		Liabilities: plaid.LiabilitiesObject{
+			Credit: []plaid.CreditCardLiability{{
+				AccountId: *plaid.NewNullableString(plaid.PtrString(itemID + "-credit")),
+				Aprs: []plaid.APR{
+					{AprType: "cash_apr", AprPercentage: 27.99},
+					{AprType: "purchase_apr", AprPercentage: 22.49},
+				},
+				LastStatementBalance:   *plaid.NewNullableFloat64(plaid.PtrFloat64(380.25)),
+				LastStatementIssueDate: *plaid.NewNullableString(plaid.PtrString("2024-01-20")),
+				MinimumPaymentAmount:   *plaid.NewNullableFloat64(plaid.PtrFloat64(35.00)),
+				NextPaymentDueDate:     *plaid.NewNullableString(plaid.PtrString("2024-02-15")),
+			}},
+			Mortgage: []plaid.MortgageLiability{{
+				AccountId:          itemID + "-mortgage",
+				InterestRate:       mortgageRate,
+				LoanTerm:           *plaid.NewNullableString(plaid.PtrString("30 year")),
+				NextMonthlyPayment: *plaid.NewNullableFloat64(plaid.PtrFloat64(1518.76)),
+				NextPaymentDueDate: *plaid.NewNullableString(plaid.PtrString("2024-02-01")),
+			}},
+			Student: []plaid.StudentLoan{{
+				AccountId:              *plaid.NewNullableString(plaid.PtrString(itemID + "-student")),
+				InterestRatePercentage: 4.99,
+				MinimumPaymentAmount:   *plaid.NewNullableFloat64(plaid.PtrFloat64(210.00)),
+				NextPaymentDueDate:     *plaid.NewNullableString(plaid.PtrString("2024-02-10")),
+			}},
+		},
+	}, nil
+}
+
+// itemForToken resolves an access token to its item ID and applies any injected error.
+func (s *FakePlaidService) itemForToken(accessToken string) (string, error) {
+	if !strings.HasPrefix(accessToken, fakeAccessTokenPrefix+fakeItemIDPrefix) {
+		return "", fakePlaidError("INVALID_ACCESS_TOKEN")
+	}
+	itemID := strings.TrimPrefix(accessToken, fakeAccessTokenPrefix)
+
+	if code, ok := strings.CutPrefix(strings.TrimPrefix(itemID, fakeItemIDPrefix), fakeErrorTokenPrefix); ok && code != "" {
+		return "", fakePlaidError(code)
+	}
+
+	s.mu.Lock()
+	code, failing := s.errors[accessToken]
+	s.mu.Unlock()
+	if failing {
+		return "", fakePlaidError(code)
+	}
+
+	return itemID, nil
+}
+
+func (s *FakePlaidService) takeSyncError(accessToken string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code, ok := s.syncErrors[accessToken]
+	if !ok {
+		return nil
+	}
+	delete(s.syncErrors, accessToken)
+	return fakePlaidError(code)
+}
+
+// fakePlaidError builds the same error type the Plaid client returns, so plaid.ToPlaidError works on it.
+func fakePlaidError(code string) error {
+	errorType := plaid.PLAIDERRORTYPE_ITEM_ERROR
+	if strings.HasPrefix(code, "INVALID_") {
+		errorType = plaid.PLAIDERRORTYPE_INVALID_INPUT
+	}
+
+	model := plaid.PlaidError{ErrorType: errorType, ErrorCode: code, ErrorMessage: "fake plaid error: " + code}
+	return plaid.MakeGenericOpenAPIError(nil, "400 Bad Request", model)
+}
+
+func fakeAccounts(itemID string) []plaid.AccountBase {
+	account := func(suffix, name string, accountType plaid.AccountType, subtype plaid.AccountSubtype, current float64) plaid.AccountBase {
+		balances := plaid.AccountBalance{
+			Current:         *plaid.NewNullableFloat64(plaid.PtrFloat64(current)),
+			IsoCurrencyCode: *plaid.NewNullableString(plaid.PtrString("USD")),
+		}
+		return plaid.AccountBase{
+			AccountId: itemID + "-" + suffix,
+			Balances:  balances,
+			Mask:      *plaid.NewNullableString(plaid.PtrString("0000")),
+			Name:      name,
+			Type:      accountType,
+			Subtype:   *plaid.NewNullableAccountSubtype(&subtype),
+		}
+	}
+
+	credit := account("credit", "Fake Credit Card", plaid.ACCOUNTTYPE_CREDIT, plaid.ACCOUNTSUBTYPE_CREDIT_CARD, 420.50)
+	credit.Balances.SetLimit(5000.00)
+
+	return []plaid.AccountBase{
+		account("checking", "Fake Checking", plaid.ACCOUNTTYPE_DEPOSITORY, plaid.ACCOUNTSUBTYPE_CHECKING, 1250.00),
+		account("savings", "Fake Savings", plaid.ACCOUNTTYPE_DEPOSITORY, plaid.ACCOUNTSUBTYPE_SAVINGS, 5400.00),
+		credit,
+		account("brokerage", "Fake Brokerage", plaid.ACCOUNTTYPE_INVESTMENT, plaid.ACCOUNTSUBTYPE_BROKERAGE, 6710.00),
+		account("mortgage", "Fake Mortgage", plaid.ACCOUNTTYPE_LOAN, plaid.ACCOUNTSUBTYPE_MORTGAGE, 245000.00),
+		account("student", "Fake Student Loan", plaid.ACCOUNTTYPE_LOAN, plaid.ACCOUNTSUBTYPE_STUDENT, 18500.00),
+	}
+}
+
+func fakeSecurities() []plaid.Security {
+	security := func(id, ticker, name string) plaid.Security {
+		return plaid.Security{
+			SecurityId:   id,
+			TickerSymbol: *plaid.NewNullableString(plaid.PtrString(ticker)),
+			Name:         *plaid.NewNullableString(plaid.PtrString(name)),
+		}
+	}
+
+	return []plaid.Security{
+		security("fake-sec-aapl", "AAPL", "Apple Inc."),
+		security("fake-sec-vti", "VTI", "Vanguard Total Stock Market ETF"),
+	}
+}
+
+func fakeSyncPages(itemID string) []plaid.TransactionsSyncResponse {
+	tx := func(n int, account string, amount float64, date, name, merchant string) plaid.Transaction {
+		t := plaid.Transaction{
+			TransactionId:   fmt.Sprintf("%s-tx-%d", itemID, n),
+			AccountId:       itemID + "-" + account,
+			Amount:          amount,
+			Date:            date,
+			Name:            name,
+			IsoCurrencyCode: *plaid.NewNullableString(plaid.PtrString("USD")),
+		}
+		if merchant != "" {
+			t.SetMerchantName(merchant)
+		}
+		return t
+	}
+	removed := func(n int) plaid.RemovedTransaction {
+		return plaid.RemovedTransaction{TransactionId: plaid.PtrString(fmt.Sprintf("%s-tx-%d", itemID, n))}
+	}
+
+	return []plaid.TransactionsSyncResponse{
+		{
+			Added: []plaid.Transaction{
+				tx(1, "checking", 4.50, "2024-01-02", "Blue Bottle Coffee", "Blue Bottle"),
+				tx(2, "checking", -2500.00, "2024-01-03", "Payroll Deposit", ""),
+				tx(3, "credit", 82.15, "2024-01-04", "Whole Foods Market", "Whole Foods"),
+			},
+			HasMore: true,
+		},
+		{
+			Added: []plaid.Transaction{
+				tx(4, "checking", 1800.00, "2024-01-05", "Rent Payment", ""),
+				tx(5, "credit", 23.40, "2024-01-06", "Uber Pending", "Uber"),
+			},
+		},
+		{
+			Added: []plaid.Transaction{
+				tx(6, "credit", 15.49, "2024-01-10", "Netflix", "Netflix"),
+			},
+			Modified: []plaid.Transaction{
+				tx(5, "credit", 25.90, "2024-01-06", "Uber Trip", "Uber"),
+			},
+			HasMore: true,
+		},
+		{
+			Added: []plaid.Transaction{
+				tx(7, "savings", -4.12, "2024-01-31", "Interest Earned", ""),
+			},
+			Removed: []plaid.RemovedTransaction{removed(3)},
+		},
+	}
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/plaid/plaid-go/v20/plaid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFakePlaid(t *testing.T) *FakePlaidService {
+	t.Helper()
+	tokens, err := NewTokenCipher("", "0123456789abcdef0123456789abcdef")
+	require.NoError(t, err)
+	fake, err := NewFakePlaidService(tokens)
+	require.NoError(t, err)
+	return fake
+}
+
+func TestFakePlaid_SignedWebhookVerifies(t *testing.T) {
+	fake := newTestFakePlaid(t)
+	body := []byte(`{"webhook_type":"TRANSACTIONS","webhook_code":"SYNC_UPDATES_AVAILABLE","item_id":"item-fake-good"}`)
+
+	signed, err := fake.SignWebhook(body)
+	require.NoError(t, err)
+
+	assert.NoError(t, NewWebhookVerifier(fake).Verify(context.Background(), signed, body))
+}
+
+func TestFakePlaid_SyncPagesAndCursors(t *testing.T) {
+	fake := newTestFakePlaid(t)
+	ctx := context.Background()
+	accessToken, _, err := fake.ExchangePublicToken(ctx, "good")
+	require.NoError(t, err)
+
+	first, err := fake.SyncTransactions(ctx, accessToken, "")
+	require.NoError(t, err)
+	assert.True(t, first.HasMore)
+	assert.Len(t, first.Added, 3)
+
+	second, err := fake.SyncTransactions(ctx, accessToken, first.NextCursor)
+	require.NoError(t, err)
+	assert.False(t, second.HasMore)
+
+	// Calling again with the same cursor returns the same page
+	again, err := fake.SyncTransactions(ctx, accessToken, first.NextCursor)
+	require.NoError(t, err)
+	assert.Equal(t, second.Added, again.Added)
+
+	_, err = fake.SyncTransactions(ctx, accessToken, "not-a-cursor")
+	plaidErr, convErr := plaid.ToPlaidError(err)
+	require.NoError(t, convErr)
+	assert.Equal(t, "INVALID_FIELD", plaidErr.ErrorCode)
+}
+
+func TestFakePlaid_ErrorCodes(t *testing.T) {
+	fake := newTestFakePlaid(t)
+	ctx := context.Background()
+
+	accessToken, _, err := fake.ExchangePublicToken(ctx, "error_ITEM_LOGIN_REQUIRED")
+	require.NoError(t, err)
+
+	_, err = fake.GetAccounts(ctx, accessToken)
+	plaidErr, convErr := plaid.ToPlaidError(err)
+	require.NoError(t, convErr)
+	assert.Equal(t, "ITEM_LOGIN_REQUIRED", plaidErr.ErrorCode)
+
+	good, _, err := fake.ExchangePublicToken(ctx, "good")
+	require.NoError(t, err)
+	fake.SetError(good, "INSTITUTION_DOWN")
+	_, err = fake.GetLiabilities(ctx, good)
+	assert.Error(t, err)
+
+	fake.SetError(good, "")
+	_, err = fake.GetLiabilities(ctx, good)
+	assert.NoError(t, err)
+}
@@ -2,22 +2,23 @@ package services
 
 import (
 	"context"
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"encoding/base64"
 	"fmt"
-	"io"
+	"math/big"
+	"time"
 
 	"github.com/plaid/plaid-go/v20/plaid"
 )
 
 type PlaidService struct {
-	client        *plaid.APIClient
-	encryptionKey []byte
+	client     *plaid.APIClient
+	tokens     *TokenCipher
+	webhookURL string
 }
 
-func NewPlaidService(clientID, secret, env, encKey string) *PlaidService {
+func NewPlaidService(clientID, secret, env string, tokens *TokenCipher, webhookURL string) *PlaidService {
 	conf := plaid.NewConfiguration()
 	conf.AddDefaultHeader("PLAID-CLIENT-ID", clientID)
 	conf.AddDefaultHeader("PLAID-SECRET", secret)
@@ -34,12 +35,13 @@ func NewPlaidService(clientID, secret, env, encKey string) *PlaidService {
 	}
 
 	return &PlaidService{
-		client:        plaid.NewAPIClient(conf),
-		encryptionKey: []byte(encKey),
+		client:     plaid.NewAPIClient(conf),
+		tokens:     tokens,
+		webhookURL: webhookURL,
 	}
 }
 
-func (s *PlaidService) CreateLinkToken(ctx context.Context, userID, clientName string) (string, error) {
+func (s *PlaidService) newLinkTokenRequest(userID, clientName string) *plaid.LinkTokenCreateRequest {
 	user := plaid.LinkTokenCreateRequestUser{
 		ClientUserId: userID,
 	}
@@ -49,7 +51,31 @@ func (s *PlaidService) CreateLinkToken(ctx context.Context, userID, clientName s
 		[]plaid.CountryCode{plaid.COUNTRYCODE_US},
 		user,
 	)
+	if s.webhookURL != "" {
+		request.SetWebhook(s.webhookURL)
+	}
+	return request
+}
+
+func (s *PlaidService) CreateLinkToken(ctx context.Context, userID, clientName string) (string, error) {
+	request := s.newLinkTokenRequest(userID, clientName)
 	request.SetProducts([]plaid.Products{plaid.PRODUCTS_TRANSACTIONS})
+	// Optional so institutions without them can still be linked
+	request.SetOptionalProducts([]plaid.Products{plaid.PRODUCTS_INVESTMENTS, plaid.PRODUCTS_LIABILITIES})
+
+	resp, _, err := s.client.PlaidApi.LinkTokenCreate(ctx).LinkTokenCreateRequest(*request).Execute()
+	if err != nil {
+		return "", err
+	}
+
+	return resp.GetLinkToken(), nil
+}
+
+// CreateUpdateLinkToken starts Link in update mode so the user can re-authenticate an existing item.
+// Plaid rejects products in update mode; the item keeps the ones it was created with.
+func (s *PlaidService) CreateUpdateLinkToken(ctx context.Context, userID, clientName, accessToken string) (string, error) {
+	request := s.newLinkTokenRequest(userID, clientName)
+	request.SetAccessToken(accessToken)
 
 	resp, _, err := s.client.PlaidApi.LinkTokenCreate(ctx).LinkTokenCreateRequest(*request).Execute()
 	if err != nil {
@@ -69,55 +95,61 @@ func (s *PlaidService) ExchangePublicToken(ctx context.Context, publicToken stri
 	return resp.GetAccessToken(), resp.GetItemId(), nil
 }
 
-// Encryption Helpers
-func (s *PlaidService) EncryptToken(token string) (string, error) {
-	block, err := aes.NewCipher(s.encryptionKey)
-	if err != nil {
-		return "", err
-	}
+// RemoveItem revokes the access token at Plaid. The item can't be used again afterwards.
+func (s *PlaidService) RemoveItem(ctx context.Context, accessToken string) error {
+	request := plaid.NewItemRemoveRequest(accessToken)
+	_, _, err := s.client.PlaidApi.ItemRemove(ctx).ItemRemoveRequest(*request).Execute()
+	return err
+}
 
-	gcm, err := cipher.NewGCM(block)
+// GetWebhookVerificationKey fetches the JWK Plaid signs webhooks with and converts it to an ECDSA key.
+func (s *PlaidService) GetWebhookVerificationKey(ctx context.Context, keyID string) (*ecdsa.PublicKey, error) {
+	request := plaid.NewWebhookVerificationKeyGetRequest(keyID)
+	resp, _, err := s.client.PlaidApi.WebhookVerificationKeyGet(ctx).WebhookVerificationKeyGetRequest(*request).Execute()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", err
+	jwk := resp.GetKey()
+	if jwk.Kty != "EC" || jwk.Crv != "P-256" {
+		return nil, fmt.Errorf("unsupported webhook key type %s/%s", jwk.Kty, jwk.Crv)
 	}
-
-	ciphertext := gcm.Seal(nonce, nonce, []byte(token), nil)
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
-}
-
-func (s *PlaidService) DecryptToken(encryptedToken string) (string, error) {
-	data, err := base64.StdEncoding.DecodeString(encryptedToken)
-	if err != nil {
-		return "", err
+	if jwk.ExpiredAt.Get() != nil {
+		return nil, fmt.Errorf("webhook key %s has expired", keyID)
 	}
 
-	block, err := aes.NewCipher(s.encryptionKey)
+	x, err := base64.RawURLEncoding.DecodeString(jwk.X)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-
-	gcm, err := cipher.NewGCM(block)
+	y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	nonceSize := gcm.NonceSize()
-	if len(data) < nonceSize {
-		return "", fmt.Errorf("ciphertext too short")
-	}
+	return &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}, nil
+}
+
+// Encryption Helpers
+func (s *PlaidService) EncryptToken(token string) (string, error) {
+	return s.tokens.Encrypt(token)
+}
+
+func (s *PlaidService) DecryptToken(encryptedToken string) (string, error) {
+	return s.tokens.Decrypt(encryptedToken)
+}
 
-	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+func (s *PlaidService) GetAccounts(ctx context.Context, accessToken string) ([]plaid.AccountBase, error) {
+	request := plaid.NewAccountsGetRequest(accessToken)
+	resp, _, err := s.client.PlaidApi.AccountsGet(ctx).AccountsGetRequest(*request).Execute()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-
-	return string(plaintext), nil
+	return resp.Accounts, nil
 }
 
 func (s *PlaidService) SyncTransactions(ctx context.Context, accessToken string, cursor string) (plaid.TransactionsSyncResponse, error) {
@@ -129,3 +161,45 @@ func (s *PlaidService) SyncTransactions(ctx context.Context, accessToken string,
 	resp, _, err := s.client.PlaidApi.TransactionsSync(ctx).TransactionsSyncRequest(*request).Execute()
 	return resp, err
 }
+
+func (s *PlaidService) GetInvestmentHoldings(ctx context.Context, accessToken string) (plaid.InvestmentsHoldingsGetResponse, error) {
+	request := plaid.NewInvestmentsHoldingsGetRequest(accessToken)
+	resp, _, err := s.client.PlaidApi.InvestmentsHoldingsGet(ctx).InvestmentsHoldingsGetRequest(*request).Execute()
+	return resp, err
+}
+
+// GetInvestmentTransactions pages through investments/transactions/get and returns every
+// transaction in the range along with the securities they reference.
+func (s *PlaidService) GetInvestmentTransactions(ctx context.Context, accessToken string, start, end time.Time) ([]plaid.InvestmentTransaction, []plaid.Security, error) {
+	var (
+		transactions []plaid.InvestmentTransaction
+		securities   []plaid.Security
+	)
+
+	for {
+		options := plaid.NewInvestmentsTransactionsGetRequestOptions()
+		options.SetCount(500)
+		options.SetOffset(int32(len(transactions)))
+
+		request := plaid.NewInvestmentsTransactionsGetRequest(accessToken, start.Format("2006-01-02"), end.Format("2006-01-02"))
+		request.SetOptions(*options)
+
+		resp, _, err := s.client.PlaidApi.InvestmentsTransactionsGet(ctx).InvestmentsTransactionsGetRequest(*request).Execute()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		transactions = append(transactions, resp.InvestmentTransactions...)
+		securities = append(securities, resp.Securities...)
+
+		if len(resp.InvestmentTransactions) == 0 || len(transactions) >= int(resp.TotalInvestmentTransactions) {
+			return transactions, securities, nil
+		}
+	}
+}
+
+func (s *PlaidService) GetLiabilities(ctx context.Context, accessToken string) (plaid.LiabilitiesGetResponse, error) {
+	request := plaid.NewLiabilitiesGetRequest(accessToken)
+	resp, _, err := s.client.PlaidApi.LiabilitiesGet(ctx).LiabilitiesGetRequest(*request).Execute()
+	return resp, err
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticKeySource struct {
+	keys  map[string]*ecdsa.PublicKey
+	calls int
+}
+
+func (s *staticKeySource) GetWebhookVerificationKey(ctx context.Context, keyID string) (*ecdsa.PublicKey, error) {
+	s.calls++
+	key, ok := s.keys[keyID]
+	if !ok {
+		return nil, errors.New("unknown key")
+	}
+	return key, nil
+}
+
+func signWebhook(t *testing.T, key *ecdsa.PrivateKey, kid string, body []byte, iat time.Time) string {
+	t.Helper()
+	sum := sha256.Sum256(body)
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iat":                 iat.Unix(),
+		"request_body_sha256": hex.EncodeToString(sum[:]),
+	})
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func newTestVerifier(t *testing.T) (*WebhookVerifier, *ecdsa.PrivateKey, *staticKeySource) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	source := &staticKeySource{keys: map[string]*ecdsa.PublicKey{"kid-1": &key.PublicKey}}
+	return NewWebhookVerifier(source), key, source
+}
+
+func TestWebhookVerifier_ValidSignature(t *testing.T) {
+	verifier, key, source := newTestVerifier(t)
+	body := []byte(`{"webhook_type":"TRANSACTIONS","webhook_code":"SYNC_UPDATES_AVAILABLE","item_id":"item-1"}`)
+
+	signed := signWebhook(t, key, "kid-1", body, time.Now())
+	assert.NoError(t, verifier.Verify(context.Background(), signed, body))
+
+	// Second call should hit the key cache
+	assert.NoError(t, verifier.Verify(context.Background(), signed, body))
+	assert.Equal(t, 1, source.calls)
+}
+
+func TestWebhookVerifier_Rejects(t *testing.T) {
+	verifier, key, _ := newTestVerifier(t)
+	body := []byte(`{"webhook_type":"ITEM","webhook_code":"ERROR","item_id":"item-1"}`)
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	hmacToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"iat": time.Now().Unix()}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	cases := map[string]string{
+		"missing header":  "",
+		"tampered body":   signWebhook(t, key, "kid-1", []byte(`{"item_id":"other"}`), time.Now()),
+		"stale token":     signWebhook(t, key, "kid-1", body, time.Now().Add(-10*time.Minute)),
+		"wrong key":       signWebhook(t, otherKey, "kid-1", body, time.Now()),
+		"unknown kid":     signWebhook(t, key, "kid-2", body, time.Now()),
+		"wrong algorithm": hmacToken,
+	}
+
+	for name, signed := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := verifier.Verify(context.Background(), signed, body)
+			assert.ErrorIs(t, err, ErrInvalidWebhookSignature)
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Key ID used when only the single ENCRYPTION_KEY is configured.
+const DefaultTokenKeyID = "default"
+
+const tokenCipherVersion = "v1"
+
+var ErrUnknownTokenKey = errors.New("access token was encrypted with a key that is not configured")
+
+// TokenCipher encrypts Plaid access tokens at rest with AES-GCM. Ciphertexts are
+// prefixed with the ID of the key that sealed them ("v1:<key id>:<base64>"), so keys can
+// be rotated: new tokens always use the current key, and older ones stay readable for as
+// long as their key is still configured.
+type TokenCipher struct {
+	currentID string
+	keys      map[string][]byte
+	// Key for tokens written before ciphertexts carried a key ID
+	legacyKey []byte
+}
+
+// NewTokenCipher parses keySpec, a comma separated list of "<id>:<key>" pairs with the
+// current key first, e.g. "2025-06:newkey...,2024-01:oldkey...". legacyKey (ENCRYPTION_KEY)
+// decrypts unprefixed tokens; with an empty keySpec it is also the current key, under
+// DefaultTokenKeyID. Every key must be 16, 24 or 32 bytes long.
+func NewTokenCipher(keySpec, legacyKey string) (*TokenCipher, error) {
+	c := &TokenCipher{keys: make(map[string][]byte)}
+
+	if legacyKey != "" {
+		if err := validateTokenKey(DefaultTokenKeyID, legacyKey); err != nil {
+			return nil, err
+		}
+		c.legacyKey = []byte(legacyKey)
+	}
+
+	if strings.TrimSpace(keySpec) == "" {
+		if legacyKey == "" {
+			return nil, errors.New("no encryption key configured: set ENCRYPTION_KEYS or ENCRYPTION_KEY")
+		}
+		keySpec = DefaultTokenKeyID + ":" + legacyKey
+	}
+
+	for _, pair := range strings.Split(keySpec, ",") {
+		id, key, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("invalid encryption key entry %q, expected <id>:<key>", pair)
+		}
+		if _, exists := c.keys[id]; exists {
+			return nil, fmt.Errorf("duplicate encryption key id %q", id)
+		}
+		if err := validateTokenKey(id, key); err != nil {
+			return nil, err
+		}
+
+		c.keys[id] = []byte(key)
+		if c.currentID == "" {
+			c.currentID = id
+		}
+	}
+
+	return c, nil
+}
+
+func validateTokenKey(id, key string) error {
+	switch len(key) {
+	case 16, 24, 32:
+		return nil
+	}
+	return fmt.Errorf("encryption key %q must be 16, 24 or 32 bytes, got %d", id, len(key))
+}
+
+// CurrentKeyID is the ID new tokens are encrypted under.
+func (c *TokenCipher) CurrentKeyID() string {
+	return c.currentID
+}
+
+func (c *TokenCipher) Encrypt(token string) (string, error) {
+	sealed, err := sealToken(c.keys[c.currentID], token)
+	if err != nil {
+		return "", err
+	}
+	return tokenCipherVersion + ":" + c.currentID + ":" + sealed, nil
+}
+
+func (c *TokenCipher) Decrypt(encryptedToken string) (string, error) {
+	key, sealed, err := c.keyFor(encryptedToken)
+	if err != nil {
+		return "", err
+	}
+	return openToken(key, sealed)
+}
+
+// Reencrypt moves a token onto the current key. It reports false, and returns the input
+// unchanged, when the token already uses it.
+func (c *TokenCipher) Reencrypt(encryptedToken string) (string, bool, error) {
+	if c.keyID(encryptedToken) == c.currentID {
+		return encryptedToken, false, nil
+	}
+
+	token, err := c.Decrypt(encryptedToken)
+	if err != nil {
+		return "", false, err
+	}
+	reencrypted, err := c.Encrypt(token)
+	if err != nil {
+		return "", false, err
+	}
+	return reencrypted, true, nil
+}
+
+// keyID returns the key ID a ciphertext names, or "" for legacy unprefixed ones.
+// Base64 never contains ':', so legacy ciphertexts can't be mistaken for versioned ones.
+func (c *TokenCipher) keyID(encryptedToken string) string {
+	parts := strings.SplitN(encryptedToken, ":", 3)
+	if len(parts) != 3 || parts[0] != tokenCipherVersion {
+		return ""
+	}
+	return parts[1]
+}
+
+func (c *TokenCipher) keyFor(encryptedToken string) ([]byte, string, error) {
+	parts := strings.SplitN(encryptedToken, ":", 3)
+	if len(parts) == 1 {
+		if c.legacyKey == nil {
+			return nil, "", ErrUnknownTokenKey
+		}
+		return c.legacyKey, encryptedToken, nil
+	}
+
+	if len(parts) != 3 || parts[0] != tokenCipherVersion {
+		return nil, "", fmt.Errorf("unsupported access token format")
+	}
+	key, ok := c.keys[parts[1]]
+	if !ok {
+		return nil, "", fmt.Errorf("%w: %s", ErrUnknownTokenKey, parts[1])
+	}
+	return key, parts[2], nil
+}
+
+func sealToken(key []byte, token string) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	ciphertext := gcm.Seal(nonce, nonce, []byte(token), nil)
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+func openToken(key []byte, sealed string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(data) < nonceSize {
+		return "", fmt.Errorf("ciphertext too short")
+	}
+
+	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
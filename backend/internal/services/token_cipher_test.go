@@ -0,0 +1,101 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	oldTestKey = "0123456789abcdef0123456789abcdef"
+	newTestKey = "fedcba9876543210fedcba9876543210"
+)
+
+func TestTokenCipher_RoundTripWithKeyID(t *testing.T) {
+	c, err := NewTokenCipher("k2:"+newTestKey+",k1:"+oldTestKey, "")
+	require.NoError(t, err)
+
+	encrypted, err := c.Encrypt("access-sandbox-123")
+	require.NoError(t, err)
+	assert.Regexp(t, `^v1:k2:`, encrypted)
+
+	token, err := c.Decrypt(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "access-sandbox-123", token)
+}
+
+func TestTokenCipher_DecryptsOlderKeysAndLegacyTokens(t *testing.T) {
+	old, err := NewTokenCipher("k1:"+oldTestKey, "")
+	require.NoError(t, err)
+	underOld, err := old.Encrypt("token-a")
+	require.NoError(t, err)
+
+	// Written before key IDs existed: bare base64 under ENCRYPTION_KEY
+	legacy, err := sealToken([]byte(oldTestKey), "token-b")
+	require.NoError(t, err)
+
+	rotated, err := NewTokenCipher("k2:"+newTestKey+",k1:"+oldTestKey, oldTestKey)
+	require.NoError(t, err)
+
+	token, err := rotated.Decrypt(underOld)
+	require.NoError(t, err)
+	assert.Equal(t, "token-a", token)
+
+	token, err = rotated.Decrypt(legacy)
+	require.NoError(t, err)
+	assert.Equal(t, "token-b", token)
+}
+
+func TestTokenCipher_Reencrypt(t *testing.T) {
+	old, err := NewTokenCipher("k1:"+oldTestKey, "")
+	require.NoError(t, err)
+	underOld, err := old.Encrypt("token-a")
+	require.NoError(t, err)
+
+	c, err := NewTokenCipher("k2:"+newTestKey+",k1:"+oldTestKey, "")
+	require.NoError(t, err)
+
+	reencrypted, changed, err := c.Reencrypt(underOld)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Regexp(t, `^v1:k2:`, reencrypted)
+
+	// Once the old key is dropped the re-encrypted token still reads
+	current, err := NewTokenCipher("k2:"+newTestKey, "")
+	require.NoError(t, err)
+	token, err := current.Decrypt(reencrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "token-a", token)
+
+	_, changed, err = c.Reencrypt(reencrypted)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	_, err = current.Decrypt(underOld)
+	assert.ErrorIs(t, err, ErrUnknownTokenKey)
+}
+
+func TestNewTokenCipher_ValidatesKeys(t *testing.T) {
+	cases := map[string]struct {
+		spec, legacy string
+	}{
+		"nothing configured": {"", ""},
+		"short legacy key":   {"", "too-short"},
+		"short key":          {"k1:too-short", ""},
+		"missing id":         {":" + oldTestKey, ""},
+		"missing separator":  {oldTestKey, ""},
+		"duplicate id":       {"k1:" + oldTestKey + ",k1:" + newTestKey, ""},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewTokenCipher(tc.spec, tc.legacy)
+			assert.Error(t, err)
+		})
+	}
+
+	c, err := NewTokenCipher("", oldTestKey)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultTokenKeyID, c.CurrentKeyID())
+}
@@ -0,0 +1,151 @@
+// Package statements works out credit card statement cycles from a card's statement and
+// due days: when each statement closes, when its payment is due, and what it owed
+// according to the ledger. A card's balance is what is owed, so charges are positive
+// entries and payments and refunds negative ones. Dates are calendar days in UTC, the
+// way entries store them.
+package statements
+
+import (
+	"math"
+	"time"
+
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+)
+
+// Days from a statement closing to its payment being due for cards without a due day,
+// the shortest grace period issuers may give.
+const defaultGraceDays = 21
+
+// Period is one statement cycle. Start and Close are its first and last days.
+type Period struct {
+	Start time.Time `json:"start"`
+	Close time.Time `json:"close"`
+	Due   time.Time `json:"due"`
+}
+
+// Statement is what the ledger says about a period. Credits are payments and refunds,
+// as a positive amount.
+type Statement struct {
+	Period
+	OpeningBalance float64 `json:"openingBalance"`
+	Charges        float64 `json:"charges"`
+	Credits        float64 `json:"credits"`
+	ClosingBalance float64 `json:"closingBalance"`
+}
+
+// PeriodFor returns the period date falls in, which closes on or after it.
+func PeriodFor(closeDay, dueDay int, date time.Time) Period {
+	day := toDay(date)
+	closing := dayIn(day.Year(), day.Month(), closeDay)
+	if day.After(closing) {
+		closing = dayIn(day.Year(), day.Month()+1, closeDay)
+	}
+	return period(closing, closeDay, dueDay)
+}
+
+// Periods returns the period open on now followed by the count periods before it,
+// newest first.
+func Periods(closeDay, dueDay int, now time.Time, count int) []Period {
+	p := PeriodFor(closeDay, dueDay, now)
+	periods := []Period{p}
+	for i := 0; i < count; i++ {
+		p = period(p.Start.AddDate(0, 0, -1), closeDay, dueDay)
+		periods = append(periods, p)
+	}
+	return periods
+}
+
+// DueDate is when the statement closing on closing must be paid: the first dueDay after
+// it, or defaultGraceDays later without a due day.
+func DueDate(closing time.Time, dueDay int) time.Time {
+	if dueDay <= 0 {
+		return closing.AddDate(0, 0, defaultGraceDays)
+	}
+	due := dayIn(closing.Year(), closing.Month(), dueDay)
+	if !due.After(closing) {
+		due = dayIn(closing.Year(), closing.Month()+1, dueDay)
+	}
+	return due
+}
+
+// Build returns the statement of each period from the card's current balance and its
+// entries, which must cover everything since the oldest period started.
+func Build(periods []Period, balance float64, entries []models.Entry) []Statement {
+	statements := make([]Statement, 0, len(periods))
+	for _, p := range periods {
+		s := Statement{Period: p, ClosingBalance: balance}
+		for _, e := range entries {
+			day := toDay(e.Date)
+			switch {
+			case day.After(p.Close):
+				s.ClosingBalance -= e.Amount
+			case day.Before(p.Start):
+			case e.Amount > 0:
+				s.Charges += e.Amount
+			default:
+				s.Credits -= e.Amount
+			}
+		}
+		s.Charges = roundCents(s.Charges)
+		s.Credits = roundCents(s.Credits)
+		s.ClosingBalance = roundCents(s.ClosingBalance)
+		s.OpeningBalance = roundCents(s.ClosingBalance - s.Charges + s.Credits)
+		statements = append(statements, s)
+	}
+	return statements
+}
+
+// NextDue is the next payment due date on or after now: the one the issuer reported if
+// it is still ahead, otherwise the one the statement cycle gives. It is false for cards
+// with neither.
+func NextDue(card *models.CreditCardDetails, now time.Time) (time.Time, bool) {
+	today := toDay(now)
+	if card == nil {
+		return time.Time{}, false
+	}
+	if d := card.NextPaymentDueDate; d != nil && !toDay(*d).Before(today) {
+		return toDay(*d), true
+	}
+	if card.StatementCloseDay <= 0 {
+		return time.Time{}, false
+	}
+
+	// The last closed statement may still be due; if not, the open one is next
+	open := PeriodFor(card.StatementCloseDay, card.PaymentDueDay, today)
+	last := period(open.Start.AddDate(0, 0, -1), card.StatementCloseDay, card.PaymentDueDay)
+	if !last.Due.Before(today) {
+		return last.Due, true
+	}
+	return open.Due, true
+}
+
+// Utilization is balance as a percentage of limit, or 0 without a limit.
+func Utilization(balance, limit float64) float64 {
+	if limit <= 0 {
+		return 0
+	}
+	return roundCents(balance / limit * 100)
+}
+
+func period(closing time.Time, closeDay, dueDay int) Period {
+	previous := dayIn(closing.Year(), closing.Month()-1, closeDay)
+	return Period{Start: previous.AddDate(0, 0, 1), Close: closing, Due: DueDate(closing, dueDay)}
+}
+
+// dayIn is day of the given month, or the month's last day where it has no such day.
+// Months outside 1-12 roll into the neighbouring years.
+func dayIn(year int, month time.Month, day int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, time.UTC)
+}
+
+func toDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
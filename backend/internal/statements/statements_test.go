@@ -0,0 +1,103 @@
+package statements
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rakibulbh/ai-finance-manager/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestPeriodFor(t *testing.T) {
+	p := PeriodFor(20, 15, date(2024, 3, 5))
+	assert.Equal(t, date(2024, 2, 21), p.Start)
+	assert.Equal(t, date(2024, 3, 20), p.Close)
+	assert.Equal(t, date(2024, 4, 15), p.Due, "a due day before the close day falls in the next month")
+
+	p = PeriodFor(20, 15, date(2024, 3, 20))
+	assert.Equal(t, date(2024, 3, 20), p.Close, "the close date is in its own period")
+
+	p = PeriodFor(31, 25, date(2024, 2, 10))
+	assert.Equal(t, date(2024, 2, 1), p.Start)
+	assert.Equal(t, date(2024, 2, 29), p.Close, "short months close on their last day")
+	assert.Equal(t, date(2024, 3, 25), p.Due)
+
+	p = PeriodFor(5, 28, date(2024, 12, 10))
+	assert.Equal(t, date(2025, 1, 5), p.Close)
+	assert.Equal(t, date(2025, 1, 28), p.Due)
+}
+
+func TestDueDate_NoDueDay(t *testing.T) {
+	assert.Equal(t, date(2024, 4, 10), DueDate(date(2024, 3, 20), 0))
+}
+
+func TestPeriods(t *testing.T) {
+	periods := Periods(20, 15, date(2024, 3, 5), 2)
+	require.Len(t, periods, 3)
+	assert.Equal(t, date(2024, 3, 20), periods[0].Close)
+	assert.Equal(t, date(2024, 2, 20), periods[1].Close)
+	assert.Equal(t, date(2024, 1, 21), periods[1].Start)
+	assert.Equal(t, date(2024, 1, 20), periods[2].Close)
+	assert.Equal(t, date(2023, 12, 21), periods[2].Start)
+}
+
+func TestBuild(t *testing.T) {
+	periods := Periods(20, 15, date(2024, 3, 5), 2)
+	entries := []models.Entry{
+		{Date: date(2024, 1, 25), Amount: 100},
+		{Date: date(2024, 2, 10), Amount: 50},
+		{Date: date(2024, 2, 18), Amount: -80},
+		{Date: date(2024, 3, 1), Amount: 30},
+	}
+
+	built := Build(periods, 500, entries)
+	require.Len(t, built, 3)
+
+	open := built[0]
+	assert.Equal(t, 30.0, open.Charges)
+	assert.Equal(t, 500.0, open.ClosingBalance)
+	assert.Equal(t, 470.0, open.OpeningBalance)
+
+	last := built[1]
+	assert.Equal(t, 150.0, last.Charges)
+	assert.Equal(t, 80.0, last.Credits)
+	assert.Equal(t, 470.0, last.ClosingBalance)
+	assert.Equal(t, 400.0, last.OpeningBalance)
+
+	assert.Equal(t, 400.0, built[2].ClosingBalance)
+	assert.Equal(t, built[2].ClosingBalance, last.OpeningBalance, "each statement opens where the one before closed")
+}
+
+func TestNextDue(t *testing.T) {
+	card := &models.CreditCardDetails{StatementCloseDay: 20, PaymentDueDay: 15}
+
+	due, ok := NextDue(card, date(2024, 3, 5))
+	require.True(t, ok)
+	assert.Equal(t, date(2024, 3, 15), due, "the closed statement is still due")
+
+	due, _ = NextDue(card, date(2024, 3, 16))
+	assert.Equal(t, date(2024, 4, 15), due)
+
+	reported := date(2024, 3, 10)
+	card.NextPaymentDueDate = &reported
+	due, _ = NextDue(card, date(2024, 3, 5))
+	assert.Equal(t, reported, due, "the issuer's date wins while it is ahead")
+
+	due, _ = NextDue(card, date(2024, 3, 12))
+	assert.Equal(t, date(2024, 3, 15), due)
+
+	_, ok = NextDue(&models.CreditCardDetails{NextPaymentDueDate: &reported}, date(2024, 3, 12))
+	assert.False(t, ok)
+	_, ok = NextDue(nil, date(2024, 3, 12))
+	assert.False(t, ok)
+}
+
+func TestUtilization(t *testing.T) {
+	assert.Equal(t, 25.0, Utilization(1250, 5000))
+	assert.Equal(t, 0.0, Utilization(1250, 0))
+}
@@ -0,0 +1,109 @@
+// Package transfers pairs entries that are the two sides of one movement of money
+// between a family's accounts. Paying a credit card from checking imports one entry
+// from each account, for opposite amounts a few days apart; counted as ordinary
+// transactions they would show up as both spending and income.
+package transfers
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WindowDays is how far apart the two sides of a transfer may be dated, allowing for
+// payments that take a few business days to post.
+const WindowDays = 5
+
+// Entry is a standard transaction entry as matching sees it. Amounts are signed as in
+// the ledger, positive when money left the account. Liability is set for entries in
+// liability accounts.
+type Entry struct {
+	ID        uuid.UUID
+	AccountID uuid.UUID
+	Amount    float64
+	Currency  string
+	Date      time.Time
+	Liability bool
+}
+
+// Pair is a possible transfer: From is the entry money left, with the positive amount,
+// and To the one it arrived in.
+type Pair struct {
+	From uuid.UUID
+	To   uuid.UUID
+}
+
+// Match is a pair found by Find. It is ambiguous when either entry could also pair
+// with another. Automatic is set for the only pairs safe to link without the family's
+// review: unambiguous payments from an asset account into a liability. Any other pair
+// could as well be a refund that happens to match a purchase.
+type Match struct {
+	Pair
+	Ambiguous bool
+	Automatic bool
+}
+
+// Find returns the pairs of entries in different accounts, in the same currency, for
+// opposite amounts, dated at most WindowDays apart, leaving out rejected pairs. Pairs
+// come in order of the outgoing entry's date, closest dates first.
+func Find(entries []Entry, rejected map[Pair]bool) []Match {
+	sorted := make([]Entry, len(entries))
+	copy(sorted, entries)
+	sort.Slice(sorted, func(i, j int) bool {
+		if !sorted[i].Date.Equal(sorted[j].Date) {
+			return sorted[i].Date.Before(sorted[j].Date)
+		}
+		return sorted[i].ID.String() < sorted[j].ID.String()
+	})
+
+	type candidate struct {
+		pair Pair
+		gap  int
+	}
+	var candidates []candidate
+	degree := make(map[uuid.UUID]int)
+	payment := make(map[Pair]bool)
+	for _, from := range sorted {
+		if cents(from.Amount) <= 0 {
+			continue
+		}
+		var found []candidate
+		for _, to := range sorted {
+			if to.AccountID == from.AccountID || to.Currency != from.Currency || cents(to.Amount) != -cents(from.Amount) {
+				continue
+			}
+			gap := daysApart(from.Date, to.Date)
+			pair := Pair{From: from.ID, To: to.ID}
+			if gap > WindowDays || rejected[pair] {
+				continue
+			}
+			found = append(found, candidate{pair, gap})
+			payment[pair] = !from.Liability && to.Liability
+			degree[from.ID]++
+			degree[to.ID]++
+		}
+		sort.SliceStable(found, func(i, j int) bool { return found[i].gap < found[j].gap })
+		candidates = append(candidates, found...)
+	}
+
+	matches := make([]Match, len(candidates))
+	for i, c := range candidates {
+		ambiguous := degree[c.pair.From] > 1 || degree[c.pair.To] > 1
+		matches[i] = Match{Pair: c.pair, Ambiguous: ambiguous, Automatic: !ambiguous && payment[c.pair]}
+	}
+	return matches
+}
+
+func cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func daysApart(a, b time.Time) int {
+	days := int(math.Round(b.Sub(a).Hours() / 24))
+	if days < 0 {
+		return -days
+	}
+	return days
+}
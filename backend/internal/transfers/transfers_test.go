@@ -0,0 +1,89 @@
+package transfers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func entry(account uuid.UUID, amount float64, d time.Time) Entry {
+	return Entry{ID: uuid.New(), AccountID: account, Amount: amount, Currency: "USD", Date: d}
+}
+
+func liability(account uuid.UUID, amount float64, d time.Time) Entry {
+	e := entry(account, amount, d)
+	e.Liability = true
+	return e
+}
+
+func TestFind_CardPayment(t *testing.T) {
+	checking, card := uuid.New(), uuid.New()
+	payment := entry(checking, 500, date(2024, 3, 1))
+	posted := liability(card, -500, date(2024, 3, 4))
+	entries := []Entry{
+		posted,
+		payment,
+		entry(checking, 42.17, date(2024, 3, 2)), // groceries
+		liability(card, -500, date(2024, 3, 20)), // too late
+		entry(checking, -500, date(2024, 3, 1)),  // same account
+		{ID: uuid.New(), AccountID: card, Amount: -500, Currency: "EUR", Date: date(2024, 3, 1), Liability: true},
+	}
+
+	matches := Find(entries, nil)
+	require.Len(t, matches, 1)
+	assert.Equal(t, Pair{From: payment.ID, To: posted.ID}, matches[0].Pair, "money leaves checking")
+	assert.False(t, matches[0].Ambiguous)
+	assert.True(t, matches[0].Automatic)
+}
+
+func TestFind_RefundIsOnlySuggested(t *testing.T) {
+	checking, card := uuid.New(), uuid.New()
+	// A purchase on the card and a refund into checking for the same amount
+	purchase := liability(card, 80, date(2024, 4, 2))
+	refund := entry(checking, -80, date(2024, 4, 3))
+
+	matches := Find([]Entry{purchase, refund}, nil)
+	require.Len(t, matches, 1)
+	assert.Equal(t, Pair{From: purchase.ID, To: refund.ID}, matches[0].Pair)
+	assert.False(t, matches[0].Ambiguous)
+	assert.False(t, matches[0].Automatic, "only a payment into a liability is linked without review")
+}
+
+func TestFind_Ambiguous(t *testing.T) {
+	checking, savings, card := uuid.New(), uuid.New(), uuid.New()
+	payment := entry(checking, 200, date(2024, 5, 10))
+	toCard := liability(card, -200, date(2024, 5, 13))
+	toSavings := entry(savings, -200, date(2024, 5, 10))
+
+	matches := Find([]Entry{payment, toCard, toSavings}, nil)
+	require.Len(t, matches, 2)
+	// Closest date first
+	assert.Equal(t, toSavings.ID, matches[0].To)
+	assert.Equal(t, toCard.ID, matches[1].To)
+	for _, m := range matches {
+		assert.True(t, m.Ambiguous)
+		assert.False(t, m.Automatic)
+	}
+
+	t.Run("rejected pair", func(t *testing.T) {
+		rejected := map[Pair]bool{{From: payment.ID, To: toSavings.ID}: true}
+		matches := Find([]Entry{payment, toCard, toSavings}, rejected)
+		require.Len(t, matches, 1)
+		assert.Equal(t, toCard.ID, matches[0].To)
+		assert.False(t, matches[0].Ambiguous)
+		assert.True(t, matches[0].Automatic)
+	})
+}
+
+func TestFind_RoundsToCents(t *testing.T) {
+	checking, card := uuid.New(), uuid.New()
+	matches := Find([]Entry{entry(checking, 0.1+0.2, date(2024, 1, 1)), liability(card, -0.3, date(2024, 1, 1))}, nil)
+	assert.Len(t, matches, 1)
+}